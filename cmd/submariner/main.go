@@ -8,7 +8,10 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/stolostron/submariner-addon/pkg/cmd/hub"
+	"github.com/stolostron/submariner-addon/pkg/cmd/render"
 	"github.com/stolostron/submariner-addon/pkg/cmd/spoke"
+	"github.com/stolostron/submariner-addon/pkg/cmd/status"
+	"github.com/stolostron/submariner-addon/pkg/cmd/validate"
 	"github.com/stolostron/submariner-addon/pkg/version"
 	"github.com/submariner-io/admiral/pkg/log/kzerolog"
 	utilflag "k8s.io/component-base/cli/flag"
@@ -58,6 +61,10 @@ func newSubmarinerControllerCommand() *cobra.Command {
 
 	cmd.AddCommand(hub.NewController())
 	cmd.AddCommand(spoke.NewAgent())
+	cmd.AddCommand(spoke.NewPreDeleteHook())
+	cmd.AddCommand(render.NewRender())
+	cmd.AddCommand(status.NewStatus())
+	cmd.AddCommand(validate.NewValidate())
 
 	return cmd
 }
@@ -79,3 +79,24 @@ func UpdateStatusFn(cond *metav1.Condition,
 		}
 	}
 }
+
+// UpdateCloudPreparePlanFn sets the cloud preparation plan, or clears it if plan is nil.
+func UpdateCloudPreparePlanFn(plan *configv1alpha1.CloudPreparePlan) UpdateStatusFunc {
+	return func(oldStatus *configv1alpha1.SubmarinerConfigStatus) {
+		oldStatus.CloudPreparePlan = plan
+	}
+}
+
+// UpdateCloudResourcesFn sets the inventory of the cloud resources created by the cloud preparation.
+func UpdateCloudResourcesFn(inventory []configv1alpha1.CloudResource) UpdateStatusFunc {
+	return func(oldStatus *configv1alpha1.SubmarinerConfigStatus) {
+		oldStatus.CloudResources = inventory
+	}
+}
+
+// UpdateOrphanedCloudResourcesFn sets the cloud resources which couldn't be cleaned up, or clears them if orphans is nil.
+func UpdateOrphanedCloudResourcesFn(orphans *configv1alpha1.OrphanedCloudResources) UpdateStatusFunc {
+	return func(oldStatus *configv1alpha1.SubmarinerConfigStatus) {
+		oldStatus.OrphanedCloudResources = orphans
+	}
+}
@@ -181,6 +181,97 @@ var _ = Describe("Update Status", func() {
 			})
 		})
 	})
+
+	When("a cloud resources inventory is specified", func() {
+		inventory := []configv1alpha1.CloudResource{{
+			Kind:  configv1alpha1.CloudResourceSecurityGroup,
+			Name:  "test-infraID-submariner-gw-sg",
+			Ports: []configv1alpha1.CloudPort{{Port: 4500, Protocol: "udp"}},
+		}}
+
+		It("should update it", func() {
+			updatedStatus, updated, err := t.doUpdateStatus(submarinerconfig.UpdateCloudResourcesFn(inventory))
+			Expect(err).To(Succeed())
+			Expect(updated).To(BeTrue())
+			Expect(updatedStatus.CloudResources).To(Equal(inventory))
+			Expect(t.getStatus().CloudResources).To(Equal(inventory))
+		})
+	})
+
+	When("a CloudPreparePlan is specified", func() {
+		plan := &configv1alpha1.CloudPreparePlan{
+			Operation:          configv1alpha1.CloudPreparePlanCreate,
+			ObservedGeneration: 2,
+			Resources: []configv1alpha1.CloudResource{{
+				Kind:         configv1alpha1.CloudResourceMachineSet,
+				Name:         "test-infraID-submariner-gw",
+				InstanceType: "m5.xlarge",
+				Replicas:     1,
+			}},
+		}
+
+		It("should update it", func() {
+			updatedStatus, updated, err := t.doUpdateStatus(submarinerconfig.UpdateCloudPreparePlanFn(plan))
+			Expect(err).To(Succeed())
+			Expect(updated).To(BeTrue())
+			Expect(updatedStatus.CloudPreparePlan).To(Equal(plan))
+			Expect(t.getStatus().CloudPreparePlan).To(Equal(plan))
+		})
+
+		Context("and then cleared", func() {
+			BeforeEach(func() {
+				t.initialStatus.CloudPreparePlan = plan
+			})
+
+			AfterEach(func() {
+				t.initialStatus.CloudPreparePlan = nil
+			})
+
+			It("should remove it", func() {
+				_, updated, err := t.doUpdateStatus(submarinerconfig.UpdateCloudPreparePlanFn(nil))
+				Expect(err).To(Succeed())
+				Expect(updated).To(BeTrue())
+				Expect(t.getStatus().CloudPreparePlan).To(BeNil())
+			})
+		})
+	})
+
+	When("orphaned cloud resources are specified", func() {
+		orphans := &configv1alpha1.OrphanedCloudResources{
+			Resources: []configv1alpha1.CloudResource{{
+				Kind: configv1alpha1.CloudResourceSecurityGroup,
+				Name: "test-infraID-submariner-gw-sg",
+			}},
+			CredentialsSecret: "aws-creds",
+			Message:           "secret \"aws-creds\" not found",
+			Since:             metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		}
+
+		It("should update them", func() {
+			updatedStatus, updated, err := t.doUpdateStatus(submarinerconfig.UpdateOrphanedCloudResourcesFn(orphans))
+			Expect(err).To(Succeed())
+			Expect(updated).To(BeTrue())
+			Expect(updatedStatus.OrphanedCloudResources).To(Equal(orphans))
+			Expect(t.getStatus().OrphanedCloudResources).To(Equal(orphans))
+		})
+
+		Context("and then cleared", func() {
+			BeforeEach(func() {
+				t.initialStatus.OrphanedCloudResources = orphans
+			})
+
+			AfterEach(func() {
+				t.initialStatus.OrphanedCloudResources = nil
+			})
+
+			It("should remove them", func() {
+				_, updated, err := t.doUpdateStatus(submarinerconfig.UpdateOrphanedCloudResourcesFn(nil))
+				Expect(err).To(Succeed())
+				Expect(updated).To(BeTrue())
+				Expect(t.getStatus().OrphanedCloudResources).To(BeNil())
+			})
+		})
+	})
 })
 
 type updateStatusTestDriver struct {
@@ -64,7 +64,8 @@ type SubmarinerConfigSpec struct {
 	AirGappedDeployment bool `json:"airGappedDeployment,omitempty"`
 
 	// LoadBalancerEnable enables or disables load balancer mode. When enabled, a LoadBalancer is created in the
-	// submariner-operator namespace (default false).
+	// submariner-operator namespace, fronting the existing worker nodes, so the cloud preparation doesn't deploy
+	// dedicated gateway nodes (default false).
 	// +optional
 	// +kubebuilder:default=false
 	LoadBalancerEnable bool `json:"loadBalancerEnable"`
@@ -127,8 +128,29 @@ type SubmarinerConfigSpec struct {
 	// GatewayConfig represents the gateways configuration of the Submariner.
 	// +optional
 	GatewayConfig `json:"gatewayConfig,omitempty"`
+
+	// CloudPreparePlanOnly enables the plan mode of the cloud preparation. The cloud resources that would be created
+	// or deleted are published in the status as a plan, but the cloud environment is not changed. Set it to false
+	// to apply the plan.
+	// +optional
+	// +kubebuilder:default=false
+	CloudPreparePlanOnly bool `json:"cloudPreparePlanOnly,omitempty"`
+
+	// CloudDriftRemediation specifies what to do when the cloud resources created by the cloud preparation are found to
+	// have drifted, e.g. when a firewall rule was deleted. The drift is always reported in the
+	// SubmarinerClusterEnvironmentPrepared condition. Available options are None (default), which only reports it, and
+	// Reapply, which also prepares the cloud environment again.
+	// +optional
+	// +kubebuilder:default=None
+	// +kubebuilder:validation:Enum=None;Reapply
+	CloudDriftRemediation string `json:"cloudDriftRemediation,omitempty"`
 }
 
+const (
+	CloudDriftRemediationNone    = "None"
+	CloudDriftRemediationReapply = "Reapply"
+)
+
 // SubscriptionConfig contains configuration specified for a submariner subscription.
 type SubscriptionConfig struct {
 	// Source represents the catalog source of a submariner subscription.
@@ -215,10 +237,34 @@ type GatewayConfig struct {
 
 	// Gateways represents the count of worker nodes that will be used to deploy the Submariner gateway
 	// component on the managed cluster. The default value is 1, if the value is greater than 1, the
-	// Submariner gateway HA will be enabled automatically.
+	// Submariner gateway HA will be enabled automatically. If GatewayNodes is specified, the count of its entries
+	// is used instead.
 	// +optional
 	// +kubebuilder:default=1
 	Gateways int `json:"gateways"`
+
+	// GatewayNodes specifies each of the gateway nodes individually, e.g. a large active gateway and smaller standby
+	// gateways, each pinned to a zone or subnet. If specified, it overrides Gateways.
+	// +optional
+	GatewayNodes []GatewayNode `json:"gatewayNodes,omitempty"`
+}
+
+// GatewayNode describes a single gateway node.
+type GatewayNode struct {
+	// InstanceType is the instance type of the gateway node. If not specified, the instance type of the platform
+	// configuration is used. On clusters whose gateways aren't deployed by submariner-addon, the worker node labeled
+	// as gateway must have this instance type.
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+
+	// Zone is the availability zone of the gateway node. If not specified, the zone is chosen by submariner-addon.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+
+	// Subnet is the name or ID of the subnet of the gateway node. If not specified, the subnet is chosen by
+	// submariner-addon. This is ignored on clusters whose gateways aren't deployed by submariner-addon.
+	// +optional
+	Subnet string `json:"subnet,omitempty"`
 }
 
 type AWS struct {
@@ -228,6 +274,12 @@ type AWS struct {
 	// +optional
 	// +kubebuilder:default=m5.xlarge
 	InstanceType string `json:"instanceType,omitempty"`
+
+	// Spot specifies that the gateway nodes are spot instances. If spot instances can't be provisioned, on-demand
+	// instances are used instead. Gateways on spot instances which receive a termination notice are moved to other
+	// worker nodes.
+	// +optional
+	Spot bool `json:"spot,omitempty"`
 }
 
 type GCP struct {
@@ -237,6 +289,12 @@ type GCP struct {
 	// +optional
 	// +kubebuilder:default=n1-standard-4
 	InstanceType string `json:"instanceType,omitempty"`
+
+	// Preemptible specifies that the gateway nodes are preemptible instances. If preemptible instances can't be
+	// provisioned, standard instances are used instead. Gateways on preemptible instances which receive a termination
+	// notice are moved to other worker nodes.
+	// +optional
+	Preemptible bool `json:"preemptible,omitempty"`
 }
 
 type RHOS struct {
@@ -255,8 +313,78 @@ type Azure struct {
 	// +optional
 	// +kubebuilder:default=Standard_F4s_v2
 	InstanceType string `json:"instanceType,omitempty"`
+
+	// Spot specifies that the gateway nodes are spot virtual machines. If spot virtual machines can't be provisioned,
+	// standard virtual machines are used instead. Gateways on spot virtual machines which receive an eviction notice
+	// are moved to other worker nodes.
+	// +optional
+	Spot bool `json:"spot,omitempty"`
+}
+
+// CloudPort describes a port opened in the cloud environment.
+type CloudPort struct {
+	// Port is the port number, 0 for protocols without ports, e.g. ESP.
+	Port int `json:"port"`
+
+	// Protocol is the IP protocol of the port, e.g. udp, or a protocol name or number, e.g. esp or 50.
+	Protocol string `json:"protocol"`
+}
+
+// CloudResource describes a cloud resource managed by the cloud preparation.
+type CloudResource struct {
+	// Kind is the kind of the resource, e.g. SecurityGroup, FirewallRule, MachineSet or MachinePool.
+	Kind string `json:"kind"`
+
+	// Name is the name or ID of the resource.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Ports lists the ports opened by a security group or firewall rule.
+	// +optional
+	Ports []CloudPort `json:"ports,omitempty"`
+
+	// InstanceType is the instance type of the gateway nodes of a MachineSet or machine pool.
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+
+	// Replicas is the number of gateway nodes of a MachineSet or machine pool.
+	// +optional
+	Replicas int `json:"replicas,omitempty"`
+
+	// SpotFallbackReason is the reason why the gateway nodes of a MachineSet were provisioned on on-demand instances
+	// although spot instances were requested.
+	// +optional
+	SpotFallbackReason string `json:"spotFallbackReason,omitempty"`
 }
 
+const (
+	CloudResourceSecurityGroup = "SecurityGroup"
+	CloudResourceFirewallRule  = "FirewallRule"
+	CloudResourceMachineSet    = "MachineSet"
+	CloudResourceMachinePool   = "MachinePool"
+)
+
+// CloudPreparePlan describes the changes the cloud preparation would make to the cloud environment of the managed
+// cluster.
+type CloudPreparePlan struct {
+	// Operation is the planned operation, either Create when preparing the cloud environment or Delete when cleaning
+	// it up.
+	Operation string `json:"operation"`
+
+	// ObservedGeneration is the generation of the SubmarinerConfig the plan was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Resources lists the cloud resources that would be created or deleted.
+	// +optional
+	Resources []CloudResource `json:"resources,omitempty"`
+}
+
+const (
+	CloudPreparePlanCreate = "Create"
+	CloudPreparePlanDelete = "Delete"
+)
+
 const (
 	// SubmarinerConfigConditionApplied means the configuration has successfully
 	// applied.
@@ -274,6 +402,36 @@ type SubmarinerConfigStatus struct {
 	// ManagedClusterInfo represents the information of a managed cluster.
 	// +optional
 	ManagedClusterInfo ManagedClusterInfo `json:"managedClusterInfo,omitempty"`
+	// CloudPreparePlan contains the changes the cloud preparation would make, when the plan mode is enabled.
+	// +optional
+	CloudPreparePlan *CloudPreparePlan `json:"cloudPreparePlan,omitempty"`
+	// CloudResources lists the cloud resources created by the cloud preparation. The cloud environment clean up is
+	// limited to these resources.
+	// +optional
+	CloudResources []CloudResource `json:"cloudResources,omitempty"`
+	// OrphanedCloudResources records the cloud resources which couldn't be cleaned up when the addon was removed, e.g.
+	// because the credentials Secret was deleted first.
+	// +optional
+	OrphanedCloudResources *OrphanedCloudResources `json:"orphanedCloudResources,omitempty"`
+}
+
+// OrphanedCloudResources describes the cloud resources left behind by a clean up which couldn't complete, along with what
+// is needed to finish it. The clean up is retried while the addon is being removed; if the addon is enabled again, the
+// orphaned resources are adopted into the inventory so they're cleaned up along with it.
+type OrphanedCloudResources struct {
+	// Resources lists the cloud resources which weren't cleaned up.
+	Resources []CloudResource `json:"resources"`
+
+	// CredentialsSecret is the name of the credentials Secret needed to clean up the resources.
+	// +optional
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+
+	// Message describes why the clean up couldn't complete.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Since is the time at which the clean up first failed.
+	Since metav1.Time `json:"since"`
 }
 
 type ManagedClusterInfo struct {
@@ -43,6 +43,66 @@ func (in *Azure) DeepCopy() *Azure {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudPort) DeepCopyInto(out *CloudPort) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudPort.
+func (in *CloudPort) DeepCopy() *CloudPort {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudPreparePlan) DeepCopyInto(out *CloudPreparePlan) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]CloudResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudPreparePlan.
+func (in *CloudPreparePlan) DeepCopy() *CloudPreparePlan {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudPreparePlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudResource) DeepCopyInto(out *CloudResource) {
+	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]CloudPort, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudResource.
+func (in *CloudResource) DeepCopy() *CloudResource {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GCP) DeepCopyInto(out *GCP) {
 	*out = *in
@@ -66,6 +126,11 @@ func (in *GatewayConfig) DeepCopyInto(out *GatewayConfig) {
 	out.GCP = in.GCP
 	out.Azure = in.Azure
 	out.RHOS = in.RHOS
+	if in.GatewayNodes != nil {
+		in, out := &in.GatewayNodes, &out.GatewayNodes
+		*out = make([]GatewayNode, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -79,6 +144,22 @@ func (in *GatewayConfig) DeepCopy() *GatewayConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayNode) DeepCopyInto(out *GatewayNode) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayNode.
+func (in *GatewayNode) DeepCopy() *GatewayNode {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManagedClusterInfo) DeepCopyInto(out *ManagedClusterInfo) {
 	*out = *in
@@ -95,6 +176,30 @@ func (in *ManagedClusterInfo) DeepCopy() *ManagedClusterInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OrphanedCloudResources) DeepCopyInto(out *OrphanedCloudResources) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]CloudResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Since.DeepCopyInto(&out.Since)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrphanedCloudResources.
+func (in *OrphanedCloudResources) DeepCopy() *OrphanedCloudResources {
+	if in == nil {
+		return nil
+	}
+	out := new(OrphanedCloudResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RHOS) DeepCopyInto(out *RHOS) {
 	*out = *in
@@ -182,7 +287,7 @@ func (in *SubmarinerConfigSpec) DeepCopyInto(out *SubmarinerConfigSpec) {
 	}
 	out.SubscriptionConfig = in.SubscriptionConfig
 	out.ImagePullSpecs = in.ImagePullSpecs
-	out.GatewayConfig = in.GatewayConfig
+	in.GatewayConfig.DeepCopyInto(&out.GatewayConfig)
 	return
 }
 
@@ -207,6 +312,23 @@ func (in *SubmarinerConfigStatus) DeepCopyInto(out *SubmarinerConfigStatus) {
 		}
 	}
 	out.ManagedClusterInfo = in.ManagedClusterInfo
+	if in.CloudPreparePlan != nil {
+		in, out := &in.CloudPreparePlan, &out.CloudPreparePlan
+		*out = new(CloudPreparePlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CloudResources != nil {
+		in, out := &in.CloudResources, &out.CloudResources
+		*out = make([]CloudResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.OrphanedCloudResources != nil {
+		in, out := &in.OrphanedCloudResources, &out.OrphanedCloudResources
+		*out = new(OrphanedCloudResources)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
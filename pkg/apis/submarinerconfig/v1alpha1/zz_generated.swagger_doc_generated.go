@@ -13,6 +13,7 @@ package v1alpha1
 // AUTO-GENERATED FUNCTIONS START HERE
 var map_AWS = map[string]string{
 	"instanceType": "InstanceType represents the Amazon Web Services EC2 instance type of the gateway node that will be created on the managed cluster. The default value is `m5.xlarge`.",
+	"spot":         "Spot specifies that the gateway nodes are spot instances. If spot instances can't be provisioned, on-demand instances are used instead. Gateways on spot instances which receive a termination notice are moved to other worker nodes.",
 }
 
 func (AWS) SwaggerDoc() map[string]string {
@@ -21,14 +22,51 @@ func (AWS) SwaggerDoc() map[string]string {
 
 var map_Azure = map[string]string{
 	"instanceType": "InstanceType represents the Azure Cloud Platform instance type of the gateway node that will be created on the managed cluster. The default value is `Standard_F4s_v2`.",
+	"spot":         "Spot specifies that the gateway nodes are spot virtual machines. If spot virtual machines can't be provisioned, standard virtual machines are used instead. Gateways on spot virtual machines which receive an eviction notice are moved to other worker nodes.",
 }
 
 func (Azure) SwaggerDoc() map[string]string {
 	return map_Azure
 }
 
+var map_CloudPort = map[string]string{
+	"":         "CloudPort describes a port opened in the cloud environment.",
+	"port":     "Port is the port number, 0 for protocols without ports, e.g. ESP.",
+	"protocol": "Protocol is the IP protocol of the port, e.g. udp, or a protocol name or number, e.g. esp or 50.",
+}
+
+func (CloudPort) SwaggerDoc() map[string]string {
+	return map_CloudPort
+}
+
+var map_CloudPreparePlan = map[string]string{
+	"":                   "CloudPreparePlan describes the changes the cloud preparation would make to the cloud environment of the managed cluster.",
+	"operation":          "Operation is the planned operation, either Create when preparing the cloud environment or Delete when cleaning it up.",
+	"observedGeneration": "ObservedGeneration is the generation of the SubmarinerConfig the plan was computed for.",
+	"resources":          "Resources lists the cloud resources that would be created or deleted.",
+}
+
+func (CloudPreparePlan) SwaggerDoc() map[string]string {
+	return map_CloudPreparePlan
+}
+
+var map_CloudResource = map[string]string{
+	"":                   "CloudResource describes a cloud resource managed by the cloud preparation.",
+	"kind":               "Kind is the kind of the resource, e.g. SecurityGroup, FirewallRule, MachineSet or MachinePool.",
+	"name":               "Name is the name or ID of the resource.",
+	"ports":              "Ports lists the ports opened by a security group or firewall rule.",
+	"instanceType":       "InstanceType is the instance type of the gateway nodes of a MachineSet or machine pool.",
+	"replicas":           "Replicas is the number of gateway nodes of a MachineSet or machine pool.",
+	"spotFallbackReason": "SpotFallbackReason is the reason why the gateway nodes of a MachineSet were provisioned on on-demand instances although spot instances were requested.",
+}
+
+func (CloudResource) SwaggerDoc() map[string]string {
+	return map_CloudResource
+}
+
 var map_GCP = map[string]string{
 	"instanceType": "InstanceType represents the Google Cloud Platform instance type of the gateway node that will be created on the managed cluster. The default value is `n1-standard-4`.",
+	"preemptible":  "Preemptible specifies that the gateway nodes are preemptible instances. If preemptible instances can't be provisioned, standard instances are used instead. Gateways on preemptible instances which receive a termination notice are moved to other worker nodes.",
 }
 
 func (GCP) SwaggerDoc() map[string]string {
@@ -36,17 +74,29 @@ func (GCP) SwaggerDoc() map[string]string {
 }
 
 var map_GatewayConfig = map[string]string{
-	"aws":      "AWS represents the configuration for Amazon Web Services. If the platform of managed cluster is not Amazon Web Services, this field will be ignored.",
-	"gcp":      "GCP represents the configuration for Google Cloud Platform. If the platform of managed cluster is not Google Cloud Platform, this field will be ignored.",
-	"azure":    "Azure represents the configuration for Azure Cloud Platform. If the platform of managed cluster is not Azure Cloud Platform, this field will be ignored.",
-	"rhos":     "RHOS represents the configuration for Redhat Openstack Platform. If the platform of managed cluster is not Redhat Openstack Platform, this field will be ignored.",
-	"gateways": "Gateways represents the count of worker nodes that will be used to deploy the Submariner gateway component on the managed cluster. The default value is 1, if the value is greater than 1, the Submariner gateway HA will be enabled automatically.",
+	"aws":          "AWS represents the configuration for Amazon Web Services. If the platform of managed cluster is not Amazon Web Services, this field will be ignored.",
+	"gcp":          "GCP represents the configuration for Google Cloud Platform. If the platform of managed cluster is not Google Cloud Platform, this field will be ignored.",
+	"azure":        "Azure represents the configuration for Azure Cloud Platform. If the platform of managed cluster is not Azure Cloud Platform, this field will be ignored.",
+	"rhos":         "RHOS represents the configuration for Redhat Openstack Platform. If the platform of managed cluster is not Redhat Openstack Platform, this field will be ignored.",
+	"gateways":     "Gateways represents the count of worker nodes that will be used to deploy the Submariner gateway component on the managed cluster. The default value is 1, if the value is greater than 1, the Submariner gateway HA will be enabled automatically. If GatewayNodes is specified, the count of its entries is used instead.",
+	"gatewayNodes": "GatewayNodes specifies each of the gateway nodes individually, e.g. a large active gateway and smaller standby gateways, each pinned to a zone or subnet. If specified, it overrides Gateways.",
 }
 
 func (GatewayConfig) SwaggerDoc() map[string]string {
 	return map_GatewayConfig
 }
 
+var map_GatewayNode = map[string]string{
+	"":             "GatewayNode describes a single gateway node.",
+	"instanceType": "InstanceType is the instance type of the gateway node. If not specified, the instance type of the platform configuration is used. On clusters whose gateways aren't deployed by submariner-addon, the worker node labeled as gateway must have this instance type.",
+	"zone":         "Zone is the availability zone of the gateway node. If not specified, the zone is chosen by submariner-addon.",
+	"subnet":       "Subnet is the name or ID of the subnet of the gateway node. If not specified, the subnet is chosen by submariner-addon. This is ignored on clusters whose gateways aren't deployed by submariner-addon.",
+}
+
+func (GatewayNode) SwaggerDoc() map[string]string {
+	return map_GatewayNode
+}
+
 var map_ManagedClusterInfo = map[string]string{
 	"clusterName":   "ClusterName represents the name of the managed cluster.",
 	"vendor":        "Vendor represents the kubernetes vendor of the managed cluster.",
@@ -61,6 +111,18 @@ func (ManagedClusterInfo) SwaggerDoc() map[string]string {
 	return map_ManagedClusterInfo
 }
 
+var map_OrphanedCloudResources = map[string]string{
+	"":                  "OrphanedCloudResources describes the cloud resources left behind by a clean up which couldn't complete, along with what is needed to finish it. The clean up is retried while the addon is being removed; if the addon is enabled again, the orphaned resources are adopted into the inventory so they're cleaned up along with it.",
+	"resources":         "Resources lists the cloud resources which weren't cleaned up.",
+	"credentialsSecret": "CredentialsSecret is the name of the credentials Secret needed to clean up the resources.",
+	"message":           "Message describes why the clean up couldn't complete.",
+	"since":             "Since is the time at which the clean up first failed.",
+}
+
+func (OrphanedCloudResources) SwaggerDoc() map[string]string {
+	return map_OrphanedCloudResources
+}
+
 var map_RHOS = map[string]string{
 	"instanceType": "InstanceType represents the Redhat Openstack instance type of the gateway node that will be created on the managed cluster. The default value is `PnTAE.CPU_4_Memory_8192_Disk_50`.",
 }
@@ -98,7 +160,7 @@ var map_SubmarinerConfigSpec = map[string]string{
 	"NATTDiscoveryPort":        "NATTDiscoveryPort specifies the port used for NAT-T Discovery (default UDP/4900).",
 	"NATTEnable":               "NATTEnable represents IPsec NAT-T enabled (default true).",
 	"airGappedDeployment":      "AirGappedDeployment specifies that the cluster is in an air-gapped environment without access to external servers.",
-	"loadBalancerEnable":       "LoadBalancerEnable enables or disables load balancer mode. When enabled, a LoadBalancer is created in the submariner-operator namespace (default false).",
+	"loadBalancerEnable":       "LoadBalancerEnable enables or disables load balancer mode. When enabled, a LoadBalancer is created in the submariner-operator namespace, fronting the existing worker nodes, so the cloud preparation doesn't deploy dedicated gateway nodes (default false).",
 	"insecureBrokerConnection": "InsecureBrokerConnection disables certificate validation when contacting the broker. This is useful for scenarios where the certificate chain isn't the same everywhere, e.g. with self-signed certificates with a different trust chain in each cluster.",
 	"haltOnCertificateError":   "HaltOnCertificateError halts pods on certificate errors (so they are restarted).",
 	"hostedCluster":            "HostedCluster enabled if the cluster is a hosted cluster.",
@@ -110,6 +172,8 @@ var map_SubmarinerConfigSpec = map[string]string{
 	"subscriptionConfig":       "SubscriptionConfig represents a Submariner subscription. SubscriptionConfig can be used to customize the Submariner subscription.",
 	"imagePullSpecs":           "ImagePullSpecs represents the desired images of submariner components installed on the managed cluster. If not specified, the default submariner images that was defined by submariner operator will be used.",
 	"gatewayConfig":            "GatewayConfig represents the gateways configuration of the Submariner.",
+	"cloudPreparePlanOnly":     "CloudPreparePlanOnly enables the plan mode of the cloud preparation. The cloud resources that would be created or deleted are published in the status as a plan, but the cloud environment is not changed. Set it to false to apply the plan.",
+	"cloudDriftRemediation":    "CloudDriftRemediation specifies what to do when the cloud resources created by the cloud preparation are found to have drifted, e.g. when a firewall rule was deleted. The drift is always reported in the SubmarinerClusterEnvironmentPrepared condition. Available options are None (default), which only reports it, and Reapply, which also prepares the cloud environment again.",
 }
 
 func (SubmarinerConfigSpec) SwaggerDoc() map[string]string {
@@ -117,9 +181,12 @@ func (SubmarinerConfigSpec) SwaggerDoc() map[string]string {
 }
 
 var map_SubmarinerConfigStatus = map[string]string{
-	"":                   "SubmarinerConfigStatus represents the current status of submariner configuration.",
-	"conditions":         "Conditions contain the different condition statuses for this configuration.",
-	"managedClusterInfo": "ManagedClusterInfo represents the information of a managed cluster.",
+	"":                       "SubmarinerConfigStatus represents the current status of submariner configuration.",
+	"conditions":             "Conditions contain the different condition statuses for this configuration.",
+	"managedClusterInfo":     "ManagedClusterInfo represents the information of a managed cluster.",
+	"cloudPreparePlan":       "CloudPreparePlan contains the changes the cloud preparation would make, when the plan mode is enabled.",
+	"cloudResources":         "CloudResources lists the cloud resources created by the cloud preparation. The cloud environment clean up is limited to these resources.",
+	"orphanedCloudResources": "OrphanedCloudResources records the cloud resources which couldn't be cleaned up when the addon was removed, e.g. because the credentials Secret was deleted first.",
 }
 
 func (SubmarinerConfigStatus) SwaggerDoc() map[string]string {
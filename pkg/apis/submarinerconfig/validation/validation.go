@@ -0,0 +1,316 @@
+package validation
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+)
+
+type Severity string
+
+const (
+	// SeverityError flags a configuration which can't be deployed as intended.
+	SeverityError Severity = "Error"
+	// SeverityWarning flags a configuration which is suspicious, but may be intended.
+	SeverityWarning Severity = "Warning"
+)
+
+const (
+	defaultIPSecIKEPort      = 500
+	defaultIPSecNATTPort     = 4500
+	defaultNATTDiscoveryPort = 4900
+
+	platformAWS       = "AWS"
+	platformGCP       = "GCP"
+	platformAzure     = "Azure"
+	platformOpenStack = "OpenStack"
+
+	annotationPrefix = "submariner.io/"
+
+	// maxTypoDistance is the maximum edit distance between an unknown annotation and a known one for the former to be
+	// reported as a typo of the latter.
+	maxTypoDistance = 3
+)
+
+var supportedCableDrivers = []string{"libreswan", "strongswan", "wireguard", "vxlan"}
+
+// knownAnnotations maps the annotations of the SubmarinerConfig read by submariner-addon to the platform they apply to,
+// empty if they apply to every platform.
+var knownAnnotations = map[string]string{
+	"submariner.io/vpc-id":              platformAWS,
+	"submariner.io/subnet-id-list":      platformAWS,
+	"submariner.io/control-plane-sg-id": platformAWS,
+	"submariner.io/worker-sg-id":        platformAWS,
+	"submariner.io/vpc-name":            platformGCP,
+	"submariner.io/public-subnet-name":  platformGCP,
+	"submariner.io/subnet-names":        platformOpenStack,
+	"submariner.io/resource-group":      platformAzure,
+	"submariner.io/skip-cloud-prepare":  "",
+	"submariner.io/ocm-cluster-id":      "",
+}
+
+// Finding is a problem found in a SubmarinerConfig.
+type Finding struct {
+	Severity Severity `json:"severity"`
+	// Field is the path of the offending field, e.g. spec.globalCIDR.
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s", f.Severity, f.Field, f.Message)
+}
+
+// Options provides the context the SubmarinerConfig is validated in. The rules needing an option are skipped if it is
+// not set.
+type Options struct {
+	// Platform is the platform of the managed cluster, e.g. AWS. It defaults to the platform recorded in the status of
+	// the SubmarinerConfig.
+	Platform string
+	// GlobalnetCIDRRange is the globalnet CIDR range of the cluster set of the managed cluster.
+	GlobalnetCIDRRange string
+}
+
+// Validate checks the given SubmarinerConfig, returning the problems found. The same rules are used by the validate
+// command and by the admission webhook.
+func Validate(config *configv1alpha1.SubmarinerConfig, options Options) []Finding {
+	if options.Platform == "" {
+		options.Platform = config.Status.ManagedClusterInfo.Platform
+	}
+
+	findings := validateGlobalCIDR(config, options.GlobalnetCIDRRange)
+	findings = append(findings, validatePorts(config)...)
+	findings = append(findings, validateCableDriver(config)...)
+	findings = append(findings, validatePlatformFields(config, options.Platform)...)
+	findings = append(findings, validateAnnotations(config, options.Platform)...)
+
+	return findings
+}
+
+// HasErrors returns true if any of the given findings is an error.
+func HasErrors(findings []Finding) bool {
+	for i := range findings {
+		if findings[i].Severity == SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateGlobalCIDR(config *configv1alpha1.SubmarinerConfig, globalnetCIDRRange string) []Finding {
+	const field = "spec.globalCIDR"
+
+	if config.Spec.GlobalCIDR == "" {
+		return nil
+	}
+
+	_, globalCIDR, err := net.ParseCIDR(config.Spec.GlobalCIDR)
+	if err != nil {
+		return []Finding{newError(field, "%q is not a valid CIDR", config.Spec.GlobalCIDR)}
+	}
+
+	if globalnetCIDRRange == "" {
+		return nil
+	}
+
+	_, cidrRange, err := net.ParseCIDR(globalnetCIDRRange)
+	if err != nil {
+		return []Finding{newError(field, "the globalnet CIDR range %q is not a valid CIDR", globalnetCIDRRange)}
+	}
+
+	rangeOnes, rangeBits := cidrRange.Mask.Size()
+	ones, bits := globalCIDR.Mask.Size()
+
+	if rangeBits != bits || ones < rangeOnes || !cidrRange.Contains(globalCIDR.IP) {
+		return []Finding{newError(field, "%q is outside the globalnet CIDR range %q of the cluster set",
+			config.Spec.GlobalCIDR, globalnetCIDRRange)}
+	}
+
+	return nil
+}
+
+func validatePorts(config *configv1alpha1.SubmarinerConfig) []Finding {
+	ports := []struct {
+		field        string
+		port         int
+		defaultValue int
+	}{
+		{field: "spec.IPSecIKEPort", port: config.Spec.IPSecIKEPort, defaultValue: defaultIPSecIKEPort},
+		{field: "spec.IPSecNATTPort", port: config.Spec.IPSecNATTPort, defaultValue: defaultIPSecNATTPort},
+		{field: "spec.NATTDiscoveryPort", port: config.Spec.NATTDiscoveryPort, defaultValue: defaultNATTDiscoveryPort},
+	}
+
+	var findings []Finding
+
+	for i := range ports {
+		if ports[i].port == 0 {
+			ports[i].port = ports[i].defaultValue
+		}
+
+		if ports[i].port < 1 || ports[i].port > 65535 {
+			findings = append(findings, newError(ports[i].field, "%d is not a valid port", ports[i].port))
+		}
+	}
+
+	for i := range ports {
+		for j := i + 1; j < len(ports); j++ {
+			if ports[i].port == ports[j].port {
+				findings = append(findings, newError(ports[j].field, "port %d collides with %s", ports[j].port, ports[i].field))
+			}
+		}
+	}
+
+	return findings
+}
+
+func validateCableDriver(config *configv1alpha1.SubmarinerConfig) []Finding {
+	if config.Spec.CableDriver == "" {
+		return nil
+	}
+
+	for _, driver := range supportedCableDrivers {
+		if config.Spec.CableDriver == driver {
+			return nil
+		}
+	}
+
+	return []Finding{newError("spec.cableDriver", "unsupported cable driver %q, the supported cable drivers are %s",
+		config.Spec.CableDriver, strings.Join(supportedCableDrivers, ", "))}
+}
+
+func validatePlatformFields(config *configv1alpha1.SubmarinerConfig, platform string) []Finding {
+	if platform == "" {
+		return nil
+	}
+
+	gatewayConfig := &config.Spec.GatewayConfig
+
+	// The instance types are compared with their defaults, which are set on every SubmarinerConfig whatever its
+	// platform.
+	fields := []struct {
+		platform string
+		field    string
+		set      bool
+	}{
+		{platformAWS, "spec.gatewayConfig.aws.instanceType", !isDefault(gatewayConfig.AWS.InstanceType, "m5.xlarge")},
+		{platformAWS, "spec.gatewayConfig.aws.spot", gatewayConfig.AWS.Spot},
+		{platformGCP, "spec.gatewayConfig.gcp.instanceType", !isDefault(gatewayConfig.GCP.InstanceType, "n1-standard-4")},
+		{platformGCP, "spec.gatewayConfig.gcp.preemptible", gatewayConfig.GCP.Preemptible},
+		{platformAzure, "spec.gatewayConfig.azure.instanceType", !isDefault(gatewayConfig.Azure.InstanceType, "Standard_F4s_v2")},
+		{platformAzure, "spec.gatewayConfig.azure.spot", gatewayConfig.Azure.Spot},
+		{
+			platformOpenStack, "spec.gatewayConfig.rhos.instanceType",
+			!isDefault(gatewayConfig.RHOS.InstanceType, "PnTAE.CPU_4_Memory_8192_Disk_50"),
+		},
+	}
+
+	var findings []Finding
+
+	for _, f := range fields {
+		if f.set && f.platform != platform {
+			findings = append(findings, newError(f.field, "only applies to the %s platform, the platform of the cluster is %s",
+				f.platform, platform))
+		}
+	}
+
+	return findings
+}
+
+func validateAnnotations(config *configv1alpha1.SubmarinerConfig, platform string) []Finding {
+	names := make([]string, 0, len(config.Annotations))
+
+	for name := range config.Annotations {
+		if strings.HasPrefix(name, annotationPrefix) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	var findings []Finding
+
+	for _, name := range names {
+		field := "metadata.annotations[" + name + "]"
+
+		// Unknown annotations are ignored by submariner-addon, so even likely typos are only warnings.
+		annotationPlatform, known := knownAnnotations[name]
+		if !known {
+			if suggestion := closestAnnotation(name); suggestion != "" {
+				findings = append(findings, newWarning(field, "unknown annotation, it is ignored, did you mean %q?", suggestion))
+			} else {
+				findings = append(findings, newWarning(field, "unknown annotation, it is ignored"))
+			}
+
+			continue
+		}
+
+		if platform != "" && annotationPlatform != "" && annotationPlatform != platform {
+			findings = append(findings, newError(field, "only applies to the %s platform, the platform of the cluster is %s",
+				annotationPlatform, platform))
+		}
+	}
+
+	return findings
+}
+
+func closestAnnotation(name string) string {
+	closest := ""
+	closestDistance := maxTypoDistance + 1
+
+	for known := range knownAnnotations {
+		distance := editDistance(name, known)
+		if distance < closestDistance || (distance == closestDistance && known < closest) {
+			closest = known
+			closestDistance = distance
+		}
+	}
+
+	if closestDistance > maxTypoDistance {
+		return ""
+	}
+
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between the given strings.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			substitution := previous[j-1]
+			if a[i-1] != b[j-1] {
+				substitution++
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, substitution)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func isDefault(value, defaultValue string) bool {
+	return value == "" || value == defaultValue
+}
+
+func newError(field, format string, args ...any) Finding {
+	return Finding{Severity: SeverityError, Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+func newWarning(field, format string, args ...any) Finding {
+	return Finding{Severity: SeverityWarning, Field: field, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,234 @@
+package validation_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Validate", func() {
+	var (
+		config   *configv1alpha1.SubmarinerConfig
+		options  validation.Options
+		findings []validation.Finding
+	)
+
+	BeforeEach(func() {
+		config = &configv1alpha1.SubmarinerConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "submariner",
+				Namespace: "cluster1",
+			},
+			Spec: configv1alpha1.SubmarinerConfigSpec{
+				CableDriver:       "libreswan",
+				IPSecIKEPort:      500,
+				IPSecNATTPort:     4500,
+				NATTDiscoveryPort: 4900,
+				GatewayConfig: configv1alpha1.GatewayConfig{
+					AWS:   configv1alpha1.AWS{InstanceType: "m5.xlarge"},
+					GCP:   configv1alpha1.GCP{InstanceType: "n1-standard-4"},
+					Azure: configv1alpha1.Azure{InstanceType: "Standard_F4s_v2"},
+					RHOS:  configv1alpha1.RHOS{InstanceType: "PnTAE.CPU_4_Memory_8192_Disk_50"},
+				},
+			},
+		}
+
+		options = validation.Options{
+			Platform:           "AWS",
+			GlobalnetCIDRRange: "242.0.0.0/8",
+		}
+	})
+
+	JustBeforeEach(func() {
+		findings = validation.Validate(config, options)
+	})
+
+	When("the SubmarinerConfig is valid", func() {
+		BeforeEach(func() {
+			config.Spec.GlobalCIDR = "242.1.0.0/16"
+			config.Spec.GatewayConfig.AWS = configv1alpha1.AWS{InstanceType: "c5d.large", Spot: true}
+			config.Annotations = map[string]string{
+				"submariner.io/vpc-id":   "vpc-1",
+				"app.kubernetes.io/name": "submariner",
+			}
+		})
+
+		It("should return no findings", func() {
+			Expect(findings).To(BeEmpty())
+			Expect(validation.HasErrors(findings)).To(BeFalse())
+		})
+	})
+
+	When("the GlobalCIDR is invalid", func() {
+		BeforeEach(func() {
+			config.Spec.GlobalCIDR = "242.1.0.0/33"
+		})
+
+		It("should return an error", func() {
+			assertError(findings, "spec.globalCIDR")
+		})
+	})
+
+	When("the GlobalCIDR is outside the globalnet CIDR range", func() {
+		BeforeEach(func() {
+			config.Spec.GlobalCIDR = "243.1.0.0/16"
+		})
+
+		It("should return an error", func() {
+			assertError(findings, "spec.globalCIDR")
+		})
+
+		Context("and the globalnet CIDR range isn't known", func() {
+			BeforeEach(func() {
+				options.GlobalnetCIDRRange = ""
+			})
+
+			It("should return no findings", func() {
+				Expect(findings).To(BeEmpty())
+			})
+		})
+	})
+
+	When("the GlobalCIDR is larger than the globalnet CIDR range", func() {
+		BeforeEach(func() {
+			config.Spec.GlobalCIDR = "242.0.0.0/7"
+		})
+
+		It("should return an error", func() {
+			assertError(findings, "spec.globalCIDR")
+		})
+	})
+
+	When("the NAT-T port collides with the NAT-T discovery port", func() {
+		BeforeEach(func() {
+			config.Spec.NATTDiscoveryPort = 4500
+		})
+
+		It("should return an error", func() {
+			assertError(findings, "spec.NATTDiscoveryPort")
+		})
+	})
+
+	When("the NAT-T discovery port collides with the default NAT-T port", func() {
+		BeforeEach(func() {
+			config.Spec.IPSecNATTPort = 0
+			config.Spec.NATTDiscoveryPort = 4500
+		})
+
+		It("should return an error", func() {
+			assertError(findings, "spec.NATTDiscoveryPort")
+		})
+	})
+
+	When("the cable driver is strongswan", func() {
+		BeforeEach(func() {
+			config.Spec.CableDriver = "strongswan"
+		})
+
+		It("should return no findings", func() {
+			Expect(findings).To(BeEmpty())
+		})
+	})
+
+	When("a port is out of range", func() {
+		BeforeEach(func() {
+			config.Spec.IPSecIKEPort = 70000
+		})
+
+		It("should return an error", func() {
+			assertError(findings, "spec.IPSecIKEPort")
+		})
+	})
+
+	When("the cable driver is unsupported", func() {
+		BeforeEach(func() {
+			config.Spec.CableDriver = "openvpn"
+		})
+
+		It("should return an error", func() {
+			assertError(findings, "spec.cableDriver")
+		})
+	})
+
+	When("a field of another platform is set", func() {
+		BeforeEach(func() {
+			config.Spec.GatewayConfig.GCP.Preemptible = true
+			config.Spec.GatewayConfig.Azure.InstanceType = "Standard_D4s_v3"
+		})
+
+		It("should return errors", func() {
+			assertError(findings, "spec.gatewayConfig.gcp.preemptible", "spec.gatewayConfig.azure.instanceType")
+		})
+
+		Context("and the platform isn't known", func() {
+			BeforeEach(func() {
+				options.Platform = ""
+			})
+
+			It("should return no findings", func() {
+				Expect(findings).To(BeEmpty())
+			})
+		})
+
+		Context("and the platform is recorded in the status", func() {
+			BeforeEach(func() {
+				options.Platform = ""
+				config.Status.ManagedClusterInfo.Platform = "AWS"
+			})
+
+			It("should return errors", func() {
+				assertError(findings, "spec.gatewayConfig.gcp.preemptible", "spec.gatewayConfig.azure.instanceType")
+			})
+		})
+	})
+
+	When("an annotation of another platform is set", func() {
+		BeforeEach(func() {
+			config.Annotations = map[string]string{"submariner.io/vpc-name": "vpc"}
+		})
+
+		It("should return an error", func() {
+			assertError(findings, "metadata.annotations[submariner.io/vpc-name]")
+		})
+	})
+
+	When("an annotation has a typo", func() {
+		BeforeEach(func() {
+			config.Annotations = map[string]string{"submariner.io/vpcid": "vpc-1"}
+		})
+
+		It("should return a warning suggesting the known annotation", func() {
+			Expect(findings).To(HaveLen(1))
+			Expect(findings[0].Severity).To(Equal(validation.SeverityWarning))
+			Expect(findings[0].Field).To(Equal("metadata.annotations[submariner.io/vpcid]"))
+			Expect(findings[0].Message).To(ContainSubstring(`"submariner.io/vpc-id"`))
+			Expect(validation.HasErrors(findings)).To(BeFalse())
+		})
+	})
+
+	When("an annotation is unknown", func() {
+		BeforeEach(func() {
+			config.Annotations = map[string]string{"submariner.io/something-else": "true"}
+		})
+
+		It("should return a warning", func() {
+			Expect(findings).To(HaveLen(1))
+			Expect(findings[0].Severity).To(Equal(validation.SeverityWarning))
+			Expect(findings[0].Field).To(Equal("metadata.annotations[submariner.io/something-else]"))
+			Expect(validation.HasErrors(findings)).To(BeFalse())
+		})
+	})
+})
+
+func assertError(findings []validation.Finding, fields ...string) {
+	Expect(findings).To(HaveLen(len(fields)), "Unexpected findings: %v", findings)
+
+	for i := range findings {
+		Expect(findings[i].Severity).To(Equal(validation.SeverityError))
+		Expect(fields).To(ContainElement(findings[i].Field))
+	}
+
+	Expect(validation.HasErrors(findings)).To(BeTrue())
+}
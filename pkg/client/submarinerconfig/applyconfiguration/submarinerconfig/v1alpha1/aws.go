@@ -9,6 +9,10 @@ type AWSApplyConfiguration struct {
 	// created on the managed cluster.
 	// The default value is `m5.xlarge`.
 	InstanceType *string `json:"instanceType,omitempty"`
+	// Spot specifies that the gateway nodes are spot instances. If spot instances can't be provisioned, on-demand
+	// instances are used instead. Gateways on spot instances which receive a termination notice are moved to other
+	// worker nodes.
+	Spot *bool `json:"spot,omitempty"`
 }
 
 // AWSApplyConfiguration constructs a declarative configuration of the AWS type for use with
@@ -24,3 +28,11 @@ func (b *AWSApplyConfiguration) WithInstanceType(value string) *AWSApplyConfigur
 	b.InstanceType = &value
 	return b
 }
+
+// WithSpot sets the Spot field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Spot field is set to the value of the last call.
+func (b *AWSApplyConfiguration) WithSpot(value bool) *AWSApplyConfiguration {
+	b.Spot = &value
+	return b
+}
@@ -9,6 +9,10 @@ type AzureApplyConfiguration struct {
 	// created on the managed cluster.
 	// The default value is `Standard_F4s_v2`.
 	InstanceType *string `json:"instanceType,omitempty"`
+	// Spot specifies that the gateway nodes are spot virtual machines. If spot virtual machines can't be provisioned,
+	// standard virtual machines are used instead. Gateways on spot virtual machines which receive an eviction notice
+	// are moved to other worker nodes.
+	Spot *bool `json:"spot,omitempty"`
 }
 
 // AzureApplyConfiguration constructs a declarative configuration of the Azure type for use with
@@ -24,3 +28,11 @@ func (b *AzureApplyConfiguration) WithInstanceType(value string) *AzureApplyConf
 	b.InstanceType = &value
 	return b
 }
+
+// WithSpot sets the Spot field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Spot field is set to the value of the last call.
+func (b *AzureApplyConfiguration) WithSpot(value bool) *AzureApplyConfiguration {
+	b.Spot = &value
+	return b
+}
@@ -19,8 +19,12 @@ type GatewayConfigApplyConfiguration struct {
 	*RHOSApplyConfiguration `json:"rhos,omitempty"`
 	// Gateways represents the count of worker nodes that will be used to deploy the Submariner gateway
 	// component on the managed cluster. The default value is 1, if the value is greater than 1, the
-	// Submariner gateway HA will be enabled automatically.
+	// Submariner gateway HA will be enabled automatically. If GatewayNodes is specified, the count of its entries
+	// is used instead.
 	Gateways *int `json:"gateways,omitempty"`
+	// GatewayNodes specifies each of the gateway nodes individually, e.g. a large active gateway and smaller standby
+	// gateways, each pinned to a zone or subnet. If specified, it overrides Gateways.
+	GatewayNodes []GatewayNodeApplyConfiguration `json:"gatewayNodes,omitempty"`
 }
 
 // GatewayConfigApplyConfiguration constructs a declarative configuration of the GatewayConfig type for use with
@@ -38,6 +42,24 @@ func (b *GatewayConfigApplyConfiguration) WithInstanceType(value string) *Gatewa
 	return b
 }
 
+// WithSpot sets the Spot field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Spot field is set to the value of the last call.
+func (b *GatewayConfigApplyConfiguration) WithSpot(value bool) *GatewayConfigApplyConfiguration {
+	b.ensureAWSApplyConfigurationExists()
+	b.AWSApplyConfiguration.Spot = &value
+	return b
+}
+
+// WithPreemptible sets the Preemptible field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Preemptible field is set to the value of the last call.
+func (b *GatewayConfigApplyConfiguration) WithPreemptible(value bool) *GatewayConfigApplyConfiguration {
+	b.ensureGCPApplyConfigurationExists()
+	b.GCPApplyConfiguration.Preemptible = &value
+	return b
+}
+
 func (b *GatewayConfigApplyConfiguration) ensureAWSApplyConfigurationExists() {
 	if b.AWSApplyConfiguration == nil {
 		b.AWSApplyConfiguration = &AWSApplyConfiguration{}
@@ -69,3 +91,16 @@ func (b *GatewayConfigApplyConfiguration) WithGateways(value int) *GatewayConfig
 	b.Gateways = &value
 	return b
 }
+
+// WithGatewayNodes adds the given value to the GatewayNodes field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the GatewayNodes field.
+func (b *GatewayConfigApplyConfiguration) WithGatewayNodes(values ...*GatewayNodeApplyConfiguration) *GatewayConfigApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithGatewayNodes")
+		}
+		b.GatewayNodes = append(b.GatewayNodes, *values[i])
+	}
+	return b
+}
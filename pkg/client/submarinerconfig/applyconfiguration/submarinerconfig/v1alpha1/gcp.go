@@ -9,6 +9,10 @@ type GCPApplyConfiguration struct {
 	// created on the managed cluster.
 	// The default value is `n1-standard-4`.
 	InstanceType *string `json:"instanceType,omitempty"`
+	// Preemptible specifies that the gateway nodes are preemptible instances. If preemptible instances can't be
+	// provisioned, standard instances are used instead. Gateways on preemptible instances which receive a termination
+	// notice are moved to other worker nodes.
+	Preemptible *bool `json:"preemptible,omitempty"`
 }
 
 // GCPApplyConfiguration constructs a declarative configuration of the GCP type for use with
@@ -24,3 +28,11 @@ func (b *GCPApplyConfiguration) WithInstanceType(value string) *GCPApplyConfigur
 	b.InstanceType = &value
 	return b
 }
+
+// WithPreemptible sets the Preemptible field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Preemptible field is set to the value of the last call.
+func (b *GCPApplyConfiguration) WithPreemptible(value bool) *GCPApplyConfiguration {
+	b.Preemptible = &value
+	return b
+}
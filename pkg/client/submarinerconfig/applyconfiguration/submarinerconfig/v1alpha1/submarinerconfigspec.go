@@ -27,7 +27,8 @@ type SubmarinerConfigSpecApplyConfiguration struct {
 	// AirGappedDeployment specifies that the cluster is in an air-gapped environment without access to external servers.
 	AirGappedDeployment *bool `json:"airGappedDeployment,omitempty"`
 	// LoadBalancerEnable enables or disables load balancer mode. When enabled, a LoadBalancer is created in the
-	// submariner-operator namespace (default false).
+	// submariner-operator namespace, fronting the existing worker nodes, so the cloud preparation doesn't deploy
+	// dedicated gateway nodes (default false).
 	LoadBalancerEnable *bool `json:"loadBalancerEnable,omitempty"`
 	// InsecureBrokerConnection disables certificate validation when contacting the broker.
 	// This is useful for scenarios where the certificate chain isn't the same everywhere, e.g. with self-signed
@@ -59,6 +60,15 @@ type SubmarinerConfigSpecApplyConfiguration struct {
 	ImagePullSpecs *SubmarinerImagePullSpecsApplyConfiguration `json:"imagePullSpecs,omitempty"`
 	// GatewayConfig represents the gateways configuration of the Submariner.
 	*GatewayConfigApplyConfiguration `json:"gatewayConfig,omitempty"`
+	// CloudPreparePlanOnly enables the plan mode of the cloud preparation. The cloud resources that would be created
+	// or deleted are published in the status as a plan, but the cloud environment is not changed. Set it to false
+	// to apply the plan.
+	CloudPreparePlanOnly *bool `json:"cloudPreparePlanOnly,omitempty"`
+	// CloudDriftRemediation specifies what to do when the cloud resources created by the cloud preparation are found to
+	// have drifted, e.g. when a firewall rule was deleted. The drift is always reported in the
+	// SubmarinerClusterEnvironmentPrepared condition. Available options are None (default), which only reports it, and
+	// Reapply, which also prepares the cloud environment again.
+	CloudDriftRemediation *string `json:"cloudDriftRemediation,omitempty"`
 }
 
 // SubmarinerConfigSpecApplyConfiguration constructs a declarative configuration of the SubmarinerConfigSpec type for use with
@@ -301,3 +311,19 @@ func (b *SubmarinerConfigSpecApplyConfiguration) ensureGatewayConfigApplyConfigu
 		b.GatewayConfigApplyConfiguration = &GatewayConfigApplyConfiguration{}
 	}
 }
+
+// WithCloudPreparePlanOnly sets the CloudPreparePlanOnly field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the CloudPreparePlanOnly field is set to the value of the last call.
+func (b *SubmarinerConfigSpecApplyConfiguration) WithCloudPreparePlanOnly(value bool) *SubmarinerConfigSpecApplyConfiguration {
+	b.CloudPreparePlanOnly = &value
+	return b
+}
+
+// WithCloudDriftRemediation sets the CloudDriftRemediation field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the CloudDriftRemediation field is set to the value of the last call.
+func (b *SubmarinerConfigSpecApplyConfiguration) WithCloudDriftRemediation(value string) *SubmarinerConfigSpecApplyConfiguration {
+	b.CloudDriftRemediation = &value
+	return b
+}
@@ -15,6 +15,14 @@ type SubmarinerConfigStatusApplyConfiguration struct {
 	Conditions []v1.ConditionApplyConfiguration `json:"conditions,omitempty"`
 	// ManagedClusterInfo represents the information of a managed cluster.
 	ManagedClusterInfo *ManagedClusterInfoApplyConfiguration `json:"managedClusterInfo,omitempty"`
+	// CloudPreparePlan contains the changes the cloud preparation would make, when the plan mode is enabled.
+	CloudPreparePlan *CloudPreparePlanApplyConfiguration `json:"cloudPreparePlan,omitempty"`
+	// CloudResources lists the cloud resources created by the cloud preparation. The cloud environment clean up is
+	// limited to these resources.
+	CloudResources []CloudResourceApplyConfiguration `json:"cloudResources,omitempty"`
+	// OrphanedCloudResources records the cloud resources which couldn't be cleaned up when the addon was removed, e.g.
+	// because the credentials Secret was deleted first.
+	OrphanedCloudResources *OrphanedCloudResourcesApplyConfiguration `json:"orphanedCloudResources,omitempty"`
 }
 
 // SubmarinerConfigStatusApplyConfiguration constructs a declarative configuration of the SubmarinerConfigStatus type for use with
@@ -43,3 +51,32 @@ func (b *SubmarinerConfigStatusApplyConfiguration) WithManagedClusterInfo(value
 	b.ManagedClusterInfo = value
 	return b
 }
+
+// WithCloudPreparePlan sets the CloudPreparePlan field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the CloudPreparePlan field is set to the value of the last call.
+func (b *SubmarinerConfigStatusApplyConfiguration) WithCloudPreparePlan(value *CloudPreparePlanApplyConfiguration) *SubmarinerConfigStatusApplyConfiguration {
+	b.CloudPreparePlan = value
+	return b
+}
+
+// WithCloudResources adds the given value to the CloudResources field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the CloudResources field.
+func (b *SubmarinerConfigStatusApplyConfiguration) WithCloudResources(values ...*CloudResourceApplyConfiguration) *SubmarinerConfigStatusApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithCloudResources")
+		}
+		b.CloudResources = append(b.CloudResources, *values[i])
+	}
+	return b
+}
+
+// WithOrphanedCloudResources sets the OrphanedCloudResources field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the OrphanedCloudResources field is set to the value of the last call.
+func (b *SubmarinerConfigStatusApplyConfiguration) WithOrphanedCloudResources(value *OrphanedCloudResourcesApplyConfiguration) *SubmarinerConfigStatusApplyConfiguration {
+	b.OrphanedCloudResources = value
+	return b
+}
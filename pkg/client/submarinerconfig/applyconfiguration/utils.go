@@ -20,12 +20,22 @@ func ForKind(kind schema.GroupVersionKind) interface{} {
 		return &submarinerconfigv1alpha1.AWSApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("Azure"):
 		return &submarinerconfigv1alpha1.AzureApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("CloudPort"):
+		return &submarinerconfigv1alpha1.CloudPortApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("CloudPreparePlan"):
+		return &submarinerconfigv1alpha1.CloudPreparePlanApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("CloudResource"):
+		return &submarinerconfigv1alpha1.CloudResourceApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("GatewayConfig"):
 		return &submarinerconfigv1alpha1.GatewayConfigApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("GatewayNode"):
+		return &submarinerconfigv1alpha1.GatewayNodeApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("GCP"):
 		return &submarinerconfigv1alpha1.GCPApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("ManagedClusterInfo"):
 		return &submarinerconfigv1alpha1.ManagedClusterInfoApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("OrphanedCloudResources"):
+		return &submarinerconfigv1alpha1.OrphanedCloudResourcesApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("RHOS"):
 		return &submarinerconfigv1alpha1.RHOSApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("SubmarinerConfig"):
@@ -5,16 +5,24 @@ import (
 	"fmt"
 	"strings"
 
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
 	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
 	"github.com/stolostron/submariner-addon/pkg/cloud/reporter"
-	"github.com/stolostron/submariner-addon/pkg/constants"
 	submreporter "github.com/submariner-io/admiral/pkg/reporter"
 	cpapi "github.com/submariner-io/cloud-prepare/pkg/api"
 	cpaws "github.com/submariner-io/cloud-prepare/pkg/aws"
 	cpclient "github.com/submariner-io/cloud-prepare/pkg/aws/client"
 	"github.com/submariner-io/cloud-prepare/pkg/ocp"
-	"github.com/submariner-io/submariner/pkg/cni"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
@@ -22,18 +30,31 @@ const (
 	accessKeyIDSecretKey = "aws_access_key_id"
 	//#nosec G101 -- This is the name of a key that will store a secret, but not a default secret
 	accessKeySecretKey = "aws_secret_access_key"
-	workName           = "aws-submariner-gateway-machineset"
+	roleARNSecretKey   = "role_arn"
+	//#nosec G101 -- This is the name of a key that will store a path, but not a default secret
+	webIdentityTokenFileSecretKey = "web_identity_token_file"
+	roleSessionName               = "submariner-addon"
+	workName                      = "aws-submariner-gateway-machineset"
 )
 
 type awsProvider struct {
+	infraID           string
+	client            cpclient.Interface
+	kubeClient        kubernetes.Interface
+	dynamicClient     dynamic.Interface
 	reporter          submreporter.Interface
 	nattPort          int64
 	nattDiscoveryPort int64
 	instanceType      string
 	cniType           string
 	gateways          int
+	gatewayNodes      []configv1alpha1.GatewayNode
+	loadBalancer      bool
+	workerSG          string
 	cloudPrepare      cpapi.Cloud
 	gatewayDeployer   cpapi.GatewayDeployer
+	spotDeployer      *provider.SpotMachineSetDeployer
+	machineSets       *provider.MachineSetRecorder
 }
 
 func NewProvider(ctx context.Context, info *provider.Info) (*awsProvider, error) {
@@ -45,62 +66,43 @@ func NewProvider(ctx context.Context, info *provider.Info) (*awsProvider, error)
 		return nil, errors.New("cluster infraID is empty")
 	}
 
-	if info.Gateways < 1 {
+	if provider.GatewayCount(&info.GatewayConfig) < 1 {
 		return nil, errors.New("the count of gateways is less than 1")
 	}
 
+	if err := validateGatewayNodes(info.GatewayNodes); err != nil {
+		return nil, err
+	}
+
 	instanceType := info.GatewayConfig.AWS.InstanceType
 	if instanceType == "" {
 		instanceType = defaultInstanceType
 	}
 
-	accessKeyID, ok := info.CredentialsSecret.Data[accessKeyIDSecretKey]
-	if !ok {
-		return nil, fmt.Errorf("the aws credentials key %s is not in secret %s/%s", accessKeyIDSecretKey, info.ClusterName,
-			info.CredentialsSecret.Name)
-	}
-
-	secretAccessKey, ok := info.CredentialsSecret.Data[accessKeySecretKey]
-	if !ok {
-		return nil, fmt.Errorf("the aws credentials key %s is not in secret %s/%s", accessKeySecretKey, info.ClusterName,
-			info.CredentialsSecret.Name)
-	}
-
-	awsClient, err := cpclient.New(ctx, info.Region, cpclient.WithCredentials(string(accessKeyID), string(secretAccessKey)))
+	awsClient, err := NewClient(ctx, info)
 	if err != nil {
-		return nil, errors.Wrap(err, "error creating AWS client")
+		return nil, err
 	}
 
-	var cloudOptions []cpaws.CloudOption
-
-	if info.SubmarinerConfigAnnotations != nil {
-		annotations := info.SubmarinerConfigAnnotations
-
-		if vpcID, exists := annotations["submariner.io/vpc-id"]; exists {
-			cloudOptions = append(cloudOptions, cpaws.WithVPCName(vpcID))
-		}
+	cloudPrepare := cpaws.NewCloud(awsClient, info.InfraID, info.Region, CloudOptions(info.SubmarinerConfigAnnotations)...)
 
-		if subnetIDList, exists := annotations["submariner.io/subnet-id-list"]; exists {
-			subnetIDs := strings.Split(subnetIDList, ",")
-			for i := range subnetIDs {
-				subnetIDs[i] = strings.TrimSpace(subnetIDs[i])
-			}
-
-			cloudOptions = append(cloudOptions, cpaws.WithPublicSubnetList(subnetIDs))
-		}
+	machineSetDeployer := ocp.NewK8sMachinesetDeployer(info.RestMapper, info.DynamicClient)
 
-		if controlPlaneSGID, exists := annotations["submariner.io/control-plane-sg-id"]; exists {
-			cloudOptions = append(cloudOptions, cpaws.WithControlPlaneSecurityGroup(controlPlaneSGID))
-		}
+	var spotDeployer *provider.SpotMachineSetDeployer
 
-		if workerSGID, exists := annotations["submariner.io/worker-sg-id"]; exists {
-			cloudOptions = append(cloudOptions, cpaws.WithWorkerSecurityGroup(workerSGID))
-		}
+	if info.GatewayConfig.AWS.Spot {
+		spotDeployer = provider.NewSpotMachineSetDeployer(machineSetDeployer, info.DynamicClient, "spotMarketOptions",
+			map[string]any{})
+		machineSetDeployer = spotDeployer
 	}
 
-	cloudPrepare := cpaws.NewCloud(awsClient, info.InfraID, info.Region, cloudOptions...)
+	machineSets := provider.NewMachineSetRecorder(machineSetDeployer, "instanceType")
+	machineSetDeployer = machineSets
 
-	machineSetDeployer := ocp.NewK8sMachinesetDeployer(info.RestMapper, info.DynamicClient)
+	if len(info.GatewayNodes) > 0 {
+		machineSetDeployer = provider.NewGatewayNodesMachineSetDeployer(machineSetDeployer, info.DynamicClient, info.GatewayNodes,
+			customizeGatewayNode)
+	}
 
 	gwDeployer, err := cpaws.NewOcpGatewayDeployer(cloudPrepare, machineSetDeployer, instanceType)
 	if err != nil {
@@ -108,58 +110,455 @@ func NewProvider(ctx context.Context, info *provider.Info) (*awsProvider, error)
 	}
 
 	return &awsProvider{
+		infraID:           info.InfraID,
+		client:            awsClient,
+		kubeClient:        info.KubeClient,
+		dynamicClient:     info.DynamicClient,
 		reporter:          reporter.NewEventRecorderWrapper("AWSCloudProvider", info.EventRecorder),
 		nattPort:          int64(info.IPSecNATTPort),
 		nattDiscoveryPort: int64(info.NATTDiscoveryPort),
 		cniType:           info.NetworkType,
 		instanceType:      instanceType,
-		gateways:          info.Gateways,
+		gateways:          provider.GatewayCount(&info.GatewayConfig),
+		gatewayNodes:      info.GatewayNodes,
+		loadBalancer:      info.LoadBalancerEnable,
+		workerSG:          info.SubmarinerConfigAnnotations[WorkerSecurityGroupAnnotation],
 		cloudPrepare:      cloudPrepare,
 		gatewayDeployer:   gwDeployer,
+		spotDeployer:      spotDeployer,
+		machineSets:       machineSets,
 	}, nil
 }
 
 // PrepareSubmarinerClusterEnv prepares submariner cluster environment on AWS.
-func (a *awsProvider) PrepareSubmarinerClusterEnv(ctx context.Context) error {
+func (a *awsProvider) PrepareSubmarinerClusterEnv(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
 	// See AWS() in https://github.com/submariner-io/subctl/blob/devel/pkg/cloud/prepare/aws.go
-	// For now we only support at least one gateway (no load-balancer)
-	if err := a.gatewayDeployer.Deploy(ctx, cpapi.GatewayDeployInput{
-		PublicPorts: []cpapi.PortSpec{
-			{Port: uint16(a.nattPort), Protocol: "udp"},          //nolint:gosec // Usable port numbers fit
-			{Port: uint16(a.nattDiscoveryPort), Protocol: "udp"}, //nolint:gosec // Usable port numbers fit
-			// ESP & AH protocols are used for private-ip to private-ip gateway communications
-			{Port: 0, Protocol: "50"},
-			{Port: 0, Protocol: "51"},
-		},
-		Gateways: a.gateways,
-	}, a.reporter); err != nil {
-		return errors.Wrap(err, "error deploying gateway")
-	}
-
-	if !strings.EqualFold(a.cniType, cni.OVNKubernetes) {
-		if err := a.cloudPrepare.OpenPorts(ctx, []cpapi.PortSpec{
-			{Port: constants.SubmarinerRoutePort, Protocol: "udp"},
+	// In load balancer mode, the load balancer fronts the existing worker nodes so no dedicated gateways are deployed
+	if !a.loadBalancer {
+		if err := a.gatewayDeployer.Deploy(ctx, cpapi.GatewayDeployInput{
+			PublicPorts: a.publicPorts(),
+			Gateways:    a.gateways,
 		}, a.reporter); err != nil {
-			return errors.Wrap(err, "error opening ports")
+			return nil, errors.Wrap(err, "error deploying gateway")
+		}
+	}
+
+	if a.loadBalancer {
+		if err := OpenLoadBalancerPorts(ctx, a.client, a.infraID, a.workerSG, a.loadBalancerPorts()); err != nil {
+			return nil, err
+		}
+	}
+
+	if ports := provider.InternalPorts(a.cniType); len(ports) > 0 {
+		if err := a.cloudPrepare.OpenPorts(ctx, ports, a.reporter); err != nil {
+			return nil, errors.Wrap(err, "error opening ports")
 		}
 	}
 
 	a.reporter.Success("The Submariner cluster environment has been set up on AWS")
 
-	return nil
+	inventory, err := a.inventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.spotDeployer.RecordFallbacks(inventory)
+
+	return inventory, nil
 }
 
-// CleanUpSubmarinerClusterEnv clean up submariner cluster environment on AWS after the SubmarinerConfig was deleted.
-func (a *awsProvider) CleanUpSubmarinerClusterEnv(ctx context.Context) error {
-	if err := a.gatewayDeployer.Cleanup(ctx, a.reporter); err != nil {
-		return errors.Wrap(err, "error cleaning up gateway")
+// inventory returns the cloud resources actually created by PrepareSubmarinerClusterEnv: the gateway security group,
+// identified by its ID, and the deployed gateway MachineSets, or the load balancer ingress rules, along with the worker
+// security group the internal ports were opened in.
+func (a *awsProvider) inventory(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
+	var resources []configv1alpha1.CloudResource
+
+	if !a.loadBalancer {
+		groupID, err := gatewaySecurityGroupID(ctx, a.client, a.infraID)
+		if err != nil {
+			return nil, err
+		}
+
+		if groupID != "" {
+			resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceSecurityGroup, groupID, a.publicPorts()))
+		}
+
+		resources = append(resources, a.machineSets.Resources()...)
+	} else {
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule,
+			provider.LoadBalancerIngressName(a.infraID), a.loadBalancerPorts()))
+	}
+
+	if ports := provider.InternalPorts(a.cniType); len(ports) > 0 {
+		groupID, err := WorkerSecurityGroupID(ctx, a.client, a.infraID, a.workerSG)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule, groupID, ports))
 	}
 
-	if err := a.cloudPrepare.ClosePorts(ctx, a.reporter); err != nil {
-		return errors.Wrap(err, "error closing ports")
+	return resources, nil
+}
+
+// CleanUpSubmarinerClusterEnv clean up submariner cluster environment on AWS after the SubmarinerConfig was deleted. Only
+// the resources of the given inventory are cleaned up, unless it doesn't name them, see provider.LegacyCleanup.
+func (a *awsProvider) CleanUpSubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	if provider.LegacyCleanup(inventory, configv1alpha1.CloudResourceMachineSet, configv1alpha1.CloudResourceSecurityGroup) {
+		if err := a.gatewayDeployer.Cleanup(ctx, a.reporter); err != nil {
+			return errors.Wrap(err, "error cleaning up gateway")
+		}
+	} else if err := a.cleanUpGateways(ctx, inventory); err != nil {
+		return err
+	}
+
+	if ports := provider.LoadBalancerIngressPorts(inventory, a.infraID, a.loadBalancer, a.loadBalancerPorts()); len(ports) > 0 {
+		if err := CloseLoadBalancerPorts(ctx, a.client, a.infraID, a.workerSG, ports); err != nil {
+			return err
+		}
+	}
+
+	if provider.InternalPortsCleanup(inventory, provider.LoadBalancerIngressName(a.infraID)) {
+		if err := a.cloudPrepare.ClosePorts(ctx, a.reporter); err != nil {
+			return errors.Wrap(err, "error closing ports")
+		}
 	}
 
 	a.reporter.Success("The Submariner cluster environment has been cleaned up on AWS")
 
 	return nil
 }
+
+// cleanUpGateways deletes exactly the gateway MachineSets and security group of the given inventory.
+func (a *awsProvider) cleanUpGateways(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	if err := a.machineSets.DeleteMachineSets(ctx, inventory); err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	for _, group := range provider.NamedResources(inventory, configv1alpha1.CloudResourceSecurityGroup) {
+		_, err := a.client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: awssdk.String(group.Name)})
+		if err != nil && !isAPIError(err, "InvalidGroup.NotFound") {
+			// The security group can't be deleted until the gateway instances are terminated, the clean up is retried
+			return errors.Wrapf(err, "error deleting security group %q", group.Name)
+		}
+	}
+
+	return nil
+}
+
+// PlanSubmarinerClusterEnv returns the cloud resources the given plan operation would change on AWS, compared with the
+// gateway MachineSets and security groups of the cluster.
+func (a *awsProvider) PlanSubmarinerClusterEnv(ctx context.Context, operation string) ([]configv1alpha1.CloudResource, error) {
+	planned := a.planned()
+	plan := &provider.Plan{}
+
+	if !a.loadBalancer {
+		machineSets, err := provider.GatewayMachineSets(ctx, a.dynamicClient, "instanceType")
+		if err != nil {
+			return nil, err //nolint:wrapcheck // No need to wrap here
+		}
+
+		plan.AddGateways(provider.NamedResources(planned, configv1alpha1.CloudResourceMachineSet), machineSets)
+	}
+
+	if err := PlanSecurityGroups(ctx, a.client, a.infraID, a.workerSG, plan, planned); err != nil {
+		return nil, err
+	}
+
+	return plan.Resources(operation), nil
+}
+
+// planned returns the cloud resources PrepareSubmarinerClusterEnv creates on AWS.
+func (a *awsProvider) planned() []configv1alpha1.CloudResource {
+	var resources []configv1alpha1.CloudResource
+
+	if !a.loadBalancer {
+		resources = append(resources,
+			provider.PortsResource(configv1alpha1.CloudResourceSecurityGroup, a.infraID+"-submariner-gw-sg", a.publicPorts()))
+		resources = append(resources, provider.GatewayResources(configv1alpha1.CloudResourceMachineSet, a.infraID+"-submariner-gw",
+			a.instanceType, a.gateways, a.gatewayNodes)...)
+	} else {
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule,
+			provider.LoadBalancerIngressName(a.infraID), a.loadBalancerPorts()))
+	}
+
+	return append(resources, provider.InternalPortsResources(a.infraID+"-worker-sg", provider.InternalPorts(a.cniType))...)
+}
+
+// VerifySubmarinerClusterEnv returns the drift of the cloud resources in the given inventory on AWS.
+func (a *awsProvider) VerifySubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) ([]string, error) {
+	drift, err := provider.VerifyGatewayNodes(ctx, a.kubeClient, inventory)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	portsDrift, err := VerifySecurityGroups(ctx, a.client, a.infraID, a.workerSG, inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(drift, portsDrift...), nil
+}
+
+// VerifySecurityGroups returns the drift of the security groups and firewall rules in the given inventory, i.e. a
+// description of each of them which is missing or doesn't allow one of its ports anymore, see securityGroupPermissions.
+func VerifySecurityGroups(ctx context.Context, client cpclient.Interface, infraID, workerSecurityGroup string,
+	inventory []configv1alpha1.CloudResource,
+) ([]string, error) {
+	var drift []string
+
+	for _, resource := range provider.NamedResources(inventory, configv1alpha1.CloudResourceSecurityGroup,
+		configv1alpha1.CloudResourceFirewallRule) {
+		permissions, found, err := securityGroupPermissions(ctx, client, infraID, workerSecurityGroup, &resource)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			drift = append(drift, fmt.Sprintf("the security group of %s %q is missing", resource.Kind, resource.Name))
+			continue
+		}
+
+		drift = append(drift, provider.MissingPorts(&resource, func(port *configv1alpha1.CloudPort) bool {
+			return isIngressAllowed(permissions, port)
+		})...)
+	}
+
+	return drift, nil
+}
+
+// PlanSecurityGroups adds the planned security groups and firewall rules to the given plan, compared with the security
+// groups of the cluster, see securityGroupPermissions.
+func PlanSecurityGroups(ctx context.Context, client cpclient.Interface, infraID, workerSecurityGroup string, plan *provider.Plan,
+	planned []configv1alpha1.CloudResource,
+) error {
+	for _, resource := range provider.NamedResources(planned, configv1alpha1.CloudResourceSecurityGroup,
+		configv1alpha1.CloudResourceFirewallRule) {
+		permissions, found, err := securityGroupPermissions(ctx, client, infraID, workerSecurityGroup, &resource)
+		if err != nil {
+			return err
+		}
+
+		plan.AddPorts(&resource, found, func(port *configv1alpha1.CloudPort) bool {
+			return isIngressAllowed(permissions, port)
+		})
+	}
+
+	return nil
+}
+
+// securityGroupPermissions returns the ingress permissions of the security group of the given security group or firewall
+// rule, and whether it exists. The firewall rules, i.e. the rules opening the internal ports and the load balancer ports,
+// are added to the worker security group, either recorded by its ID or, in the plans and the inventories recorded before
+// the IDs were, looked up with the given worker security group.
+func securityGroupPermissions(ctx context.Context, client cpclient.Interface, infraID, workerSecurityGroup string,
+	resource *configv1alpha1.CloudResource,
+) ([]types.IpPermission, bool, error) {
+	nameOrID := resource.Name
+
+	if resource.Kind == configv1alpha1.CloudResourceFirewallRule && !strings.HasPrefix(nameOrID, "sg-") {
+		groupID, err := WorkerSecurityGroupID(ctx, client, infraID, workerSecurityGroup)
+		if err != nil {
+			return nil, false, err
+		}
+
+		nameOrID = groupID
+	}
+
+	output, err := client.DescribeSecurityGroups(ctx, describeSecurityGroupInput(nameOrID))
+	if isAPIError(err, "InvalidGroup.NotFound") {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "error retrieving security group %q", nameOrID)
+	}
+
+	if len(output.SecurityGroups) == 0 {
+		return nil, false, nil
+	}
+
+	return output.SecurityGroups[0].IpPermissions, true, nil
+}
+
+// gatewaySecurityGroupID returns the ID of the gateway security group created by cloud-prepare, or an empty string if
+// there's none.
+func gatewaySecurityGroupID(ctx context.Context, client cpclient.Interface, infraID string) (string, error) {
+	name := infraID + "-submariner-gw-sg"
+
+	output, err := client.DescribeSecurityGroups(ctx, describeSecurityGroupInput(name))
+	if err != nil {
+		return "", errors.Wrapf(err, "error retrieving security group %q", name)
+	}
+
+	if len(output.SecurityGroups) == 0 {
+		return "", nil
+	}
+
+	return awssdk.ToString(output.SecurityGroups[0].GroupId), nil
+}
+
+// describeSecurityGroupInput returns the input describing the security group with the given ID or, for the inventories
+// recorded before the IDs were, name.
+func describeSecurityGroupInput(nameOrID string) *ec2.DescribeSecurityGroupsInput {
+	if strings.HasPrefix(nameOrID, "sg-") {
+		return &ec2.DescribeSecurityGroupsInput{GroupIds: []string{nameOrID}}
+	}
+
+	return &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{{Name: awssdk.String("group-name"), Values: []string{nameOrID}}},
+	}
+}
+
+func isIngressAllowed(permissions []types.IpPermission, port *configv1alpha1.CloudPort) bool {
+	for i := range permissions {
+		protocol := awssdk.ToString(permissions[i].IpProtocol)
+		if protocol == "-1" {
+			// All the protocols and ports are allowed
+			return true
+		}
+
+		if protocol != port.Protocol {
+			continue
+		}
+
+		if port.Port == 0 {
+			return true
+		}
+
+		if int(awssdk.ToInt32(permissions[i].FromPort)) <= port.Port && port.Port <= int(awssdk.ToInt32(permissions[i].ToPort)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadBalancerPorts returns the ports opened to external sources in the worker security group in load balancer mode.
+func (a *awsProvider) loadBalancerPorts() []cpapi.PortSpec {
+	return provider.LoadBalancerPorts(uint16(a.nattPort), uint16(a.nattDiscoveryPort)) //nolint:gosec // Usable port numbers fit
+}
+
+func (a *awsProvider) publicPorts() []cpapi.PortSpec {
+	return []cpapi.PortSpec{
+		{Port: uint16(a.nattPort), Protocol: "udp"},          //nolint:gosec // Usable port numbers fit
+		{Port: uint16(a.nattDiscoveryPort), Protocol: "udp"}, //nolint:gosec // Usable port numbers fit
+		// ESP & AH protocols are used for private-ip to private-ip gateway communications
+		{Port: 0, Protocol: "50"},
+		{Port: 0, Protocol: "51"},
+	}
+}
+
+// NewClient creates an AWS client using the credentials from the provider Info's credentials Secret. If the Secret
+// contains a role ARN, the role is assumed using the agent's projected service account token (STS web identity);
+// otherwise the Secret must contain a static access key.
+func NewClient(ctx context.Context, info *provider.Info) (cpclient.Interface, error) {
+	if roleARN, ok := info.CredentialsSecret.Data[roleARNSecretKey]; ok {
+		tokenFile, err := provider.ServiceAccountTokenFile(string(info.CredentialsSecret.Data[webIdentityTokenFileSecretKey]))
+		if err != nil {
+			return nil, err //nolint:wrapcheck // No need to wrap here
+		}
+
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(info.Region),
+			config.WithCredentialsProvider(newWebIdentityCredentials(info.Region, string(roleARN), tokenFile)))
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading the AWS configuration")
+		}
+
+		return ec2.NewFromConfig(cfg), nil
+	}
+
+	accessKeyID, ok := info.CredentialsSecret.Data[accessKeyIDSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("the aws credentials key %s is not in secret %s/%s", accessKeyIDSecretKey, info.ClusterName,
+			info.CredentialsSecret.Name)
+	}
+
+	secretAccessKey, ok := info.CredentialsSecret.Data[accessKeySecretKey]
+	if !ok {
+		return nil, fmt.Errorf("the aws credentials key %s is not in secret %s/%s", accessKeySecretKey, info.ClusterName,
+			info.CredentialsSecret.Name)
+	}
+
+	awsClient, err := cpclient.New(ctx, info.Region, cpclient.WithCredentials(string(accessKeyID), string(secretAccessKey)))
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating AWS client")
+	}
+
+	return awsClient, nil
+}
+
+// newWebIdentityCredentials returns a provider of temporary credentials obtained by assuming the given role with the
+// token read from tokenFile. The token file is re-read whenever the credentials are refreshed, so rotated projected
+// tokens are picked up.
+func newWebIdentityCredentials(region, roleARN, tokenFile string, optFns ...func(*sts.Options)) awssdk.CredentialsProvider {
+	stsClient := sts.New(sts.Options{Region: region}, optFns...)
+
+	return awssdk.NewCredentialsCache(stscreds.NewWebIdentityRoleProvider(stsClient, roleARN, stscreds.IdentityTokenFile(tokenFile),
+		func(o *stscreds.WebIdentityRoleOptions) {
+			o.RoleSessionName = roleSessionName
+		}))
+}
+
+// CloudOptions returns the cloud-prepare options specified by the given SubmarinerConfig annotations.
+func CloudOptions(annotations map[string]string) []cpaws.CloudOption {
+	var cloudOptions []cpaws.CloudOption
+
+	if vpcID, exists := annotations["submariner.io/vpc-id"]; exists {
+		cloudOptions = append(cloudOptions, cpaws.WithVPCName(vpcID))
+	}
+
+	if subnetIDList, exists := annotations["submariner.io/subnet-id-list"]; exists {
+		subnetIDs := strings.Split(subnetIDList, ",")
+		for i := range subnetIDs {
+			subnetIDs[i] = strings.TrimSpace(subnetIDs[i])
+		}
+
+		cloudOptions = append(cloudOptions, cpaws.WithPublicSubnetList(subnetIDs))
+	}
+
+	if controlPlaneSGID, exists := annotations["submariner.io/control-plane-sg-id"]; exists {
+		cloudOptions = append(cloudOptions, cpaws.WithControlPlaneSecurityGroup(controlPlaneSGID))
+	}
+
+	if workerSGID, exists := annotations[WorkerSecurityGroupAnnotation]; exists {
+		cloudOptions = append(cloudOptions, cpaws.WithWorkerSecurityGroup(workerSGID))
+	}
+
+	return cloudOptions
+}
+
+// validateGatewayNodes checks that the given gateway nodes which specify a zone also specify a subnet: AWS subnets belong
+// to a single zone, so the subnet of the MachineSet template, in another zone, can't be kept.
+func validateGatewayNodes(nodes []configv1alpha1.GatewayNode) error {
+	for i := range nodes {
+		if nodes[i].Zone != "" && nodes[i].Subnet == "" {
+			return errors.Errorf("gateway node %d specifies zone %q without a subnet, the subnet must be specified along with the "+
+				"zone on AWS", i, nodes[i].Zone)
+		}
+	}
+
+	return nil
+}
+
+// customizeGatewayNode sets the instance type, availability zone and subnet of the given gateway node in the provider spec
+// of an AWS gateway MachineSet. The subnet can be specified by ID or by name. The zone is only specified along with the
+// subnet, see validateGatewayNodes.
+func customizeGatewayNode(providerSpec map[string]any, node *configv1alpha1.GatewayNode) {
+	if node.InstanceType != "" {
+		providerSpec["instanceType"] = node.InstanceType
+	}
+
+	if node.Zone != "" {
+		_ = unstructured.SetNestedField(providerSpec, node.Zone, "placement", "availabilityZone")
+	}
+
+	switch {
+	case strings.HasPrefix(node.Subnet, "subnet-"):
+		providerSpec["subnet"] = map[string]any{"id": node.Subnet}
+	case node.Subnet != "":
+		providerSpec["subnet"] = map[string]any{
+			"filters": []any{map[string]any{"name": "tag:Name", "values": []any{node.Subnet}}},
+		}
+	}
+}
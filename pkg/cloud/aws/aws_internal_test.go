@@ -0,0 +1,271 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	cpapi "github.com/submariner-io/cloud-prepare/pkg/api"
+)
+
+const (
+	testRoleARN = "arn:aws:iam::123456789012:role/submariner"
+
+	assumeRoleResponse = `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>ASIATEST</AccessKeyId>
+      <SecretAccessKey>test-secret</SecretAccessKey>
+      <SessionToken>test-session-token</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`
+)
+
+var _ = Describe("newWebIdentityCredentials", func() {
+	var (
+		tokenFile   string
+		stsStatus   int
+		stsRequests []url.Values
+		withFakeSTS func(*sts.Options)
+	)
+
+	BeforeEach(func() {
+		tokenFile = filepath.Join(GinkgoT().TempDir(), "token")
+		Expect(os.WriteFile(tokenFile, []byte("projected-token"), 0o600)).To(Succeed())
+
+		stsStatus = http.StatusOK
+		stsRequests = nil
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.ParseForm()).To(Succeed())
+			stsRequests = append(stsRequests, r.PostForm)
+
+			w.Header().Set("Content-Type", "text/xml")
+			w.WriteHeader(stsStatus)
+
+			if stsStatus == http.StatusOK {
+				_, _ = w.Write([]byte(assumeRoleResponse))
+			}
+		}))
+
+		DeferCleanup(server.Close)
+
+		withFakeSTS = func(o *sts.Options) {
+			o.BaseEndpoint = awssdk.String(server.URL)
+		}
+	})
+
+	It("should assume the role using the projected token", func() {
+		creds, err := newWebIdentityCredentials("us-east-1", testRoleARN, tokenFile, withFakeSTS).Retrieve(context.TODO())
+		Expect(err).To(Succeed())
+		Expect(creds.AccessKeyID).To(Equal("ASIATEST"))
+		Expect(creds.SecretAccessKey).To(Equal("test-secret"))
+		Expect(creds.SessionToken).To(Equal("test-session-token"))
+
+		Expect(stsRequests).To(HaveLen(1))
+		Expect(stsRequests[0].Get("Action")).To(Equal("AssumeRoleWithWebIdentity"))
+		Expect(stsRequests[0].Get("RoleArn")).To(Equal(testRoleARN))
+		Expect(stsRequests[0].Get("RoleSessionName")).To(Equal(roleSessionName))
+		Expect(stsRequests[0].Get("WebIdentityToken")).To(Equal("projected-token"))
+	})
+
+	It("should cache the credentials until they expire", func() {
+		provider := newWebIdentityCredentials("us-east-1", testRoleARN, tokenFile, withFakeSTS)
+
+		_, err := provider.Retrieve(context.TODO())
+		Expect(err).To(Succeed())
+
+		_, err = provider.Retrieve(context.TODO())
+		Expect(err).To(Succeed())
+
+		Expect(stsRequests).To(HaveLen(1))
+	})
+
+	When("STS rejects the token", func() {
+		BeforeEach(func() {
+			stsStatus = http.StatusForbidden
+		})
+
+		It("should return an error", func() {
+			_, err := newWebIdentityCredentials("us-east-1", testRoleARN, tokenFile, withFakeSTS).Retrieve(context.TODO())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("the token file doesn't exist", func() {
+		It("should return an error", func() {
+			_, err := newWebIdentityCredentials("us-east-1", testRoleARN, tokenFile+"-missing", withFakeSTS).Retrieve(context.TODO())
+			Expect(err).To(HaveOccurred())
+			Expect(stsRequests).To(BeEmpty())
+		})
+	})
+})
+
+var _ = DescribeTable("isIngressAllowed",
+	func(port configv1alpha1.CloudPort, expected bool) {
+		permissions := []types.IpPermission{
+			{IpProtocol: awssdk.String("udp"), FromPort: awssdk.Int32(4500), ToPort: awssdk.Int32(4500)},
+			{IpProtocol: awssdk.String("udp"), FromPort: awssdk.Int32(4800), ToPort: awssdk.Int32(4900)},
+			{IpProtocol: awssdk.String("50")},
+		}
+
+		Expect(isIngressAllowed(permissions, &port)).To(Equal(expected))
+	},
+	Entry("an allowed port", configv1alpha1.CloudPort{Port: 4500, Protocol: "udp"}, true),
+	Entry("a port in an allowed range", configv1alpha1.CloudPort{Port: 4900, Protocol: "udp"}, true),
+	Entry("a disallowed port", configv1alpha1.CloudPort{Port: 500, Protocol: "udp"}, false),
+	Entry("an allowed port with another protocol", configv1alpha1.CloudPort{Port: 4500, Protocol: "tcp"}, false),
+	Entry("an allowed protocol without ports", configv1alpha1.CloudPort{Port: 0, Protocol: "50"}, true),
+	Entry("a disallowed protocol", configv1alpha1.CloudPort{Port: 0, Protocol: "51"}, false),
+)
+
+var _ = Describe("planned", func() {
+	var provider *awsProvider
+
+	BeforeEach(func() {
+		provider = &awsProvider{
+			infraID:           "test",
+			nattPort:          4500,
+			nattDiscoveryPort: 4900,
+			instanceType:      defaultInstanceType,
+			cniType:           "OpenShiftSDN",
+			gateways:          1,
+		}
+	})
+
+	It("should plan the gateway MachineSet and security groups", func() {
+		resources := provider.planned()
+		Expect(resources).To(HaveLen(3))
+		Expect(resources[1]).To(Equal(configv1alpha1.CloudResource{
+			Kind:         configv1alpha1.CloudResourceMachineSet,
+			Name:         "test-submariner-gw",
+			InstanceType: defaultInstanceType,
+			Replicas:     1,
+		}))
+	})
+
+	When("the load balancer mode is enabled", func() {
+		BeforeEach(func() {
+			provider.loadBalancer = true
+		})
+
+		It("should only plan opening the load balancer ports to external sources and the route port on the worker nodes",
+			func() {
+				Expect(provider.planned()).To(Equal([]configv1alpha1.CloudResource{
+					{
+						Kind: configv1alpha1.CloudResourceFirewallRule,
+						Name: "test-submariner-lb-ingress",
+						Ports: []configv1alpha1.CloudPort{
+							{Port: 4500, Protocol: "udp"},
+							{Port: 4900, Protocol: "udp"},
+							{Port: 10256, Protocol: "tcp"},
+						},
+					},
+					{
+						Kind:  configv1alpha1.CloudResourceFirewallRule,
+						Name:  "test-worker-sg",
+						Ports: []configv1alpha1.CloudPort{{Port: 4800, Protocol: "udp"}},
+					},
+				}))
+			})
+	})
+})
+
+var _ = Describe("loadBalancerPermission", func() {
+	It("should open the port to any source with a description identifying the rule", func() {
+		Expect(loadBalancerPermission("test", &cpapi.PortSpec{Port: 4500, Protocol: "udp"})).To(Equal(types.IpPermission{
+			IpProtocol: awssdk.String("udp"),
+			FromPort:   awssdk.Int32(4500),
+			ToPort:     awssdk.Int32(4500),
+			IpRanges: []types.IpRange{{
+				CidrIp:      awssdk.String("0.0.0.0/0"),
+				Description: awssdk.String("test-submariner-lb-ingress"),
+			}},
+		}))
+	})
+})
+
+var _ = Describe("isIngressAllowed", func() {
+	permissions := []types.IpPermission{{
+		IpProtocol: awssdk.String("udp"),
+		FromPort:   awssdk.Int32(4490),
+		ToPort:     awssdk.Int32(4500),
+	}}
+
+	It("should return true for a port in the range of a permission of its protocol", func() {
+		Expect(isIngressAllowed(permissions, &configv1alpha1.CloudPort{Port: 4500, Protocol: "udp"})).To(BeTrue())
+	})
+
+	It("should return false for a port outside the ranges of the permissions of its protocol", func() {
+		Expect(isIngressAllowed(permissions, &configv1alpha1.CloudPort{Port: 4800, Protocol: "udp"})).To(BeFalse())
+		Expect(isIngressAllowed(permissions, &configv1alpha1.CloudPort{Port: 4500, Protocol: "tcp"})).To(BeFalse())
+	})
+
+	It("should return true for any port if all the protocols are allowed", func() {
+		Expect(isIngressAllowed([]types.IpPermission{{IpProtocol: awssdk.String("-1")}},
+			&configv1alpha1.CloudPort{Port: 4800, Protocol: "udp"})).To(BeTrue())
+	})
+})
+
+var _ = Describe("validateGatewayNodes", func() {
+	It("should accept gateway nodes specifying a zone along with a subnet", func() {
+		Expect(validateGatewayNodes([]configv1alpha1.GatewayNode{
+			{InstanceType: "c5.large"},
+			{Zone: "us-east-1b", Subnet: "subnet-b"},
+			{Subnet: "public-c"},
+		})).To(Succeed())
+	})
+
+	It("should reject a gateway node specifying a zone without a subnet", func() {
+		Expect(validateGatewayNodes([]configv1alpha1.GatewayNode{
+			{Zone: "us-east-1a", Subnet: "subnet-a"},
+			{Zone: "us-east-1b"},
+		})).To(MatchError(ContainSubstring("us-east-1b")))
+	})
+})
+
+var _ = Describe("customizeGatewayNode", func() {
+	var providerSpec map[string]any
+
+	BeforeEach(func() {
+		providerSpec = map[string]any{
+			"instanceType": "m5.xlarge",
+			"placement":    map[string]any{"region": "us-east-1", "availabilityZone": "us-east-1a"},
+			"subnet":       map[string]any{"id": "subnet-a"},
+		}
+	})
+
+	It("should set the specified instance type, zone and subnet ID", func() {
+		customizeGatewayNode(providerSpec, &configv1alpha1.GatewayNode{
+			InstanceType: "c5.large",
+			Zone:         "us-east-1b",
+			Subnet:       "subnet-b",
+		})
+
+		Expect(providerSpec).To(Equal(map[string]any{
+			"instanceType": "c5.large",
+			"placement":    map[string]any{"region": "us-east-1", "availabilityZone": "us-east-1b"},
+			"subnet":       map[string]any{"id": "subnet-b"},
+		}))
+	})
+
+	It("should select a subnet specified by name with a tag filter", func() {
+		customizeGatewayNode(providerSpec, &configv1alpha1.GatewayNode{Subnet: "public-b"})
+
+		Expect(providerSpec["instanceType"]).To(Equal("m5.xlarge"))
+		Expect(providerSpec["subnet"]).To(Equal(map[string]any{
+			"filters": []any{map[string]any{"name": "tag:Name", "values": []any{"public-b"}}},
+		}))
+	})
+})
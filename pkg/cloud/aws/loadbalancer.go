@@ -0,0 +1,112 @@
+package aws
+
+import (
+	"context"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/pkg/errors"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	cpapi "github.com/submariner-io/cloud-prepare/pkg/api"
+	cpclient "github.com/submariner-io/cloud-prepare/pkg/aws/client"
+)
+
+const (
+	// WorkerSecurityGroupAnnotation can be set on the SubmarinerConfig to specify the worker security group ID instead of
+	// looking it up.
+	WorkerSecurityGroupAnnotation = "submariner.io/worker-sg-id"
+	anyIPv4                       = "0.0.0.0/0"
+)
+
+// OpenLoadBalancerPorts opens the given ports of the worker security group to any source, so that the load balancer
+// fronting the worker nodes in load balancer mode can forward the traffic of the remote clusters and probe the nodes.
+// The rules are described with the load balancer ingress name so that they can be told apart from the cluster's.
+func OpenLoadBalancerPorts(ctx context.Context, client cpclient.Interface, infraID, workerSecurityGroup string,
+	ports []cpapi.PortSpec,
+) error {
+	groupID, err := WorkerSecurityGroupID(ctx, client, infraID, workerSecurityGroup)
+	if err != nil {
+		return err
+	}
+
+	// The ports are authorized one by one since a request fails as a whole if one of them already is
+	for i := range ports {
+		_, err := client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       awssdk.String(groupID),
+			IpPermissions: []types.IpPermission{loadBalancerPermission(infraID, &ports[i])},
+		})
+		if err != nil && !isAPIError(err, "InvalidPermission.Duplicate") {
+			return errors.Wrapf(err, "error opening port %d/%s of security group %q", ports[i].Port, ports[i].Protocol, groupID)
+		}
+	}
+
+	return nil
+}
+
+// CloseLoadBalancerPorts revokes the rules opened by OpenLoadBalancerPorts for the given ports.
+func CloseLoadBalancerPorts(ctx context.Context, client cpclient.Interface, infraID, workerSecurityGroup string,
+	ports []cpapi.PortSpec,
+) error {
+	groupID, err := WorkerSecurityGroupID(ctx, client, infraID, workerSecurityGroup)
+	if err != nil {
+		return err
+	}
+
+	for i := range ports {
+		_, err := client.RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       awssdk.String(groupID),
+			IpPermissions: []types.IpPermission{loadBalancerPermission(infraID, &ports[i])},
+		})
+		if err != nil && !isAPIError(err, "InvalidPermission.NotFound") {
+			return errors.Wrapf(err, "error closing port %d/%s of security group %q", ports[i].Port, ports[i].Protocol, groupID)
+		}
+	}
+
+	return nil
+}
+
+func loadBalancerPermission(infraID string, port *cpapi.PortSpec) types.IpPermission {
+	permission := types.IpPermission{
+		IpProtocol: awssdk.String(port.Protocol),
+		IpRanges: []types.IpRange{{
+			CidrIp:      awssdk.String(anyIPv4),
+			Description: awssdk.String(provider.LoadBalancerIngressName(infraID)),
+		}},
+	}
+
+	if port.Port != 0 {
+		permission.FromPort = awssdk.Int32(int32(port.Port))
+		permission.ToPort = awssdk.Int32(int32(port.Port))
+	}
+
+	return permission
+}
+
+// WorkerSecurityGroupID returns the given worker security group ID or, if empty, looks up the worker security group of
+// the cluster, named after the infrastructure ID by the installer.
+func WorkerSecurityGroupID(ctx context.Context, client cpclient.Interface, infraID, workerSecurityGroup string) (string, error) {
+	if workerSecurityGroup != "" {
+		return workerSecurityGroup, nil
+	}
+
+	output, err := client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []types.Filter{{Name: awssdk.String("tag:Name"), Values: []string{infraID + "-worker-sg", infraID + "-node"}}},
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "error retrieving the worker security group")
+	}
+
+	if len(output.SecurityGroups) == 0 {
+		return "", errors.Errorf("the worker security group of cluster %q wasn't found", infraID)
+	}
+
+	return awssdk.ToString(output.SecurityGroups[0].GroupId), nil
+}
+
+func isAPIError(err error, code string) bool {
+	var apiErr smithy.APIError
+
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == code
+}
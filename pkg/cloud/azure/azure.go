@@ -3,38 +3,54 @@ package azure
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
-	"strings"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
 	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
 	"github.com/stolostron/submariner-addon/pkg/cloud/reporter"
-	"github.com/stolostron/submariner-addon/pkg/constants"
 	submreporter "github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/cloud-prepare/pkg/api"
 	"github.com/submariner-io/cloud-prepare/pkg/azure"
 	"github.com/submariner-io/cloud-prepare/pkg/k8s"
 	"github.com/submariner-io/cloud-prepare/pkg/ocp"
-	"github.com/submariner-io/submariner/pkg/cni"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
 	servicePrincipalJSON = "osServicePrincipal.json"
 	gwInstanceType       = "Standard_F4s_v2"
+
+	// The keys used for workload identity, the same as in the credentials Secrets created for OpenShift components
+	clientIDKey           = "azure_client_id"
+	tenantIDKey           = "azure_tenant_id"
+	subscriptionIDKey     = "azure_subscription_id"
+	federatedTokenFileKey = "azure_federated_token_file"
 )
 
 type azureProvider struct {
 	infraID           string
+	instanceType      string
+	kubeClient        kubernetes.Interface
 	cniType           string
+	cloudInfo         *azure.CloudInfo
 	cloudPrepare      api.Cloud
 	reporter          submreporter.Interface
 	gwDeployer        api.GatewayDeployer
 	nattDiscoveryPort int64
 	gateways          int
+	gatewayNodes      []configv1alpha1.GatewayNode
+	loadBalancer      bool
 	nattPort          uint16
 	airGapped         bool
+	spotDeployer      *provider.SpotMachineSetDeployer
+	machineSets       *provider.MachineSetRecorder
+	dynamicClient     dynamic.Interface
 }
 
 func NewProvider(info *provider.Info) (*azureProvider, error) {
@@ -47,24 +63,33 @@ func NewProvider(info *provider.Info) (*azureProvider, error) {
 		instanceType = gwInstanceType
 	}
 
-	if info.Gateways < 1 {
+	if provider.GatewayCount(&info.GatewayConfig) < 1 {
 		return nil, errors.New("the count of gateways is less than 1")
 	}
 
-	subscriptionID, err := initializeFromAuthFile(info.CredentialsSecret)
+	subscriptionID, credentials, err := NewCredential(info.CredentialsSecret)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to initialize from auth file")
-	}
-
-	credentials, err := azidentity.NewEnvironmentCredential(nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to create the Azure credentials")
+		return nil, err
 	}
 
 	k8sClient := k8s.NewInterface(info.KubeClient)
 
 	msDeployer := ocp.NewK8sMachinesetDeployer(info.RestMapper, info.DynamicClient)
 
+	var spotDeployer *provider.SpotMachineSetDeployer
+
+	if info.GatewayConfig.Azure.Spot {
+		spotDeployer = provider.NewSpotMachineSetDeployer(msDeployer, info.DynamicClient, "spotVMOptions", map[string]any{})
+		msDeployer = spotDeployer
+	}
+
+	machineSets := provider.NewMachineSetRecorder(msDeployer, "vmSize")
+	msDeployer = machineSets
+
+	if len(info.GatewayNodes) > 0 {
+		msDeployer = provider.NewGatewayNodesMachineSetDeployer(msDeployer, info.DynamicClient, info.GatewayNodes, CustomizeGatewayNode)
+	}
+
 	cloudInfo := azure.CloudInfo{
 		SubscriptionID:  subscriptionID,
 		InfraID:         info.InfraID,
@@ -80,14 +105,22 @@ func NewProvider(info *provider.Info) (*azureProvider, error) {
 
 	return &azureProvider{
 		infraID:           info.InfraID,
+		instanceType:      instanceType,
+		kubeClient:        info.KubeClient,
+		dynamicClient:     info.DynamicClient,
 		nattPort:          uint16(info.IPSecNATTPort), //nolint:gosec // Usable port numbers fit
 		cniType:           info.NetworkType,
+		cloudInfo:         &cloudInfo,
 		cloudPrepare:      cloudPrepare,
 		gwDeployer:        gwDeployer,
 		reporter:          reporter.NewEventRecorderWrapper("AzureCloudProvider", info.EventRecorder),
 		nattDiscoveryPort: int64(info.NATTDiscoveryPort),
-		gateways:          info.Gateways,
+		gateways:          provider.GatewayCount(&info.GatewayConfig),
+		gatewayNodes:      info.GatewayNodes,
+		loadBalancer:      info.LoadBalancerEnable,
 		airGapped:         info.SubmarinerConfigSpec.AirGappedDeployment,
+		spotDeployer:      spotDeployer,
+		machineSets:       machineSets,
 	}, nil
 }
 
@@ -97,46 +130,104 @@ func NewProvider(info *provider.Info) (*azureProvider, error) {
 //   - NAT traversal port (by default 4500/UDP)
 //   - 4800/UDP port to encapsulate Pod traffic from worker and master nodes to the Submariner Gateway nodes
 //   - ESP & AH protocols for private-ip to private-ip gateway communications
-func (r *azureProvider) PrepareSubmarinerClusterEnv(ctx context.Context) error {
+func (r *azureProvider) PrepareSubmarinerClusterEnv(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
 	// TODO For ovn the port 4800 need not be opened.
-	if err := r.gwDeployer.Deploy(ctx, api.GatewayDeployInput{
-		PublicPorts: []api.PortSpec{
-			{Port: r.nattPort, Protocol: "udp"},
-			{Port: uint16(r.nattDiscoveryPort), Protocol: "udp"}, //nolint:gosec // Usable port numbers fit
-			{Port: 0, Protocol: "esp"},
-			{Port: 0, Protocol: "ah"},
-		},
-		Gateways:  r.gateways,
-		AirGapped: r.airGapped,
-	}, r.reporter); err != nil {
-		return errors.Wrap(err, "error deploying gateway")
-	}
-
-	if !strings.EqualFold(r.cniType, cni.OVNKubernetes) {
-		if err := r.cloudPrepare.OpenPorts(ctx, []api.PortSpec{
-			{Port: constants.SubmarinerRoutePort, Protocol: "udp"},
+	// In load balancer mode, the load balancer fronts the existing worker nodes so no dedicated gateways are deployed
+	if !r.loadBalancer {
+		if err := r.gwDeployer.Deploy(ctx, api.GatewayDeployInput{
+			PublicPorts: r.publicPorts(),
+			Gateways:    r.gateways,
+			AirGapped:   r.airGapped,
 		}, r.reporter); err != nil {
-			return errors.Wrap(err, "error opening ports")
+			return nil, errors.Wrap(err, "error deploying gateway")
+		}
+	}
+
+	if r.loadBalancer {
+		if err := OpenLoadBalancerPorts(ctx, r.cloudInfo, r.loadBalancerPorts()); err != nil {
+			return nil, err
+		}
+	}
+
+	if ports := provider.InternalPorts(r.cniType); len(ports) > 0 {
+		if err := r.cloudPrepare.OpenPorts(ctx, ports, r.reporter); err != nil {
+			return nil, errors.Wrap(err, "error opening ports")
 		}
 	}
 
 	r.reporter.Success("The Submariner cluster environment has been set up on Azure")
 
-	return nil
+	inventory, err := r.inventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.spotDeployer.RecordFallbacks(inventory)
+
+	return inventory, nil
+}
+
+// inventory returns the cloud resources actually created by PrepareSubmarinerClusterEnv: the existing external network
+// security group and the deployed gateway MachineSets, or the load balancer ingress rules, along with the existing
+// internal network security group.
+func (r *azureProvider) inventory(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
+	var resources []configv1alpha1.CloudResource
+
+	if !r.loadBalancer {
+		group, err := SecurityGroupResource(ctx, r.cloudInfo, configv1alpha1.CloudResourceSecurityGroup,
+			ExternalSecurityGroupName(r.infraID), r.publicPorts())
+		if err != nil {
+			return nil, err
+		}
+
+		if group != nil {
+			resources = append(resources, *group)
+		}
+
+		resources = append(resources, r.machineSets.Resources()...)
+	} else {
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule,
+			provider.LoadBalancerIngressName(r.infraID), r.loadBalancerPorts()))
+	}
+
+	if ports := provider.InternalPorts(r.cniType); len(ports) > 0 {
+		group, err := SecurityGroupResource(ctx, r.cloudInfo, configv1alpha1.CloudResourceFirewallRule,
+			InternalSecurityGroupName(r.infraID), ports)
+		if err != nil {
+			return nil, err
+		}
+
+		if group != nil {
+			resources = append(resources, *group)
+		}
+	}
+
+	return resources, nil
 }
 
 // CleanUpSubmarinerClusterEnv clean up submariner cluster environment on Azure after the SubmarinerConfig was deleted
 // 1. delete any dedicated gateways that were previously deployed.
 // 2. delete the inbound and outbound firewall rules to close submariner ports.
-func (r *azureProvider) CleanUpSubmarinerClusterEnv(ctx context.Context) error {
-	err := r.gwDeployer.Cleanup(ctx, r.reporter)
-	if err != nil {
-		return errors.Wrap(err, "error cleaning up gateway")
+// Only the resources of the given inventory are cleaned up, unless it doesn't name them, see provider.LegacyCleanup.
+func (r *azureProvider) CleanUpSubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	if provider.LegacyCleanup(inventory, configv1alpha1.CloudResourceMachineSet, configv1alpha1.CloudResourceSecurityGroup) {
+		if err := r.gwDeployer.Cleanup(ctx, r.reporter); err != nil {
+			return errors.Wrap(err, "error cleaning up gateway")
+		}
+	} else if err := r.cleanUpGateways(ctx, inventory); err != nil {
+		return err
 	}
 
-	err = r.cloudPrepare.ClosePorts(ctx, r.reporter)
-	if err != nil {
-		return errors.Wrap(err, "error closing ports")
+	if ports := provider.LoadBalancerIngressPorts(inventory, r.infraID, r.loadBalancer, r.loadBalancerPorts()); len(ports) > 0 {
+		if err := CloseLoadBalancerPorts(ctx, r.cloudInfo, ports); err != nil {
+			return err
+		}
+	}
+
+	if provider.InternalPortsCleanup(inventory, provider.LoadBalancerIngressName(r.infraID)) {
+		if err := r.cloudPrepare.ClosePorts(ctx, r.reporter); err != nil {
+			return errors.Wrap(err, "error closing ports")
+		}
 	}
 
 	r.reporter.Success("The Submariner cluster environment has been cleaned up on Azure")
@@ -144,6 +235,142 @@ func (r *azureProvider) CleanUpSubmarinerClusterEnv(ctx context.Context) error {
 	return nil
 }
 
+// cleanUpGateways deletes exactly the gateway MachineSets and network security group of the given inventory.
+func (r *azureProvider) cleanUpGateways(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	if err := r.machineSets.DeleteMachineSets(ctx, inventory); err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	for _, group := range provider.NamedResources(inventory, configv1alpha1.CloudResourceSecurityGroup) {
+		if err := DeleteSecurityGroup(ctx, r.cloudInfo, group.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PlanSubmarinerClusterEnv returns the cloud resources the given plan operation would change on Azure, compared with the
+// gateway MachineSets and network security groups of the cluster.
+func (r *azureProvider) PlanSubmarinerClusterEnv(ctx context.Context, operation string) ([]configv1alpha1.CloudResource, error) {
+	planned := r.planned()
+	plan := &provider.Plan{}
+
+	if !r.loadBalancer {
+		machineSets, err := provider.GatewayMachineSets(ctx, r.dynamicClient, "vmSize")
+		if err != nil {
+			return nil, err //nolint:wrapcheck // No need to wrap here
+		}
+
+		plan.AddGateways(provider.NamedResources(planned, configv1alpha1.CloudResourceMachineSet), machineSets)
+	}
+
+	if err := PlanSecurityGroups(ctx, r.cloudInfo, plan, planned); err != nil {
+		return nil, err
+	}
+
+	return plan.Resources(operation), nil
+}
+
+// planned returns the cloud resources PrepareSubmarinerClusterEnv creates on Azure.
+func (r *azureProvider) planned() []configv1alpha1.CloudResource {
+	var resources []configv1alpha1.CloudResource
+
+	if !r.loadBalancer {
+		resources = append(resources,
+			provider.PortsResource(configv1alpha1.CloudResourceSecurityGroup, ExternalSecurityGroupName(r.infraID), r.publicPorts()))
+		resources = append(resources, provider.GatewayResources(configv1alpha1.CloudResourceMachineSet, r.infraID+"-submariner-gw",
+			r.instanceType, r.gateways, r.gatewayNodes)...)
+	} else {
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule,
+			provider.LoadBalancerIngressName(r.infraID), r.loadBalancerPorts()))
+	}
+
+	return append(resources, provider.InternalPortsResources(InternalSecurityGroupName(r.infraID), provider.InternalPorts(r.cniType))...)
+}
+
+// VerifySubmarinerClusterEnv returns the drift of the cloud resources in the given inventory on Azure.
+func (r *azureProvider) VerifySubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) ([]string, error) {
+	drift, err := provider.VerifyGatewayNodes(ctx, r.kubeClient, inventory)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	portsDrift, err := VerifySecurityGroups(ctx, r.cloudInfo, inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(drift, portsDrift...), nil
+}
+
+// loadBalancerPorts returns the ports opened to external sources on the worker nodes in load balancer mode. In an
+// air-gapped deployment, where the load balancer is internal and the clusters connect over private networks, they include
+// the ESP & AH protocols used for private-ip to private-ip gateway communications.
+func (r *azureProvider) loadBalancerPorts() []api.PortSpec {
+	ports := provider.LoadBalancerPorts(r.nattPort, uint16(r.nattDiscoveryPort)) //nolint:gosec // Usable port numbers fit
+	if r.airGapped {
+		ports = append(ports, api.PortSpec{Port: 0, Protocol: "esp"}, api.PortSpec{Port: 0, Protocol: "ah"})
+	}
+
+	return ports
+}
+
+func (r *azureProvider) publicPorts() []api.PortSpec {
+	return []api.PortSpec{
+		{Port: r.nattPort, Protocol: "udp"},
+		{Port: uint16(r.nattDiscoveryPort), Protocol: "udp"}, //nolint:gosec // Usable port numbers fit
+		{Port: 0, Protocol: "esp"},
+		{Port: 0, Protocol: "ah"},
+	}
+}
+
+// NewCredential creates an Azure token credential from the given Secret and returns it along with the subscription ID.
+// If the Secret contains a client ID, a federated token credential is created from the agent's projected service
+// account token (workload identity); otherwise the Secret must contain a service principal with a client secret.
+func NewCredential(credentialsSecret *corev1.Secret) (string, azcore.TokenCredential, error) {
+	if _, ok := credentialsSecret.Data[clientIDKey]; ok {
+		return newWorkloadIdentityCredential(credentialsSecret)
+	}
+
+	subscriptionID, err := initializeFromAuthFile(credentialsSecret)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "unable to initialize from auth file")
+	}
+
+	credentials, err := azidentity.NewEnvironmentCredential(nil)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "unable to create the Azure credentials")
+	}
+
+	return subscriptionID, credentials, nil
+}
+
+func newWorkloadIdentityCredential(credentialsSecret *corev1.Secret) (string, azcore.TokenCredential, error) {
+	for _, key := range []string{tenantIDKey, subscriptionIDKey} {
+		if len(credentialsSecret.Data[key]) == 0 {
+			return "", nil, fmt.Errorf("the azure credentials key %s is not in secret %s/%s", key, credentialsSecret.Namespace,
+				credentialsSecret.Name)
+		}
+	}
+
+	tokenFile, err := provider.ServiceAccountTokenFile(string(credentialsSecret.Data[federatedTokenFileKey]))
+	if err != nil {
+		return "", nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	credentials, err := azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+		ClientID:      string(credentialsSecret.Data[clientIDKey]),
+		TenantID:      string(credentialsSecret.Data[tenantIDKey]),
+		TokenFilePath: tokenFile,
+	})
+	if err != nil {
+		return "", nil, errors.Wrap(err, "unable to create the Azure workload identity credentials")
+	}
+
+	return string(credentialsSecret.Data[subscriptionIDKey]), credentials, nil
+}
+
 func initializeFromAuthFile(credentialsSecret *corev1.Secret) (string, error) {
 	servicePrincipalJSON, ok := credentialsSecret.Data[servicePrincipalJSON]
 
@@ -177,3 +404,19 @@ func initializeFromAuthFile(credentialsSecret *corev1.Secret) (string, error) {
 
 	return authInfo.SubscriptionId, nil
 }
+
+// CustomizeGatewayNode sets the VM size, zone and subnet of the given gateway node in the provider spec of an Azure gateway
+// MachineSet.
+func CustomizeGatewayNode(providerSpec map[string]any, node *configv1alpha1.GatewayNode) {
+	if node.InstanceType != "" {
+		providerSpec["vmSize"] = node.InstanceType
+	}
+
+	if node.Zone != "" {
+		providerSpec["zone"] = node.Zone
+	}
+
+	if node.Subnet != "" {
+		providerSpec["subnet"] = node.Subnet
+	}
+}
@@ -3,8 +3,13 @@ package azure
 import (
 	"os"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v10"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/constants"
+	"github.com/submariner-io/cloud-prepare/pkg/api"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -45,3 +50,118 @@ var _ = Describe("initializeFromAuthFile", func() {
 		Expect(err).To(HaveOccurred())
 	})
 })
+
+var _ = Describe("NewCredential", func() {
+	When("the credentials Secret contains a workload identity", func() {
+		It("should create a federated token credential", func() {
+			subscriptionID, credential, err := NewCredential(&corev1.Secret{
+				Data: map[string][]byte{
+					clientIDKey:           []byte("my-client-id"),
+					tenantIDKey:           []byte("my-tenant-id"),
+					subscriptionIDKey:     []byte("my-subscription-id"),
+					federatedTokenFileKey: []byte(constants.ServiceAccountTokenFile),
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subscriptionID).To(Equal("my-subscription-id"))
+			Expect(credential).To(BeAssignableToTypeOf(&azidentity.WorkloadIdentityCredential{}))
+		})
+
+		It("should return an error if the token file isn't the projected service account token", func() {
+			_, _, err := NewCredential(&corev1.Secret{
+				Data: map[string][]byte{
+					clientIDKey:           []byte("my-client-id"),
+					tenantIDKey:           []byte("my-tenant-id"),
+					subscriptionIDKey:     []byte("my-subscription-id"),
+					federatedTokenFileKey: []byte("/tmp/token"),
+				},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should return an error if the tenant ID is missing", func() {
+			_, _, err := NewCredential(&corev1.Secret{
+				Data: map[string][]byte{
+					clientIDKey:       []byte("my-client-id"),
+					subscriptionIDKey: []byte("my-subscription-id"),
+				},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("the credentials Secret contains a service principal", func() {
+		It("should create an environment credential", func() {
+			subscriptionID, credential, err := NewCredential(&corev1.Secret{
+				Data: map[string][]byte{
+					servicePrincipalJSON: []byte(servicePrincipalJSONData),
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subscriptionID).To(Equal("my-subscription-id"))
+			Expect(credential).To(BeAssignableToTypeOf(&azidentity.EnvironmentCredential{}))
+		})
+	})
+})
+
+var _ = Describe("planned in load balancer mode", func() {
+	var provider *azureProvider
+
+	BeforeEach(func() {
+		provider = &azureProvider{
+			infraID:           "test",
+			instanceType:      gwInstanceType,
+			nattPort:          4500,
+			nattDiscoveryPort: 4900,
+			cniType:           "OVNKubernetes",
+			gateways:          1,
+			loadBalancer:      true,
+		}
+	})
+
+	It("should only plan opening the load balancer ports of the worker nodes to external sources", func() {
+		Expect(provider.planned()).To(Equal([]configv1alpha1.CloudResource{{
+			Kind: configv1alpha1.CloudResourceFirewallRule,
+			Name: "test-submariner-lb-ingress",
+			Ports: []configv1alpha1.CloudPort{
+				{Port: 4500, Protocol: "udp"},
+				{Port: 4900, Protocol: "udp"},
+				{Port: 10256, Protocol: "tcp"},
+			},
+		}}))
+	})
+
+	When("the deployment is air-gapped", func() {
+		BeforeEach(func() {
+			provider.airGapped = true
+		})
+
+		It("should also plan opening the ESP and AH protocols", func() {
+			resources := provider.planned()
+			Expect(resources).To(HaveLen(1))
+			Expect(resources[0].Ports).To(ContainElements(
+				configv1alpha1.CloudPort{Port: 0, Protocol: "esp"},
+				configv1alpha1.CloudPort{Port: 0, Protocol: "ah"}))
+			Expect(resources[0].Ports).ToNot(ContainElement(configv1alpha1.CloudPort{Port: 4800, Protocol: "udp"}))
+		})
+	})
+})
+
+var _ = Describe("loadBalancerRule", func() {
+	It("should allow the port from any source", func() {
+		rule := loadBalancerRule("test", &api.PortSpec{Port: 4500, Protocol: "udp"}, 4000)
+		Expect(*rule.Properties.Protocol).To(Equal(armnetwork.SecurityRuleProtocolUDP))
+		Expect(*rule.Properties.DestinationPortRange).To(Equal("4500"))
+		Expect(*rule.Properties.SourceAddressPrefix).To(Equal("*"))
+		Expect(*rule.Properties.Direction).To(Equal(armnetwork.SecurityRuleDirectionInbound))
+		Expect(*rule.Properties.Priority).To(Equal(int32(4000)))
+		Expect(loadBalancerRuleName("test", &api.PortSpec{Port: 4500, Protocol: "udp"})).To(Equal("test-submariner-lb-ingress-udp-4500"))
+	})
+
+	It("should allow a protocol without ports", func() {
+		rule := loadBalancerRule("test", &api.PortSpec{Port: 0, Protocol: "esp"}, 4003)
+		Expect(*rule.Properties.Protocol).To(Equal(armnetwork.SecurityRuleProtocolEsp))
+		Expect(*rule.Properties.DestinationPortRange).To(Equal("*"))
+		Expect(loadBalancerRuleName("test", &api.PortSpec{Port: 0, Protocol: "esp"})).To(Equal("test-submariner-lb-ingress-esp"))
+	})
+})
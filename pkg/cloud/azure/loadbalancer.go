@@ -0,0 +1,112 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v10"
+	"github.com/pkg/errors"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"github.com/submariner-io/cloud-prepare/pkg/api"
+	"github.com/submariner-io/cloud-prepare/pkg/azure"
+)
+
+// The priorities of the load balancer rules, above those of the rules created by cloud-prepare.
+const loadBalancerRulePriority = 4000
+
+// OpenLoadBalancerPorts creates or updates the rules of the cluster network security group opening the given ports of the
+// nodes to any source, so that the load balancer fronting the worker nodes in load balancer mode can forward the traffic
+// of the remote clusters and probe the nodes. There's a rule per port, named after the load balancer ingress name.
+func OpenLoadBalancerPorts(ctx context.Context, cloudInfo *azure.CloudInfo, ports []api.PortSpec) error {
+	client, err := armnetwork.NewSecurityRulesClient(cloudInfo.SubscriptionID, cloudInfo.TokenCredential, nil)
+	if err != nil {
+		return errors.Wrap(err, "error creating the Azure security rules client")
+	}
+
+	for i := range ports {
+		name := loadBalancerRuleName(cloudInfo.InfraID, &ports[i])
+
+		poller, err := client.BeginCreateOrUpdate(ctx, cloudInfo.BaseGroupName, securityGroupName(cloudInfo), name,
+			loadBalancerRule(cloudInfo.InfraID, &ports[i], int32(loadBalancerRulePriority+i)), nil) //nolint:gosec // Few ports
+		if err == nil {
+			_, err = poller.PollUntilDone(ctx, nil)
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "error creating security rule %q", name)
+		}
+	}
+
+	return nil
+}
+
+// CloseLoadBalancerPorts deletes the rules created by OpenLoadBalancerPorts for the given ports.
+func CloseLoadBalancerPorts(ctx context.Context, cloudInfo *azure.CloudInfo, ports []api.PortSpec) error {
+	client, err := armnetwork.NewSecurityRulesClient(cloudInfo.SubscriptionID, cloudInfo.TokenCredential, nil)
+	if err != nil {
+		return errors.Wrap(err, "error creating the Azure security rules client")
+	}
+
+	for i := range ports {
+		name := loadBalancerRuleName(cloudInfo.InfraID, &ports[i])
+
+		poller, err := client.BeginDelete(ctx, cloudInfo.BaseGroupName, securityGroupName(cloudInfo), name, nil)
+		if err == nil {
+			_, err = poller.PollUntilDone(ctx, nil)
+		}
+
+		if err != nil && !isNotFoundError(err) {
+			return errors.Wrapf(err, "error deleting security rule %q", name)
+		}
+	}
+
+	return nil
+}
+
+// securityGroupName returns the name given by the installer to the network security group of the cluster nodes.
+func securityGroupName(cloudInfo *azure.CloudInfo) string {
+	return cloudInfo.InfraID + "-nsg"
+}
+
+func loadBalancerRuleName(infraID string, port *api.PortSpec) string {
+	if port.Port == 0 {
+		return fmt.Sprintf("%s-%s", provider.LoadBalancerIngressName(infraID), port.Protocol)
+	}
+
+	return fmt.Sprintf("%s-%s-%d", provider.LoadBalancerIngressName(infraID), port.Protocol, port.Port)
+}
+
+func loadBalancerRule(infraID string, port *api.PortSpec, priority int32) armnetwork.SecurityRule {
+	portRange := "*"
+	if port.Port != 0 {
+		portRange = strconv.Itoa(int(port.Port))
+	}
+
+	// The protocol names are capitalized, e.g. Udp or Esp
+	protocol := strings.ToUpper(port.Protocol[:1]) + strings.ToLower(port.Protocol[1:])
+
+	return armnetwork.SecurityRule{
+		Properties: &armnetwork.SecurityRulePropertiesFormat{
+			Description:              to.Ptr(provider.LoadBalancerIngressName(infraID)),
+			Access:                   to.Ptr(armnetwork.SecurityRuleAccessAllow),
+			Direction:                to.Ptr(armnetwork.SecurityRuleDirectionInbound),
+			Protocol:                 to.Ptr(armnetwork.SecurityRuleProtocol(protocol)),
+			Priority:                 to.Ptr(priority),
+			SourceAddressPrefix:      to.Ptr("*"),
+			SourcePortRange:          to.Ptr("*"),
+			DestinationAddressPrefix: to.Ptr("*"),
+			DestinationPortRange:     to.Ptr(portRange),
+		},
+	}
+}
+
+func isNotFoundError(err error) bool {
+	var respErr *azcore.ResponseError
+
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,202 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v10"
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"github.com/submariner-io/cloud-prepare/pkg/api"
+	"github.com/submariner-io/cloud-prepare/pkg/azure"
+	"k8s.io/utils/ptr"
+)
+
+// ExternalSecurityGroupName returns the name of the network security group opening the public ports of the gateways,
+// created by cloud-prepare.
+func ExternalSecurityGroupName(infraID string) string {
+	return infraID + "-submariner-external-sg"
+}
+
+// InternalSecurityGroupName returns the name of the network security group opening the ports within the cluster, created
+// by cloud-prepare.
+func InternalSecurityGroupName(infraID string) string {
+	return infraID + "-submariner-internal-sg"
+}
+
+// SecurityGroupResource returns the network security group of the given kind with the given name opening the given ports,
+// or nil if it doesn't exist.
+func SecurityGroupResource(ctx context.Context, cloudInfo *azure.CloudInfo, kind, name string, ports []api.PortSpec,
+) (*configv1alpha1.CloudResource, error) {
+	client, err := armnetwork.NewSecurityGroupsClient(cloudInfo.SubscriptionID, cloudInfo.TokenCredential, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the Azure security groups client")
+	}
+
+	group, err := client.Get(ctx, cloudInfo.BaseGroupName, name, nil)
+	if isNotFoundError(err) {
+		return nil, nil //nolint:nilnil // A missing security group is not an error
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving security group %q", name)
+	}
+
+	resource := provider.PortsResource(kind, *group.Name, ports)
+
+	return &resource, nil
+}
+
+// DeleteSecurityGroup deletes the network security group with the given name, if it exists.
+func DeleteSecurityGroup(ctx context.Context, cloudInfo *azure.CloudInfo, name string) error {
+	client, err := armnetwork.NewSecurityGroupsClient(cloudInfo.SubscriptionID, cloudInfo.TokenCredential, nil)
+	if err != nil {
+		return errors.Wrap(err, "error creating the Azure security groups client")
+	}
+
+	poller, err := client.BeginDelete(ctx, cloudInfo.BaseGroupName, name, nil)
+	if err == nil {
+		_, err = poller.PollUntilDone(ctx, nil)
+	}
+
+	if err != nil && !isNotFoundError(err) {
+		// The security group can't be deleted while the gateway network interfaces use it, the clean up is retried
+		return errors.Wrapf(err, "error deleting security group %q", name)
+	}
+
+	return nil
+}
+
+// VerifySecurityGroups returns the drift of the network security groups and firewall rules in the given inventory, i.e. a
+// description of each of them which is missing or doesn't allow one of its ports anymore, see securityGroupProperties.
+func VerifySecurityGroups(ctx context.Context, cloudInfo *azure.CloudInfo, inventory []configv1alpha1.CloudResource) ([]string, error) {
+	client, err := armnetwork.NewSecurityGroupsClient(cloudInfo.SubscriptionID, cloudInfo.TokenCredential, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the Azure security groups client")
+	}
+
+	var drift []string
+
+	for _, resource := range provider.NamedResources(inventory, configv1alpha1.CloudResourceSecurityGroup,
+		configv1alpha1.CloudResourceFirewallRule) {
+		properties, found, err := securityGroupProperties(ctx, client, cloudInfo, &resource)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			drift = append(drift, fmt.Sprintf("the security group of %s %q is missing", resource.Kind, resource.Name))
+			continue
+		}
+
+		drift = append(drift, provider.MissingPorts(&resource, func(port *configv1alpha1.CloudPort) bool {
+			return isSecurityRuleAllowed(properties, port)
+		})...)
+	}
+
+	return drift, nil
+}
+
+// PlanSecurityGroups adds the planned network security groups and firewall rules to the given plan, compared with the
+// network security groups of the cluster, see securityGroupProperties.
+func PlanSecurityGroups(ctx context.Context, cloudInfo *azure.CloudInfo, plan *provider.Plan, planned []configv1alpha1.CloudResource,
+) error {
+	client, err := armnetwork.NewSecurityGroupsClient(cloudInfo.SubscriptionID, cloudInfo.TokenCredential, nil)
+	if err != nil {
+		return errors.Wrap(err, "error creating the Azure security groups client")
+	}
+
+	for _, resource := range provider.NamedResources(planned, configv1alpha1.CloudResourceSecurityGroup,
+		configv1alpha1.CloudResourceFirewallRule) {
+		properties, found, err := securityGroupProperties(ctx, client, cloudInfo, &resource)
+		if err != nil {
+			return err
+		}
+
+		plan.AddPorts(&resource, found, func(port *configv1alpha1.CloudPort) bool {
+			return isSecurityRuleAllowed(properties, port)
+		})
+	}
+
+	return nil
+}
+
+// securityGroupProperties returns the properties of the network security group of the given security group or firewall
+// rule, and whether it exists. The load balancer ingress rules are added to the network security group of the cluster
+// nodes.
+func securityGroupProperties(ctx context.Context, client *armnetwork.SecurityGroupsClient, cloudInfo *azure.CloudInfo,
+	resource *configv1alpha1.CloudResource,
+) (*armnetwork.SecurityGroupPropertiesFormat, bool, error) {
+	name := resource.Name
+	if name == provider.LoadBalancerIngressName(cloudInfo.InfraID) {
+		name = securityGroupName(cloudInfo)
+	}
+
+	group, err := client.Get(ctx, cloudInfo.BaseGroupName, name, nil)
+	if isNotFoundError(err) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "error retrieving security group %q", name)
+	}
+
+	return group.Properties, true, nil
+}
+
+func isSecurityRuleAllowed(group *armnetwork.SecurityGroupPropertiesFormat, port *configv1alpha1.CloudPort) bool {
+	if group == nil {
+		return false
+	}
+
+	for _, rule := range group.SecurityRules {
+		if rule == nil || rule.Properties == nil {
+			continue
+		}
+
+		properties := rule.Properties
+
+		if ptr.Deref(properties.Direction, "") != armnetwork.SecurityRuleDirectionInbound ||
+			ptr.Deref(properties.Access, "") != armnetwork.SecurityRuleAccessAllow {
+			continue
+		}
+
+		protocol := ptr.Deref(properties.Protocol, "")
+		if protocol != armnetwork.SecurityRuleProtocolAsterisk && !strings.EqualFold(string(protocol), port.Protocol) {
+			continue
+		}
+
+		if port.Port == 0 || portRangeIncludes(ptr.Deref(properties.DestinationPortRange, ""), port.Port) {
+			return true
+		}
+
+		for _, portRange := range properties.DestinationPortRanges {
+			if portRangeIncludes(ptr.Deref(portRange, ""), port.Port) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// portRangeIncludes returns whether the given port range of a security rule, "*", a port or a range of ports, includes the
+// given port.
+func portRangeIncludes(portRange string, port int) bool {
+	if portRange == "*" {
+		return true
+	}
+
+	from, to, isRange := strings.Cut(portRange, "-")
+	if !isRange {
+		to = from
+	}
+
+	low, lowErr := strconv.Atoi(from)
+	high, highErr := strconv.Atoi(to)
+
+	return lowErr == nil && highErr == nil && low <= port && port <= high
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/stolostron/submariner-addon/pkg/cloud/aws"
 	"github.com/stolostron/submariner-addon/pkg/cloud/azure"
 	"github.com/stolostron/submariner-addon/pkg/cloud/gcp"
+	"github.com/stolostron/submariner-addon/pkg/cloud/managed"
+	"github.com/stolostron/submariner-addon/pkg/cloud/plugin"
 	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
 	"github.com/stolostron/submariner-addon/pkg/cloud/rhos"
 	"github.com/stolostron/submariner-addon/pkg/constants"
@@ -24,10 +26,19 @@ import (
 //go:generate mockgen -source=./cloud.go -destination=./fake/cloud.go -package=fake
 
 type Provider interface {
-	// PrepareSubmarinerClusterEnv prepares submariner cluster environment
-	PrepareSubmarinerClusterEnv(ctx context.Context) error
-	// CleanUpSubmarinerClusterEnv clean up the prepared submariner cluster environment
-	CleanUpSubmarinerClusterEnv(ctx context.Context) error
+	// PrepareSubmarinerClusterEnv prepares submariner cluster environment and returns the inventory of the cloud resources
+	// it created
+	PrepareSubmarinerClusterEnv(ctx context.Context) ([]configv1alpha1.CloudResource, error)
+	// CleanUpSubmarinerClusterEnv clean up the prepared submariner cluster environment, limited to the resources in the given
+	// inventory if any
+	CleanUpSubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) error
+	// PlanSubmarinerClusterEnv returns the cloud resources the given plan operation would change, compared with the cloud
+	// environment: the resources PrepareSubmarinerClusterEnv would create or update for Create, the existing resources a
+	// CleanUpSubmarinerClusterEnv without inventory would delete for Delete
+	PlanSubmarinerClusterEnv(ctx context.Context, operation string) ([]configv1alpha1.CloudResource, error)
+	// VerifySubmarinerClusterEnv returns a description of each drift of the cloud resources in the given inventory from
+	// their prepared state, e.g. closed ports or missing gateway nodes
+	VerifySubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) ([]string, error)
 }
 
 type ProviderFactory interface {
@@ -43,7 +54,15 @@ type providerFactory struct {
 
 type ProviderFn func(*provider.Info) (Provider, error)
 
-var providers = map[string]ProviderFn{}
+type providerKey struct {
+	vendor   string
+	platform string
+}
+
+var (
+	providers     = map[providerKey]ProviderFn{}
+	pluginClients = map[providerKey]*plugin.Client{}
+)
 
 func init() {
 	RegisterProvider("AWS", func(info *provider.Info) (Provider, error) {
@@ -61,10 +80,69 @@ func init() {
 	RegisterProvider("Azure", func(info *provider.Info) (Provider, error) {
 		return azure.NewProvider(info)
 	})
+
+	awsManagedFn := func(info *provider.Info) (Provider, error) {
+		return managed.NewAWSProvider(context.TODO(), info)
+	}
+
+	RegisterVendorProvider(constants.ProductROSA, "AWS", awsManagedFn)
+	RegisterVendorProvider(constants.ProductOSD, "AWS", awsManagedFn)
+
+	RegisterVendorProvider(constants.ProductOSD, "GCP", func(info *provider.Info) (Provider, error) {
+		return managed.NewGCPProvider(context.TODO(), info)
+	})
+
+	RegisterVendorProvider(constants.ProductARO, "Azure", func(info *provider.Info) (Provider, error) {
+		return managed.NewAROProvider(context.TODO(), info)
+	})
 }
 
+// RegisterProvider registers the provider for self-managed OpenShift clusters on the given platform.
 func RegisterProvider(platform string, f ProviderFn) {
-	providers[platform] = f
+	RegisterVendorProvider(constants.ProductOCP, platform, f)
+}
+
+// RegisterVendorProvider registers the provider for clusters of the given vendor, e.g. a managed OpenShift offering, on the
+// given platform.
+func RegisterVendorProvider(vendor, platform string, f ProviderFn) {
+	providers[providerKey{vendor: vendor, platform: platform}] = f
+}
+
+// RegisterPluginProvider registers an out-of-tree provider for clusters of the given vendor on the given platform, served
+// by a plugin listening on the given Unix socket. It takes precedence over any built-in provider for the vendor and platform.
+// The providers share a single connection to the plugin, replacing the connection of any plugin previously registered for
+// the vendor and platform.
+func RegisterPluginProvider(vendor, platform, socketPath string) error {
+	client, err := plugin.NewClient(socketPath)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	key := providerKey{vendor: vendor, platform: platform}
+
+	if previous, found := pluginClients[key]; found {
+		if err := previous.Close(); err != nil {
+			klog.Warningf("Failed to close the previous cloud provider plugin client for %s on %s: %v", vendor, platform, err)
+		}
+	}
+
+	pluginClients[key] = client
+
+	RegisterVendorProvider(vendor, platform, func(info *provider.Info) (Provider, error) {
+		return plugin.NewProvider(client, info), nil
+	})
+
+	return nil
+}
+
+func isSupportedVendor(vendor string) bool {
+	for key := range providers {
+		if key.vendor == vendor {
+			return true
+		}
+	}
+
+	return false
 }
 
 func NewProviderFactory(restMapper meta.RESTMapper, kubeClient kubernetes.Interface, dynamicClient dynamic.Interface,
@@ -105,15 +183,17 @@ func (f *providerFactory) Get(config *configv1alpha1.SubmarinerConfig, eventsRec
 	}
 
 	vendor := managedClusterInfo.Vendor
-	if vendor == constants.ProductROSA || vendor == constants.ProductARO || vendor == constants.ProductROKS {
+
+	// ROKS clusters are prepared by IBM Cloud, there's nothing to do
+	if vendor == constants.ProductROKS {
 		return nil, false, nil
 	}
 
-	if vendor != constants.ProductOCP {
+	if !isSupportedVendor(vendor) {
 		return nil, false, fmt.Errorf("unsupported vendor %q for cluster %q", vendor, managedClusterInfo.ClusterName)
 	}
 
-	providerFn, found := providers[managedClusterInfo.Platform]
+	providerFn, found := providers[providerKey{vendor: vendor, platform: managedClusterInfo.Platform}]
 	if !found {
 		return nil, false, nil
 	}
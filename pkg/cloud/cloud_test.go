@@ -2,6 +2,7 @@ package cloud_test
 
 import (
 	"context"
+	"path/filepath"
 	"strconv"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -10,6 +11,8 @@ import (
 	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
 	"github.com/stolostron/submariner-addon/pkg/cloud"
 	"github.com/stolostron/submariner-addon/pkg/cloud/fake"
+	"github.com/stolostron/submariner-addon/pkg/cloud/plugin"
+	pluginFake "github.com/stolostron/submariner-addon/pkg/cloud/plugin/fake"
 	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
 	"github.com/stolostron/submariner-addon/pkg/constants"
 	corev1 "k8s.io/api/core/v1"
@@ -69,7 +72,20 @@ var _ = Describe("ProviderFactory Get", func() {
 		})
 	})
 
-	When("the ManagedClusterInfo Vendor is ROSA", func() {
+	When("the ManagedClusterInfo Vendor is ROKS", func() {
+		BeforeEach(func() {
+			submarinerConfig.Status.ManagedClusterInfo.Vendor = constants.ProductROKS
+		})
+
+		It("should return false", func() {
+			provider, found, err := providerFactory.Get(submarinerConfig, events.NewLoggingEventRecorder("test", clock.RealClock{}))
+			Expect(err).To(Succeed())
+			Expect(found).To(BeFalse())
+			Expect(provider).To(BeNil())
+		})
+	})
+
+	When("the ManagedClusterInfo Vendor is ROSA and the Platform has no provider implementation", func() {
 		BeforeEach(func() {
 			submarinerConfig.Status.ManagedClusterInfo.Vendor = constants.ProductROSA
 		})
@@ -82,6 +98,47 @@ var _ = Describe("ProviderFactory Get", func() {
 		})
 	})
 
+	When("a vendor-specific provider implementation is registered", func() {
+		ocpProvider := &fake.MockProvider{}
+		vendorProvider := &fake.MockProvider{}
+
+		BeforeEach(func() {
+			submarinerConfig.Status.ManagedClusterInfo.Platform = "BAR"
+			submarinerConfig.Spec.CredentialsSecret = &corev1.LocalObjectReference{Name: "test-secret"}
+
+			_, err := hubKubeClient.CoreV1().Secrets(clusterName).Create(context.TODO(), &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: clusterName},
+			}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+
+			cloud.RegisterProvider("BAR", func(_ *provider.Info) (cloud.Provider, error) {
+				return ocpProvider, nil
+			})
+
+			cloud.RegisterVendorProvider(constants.ProductARO, "BAR", func(info *provider.Info) (cloud.Provider, error) {
+				Expect(info.Vendor).To(Equal(constants.ProductARO))
+
+				return vendorProvider, nil
+			})
+		})
+
+		It("should return the instance for the vendor", func() {
+			submarinerConfig.Status.ManagedClusterInfo.Vendor = constants.ProductARO
+
+			provider, found, err := providerFactory.Get(submarinerConfig, events.NewLoggingEventRecorder("test", clock.RealClock{}))
+			Expect(err).To(Succeed())
+			Expect(found).To(BeTrue())
+			Expect(provider).To(BeIdenticalTo(vendorProvider))
+
+			submarinerConfig.Status.ManagedClusterInfo.Vendor = constants.ProductOCP
+
+			provider, found, err = providerFactory.Get(submarinerConfig, events.NewLoggingEventRecorder("test", clock.RealClock{}))
+			Expect(err).To(Succeed())
+			Expect(found).To(BeTrue())
+			Expect(provider).To(BeIdenticalTo(ocpProvider))
+		})
+	})
+
 	When("a provider implementation is registered", func() {
 		mockProvider := &fake.MockProvider{}
 		credentialsSecret := &corev1.Secret{
@@ -141,6 +198,52 @@ var _ = Describe("ProviderFactory Get", func() {
 		})
 	})
 
+	When("a plugin provider is registered", func() {
+		var fakePlugin *pluginFake.Plugin
+
+		BeforeEach(func() {
+			submarinerConfig.Status.ManagedClusterInfo.Platform = "PrivateCloud"
+			submarinerConfig.Spec.CredentialsSecret = &corev1.LocalObjectReference{Name: "test-secret"}
+
+			_, err := hubKubeClient.CoreV1().Secrets(clusterName).Create(context.TODO(), &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: clusterName},
+				Data:       map[string][]byte{"token": []byte("secret")},
+			}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+
+			fakePlugin = pluginFake.NewPlugin()
+			socketPath := filepath.Join(GinkgoT().TempDir(), "plugin.sock")
+
+			ctx, stop := context.WithCancel(context.Background())
+			DeferCleanup(stop)
+
+			go func() {
+				defer GinkgoRecover()
+				Expect(plugin.Serve(ctx, socketPath, fakePlugin)).To(Succeed())
+			}()
+
+			Expect(cloud.RegisterPluginProvider(constants.ProductOCP, "PrivateCloud", socketPath)).To(Succeed())
+		})
+
+		It("should route to the plugin for the platform", func(ctx context.Context) {
+			provider, found, err := providerFactory.Get(submarinerConfig, events.NewLoggingEventRecorder("test", clock.RealClock{}))
+			Expect(err).To(Succeed())
+			Expect(found).To(BeTrue())
+
+			Eventually(func() error {
+				_, err := provider.PrepareSubmarinerClusterEnv(ctx)
+				return err
+			}).Should(Succeed())
+
+			Expect(fakePlugin.Requests()).ToNot(BeEmpty())
+
+			request, ok := fakePlugin.Requests()[0].(*plugin.PrepareRequest)
+			Expect(ok).To(BeTrue())
+			Expect(request.Info.ManagedClusterInfo.Platform).To(Equal("PrivateCloud"))
+			Expect(request.Info.Credentials).To(HaveKeyWithValue("token", []byte("secret")))
+		})
+	})
+
 	When("skip prepare is enabled", func() {
 		BeforeEach(func() {
 			submarinerConfig.Annotations = map[string]string{"submariner.io/skip-cloud-prepare": strconv.FormatBool(true)}
@@ -44,25 +44,41 @@ func (m *MockProvider) EXPECT() *MockProviderMockRecorder {
 }
 
 // CleanUpSubmarinerClusterEnv mocks base method.
-func (m *MockProvider) CleanUpSubmarinerClusterEnv(ctx context.Context) error {
+func (m *MockProvider) CleanUpSubmarinerClusterEnv(ctx context.Context, inventory []v1alpha1.CloudResource) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CleanUpSubmarinerClusterEnv", ctx)
+	ret := m.ctrl.Call(m, "CleanUpSubmarinerClusterEnv", ctx, inventory)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CleanUpSubmarinerClusterEnv indicates an expected call of CleanUpSubmarinerClusterEnv.
-func (mr *MockProviderMockRecorder) CleanUpSubmarinerClusterEnv(ctx any) *gomock.Call {
+func (mr *MockProviderMockRecorder) CleanUpSubmarinerClusterEnv(ctx, inventory any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanUpSubmarinerClusterEnv", reflect.TypeOf((*MockProvider)(nil).CleanUpSubmarinerClusterEnv), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanUpSubmarinerClusterEnv", reflect.TypeOf((*MockProvider)(nil).CleanUpSubmarinerClusterEnv), ctx, inventory)
+}
+
+// PlanSubmarinerClusterEnv mocks base method.
+func (m *MockProvider) PlanSubmarinerClusterEnv(ctx context.Context, operation string) ([]v1alpha1.CloudResource, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlanSubmarinerClusterEnv", ctx, operation)
+	ret0, _ := ret[0].([]v1alpha1.CloudResource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlanSubmarinerClusterEnv indicates an expected call of PlanSubmarinerClusterEnv.
+func (mr *MockProviderMockRecorder) PlanSubmarinerClusterEnv(ctx, operation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlanSubmarinerClusterEnv", reflect.TypeOf((*MockProvider)(nil).PlanSubmarinerClusterEnv), ctx, operation)
 }
 
 // PrepareSubmarinerClusterEnv mocks base method.
-func (m *MockProvider) PrepareSubmarinerClusterEnv(ctx context.Context) error {
+func (m *MockProvider) PrepareSubmarinerClusterEnv(ctx context.Context) ([]v1alpha1.CloudResource, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "PrepareSubmarinerClusterEnv", ctx)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].([]v1alpha1.CloudResource)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // PrepareSubmarinerClusterEnv indicates an expected call of PrepareSubmarinerClusterEnv.
@@ -71,6 +87,21 @@ func (mr *MockProviderMockRecorder) PrepareSubmarinerClusterEnv(ctx any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareSubmarinerClusterEnv", reflect.TypeOf((*MockProvider)(nil).PrepareSubmarinerClusterEnv), ctx)
 }
 
+// VerifySubmarinerClusterEnv mocks base method.
+func (m *MockProvider) VerifySubmarinerClusterEnv(ctx context.Context, inventory []v1alpha1.CloudResource) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifySubmarinerClusterEnv", ctx, inventory)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifySubmarinerClusterEnv indicates an expected call of VerifySubmarinerClusterEnv.
+func (mr *MockProviderMockRecorder) VerifySubmarinerClusterEnv(ctx, inventory any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifySubmarinerClusterEnv", reflect.TypeOf((*MockProvider)(nil).VerifySubmarinerClusterEnv), ctx, inventory)
+}
+
 // MockProviderFactory is a mock of ProviderFactory interface.
 type MockProviderFactory struct {
 	ctrl     *gomock.Controller
@@ -2,41 +2,59 @@ package gcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
 	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
 	"github.com/stolostron/submariner-addon/pkg/cloud/reporter"
-	"github.com/stolostron/submariner-addon/pkg/constants"
 	submreporter "github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/cloud-prepare/pkg/api"
 	cloudpreparegcp "github.com/submariner-io/cloud-prepare/pkg/gcp"
 	gcpclient "github.com/submariner-io/cloud-prepare/pkg/gcp/client"
 	"github.com/submariner-io/cloud-prepare/pkg/k8s"
 	"github.com/submariner-io/cloud-prepare/pkg/ocp"
-	"github.com/submariner-io/submariner/pkg/cni"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/compute/v1"
 	"google.golang.org/api/dns/v1"
 	"google.golang.org/api/option"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 )
 
 const (
 	gcpCredentialsName = "osServiceAccount.json"
+	projectIDKey       = "gcp_project_id"
 	gwInstanceType     = "n1-standard-4"
 )
 
 type gcpProvider struct {
-	infraID           string
-	nattPort          uint16
-	cniType           string
-	cloudPrepare      api.Cloud
-	reporter          submreporter.Interface
-	gwDeployer        api.GatewayDeployer
-	gateways          int
+	infraID      string
+	instanceType string
+	projectID    string
+	client       gcpclient.Interface
+	kubeClient   kubernetes.Interface
+	nattPort     uint16
+	cniType      string
+	cloudPrepare api.Cloud
+	reporter     submreporter.Interface
+	gwDeployer   api.GatewayDeployer
+	gateways     int
+
+	gatewayNodes      []configv1alpha1.GatewayNode
 	nattDiscoveryPort int64
+	loadBalancer      bool
+	vpcName           string
+	spotDeployer      *provider.SpotMachineSetDeployer
+	machineSets       *provider.MachineSetRecorder
+	dynamicClient     dynamic.Interface
 }
 
 func NewProvider(ctx context.Context, info *provider.Info) (*gcpProvider, error) {
@@ -49,11 +67,11 @@ func NewProvider(ctx context.Context, info *provider.Info) (*gcpProvider, error)
 		instanceType = gwInstanceType
 	}
 
-	if info.Gateways < 1 {
+	if provider.GatewayCount(&info.GatewayConfig) < 1 {
 		return nil, errors.New("the count of gateways is less than 1")
 	}
 
-	projectID, gcpClient, err := newClient(ctx, info.CredentialsSecret)
+	projectID, gcpClient, err := NewClient(ctx, info.CredentialsSecret)
 	if err != nil {
 		klog.Errorf("Unable to retrieve the gcpclient :%v", err)
 		return nil, err
@@ -82,19 +100,43 @@ func NewProvider(ctx context.Context, info *provider.Info) (*gcpProvider, error)
 
 	msDeployer := ocp.NewK8sMachinesetDeployer(info.RestMapper, info.DynamicClient)
 
+	var spotDeployer *provider.SpotMachineSetDeployer
+
+	if info.GatewayConfig.GCP.Preemptible {
+		spotDeployer = provider.NewSpotMachineSetDeployer(msDeployer, info.DynamicClient, "preemptible", true)
+		msDeployer = spotDeployer
+	}
+
+	machineSets := provider.NewMachineSetRecorder(msDeployer, "machineType")
+	msDeployer = machineSets
+
+	if len(info.GatewayNodes) > 0 {
+		msDeployer = provider.NewGatewayNodesMachineSetDeployer(msDeployer, info.DynamicClient, info.GatewayNodes, customizeGatewayNode)
+	}
+
 	k8sClient := k8s.NewInterface(info.KubeClient)
 
 	gwDeployer := cloudpreparegcp.NewOcpGatewayDeployer(cloudInfo, msDeployer, instanceType, "", k8sClient)
 
 	return &gcpProvider{
 		infraID:           info.InfraID,
+		instanceType:      instanceType,
+		projectID:         projectID,
+		client:            gcpClient,
+		kubeClient:        info.KubeClient,
+		dynamicClient:     info.DynamicClient,
 		nattPort:          uint16(info.IPSecNATTPort), //nolint:gosec // Usable port numbers fit
 		cniType:           info.NetworkType,
 		cloudPrepare:      cloudPrepare,
 		gwDeployer:        gwDeployer,
 		reporter:          reporter.NewEventRecorderWrapper("GCPCloudProvider", info.EventRecorder),
 		nattDiscoveryPort: int64(info.NATTDiscoveryPort),
-		gateways:          info.Gateways,
+		gateways:          provider.GatewayCount(&info.GatewayConfig),
+		gatewayNodes:      info.GatewayNodes,
+		loadBalancer:      info.LoadBalancerEnable,
+		vpcName:           cloudInfo.VpcName,
+		spotDeployer:      spotDeployer,
+		machineSets:       machineSets,
 	}, nil
 }
 
@@ -104,63 +146,314 @@ func NewProvider(ctx context.Context, info *provider.Info) (*gcpProvider, error)
 //   - IPsec IKE port (by default 500/UDP)
 //   - NAT traversal port (by default 4500/UDP)
 //   - 4800/UDP port to encapsulate Pod traffic from worker and master nodes to the Submariner Gateway nodes
-func (g *gcpProvider) PrepareSubmarinerClusterEnv(ctx context.Context) error {
-	if err := g.gwDeployer.Deploy(ctx, api.GatewayDeployInput{
-		PublicPorts: []api.PortSpec{
-			{Port: g.nattPort, Protocol: "udp"},
-			{Port: uint16(g.nattDiscoveryPort), Protocol: "udp"}, //nolint:gosec // Usable port numbers fit
-			// ESP & AH protocols are used for private-ip to private-ip gateway communications
-			{Port: 0, Protocol: "esp"},
-			{Port: 0, Protocol: "ah"},
-		},
-		Gateways: g.gateways,
-	}, g.reporter); err != nil {
-		return errors.Wrap(err, "error deploying gateway")
-	}
-
-	if !strings.EqualFold(g.cniType, cni.OVNKubernetes) {
-		if err := g.cloudPrepare.OpenPorts(ctx, []api.PortSpec{
-			{Port: constants.SubmarinerRoutePort, Protocol: "udp"},
+func (g *gcpProvider) PrepareSubmarinerClusterEnv(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
+	// In load balancer mode, the load balancer fronts the existing worker nodes so no dedicated gateways are deployed
+	if !g.loadBalancer {
+		if err := g.gwDeployer.Deploy(ctx, api.GatewayDeployInput{
+			PublicPorts: g.publicPorts(),
+			Gateways:    g.gateways,
 		}, g.reporter); err != nil {
-			return errors.Wrap(err, "error opening ports")
+			return nil, errors.Wrap(err, "error deploying gateway")
+		}
+	}
+
+	if g.loadBalancer {
+		if err := OpenLoadBalancerPorts(g.client, g.projectID, g.infraID, g.vpcName, g.loadBalancerPorts()); err != nil {
+			return nil, err
+		}
+	}
+
+	if ports := provider.InternalPorts(g.cniType); len(ports) > 0 {
+		if err := g.cloudPrepare.OpenPorts(ctx, ports, g.reporter); err != nil {
+			return nil, errors.Wrap(err, "error opening ports")
 		}
 	}
 
 	g.reporter.Success("The Submariner cluster environment has been set up on GCP")
 
-	return nil
+	inventory, err := g.inventory()
+	if err != nil {
+		return nil, err
+	}
+
+	g.spotDeployer.RecordFallbacks(inventory)
+
+	return inventory, nil
+}
+
+// inventory returns the cloud resources actually created by PrepareSubmarinerClusterEnv: the existing gateway firewall
+// rule and the deployed gateway MachineSets, or the load balancer ingress rule, along with the existing internal firewall
+// rule.
+func (g *gcpProvider) inventory() ([]configv1alpha1.CloudResource, error) {
+	var resources []configv1alpha1.CloudResource
+
+	if !g.loadBalancer {
+		rule, err := FirewallRuleResource(g.client, g.projectID, g.publicFirewallRuleName(), g.publicPorts())
+		if err != nil {
+			return nil, err
+		}
+
+		if rule != nil {
+			resources = append(resources, *rule)
+		}
+
+		resources = append(resources, g.machineSets.Resources()...)
+	} else {
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule,
+			provider.LoadBalancerIngressName(g.infraID), g.loadBalancerPorts()))
+	}
+
+	if ports := provider.InternalPorts(g.cniType); len(ports) > 0 {
+		rule, err := FirewallRuleResource(g.client, g.projectID, InternalFirewallRuleName(g.infraID), ports)
+		if err != nil {
+			return nil, err
+		}
+
+		if rule != nil {
+			resources = append(resources, *rule)
+		}
+	}
+
+	return resources, nil
 }
 
 // CleanUpSubmarinerClusterEnv clean up submariner cluster environment on GCP after the SubmarinerConfig was deleted
-// 1. delete the inbound and outbound firewall rules to close submariner ports.
-func (g *gcpProvider) CleanUpSubmarinerClusterEnv(ctx context.Context) error {
-	err := g.gwDeployer.Cleanup(ctx, g.reporter)
+// 1. delete the gateways and the inbound and outbound firewall rules to close submariner ports.
+// Only the resources of the given inventory are cleaned up, unless it doesn't name them, see provider.LegacyCleanup.
+func (g *gcpProvider) CleanUpSubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	if provider.LegacyCleanup(inventory, configv1alpha1.CloudResourceMachineSet, configv1alpha1.CloudResourceFirewallRule) {
+		if err := g.gwDeployer.Cleanup(ctx, g.reporter); err != nil {
+			return errors.Wrap(err, "error cleaning up gateway")
+		}
+	} else if err := g.cleanUpGateways(ctx, inventory); err != nil {
+		return err
+	}
+
+	if ports := provider.LoadBalancerIngressPorts(inventory, g.infraID, g.loadBalancer, g.loadBalancerPorts()); len(ports) > 0 {
+		if err := CloseLoadBalancerPorts(g.client, g.projectID, g.infraID); err != nil {
+			return err
+		}
+	}
+
+	if provider.InternalPortsCleanup(inventory, provider.LoadBalancerIngressName(g.infraID), g.publicFirewallRuleName()) {
+		if err := g.cloudPrepare.ClosePorts(ctx, g.reporter); err != nil {
+			return errors.Wrap(err, "error closing ports")
+		}
+	}
+
+	g.reporter.Success("The Submariner cluster environment has been cleaned up on GCP")
+
+	return nil
+}
+
+// cleanUpGateways deletes exactly the gateway MachineSets and firewall rule of the given inventory.
+func (g *gcpProvider) cleanUpGateways(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	if err := g.machineSets.DeleteMachineSets(ctx, inventory); err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	if provider.FindResource(inventory, configv1alpha1.CloudResourceFirewallRule, g.publicFirewallRuleName()) == nil {
+		return nil
+	}
+
+	err := g.client.DeleteFirewallRule(g.projectID, g.publicFirewallRuleName())
+	if err != nil && !gcpclient.IsGCPNotFoundError(err) {
+		return errors.Wrapf(err, "error deleting firewall rule %q", g.publicFirewallRuleName())
+	}
+
+	return nil
+}
+
+// PlanSubmarinerClusterEnv returns the cloud resources the given plan operation would change on GCP, compared with the
+// gateway MachineSets and firewall rules of the cluster.
+func (g *gcpProvider) PlanSubmarinerClusterEnv(ctx context.Context, operation string) ([]configv1alpha1.CloudResource, error) {
+	planned := g.planned()
+	plan := &provider.Plan{}
+
+	if !g.loadBalancer {
+		machineSets, err := provider.GatewayMachineSets(ctx, g.dynamicClient, "machineType")
+		if err != nil {
+			return nil, err //nolint:wrapcheck // No need to wrap here
+		}
+
+		plan.AddGateways(provider.NamedResources(planned, configv1alpha1.CloudResourceMachineSet), machineSets)
+	}
+
+	if err := PlanFirewallRules(g.client, g.projectID, plan, planned); err != nil {
+		return nil, err
+	}
+
+	return plan.Resources(operation), nil
+}
+
+// planned returns the cloud resources PrepareSubmarinerClusterEnv creates on GCP.
+func (g *gcpProvider) planned() []configv1alpha1.CloudResource {
+	var resources []configv1alpha1.CloudResource
+
+	if !g.loadBalancer {
+		resources = append(resources,
+			provider.PortsResource(configv1alpha1.CloudResourceFirewallRule, g.publicFirewallRuleName(), g.publicPorts()))
+		resources = append(resources, provider.GatewayResources(configv1alpha1.CloudResourceMachineSet, g.infraID+"-submariner-gw",
+			g.instanceType, g.gateways, g.gatewayNodes)...)
+	} else {
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule,
+			provider.LoadBalancerIngressName(g.infraID), g.loadBalancerPorts()))
+	}
+
+	return append(resources, provider.InternalPortsResources(InternalFirewallRuleName(g.infraID),
+		provider.InternalPorts(g.cniType))...)
+}
+
+// VerifySubmarinerClusterEnv returns the drift of the cloud resources in the given inventory on GCP.
+func (g *gcpProvider) VerifySubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) ([]string, error) {
+	drift, err := provider.VerifyGatewayNodes(ctx, g.kubeClient, inventory)
 	if err != nil {
-		return errors.Wrap(err, "error cleaning up gateway")
+		return nil, err //nolint:wrapcheck // No need to wrap here
 	}
 
-	err = g.cloudPrepare.ClosePorts(ctx, g.reporter)
+	rulesDrift, err := VerifyFirewallRules(g.client, g.projectID, inventory)
 	if err != nil {
-		return errors.Wrap(err, "error closing ports")
+		return nil, err
 	}
 
-	g.reporter.Success("The Submariner cluster environment has been cleaned up on GCP")
+	return append(drift, rulesDrift...), nil
+}
+
+// VerifyFirewallRules returns the drift of the firewall rules in the given inventory, i.e. a description of each of them
+// which is missing or doesn't allow one of its ports anymore.
+func VerifyFirewallRules(client gcpclient.Interface, projectID string, inventory []configv1alpha1.CloudResource) ([]string, error) {
+	var drift []string
+
+	for _, resource := range provider.NamedResources(inventory, configv1alpha1.CloudResourceFirewallRule) {
+		allowed, found, err := firewallRuleAllowed(client, projectID, resource.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			drift = append(drift, fmt.Sprintf("firewall rule %q is missing", resource.Name))
+			continue
+		}
+
+		drift = append(drift, provider.MissingPorts(&resource, func(port *configv1alpha1.CloudPort) bool {
+			return isFirewallRuleAllowed(allowed, port)
+		})...)
+	}
+
+	return drift, nil
+}
+
+// PlanFirewallRules adds the planned firewall rules to the given plan, compared with the firewall rules of the project.
+func PlanFirewallRules(client gcpclient.Interface, projectID string, plan *provider.Plan, planned []configv1alpha1.CloudResource) error {
+	for _, resource := range provider.NamedResources(planned, configv1alpha1.CloudResourceFirewallRule) {
+		allowed, found, err := firewallRuleAllowed(client, projectID, resource.Name)
+		if err != nil {
+			return err
+		}
+
+		plan.AddPorts(&resource, found, func(port *configv1alpha1.CloudPort) bool {
+			return isFirewallRuleAllowed(allowed, port)
+		})
+	}
 
 	return nil
 }
 
-func newClient(ctx context.Context, credentialsSecret *corev1.Secret) (string, gcpclient.Interface, error) {
-	authJSON, ok := credentialsSecret.Data[gcpCredentialsName]
-	if !ok {
-		return "", nil, fmt.Errorf("the gcp credentials %s is not in secret %s/%s", gcpCredentialsName,
-			credentialsSecret.Namespace, credentialsSecret.Name)
+// firewallRuleAllowed returns what the firewall rule with the given name allows, and whether it exists.
+func firewallRuleAllowed(client gcpclient.Interface, projectID, name string) ([]*compute.FirewallAllowed, bool, error) {
+	rule, err := client.GetFirewallRule(projectID, name)
+	if gcpclient.IsGCPNotFoundError(err) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "error retrieving firewall rule %q", name)
+	}
+
+	return rule.Allowed, true, nil
+}
+
+// InternalFirewallRuleName returns the name of the firewall rule opening the ports within the cluster, created by
+// cloud-prepare.
+func InternalFirewallRuleName(infraID string) string {
+	return infraID + "-submariner-internal-ingress"
+}
+
+// FirewallRuleResource returns the firewall rule with the given name opening the given ports, or nil if it doesn't exist.
+func FirewallRuleResource(client gcpclient.Interface, projectID, name string, ports []api.PortSpec,
+) (*configv1alpha1.CloudResource, error) {
+	rule, err := client.GetFirewallRule(projectID, name)
+	if gcpclient.IsGCPNotFoundError(err) {
+		return nil, nil //nolint:nilnil // A missing firewall rule is not an error
 	}
 
-	// since we're using a single creds var, we should specify all the required scopes when initializing
-	// Use CredentialsFromJSONWithType to explicitly validate that we're loading a service account credential
-	creds, err := google.CredentialsFromJSONWithType(ctx, authJSON, google.ServiceAccount, dns.CloudPlatformScope)
 	if err != nil {
-		return "", nil, errors.Wrap(err, "error retrieving credentials")
+		return nil, errors.Wrapf(err, "error retrieving firewall rule %q", name)
+	}
+
+	resource := provider.PortsResource(configv1alpha1.CloudResourceFirewallRule, rule.Name, ports)
+
+	return &resource, nil
+}
+
+func isFirewallRuleAllowed(allowed []*compute.FirewallAllowed, port *configv1alpha1.CloudPort) bool {
+	for _, a := range allowed {
+		if a.IPProtocol == "all" {
+			return true
+		}
+
+		if a.IPProtocol != port.Protocol {
+			continue
+		}
+
+		if port.Port == 0 || len(a.Ports) == 0 {
+			return true
+		}
+
+		for _, p := range a.Ports {
+			from, to, isRange := strings.Cut(p, "-")
+			if !isRange {
+				to = from
+			}
+
+			low, lowErr := strconv.Atoi(from)
+			high, highErr := strconv.Atoi(to)
+
+			if lowErr == nil && highErr == nil && low <= port.Port && port.Port <= high {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// loadBalancerPorts returns the ports opened to external sources on the worker nodes in load balancer mode.
+func (g *gcpProvider) loadBalancerPorts() []api.PortSpec {
+	return provider.LoadBalancerPorts(g.nattPort, uint16(g.nattDiscoveryPort)) //nolint:gosec // Usable port numbers fit
+}
+
+// publicFirewallRuleName returns the name of the firewall rule opening the public ports of the gateways, created by
+// cloud-prepare.
+func (g *gcpProvider) publicFirewallRuleName() string {
+	return g.infraID + "-submariner-public-ingress"
+}
+
+func (g *gcpProvider) publicPorts() []api.PortSpec {
+	return []api.PortSpec{
+		{Port: g.nattPort, Protocol: "udp"},
+		{Port: uint16(g.nattDiscoveryPort), Protocol: "udp"}, //nolint:gosec // Usable port numbers fit
+		// ESP & AH protocols are used for private-ip to private-ip gateway communications
+		{Port: 0, Protocol: "esp"},
+		{Port: 0, Protocol: "ah"},
+	}
+}
+
+// NewClient creates a GCP client using the credentials from the given Secret and returns it along with the project ID.
+func NewClient(ctx context.Context, credentialsSecret *corev1.Secret) (string, gcpclient.Interface, error) {
+	creds, err := newCredentials(ctx, credentialsSecret)
+	if err != nil {
+		return "", nil, err
 	}
 
 	// Create a GCP client with the credentials.
@@ -171,3 +464,118 @@ func newClient(ctx context.Context, credentialsSecret *corev1.Secret) (string, g
 
 	return creds.ProjectID, computeClient, nil
 }
+
+// newCredentials loads either a service account key or, for workload identity federation, an external account
+// configuration whose credential source is the agent's projected service account token. External account
+// configurations don't include a project ID so it must be provided in the Secret.
+func newCredentials(ctx context.Context, credentialsSecret *corev1.Secret) (*google.Credentials, error) {
+	authJSON, ok := credentialsSecret.Data[gcpCredentialsName]
+	if !ok {
+		return nil, fmt.Errorf("the gcp credentials %s is not in secret %s/%s", gcpCredentialsName,
+			credentialsSecret.Namespace, credentialsSecret.Name)
+	}
+
+	var credsFile struct {
+		Type google.CredentialsType `json:"type"`
+	}
+
+	if err := json.Unmarshal(authJSON, &credsFile); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling the gcp credentials")
+	}
+
+	// Only service account keys and external accounts are accepted, other credential types can't be used by the agent
+	if credsFile.Type != google.ExternalAccount {
+		credsFile.Type = google.ServiceAccount
+	} else if err := validateExternalAccount(authJSON); err != nil {
+		return nil, err
+	}
+
+	// since we're using a single creds var, we should specify all the required scopes when initializing
+	// Use CredentialsFromJSONWithType to explicitly validate the credential type
+	creds, err := google.CredentialsFromJSONWithType(ctx, authJSON, credsFile.Type, dns.CloudPlatformScope)
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving credentials")
+	}
+
+	if projectID, ok := credentialsSecret.Data[projectIDKey]; ok && len(projectID) > 0 {
+		creds.ProjectID = string(projectID)
+	}
+
+	if creds.ProjectID == "" {
+		return nil, fmt.Errorf("the gcp project ID is not in the credentials nor in the %s key of secret %s/%s", projectIDKey,
+			credentialsSecret.Namespace, credentialsSecret.Name)
+	}
+
+	return creds, nil
+}
+
+// validateExternalAccount checks that the given external account configuration only makes the agent read its projected
+// service account token and call Google endpoints. Since the credentials Secret is written on the hub, it could otherwise
+// make the agent read any local file, or call any URL or executable.
+func validateExternalAccount(authJSON []byte) error {
+	var account struct {
+		TokenURL                       string         `json:"token_url"`
+		TokenInfoURL                   string         `json:"token_info_url"`
+		ServiceAccountImpersonationURL string         `json:"service_account_impersonation_url"`
+		CredentialSource               map[string]any `json:"credential_source"`
+	}
+
+	if err := json.Unmarshal(authJSON, &account); err != nil {
+		return errors.Wrap(err, "error unmarshalling the gcp external account")
+	}
+
+	for key := range account.CredentialSource {
+		if key != "file" && key != "format" {
+			return fmt.Errorf("the %q credential source of the gcp external account is not allowed, only a file is", key)
+		}
+	}
+
+	file, _ := account.CredentialSource["file"].(string)
+	if file == "" {
+		return errors.New("the gcp external account has no credential source file")
+	}
+
+	if _, err := provider.ServiceAccountTokenFile(file); err != nil {
+		return errors.Wrap(err, "invalid credential source of the gcp external account")
+	}
+
+	for _, endpoint := range []string{account.TokenURL, account.TokenInfoURL, account.ServiceAccountImpersonationURL} {
+		if endpoint == "" {
+			continue
+		}
+
+		parsed, err := url.Parse(endpoint)
+		if err != nil || parsed.Scheme != "https" || !strings.HasSuffix(parsed.Hostname(), ".googleapis.com") {
+			return fmt.Errorf("the gcp external account URL %q is not a Google endpoint", endpoint)
+		}
+	}
+
+	return nil
+}
+
+// customizeGatewayNode sets the machine type, zone and subnetwork of the given gateway node in the provider spec of a GCP
+// gateway MachineSet.
+func customizeGatewayNode(providerSpec map[string]any, node *configv1alpha1.GatewayNode) {
+	if node.InstanceType != "" {
+		providerSpec["machineType"] = node.InstanceType
+	}
+
+	if node.Zone != "" {
+		providerSpec["zone"] = node.Zone
+	}
+
+	if node.Subnet == "" {
+		return
+	}
+
+	interfaces, _, _ := unstructured.NestedSlice(providerSpec, "networkInterfaces")
+	if len(interfaces) == 0 {
+		interfaces = []any{map[string]any{}}
+	}
+
+	if networkInterface, ok := interfaces[0].(map[string]any); ok {
+		networkInterface["subnetwork"] = node.Subnet
+	}
+
+	providerSpec["networkInterfaces"] = interfaces
+}
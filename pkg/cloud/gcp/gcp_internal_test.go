@@ -0,0 +1,142 @@
+package gcp
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/submariner-io/cloud-prepare/pkg/api"
+	"google.golang.org/api/compute/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	serviceAccountJSON = `
+{
+  "type": "service_account",
+  "project_id": "my-project",
+  "private_key_id": "key-id",
+  "private_key": "",
+  "client_email": "submariner@my-project.iam.gserviceaccount.com",
+  "client_id": "1234",
+  "token_uri": "https://oauth2.googleapis.com/token"
+}`
+
+	externalAccountJSON = `
+{
+  "type": "external_account",
+  "audience": "//iam.googleapis.com/projects/1234/locations/global/workloadIdentityPools/pool/providers/provider",
+  "subject_token_type": "urn:ietf:params:oauth:token-type:jwt",
+  "token_url": "https://sts.googleapis.com/v1/token",
+  "credential_source": {
+    "file": "/var/run/secrets/openshift/serviceaccount/token",
+    "format": {"type": "text"}
+  }
+}`
+)
+
+var _ = Describe("newCredentials", func() {
+	It("should load service account credentials", func() {
+		creds, err := newCredentials(context.TODO(), &corev1.Secret{
+			Data: map[string][]byte{gcpCredentialsName: []byte(serviceAccountJSON)},
+		})
+		Expect(err).To(Succeed())
+		Expect(creds.ProjectID).To(Equal("my-project"))
+	})
+
+	It("should load workload identity federation credentials", func() {
+		creds, err := newCredentials(context.TODO(), &corev1.Secret{
+			Data: map[string][]byte{
+				gcpCredentialsName: []byte(externalAccountJSON),
+				projectIDKey:       []byte("my-project"),
+			},
+		})
+		Expect(err).To(Succeed())
+		Expect(creds.ProjectID).To(Equal("my-project"))
+	})
+
+	DescribeTable("should return an error for an external account which doesn't only use the projected token",
+		func(original, replacement string) {
+			_, err := newCredentials(context.TODO(), &corev1.Secret{
+				Data: map[string][]byte{
+					gcpCredentialsName: []byte(strings.Replace(externalAccountJSON, original, replacement, 1)),
+					projectIDKey:       []byte("my-project"),
+				},
+			})
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("another file", `"file": "/var/run/secrets/openshift/serviceaccount/token"`, `"file": "/etc/shadow"`),
+		Entry("a URL", `"file": "/var/run/secrets/openshift/serviceaccount/token"`, `"url": "http://169.254.169.254/token"`),
+		Entry("an executable", `"file": "/var/run/secrets/openshift/serviceaccount/token"`,
+			`"file": "/var/run/secrets/openshift/serviceaccount/token", "executable": {"command": "/bin/sh"}`),
+		Entry("another token URL", `"https://sts.googleapis.com/v1/token"`, `"https://attacker.example.com/v1/token"`),
+	)
+
+	It("should return an error if the project ID is missing", func() {
+		_, err := newCredentials(context.TODO(), &corev1.Secret{
+			Data: map[string][]byte{gcpCredentialsName: []byte(externalAccountJSON)},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error for other credential types", func() {
+		_, err := newCredentials(context.TODO(), &corev1.Secret{
+			Data: map[string][]byte{gcpCredentialsName: []byte(`{"type": "authorized_user"}`)},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should return an error if the credentials are missing", func() {
+		_, err := newCredentials(context.TODO(), &corev1.Secret{})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = DescribeTable("isFirewallRuleAllowed",
+	func(port configv1alpha1.CloudPort, expected bool) {
+		allowed := []*compute.FirewallAllowed{
+			{IPProtocol: "udp", Ports: []string{"4500", "4800-4900"}},
+			{IPProtocol: "esp"},
+		}
+
+		Expect(isFirewallRuleAllowed(allowed, &port)).To(Equal(expected))
+	},
+	Entry("a listed port", configv1alpha1.CloudPort{Port: 4500, Protocol: "udp"}, true),
+	Entry("a port in a listed range", configv1alpha1.CloudPort{Port: 4900, Protocol: "udp"}, true),
+	Entry("an unlisted port", configv1alpha1.CloudPort{Port: 500, Protocol: "udp"}, false),
+	Entry("a listed port with another protocol", configv1alpha1.CloudPort{Port: 4500, Protocol: "tcp"}, false),
+	Entry("a listed protocol without ports", configv1alpha1.CloudPort{Port: 0, Protocol: "esp"}, true),
+	Entry("an unlisted protocol", configv1alpha1.CloudPort{Port: 0, Protocol: "ah"}, false),
+)
+
+var _ = Describe("isFirewallRuleAllowed", func() {
+	It("should return true for any port if all the protocols are allowed", func() {
+		Expect(isFirewallRuleAllowed([]*compute.FirewallAllowed{{IPProtocol: "all"}},
+			&configv1alpha1.CloudPort{Port: 4800, Protocol: "udp"})).To(BeTrue())
+	})
+})
+
+var _ = Describe("loadBalancerFirewallRule", func() {
+	ports := []api.PortSpec{{Port: 4500, Protocol: "udp"}, {Port: 10256, Protocol: "tcp"}}
+
+	It("should open the ports of the worker nodes to any source in the cluster network", func() {
+		Expect(loadBalancerFirewallRule("my-project", "test", "", ports)).To(Equal(&compute.Firewall{
+			Name:         "test-submariner-lb-ingress",
+			Network:      "projects/my-project/global/networks/test-network",
+			Direction:    "INGRESS",
+			SourceRanges: []string{"0.0.0.0/0"},
+			TargetTags:   []string{"test-worker"},
+			Allowed: []*compute.FirewallAllowed{
+				{IPProtocol: "udp", Ports: []string{"4500"}},
+				{IPProtocol: "tcp", Ports: []string{"10256"}},
+			},
+		}))
+	})
+
+	It("should use the specified VPC", func() {
+		Expect(loadBalancerFirewallRule("my-project", "test", "my-vpc", ports).Network).To(
+			Equal("projects/my-project/global/networks/my-vpc"))
+	})
+})
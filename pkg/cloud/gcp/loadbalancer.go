@@ -0,0 +1,68 @@
+package gcp
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"github.com/submariner-io/cloud-prepare/pkg/api"
+	gcpclient "github.com/submariner-io/cloud-prepare/pkg/gcp/client"
+	"google.golang.org/api/compute/v1"
+)
+
+// OpenLoadBalancerPorts creates or updates the firewall rule opening the given ports of the worker nodes to any source, so
+// that the load balancer fronting the worker nodes in load balancer mode can forward the traffic of the remote clusters
+// and probe the nodes. The worker nodes are selected with the network tag set by the installer. The rule is created in
+// the given VPC, or in the cluster's VPC if empty.
+func OpenLoadBalancerPorts(client gcpclient.Interface, projectID, infraID, vpcName string, ports []api.PortSpec) error {
+	rule := loadBalancerFirewallRule(projectID, infraID, vpcName, ports)
+
+	_, err := client.GetFirewallRule(projectID, rule.Name)
+	if gcpclient.IsGCPNotFoundError(err) {
+		return errors.Wrapf(client.InsertFirewallRule(projectID, rule), "error creating firewall rule %q", rule.Name)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving firewall rule %q", rule.Name)
+	}
+
+	return errors.Wrapf(client.UpdateFirewallRule(projectID, rule.Name, rule), "error updating firewall rule %q", rule.Name)
+}
+
+// CloseLoadBalancerPorts deletes the firewall rule created by OpenLoadBalancerPorts.
+func CloseLoadBalancerPorts(client gcpclient.Interface, projectID, infraID string) error {
+	name := provider.LoadBalancerIngressName(infraID)
+
+	err := client.DeleteFirewallRule(projectID, name)
+	if err != nil && !gcpclient.IsGCPNotFoundError(err) {
+		return errors.Wrapf(err, "error deleting firewall rule %q", name)
+	}
+
+	return nil
+}
+
+func loadBalancerFirewallRule(projectID, infraID, vpcName string, ports []api.PortSpec) *compute.Firewall {
+	if vpcName == "" {
+		vpcName = infraID + "-network"
+	}
+
+	rule := &compute.Firewall{
+		Name:         provider.LoadBalancerIngressName(infraID),
+		Network:      fmt.Sprintf("projects/%s/global/networks/%s", projectID, vpcName),
+		Direction:    "INGRESS",
+		SourceRanges: []string{"0.0.0.0/0"},
+		TargetTags:   []string{infraID + "-worker"},
+	}
+
+	for _, port := range ports {
+		allowed := &compute.FirewallAllowed{IPProtocol: port.Protocol}
+		if port.Port != 0 {
+			allowed.Ports = []string{strconv.Itoa(int(port.Port))}
+		}
+
+		rule.Allowed = append(rule.Allowed, allowed)
+	}
+
+	return rule
+}
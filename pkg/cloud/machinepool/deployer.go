@@ -0,0 +1,248 @@
+package machinepool
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/ocm"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	cpapi "github.com/submariner-io/cloud-prepare/pkg/api"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// Name is the name of the machine pool hosting the Submariner gateways.
+	Name = "submariner-gw"
+	// ClusterIDAnnotation can be set on the SubmarinerConfig to specify the OCM cluster ID instead of looking it up.
+	ClusterIDAnnotation = "submariner.io/ocm-cluster-id"
+
+	gatewayLabel = "submariner.io/gateway"
+)
+
+var clusterVersionGVR = schema.GroupVersionResource{
+	Group:    "config.openshift.io",
+	Version:  "v1",
+	Resource: "clusterversions",
+}
+
+type gatewayDeployer struct {
+	client       ocm.Client
+	instanceType string
+	nodes        []configv1alpha1.GatewayNode
+}
+
+// NewGatewayDeployer returns a GatewayDeployer that deploys the Submariner gateways in a dedicated OCM machine pool. This is
+// used for managed offerings, such as ROSA and OSD, where MachineSets are reconciled away by the service. If gateway nodes
+// are specified individually, each is deployed in its own machine pool.
+func NewGatewayDeployer(client ocm.Client, instanceType string, nodes []configv1alpha1.GatewayNode) cpapi.GatewayDeployer {
+	return &gatewayDeployer{
+		client:       client,
+		instanceType: instanceType,
+		nodes:        nodes,
+	}
+}
+
+// NewOCMClient creates an OCM client for the managed cluster described by the provider Info. The OCM cluster is
+// identified by the ClusterIDAnnotation if present, otherwise by the cluster ID from the ClusterVersion resource.
+func NewOCMClient(ctx context.Context, info *provider.Info) (ocm.Client, error) {
+	config, err := ocm.ConfigFromSecret(info.CredentialsSecret)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading the OCM credentials")
+	}
+
+	config.ClusterID = info.SubmarinerConfigAnnotations[ClusterIDAnnotation]
+
+	if config.ClusterID == "" {
+		clusterVersion, err := info.DynamicClient.Resource(clusterVersionGVR).Get(ctx, "version", metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "error retrieving the ClusterVersion")
+		}
+
+		config.ExternalID, _, _ = unstructured.NestedString(clusterVersion.Object, "spec", "clusterID")
+	}
+
+	client, err := ocm.NewClient(ctx, config)
+
+	return client, errors.Wrap(err, "error creating the OCM client")
+}
+
+func (d *gatewayDeployer) Deploy(ctx context.Context, input cpapi.GatewayDeployInput, status reporter.Interface) error {
+	if len(d.nodes) == 0 {
+		err := d.deployMachinePool(ctx, &ocm.MachinePool{ID: Name, InstanceType: d.instanceType, Replicas: input.Gateways}, status)
+		if err != nil {
+			return err
+		}
+
+		return d.deleteMachinePoolsFrom(ctx, 1, status)
+	}
+
+	for i := range d.nodes {
+		pool := &ocm.MachinePool{
+			ID:           provider.GatewayNodeName(Name, i),
+			InstanceType: provider.GatewayNodeInstanceType(&d.nodes[i], d.instanceType),
+			Replicas:     1,
+		}
+
+		if d.nodes[i].Zone != "" {
+			pool.AvailabilityZones = []string{d.nodes[i].Zone}
+		}
+
+		if d.nodes[i].Subnet != "" {
+			pool.Subnets = []string{d.nodes[i].Subnet}
+		}
+
+		if err := d.deployMachinePool(ctx, pool, status); err != nil {
+			return err
+		}
+	}
+
+	// Delete the machine pools of the gateway nodes which are no longer specified
+	return d.deleteMachinePoolsFrom(ctx, len(d.nodes), status)
+}
+
+func (d *gatewayDeployer) deployMachinePool(ctx context.Context, desired *ocm.MachinePool, status reporter.Interface) error {
+	status.Start("Deploying %d Submariner gateway node(s) in machine pool %q", desired.Replicas, desired.ID)
+	defer status.End()
+
+	pool, found, err := d.client.GetMachinePool(ctx, desired.ID)
+	if err != nil {
+		return status.Error(err, "unable to retrieve the gateway machine pool") //nolint:wrapcheck // The reporter wraps the error
+	}
+
+	if !found {
+		desired.Labels = map[string]string{gatewayLabel: "true"}
+
+		err = d.client.CreateMachinePool(ctx, desired)
+		if err != nil {
+			return status.Error(err, "unable to create the gateway machine pool") //nolint:wrapcheck // The reporter wraps the error
+		}
+
+		status.Success("Created machine pool %q with %d gateway node(s)", desired.ID, desired.Replicas)
+
+		return nil
+	}
+
+	if difference := creationOnlyDifference(pool, desired); difference != "" {
+		err = errors.Errorf("machine pool %q has %s, delete it to have it recreated", desired.ID, difference)
+
+		return status.Error(err, "unable to update the gateway machine pool") //nolint:wrapcheck // The reporter wraps the error
+	}
+
+	if pool.Replicas == desired.Replicas && pool.Labels[gatewayLabel] == "true" {
+		status.Success("Machine pool %q is up to date", desired.ID)
+
+		return nil
+	}
+
+	if pool.Labels == nil {
+		pool.Labels = map[string]string{}
+	}
+
+	pool.Replicas = desired.Replicas
+	pool.Labels[gatewayLabel] = "true"
+
+	if err := d.client.UpdateMachinePool(ctx, pool); err != nil {
+		return status.Error(err, "unable to update the gateway machine pool") //nolint:wrapcheck // The reporter wraps the error
+	}
+
+	status.Success("Updated machine pool %q to %d gateway node(s)", desired.ID, desired.Replicas)
+
+	return nil
+}
+
+// creationOnlyDifference describes how the existing machine pool differs from the desired one in the settings OCM only
+// accepts when a machine pool is created, if it does. Zones and subnets are only compared when they are specified.
+func creationOnlyDifference(pool, desired *ocm.MachinePool) string {
+	switch {
+	case pool.InstanceType != desired.InstanceType:
+		return fmt.Sprintf("instance type %q instead of %q", pool.InstanceType, desired.InstanceType)
+	case len(desired.AvailabilityZones) > 0 && !slices.Equal(pool.AvailabilityZones, desired.AvailabilityZones):
+		return fmt.Sprintf("zones %v instead of %v", pool.AvailabilityZones, desired.AvailabilityZones)
+	case len(desired.Subnets) > 0 && !slices.Equal(pool.Subnets, desired.Subnets):
+		return fmt.Sprintf("subnets %v instead of %v", pool.Subnets, desired.Subnets)
+	}
+
+	return ""
+}
+
+func (d *gatewayDeployer) Cleanup(ctx context.Context, status reporter.Interface) error {
+	return d.deleteMachinePoolsFrom(ctx, 0, status)
+}
+
+// deleteMachinePoolsFrom deletes the gateway machine pools starting with the one of the gateway node with the given index.
+func (d *gatewayDeployer) deleteMachinePoolsFrom(ctx context.Context, index int, status reporter.Interface) error {
+	for ; ; index++ {
+		id := provider.GatewayNodeName(Name, index)
+
+		_, found, err := d.client.GetMachinePool(ctx, id)
+		if err != nil {
+			return errors.Wrapf(err, "error retrieving machine pool %q", id)
+		}
+
+		// The first machine pool is always probed so that the clean up is complete
+		if !found && index > 0 {
+			return nil
+		}
+
+		if !found {
+			continue
+		}
+
+		if err := deleteMachinePool(ctx, d.client, id, status); err != nil {
+			return err
+		}
+	}
+}
+
+// Resources returns the given planned gateway machine pools which actually exist, with their actual instance type and
+// replicas.
+func Resources(ctx context.Context, client ocm.Client, planned []configv1alpha1.CloudResource,
+) ([]configv1alpha1.CloudResource, error) {
+	var resources []configv1alpha1.CloudResource
+
+	for _, resource := range provider.NamedResources(planned, configv1alpha1.CloudResourceMachinePool) {
+		pool, found, err := client.GetMachinePool(ctx, resource.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error retrieving machine pool %q", resource.Name)
+		}
+
+		if found {
+			resources = append(resources, provider.GatewayResource(configv1alpha1.CloudResourceMachinePool, pool.ID,
+				pool.InstanceType, pool.Replicas))
+		}
+	}
+
+	return resources, nil
+}
+
+// DeleteMachinePools deletes exactly the named gateway machine pools of the given inventory.
+func DeleteMachinePools(ctx context.Context, client ocm.Client, inventory []configv1alpha1.CloudResource,
+	status reporter.Interface,
+) error {
+	for _, resource := range provider.NamedResources(inventory, configv1alpha1.CloudResourceMachinePool) {
+		if err := deleteMachinePool(ctx, client, resource.Name, status); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteMachinePool(ctx context.Context, client ocm.Client, id string, status reporter.Interface) error {
+	status.Start("Deleting the Submariner gateway machine pool %q", id)
+	defer status.End()
+
+	if err := client.DeleteMachinePool(ctx, id); err != nil {
+		return status.Error(err, "unable to delete the gateway machine pool") //nolint:wrapcheck // The reporter wraps the error
+	}
+
+	status.Success("Deleted the gateway machine pool %q", id)
+
+	return nil
+}
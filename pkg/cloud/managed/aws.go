@@ -0,0 +1,241 @@
+package managed
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/aws"
+	"github.com/stolostron/submariner-addon/pkg/cloud/machinepool"
+	"github.com/stolostron/submariner-addon/pkg/cloud/ocm"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"github.com/stolostron/submariner-addon/pkg/cloud/reporter"
+	submreporter "github.com/submariner-io/admiral/pkg/reporter"
+	cpapi "github.com/submariner-io/cloud-prepare/pkg/api"
+	cpaws "github.com/submariner-io/cloud-prepare/pkg/aws"
+	cpclient "github.com/submariner-io/cloud-prepare/pkg/aws/client"
+	"k8s.io/client-go/kubernetes"
+)
+
+const awsInstanceType = "m5.xlarge"
+
+type awsProvider struct {
+	product           string
+	infraID           string
+	instanceType      string
+	kubeClient        kubernetes.Interface
+	ocmClient         ocm.Client
+	reporter          submreporter.Interface
+	nattPort          uint16
+	nattDiscoveryPort uint16
+	cniType           string
+	gateways          int
+	gatewayNodes      []configv1alpha1.GatewayNode
+	loadBalancer      bool
+	awsClient         cpclient.Interface
+	workerSG          string
+	cloudPrepare      cpapi.Cloud
+	gatewayDeployer   cpapi.GatewayDeployer
+}
+
+// NewAWSProvider creates a provider for OCM-managed clusters running on AWS, i.e. ROSA and OSD. The gateways are deployed
+// in an OCM machine pool and the Submariner ports are opened in the cluster's worker security group.
+func NewAWSProvider(ctx context.Context, info *provider.Info) (*awsProvider, error) {
+	if info.Region == "" {
+		return nil, errors.New("cluster region is empty")
+	}
+
+	if info.InfraID == "" {
+		return nil, errors.New("cluster infraID is empty")
+	}
+
+	if provider.GatewayCount(&info.GatewayConfig) < 1 {
+		return nil, errors.New("the count of gateways is less than 1")
+	}
+
+	instanceType := info.GatewayConfig.AWS.InstanceType
+	if instanceType == "" {
+		instanceType = awsInstanceType
+	}
+
+	awsClient, err := aws.NewClient(ctx, info)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the AWS client")
+	}
+
+	ocmClient, err := machinepool.NewOCMClient(ctx, info)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating the OCM client for cluster %q", info.ClusterName)
+	}
+
+	return &awsProvider{
+		product:           info.Vendor,
+		infraID:           info.InfraID,
+		instanceType:      instanceType,
+		kubeClient:        info.KubeClient,
+		ocmClient:         ocmClient,
+		reporter:          reporter.NewEventRecorderWrapper("ManagedAWSCloudProvider", info.EventRecorder),
+		nattPort:          uint16(info.IPSecNATTPort),     //nolint:gosec // Usable port numbers fit
+		nattDiscoveryPort: uint16(info.NATTDiscoveryPort), //nolint:gosec // Usable port numbers fit
+		cniType:           info.NetworkType,
+		gateways:          provider.GatewayCount(&info.GatewayConfig),
+		gatewayNodes:      info.GatewayNodes,
+		loadBalancer:      info.LoadBalancerEnable,
+		awsClient:         awsClient,
+		workerSG:          info.SubmarinerConfigAnnotations[aws.WorkerSecurityGroupAnnotation],
+		cloudPrepare:      cpaws.NewCloud(awsClient, info.InfraID, info.Region, aws.CloudOptions(info.SubmarinerConfigAnnotations)...),
+		gatewayDeployer:   machinepool.NewGatewayDeployer(ocmClient, instanceType, info.GatewayNodes),
+	}, nil
+}
+
+// PrepareSubmarinerClusterEnv prepares submariner cluster environment on ROSA or OSD on AWS.
+func (a *awsProvider) PrepareSubmarinerClusterEnv(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
+	// In load balancer mode, the load balancer fronts the existing worker nodes so no gateway machine pool is created
+	if !a.loadBalancer {
+		if err := a.gatewayDeployer.Deploy(ctx, cpapi.GatewayDeployInput{Gateways: a.gateways}, a.reporter); err != nil {
+			return nil, errors.Wrap(err, "error deploying gateway")
+		}
+	}
+
+	if a.loadBalancer {
+		if err := aws.OpenLoadBalancerPorts(ctx, a.awsClient, a.infraID, a.workerSG,
+			provider.LoadBalancerPorts(a.nattPort, a.nattDiscoveryPort)); err != nil {
+			return nil, errors.Wrap(err, "error opening the load balancer ports")
+		}
+	}
+
+	if ports := gatewayPorts(a.nattPort, a.nattDiscoveryPort, "50", "51", a.cniType, a.loadBalancer); len(ports) > 0 {
+		if err := a.cloudPrepare.OpenPorts(ctx, ports, a.reporter); err != nil {
+			return nil, errors.Wrap(err, "error opening ports")
+		}
+	}
+
+	a.reporter.Success("The Submariner cluster environment has been set up on %s", a.product)
+
+	return a.inventory(ctx)
+}
+
+// inventory returns the cloud resources actually created by PrepareSubmarinerClusterEnv: the existing gateway machine
+// pools or the load balancer ingress rules, along with the worker security group the gateway ports were opened in,
+// identified by its ID.
+func (a *awsProvider) inventory(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
+	planned := a.planned()
+
+	resources, err := machinepool.Resources(ctx, a.ocmClient, planned)
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving the gateway machine pools")
+	}
+
+	if lbIngress := provider.FindResource(planned, configv1alpha1.CloudResourceFirewallRule,
+		provider.LoadBalancerIngressName(a.infraID)); lbIngress != nil {
+		resources = append(resources, *lbIngress)
+	}
+
+	if ports := gatewayPorts(a.nattPort, a.nattDiscoveryPort, "50", "51", a.cniType, a.loadBalancer); len(ports) > 0 {
+		groupID, err := aws.WorkerSecurityGroupID(ctx, a.awsClient, a.infraID, a.workerSG)
+		if err != nil {
+			return nil, errors.Wrap(err, "error retrieving the worker security group")
+		}
+
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule, groupID, ports))
+	}
+
+	return resources, nil
+}
+
+// CleanUpSubmarinerClusterEnv clean up submariner cluster environment on ROSA or OSD on AWS after the SubmarinerConfig was
+// deleted. Only the resources of the given inventory are cleaned up, unless it doesn't name them, see
+// provider.LegacyCleanup.
+func (a *awsProvider) CleanUpSubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	if provider.LegacyCleanup(inventory, configv1alpha1.CloudResourceMachinePool) {
+		if err := a.gatewayDeployer.Cleanup(ctx, a.reporter); err != nil {
+			return errors.Wrap(err, "error cleaning up gateway")
+		}
+	} else if err := machinepool.DeleteMachinePools(ctx, a.ocmClient, inventory, a.reporter); err != nil {
+		return errors.Wrap(err, "error cleaning up gateway")
+	}
+
+	if ports := provider.LoadBalancerIngressPorts(inventory, a.infraID, a.loadBalancer,
+		provider.LoadBalancerPorts(a.nattPort, a.nattDiscoveryPort)); len(ports) > 0 {
+		if err := aws.CloseLoadBalancerPorts(ctx, a.awsClient, a.infraID, a.workerSG, ports); err != nil {
+			return errors.Wrap(err, "error closing the load balancer ports")
+		}
+	}
+
+	if provider.InternalPortsCleanup(inventory, provider.LoadBalancerIngressName(a.infraID)) {
+		if err := a.cloudPrepare.ClosePorts(ctx, a.reporter); err != nil {
+			return errors.Wrap(err, "error closing ports")
+		}
+	}
+
+	a.reporter.Success("The Submariner cluster environment has been cleaned up on %s", a.product)
+
+	return nil
+}
+
+// PlanSubmarinerClusterEnv returns the cloud resources the given plan operation would change on ROSA or OSD on AWS, compared
+// with the gateway machine pools and the worker security group of the cluster.
+func (a *awsProvider) PlanSubmarinerClusterEnv(ctx context.Context, operation string) ([]configv1alpha1.CloudResource, error) {
+	planned := a.planned()
+
+	pools, err := machinepool.Resources(ctx, a.ocmClient, planned)
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving the gateway machine pools")
+	}
+
+	plan := &provider.Plan{}
+	plan.AddGateways(provider.NamedResources(planned, configv1alpha1.CloudResourceMachinePool), pools)
+
+	if err := aws.PlanSecurityGroups(ctx, a.awsClient, a.infraID, a.workerSG, plan, planned); err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	return plan.Resources(operation), nil
+}
+
+// planned returns the cloud resources PrepareSubmarinerClusterEnv creates on ROSA or OSD on AWS.
+func (a *awsProvider) planned() []configv1alpha1.CloudResource {
+	var resources []configv1alpha1.CloudResource
+
+	if !a.loadBalancer {
+		resources = append(resources, provider.GatewayResources(configv1alpha1.CloudResourceMachinePool, machinepool.Name,
+			a.instanceType, a.gateways, a.gatewayNodes)...)
+	} else {
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule,
+			provider.LoadBalancerIngressName(a.infraID), provider.LoadBalancerPorts(a.nattPort, a.nattDiscoveryPort)))
+	}
+
+	return append(resources, provider.InternalPortsResources(a.infraID+"-worker-sg",
+		gatewayPorts(a.nattPort, a.nattDiscoveryPort, "50", "51", a.cniType, a.loadBalancer))...)
+}
+
+// gatewayPorts returns the ports that need to be opened within the cluster for the gateways, using the given protocol names
+// for ESP and AH since they differ between clouds. In load balancer mode, the ports forwarded by the load balancer are
+// opened to external sources separately. The route port is only needed if the CNI isn't OVNKubernetes.
+func gatewayPorts(nattPort, nattDiscoveryPort uint16, espProtocol, ahProtocol, cniType string, loadBalancer bool) []cpapi.PortSpec {
+	if loadBalancer {
+		return provider.InternalPorts(cniType)
+	}
+
+	return provider.InternalPorts(cniType,
+		cpapi.PortSpec{Port: nattPort, Protocol: "udp"},
+		cpapi.PortSpec{Port: nattDiscoveryPort, Protocol: "udp"},
+		// ESP & AH protocols are used for private-ip to private-ip gateway communications
+		cpapi.PortSpec{Port: 0, Protocol: espProtocol},
+		cpapi.PortSpec{Port: 0, Protocol: ahProtocol})
+}
+
+// VerifySubmarinerClusterEnv returns the drift of the cloud resources in the given inventory on ROSA or OSD on AWS.
+func (a *awsProvider) VerifySubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) ([]string, error) {
+	drift, err := verifyMachinePool(ctx, a.ocmClient, a.kubeClient, inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	portsDrift, err := aws.VerifySecurityGroups(ctx, a.awsClient, a.infraID, a.workerSG, inventory)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	return append(drift, portsDrift...), nil
+}
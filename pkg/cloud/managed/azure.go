@@ -0,0 +1,342 @@
+package managed
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/azure"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"github.com/stolostron/submariner-addon/pkg/cloud/reporter"
+	submreporter "github.com/submariner-io/admiral/pkg/reporter"
+	cpapi "github.com/submariner-io/cloud-prepare/pkg/api"
+	cpazure "github.com/submariner-io/cloud-prepare/pkg/azure"
+	"github.com/submariner-io/cloud-prepare/pkg/k8s"
+	"github.com/submariner-io/cloud-prepare/pkg/ocp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	azureInstanceType = "Standard_D4s_v3"
+	// ResourceGroupAnnotation can be set on the SubmarinerConfig to specify the ARO cluster resource group instead of
+	// looking it up.
+	ResourceGroupAnnotation = "submariner.io/resource-group"
+)
+
+var infrastructureGVR = schema.GroupVersionResource{
+	Group:    "config.openshift.io",
+	Version:  "v1",
+	Resource: "infrastructures",
+}
+
+type aroProvider struct {
+	infraID           string
+	instanceType      string
+	kubeClient        kubernetes.Interface
+	reporter          submreporter.Interface
+	nattPort          uint16
+	nattDiscoveryPort uint16
+	cniType           string
+	gateways          int
+	gatewayNodes      []configv1alpha1.GatewayNode
+	loadBalancer      bool
+	airGapped         bool
+	cloudInfo         *cpazure.CloudInfo
+	cloudPrepare      cpapi.Cloud
+	gatewayDeployer   cpapi.GatewayDeployer
+	spotDeployer      *provider.SpotMachineSetDeployer
+	machineSets       *provider.MachineSetRecorder
+	dynamicClient     dynamic.Interface
+}
+
+// NewAROProvider creates a provider for ARO clusters. ARO supports adding worker nodes through MachineSets, so the gateways
+// are deployed in a dedicated MachineSet, and the Submariner ports are opened with network security group rules in the
+// cluster resource group managed by ARO. The service principal in the credentials Secret must be allowed to manage that
+// resource group, which is the case for the cluster's own service principal.
+func NewAROProvider(ctx context.Context, info *provider.Info) (*aroProvider, error) {
+	if info.InfraID == "" {
+		return nil, errors.New("cluster infraID is empty")
+	}
+
+	if provider.GatewayCount(&info.GatewayConfig) < 1 {
+		return nil, errors.New("the count of gateways is less than 1")
+	}
+
+	instanceType := info.GatewayConfig.Azure.InstanceType
+	if instanceType == "" {
+		instanceType = azureInstanceType
+	}
+
+	subscriptionID, credentials, err := azure.NewCredential(info.CredentialsSecret)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the Azure credentials")
+	}
+
+	resourceGroup, err := aroResourceGroup(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	cloudInfo := &cpazure.CloudInfo{
+		SubscriptionID:  subscriptionID,
+		InfraID:         info.InfraID,
+		Region:          info.Region,
+		BaseGroupName:   resourceGroup,
+		TokenCredential: credentials,
+		K8sClient:       k8s.NewInterface(info.KubeClient),
+	}
+
+	msDeployer := ocp.NewK8sMachinesetDeployer(info.RestMapper, info.DynamicClient)
+
+	var spotDeployer *provider.SpotMachineSetDeployer
+
+	if info.GatewayConfig.Azure.Spot {
+		spotDeployer = provider.NewSpotMachineSetDeployer(msDeployer, info.DynamicClient, "spotVMOptions", map[string]any{})
+		msDeployer = spotDeployer
+	}
+
+	machineSets := provider.NewMachineSetRecorder(msDeployer, "vmSize")
+	msDeployer = machineSets
+
+	if len(info.GatewayNodes) > 0 {
+		msDeployer = provider.NewGatewayNodesMachineSetDeployer(msDeployer, info.DynamicClient, info.GatewayNodes, azure.CustomizeGatewayNode)
+	}
+
+	return &aroProvider{
+		infraID:           info.InfraID,
+		instanceType:      instanceType,
+		kubeClient:        info.KubeClient,
+		dynamicClient:     info.DynamicClient,
+		reporter:          reporter.NewEventRecorderWrapper("AROCloudProvider", info.EventRecorder),
+		nattPort:          uint16(info.IPSecNATTPort),     //nolint:gosec // Usable port numbers fit
+		nattDiscoveryPort: uint16(info.NATTDiscoveryPort), //nolint:gosec // Usable port numbers fit
+		cniType:           info.NetworkType,
+		gateways:          provider.GatewayCount(&info.GatewayConfig),
+		gatewayNodes:      info.GatewayNodes,
+		loadBalancer:      info.LoadBalancerEnable,
+		airGapped:         info.AirGappedDeployment,
+		cloudInfo:         cloudInfo,
+		cloudPrepare:      cpazure.NewCloud(cloudInfo),
+		gatewayDeployer:   cpazure.NewOcpGatewayDeployer(cloudInfo, msDeployer, instanceType),
+		spotDeployer:      spotDeployer,
+		machineSets:       machineSets,
+	}, nil
+}
+
+// PrepareSubmarinerClusterEnv prepares submariner cluster environment on ARO.
+func (r *aroProvider) PrepareSubmarinerClusterEnv(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
+	// In load balancer mode, the load balancer fronts the existing worker nodes so no dedicated gateways are deployed
+	if !r.loadBalancer {
+		if err := r.gatewayDeployer.Deploy(ctx, cpapi.GatewayDeployInput{
+			PublicPorts: r.publicPorts(),
+			Gateways:    r.gateways,
+			AirGapped:   r.airGapped,
+		}, r.reporter); err != nil {
+			return nil, errors.Wrap(err, "error deploying gateway")
+		}
+	}
+
+	if r.loadBalancer {
+		if err := azure.OpenLoadBalancerPorts(ctx, r.cloudInfo, r.loadBalancerPorts()); err != nil {
+			return nil, errors.Wrap(err, "error opening the load balancer ports")
+		}
+	}
+
+	if ports := provider.InternalPorts(r.cniType); len(ports) > 0 {
+		if err := r.cloudPrepare.OpenPorts(ctx, ports, r.reporter); err != nil {
+			return nil, errors.Wrap(err, "error opening ports")
+		}
+	}
+
+	r.reporter.Success("The Submariner cluster environment has been set up on ARO")
+
+	inventory, err := r.inventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.spotDeployer.RecordFallbacks(inventory)
+
+	return inventory, nil
+}
+
+// inventory returns the cloud resources actually created by PrepareSubmarinerClusterEnv: the existing external network
+// security group and the deployed gateway MachineSets, or the load balancer ingress rules, along with the existing
+// internal network security group.
+func (r *aroProvider) inventory(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
+	var resources []configv1alpha1.CloudResource
+
+	if !r.loadBalancer {
+		group, err := azure.SecurityGroupResource(ctx, r.cloudInfo, configv1alpha1.CloudResourceSecurityGroup,
+			azure.ExternalSecurityGroupName(r.infraID), r.publicPorts())
+		if err != nil {
+			return nil, errors.Wrap(err, "error retrieving the external security group")
+		}
+
+		if group != nil {
+			resources = append(resources, *group)
+		}
+
+		resources = append(resources, r.machineSets.Resources()...)
+	} else {
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule,
+			provider.LoadBalancerIngressName(r.infraID), r.loadBalancerPorts()))
+	}
+
+	if ports := provider.InternalPorts(r.cniType); len(ports) > 0 {
+		group, err := azure.SecurityGroupResource(ctx, r.cloudInfo, configv1alpha1.CloudResourceFirewallRule,
+			azure.InternalSecurityGroupName(r.infraID), ports)
+		if err != nil {
+			return nil, errors.Wrap(err, "error retrieving the internal security group")
+		}
+
+		if group != nil {
+			resources = append(resources, *group)
+		}
+	}
+
+	return resources, nil
+}
+
+// CleanUpSubmarinerClusterEnv clean up submariner cluster environment on ARO after the SubmarinerConfig was deleted.
+// Only the resources of the given inventory are cleaned up, unless it doesn't name them, see provider.LegacyCleanup.
+func (r *aroProvider) CleanUpSubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	if provider.LegacyCleanup(inventory, configv1alpha1.CloudResourceMachineSet, configv1alpha1.CloudResourceSecurityGroup) {
+		if err := r.gatewayDeployer.Cleanup(ctx, r.reporter); err != nil {
+			return errors.Wrap(err, "error cleaning up gateway")
+		}
+	} else if err := r.cleanUpGateways(ctx, inventory); err != nil {
+		return err
+	}
+
+	if ports := provider.LoadBalancerIngressPorts(inventory, r.infraID, r.loadBalancer, r.loadBalancerPorts()); len(ports) > 0 {
+		if err := azure.CloseLoadBalancerPorts(ctx, r.cloudInfo, ports); err != nil {
+			return errors.Wrap(err, "error closing the load balancer ports")
+		}
+	}
+
+	if provider.InternalPortsCleanup(inventory, provider.LoadBalancerIngressName(r.infraID)) {
+		if err := r.cloudPrepare.ClosePorts(ctx, r.reporter); err != nil {
+			return errors.Wrap(err, "error closing ports")
+		}
+	}
+
+	r.reporter.Success("The Submariner cluster environment has been cleaned up on ARO")
+
+	return nil
+}
+
+// cleanUpGateways deletes exactly the gateway MachineSets and network security group of the given inventory.
+func (r *aroProvider) cleanUpGateways(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	if err := r.machineSets.DeleteMachineSets(ctx, inventory); err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	for _, group := range provider.NamedResources(inventory, configv1alpha1.CloudResourceSecurityGroup) {
+		if err := azure.DeleteSecurityGroup(ctx, r.cloudInfo, group.Name); err != nil {
+			return err //nolint:wrapcheck // No need to wrap here
+		}
+	}
+
+	return nil
+}
+
+// PlanSubmarinerClusterEnv returns the cloud resources the given plan operation would change on ARO, compared with the
+// gateway MachineSets and network security groups of the cluster.
+func (r *aroProvider) PlanSubmarinerClusterEnv(ctx context.Context, operation string) ([]configv1alpha1.CloudResource, error) {
+	planned := r.planned()
+	plan := &provider.Plan{}
+
+	if !r.loadBalancer {
+		machineSets, err := provider.GatewayMachineSets(ctx, r.dynamicClient, "vmSize")
+		if err != nil {
+			return nil, err //nolint:wrapcheck // No need to wrap here
+		}
+
+		plan.AddGateways(provider.NamedResources(planned, configv1alpha1.CloudResourceMachineSet), machineSets)
+	}
+
+	if err := azure.PlanSecurityGroups(ctx, r.cloudInfo, plan, planned); err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	return plan.Resources(operation), nil
+}
+
+// planned returns the cloud resources PrepareSubmarinerClusterEnv creates on ARO.
+func (r *aroProvider) planned() []configv1alpha1.CloudResource {
+	var resources []configv1alpha1.CloudResource
+
+	if !r.loadBalancer {
+		resources = append(resources,
+			provider.PortsResource(configv1alpha1.CloudResourceSecurityGroup, azure.ExternalSecurityGroupName(r.infraID), r.publicPorts()))
+		resources = append(resources, provider.GatewayResources(configv1alpha1.CloudResourceMachineSet, r.infraID+"-submariner-gw",
+			r.instanceType, r.gateways, r.gatewayNodes)...)
+	} else {
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule,
+			provider.LoadBalancerIngressName(r.infraID), r.loadBalancerPorts()))
+	}
+
+	return append(resources,
+		provider.InternalPortsResources(azure.InternalSecurityGroupName(r.infraID), provider.InternalPorts(r.cniType))...)
+}
+
+// VerifySubmarinerClusterEnv returns the drift of the cloud resources in the given inventory on ARO.
+func (r *aroProvider) VerifySubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) ([]string, error) {
+	drift, err := provider.VerifyGatewayNodes(ctx, r.kubeClient, inventory)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	portsDrift, err := azure.VerifySecurityGroups(ctx, r.cloudInfo, inventory)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	return append(drift, portsDrift...), nil
+}
+
+// loadBalancerPorts returns the ports opened to external sources on the worker nodes in load balancer mode. In an
+// air-gapped deployment, where the load balancer is internal and the clusters connect over private networks, they include
+// the ESP & AH protocols used for private-ip to private-ip gateway communications.
+func (r *aroProvider) loadBalancerPorts() []cpapi.PortSpec {
+	ports := provider.LoadBalancerPorts(r.nattPort, r.nattDiscoveryPort)
+	if r.airGapped {
+		ports = append(ports, cpapi.PortSpec{Port: 0, Protocol: "esp"}, cpapi.PortSpec{Port: 0, Protocol: "ah"})
+	}
+
+	return ports
+}
+
+func (r *aroProvider) publicPorts() []cpapi.PortSpec {
+	return []cpapi.PortSpec{
+		{Port: r.nattPort, Protocol: "udp"},
+		{Port: r.nattDiscoveryPort, Protocol: "udp"},
+		{Port: 0, Protocol: "esp"},
+		{Port: 0, Protocol: "ah"},
+	}
+}
+
+// aroResourceGroup returns the resource group holding the ARO cluster resources, as reported by the Infrastructure
+// resource, unless overridden by the ResourceGroupAnnotation.
+func aroResourceGroup(ctx context.Context, info *provider.Info) (string, error) {
+	if resourceGroup := info.SubmarinerConfigAnnotations[ResourceGroupAnnotation]; resourceGroup != "" {
+		return resourceGroup, nil
+	}
+
+	infrastructure, err := info.DynamicClient.Resource(infrastructureGVR).Get(ctx, "cluster", metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrap(err, "error retrieving the Infrastructure")
+	}
+
+	resourceGroup, _, _ := unstructured.NestedString(infrastructure.Object, "status", "platformStatus", "azure", "resourceGroupName")
+	if resourceGroup == "" {
+		return "", errors.New("the Infrastructure doesn't specify the cluster resource group")
+	}
+
+	return resourceGroup, nil
+}
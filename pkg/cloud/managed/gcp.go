@@ -0,0 +1,232 @@
+package managed
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/gcp"
+	"github.com/stolostron/submariner-addon/pkg/cloud/machinepool"
+	"github.com/stolostron/submariner-addon/pkg/cloud/ocm"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"github.com/stolostron/submariner-addon/pkg/cloud/reporter"
+	submreporter "github.com/submariner-io/admiral/pkg/reporter"
+	cpapi "github.com/submariner-io/cloud-prepare/pkg/api"
+	cpgcp "github.com/submariner-io/cloud-prepare/pkg/gcp"
+	gcpclient "github.com/submariner-io/cloud-prepare/pkg/gcp/client"
+	"k8s.io/client-go/kubernetes"
+)
+
+const gcpInstanceType = "n1-standard-4"
+
+type gcpProvider struct {
+	product           string
+	infraID           string
+	instanceType      string
+	kubeClient        kubernetes.Interface
+	ocmClient         ocm.Client
+	reporter          submreporter.Interface
+	nattPort          uint16
+	nattDiscoveryPort uint16
+	cniType           string
+	gateways          int
+	gatewayNodes      []configv1alpha1.GatewayNode
+	loadBalancer      bool
+	projectID         string
+	gcpClient         gcpclient.Interface
+	vpcName           string
+	cloudPrepare      cpapi.Cloud
+	gatewayDeployer   cpapi.GatewayDeployer
+}
+
+// NewGCPProvider creates a provider for OCM-managed clusters running on GCP, i.e. OSD. The gateways are deployed in an
+// OCM machine pool and the Submariner ports are opened with VPC firewall rules.
+func NewGCPProvider(ctx context.Context, info *provider.Info) (*gcpProvider, error) {
+	if info.InfraID == "" {
+		return nil, errors.New("cluster infraID is empty")
+	}
+
+	if provider.GatewayCount(&info.GatewayConfig) < 1 {
+		return nil, errors.New("the count of gateways is less than 1")
+	}
+
+	instanceType := info.GatewayConfig.GCP.InstanceType
+	if instanceType == "" {
+		instanceType = gcpInstanceType
+	}
+
+	projectID, gcpClient, err := gcp.NewClient(ctx, info.CredentialsSecret)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the GCP client")
+	}
+
+	ocmClient, err := machinepool.NewOCMClient(ctx, info)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating the OCM client for cluster %q", info.ClusterName)
+	}
+
+	cloudInfo := cpgcp.CloudInfo{
+		InfraID:          info.InfraID,
+		Region:           info.Region,
+		ProjectID:        projectID,
+		Client:           gcpClient,
+		VpcName:          info.SubmarinerConfigAnnotations["submariner.io/vpc-name"],
+		PublicSubnetName: info.SubmarinerConfigAnnotations["submariner.io/public-subnet-name"],
+	}
+
+	return &gcpProvider{
+		product:           info.Vendor,
+		infraID:           info.InfraID,
+		instanceType:      instanceType,
+		kubeClient:        info.KubeClient,
+		ocmClient:         ocmClient,
+		reporter:          reporter.NewEventRecorderWrapper("ManagedGCPCloudProvider", info.EventRecorder),
+		nattPort:          uint16(info.IPSecNATTPort),     //nolint:gosec // Usable port numbers fit
+		nattDiscoveryPort: uint16(info.NATTDiscoveryPort), //nolint:gosec // Usable port numbers fit
+		cniType:           info.NetworkType,
+		gateways:          provider.GatewayCount(&info.GatewayConfig),
+		gatewayNodes:      info.GatewayNodes,
+		loadBalancer:      info.LoadBalancerEnable,
+		projectID:         projectID,
+		gcpClient:         gcpClient,
+		vpcName:           cloudInfo.VpcName,
+		cloudPrepare:      cpgcp.NewCloud(cloudInfo),
+		gatewayDeployer:   machinepool.NewGatewayDeployer(ocmClient, instanceType, info.GatewayNodes),
+	}, nil
+}
+
+// PrepareSubmarinerClusterEnv prepares submariner cluster environment on OSD on GCP.
+func (g *gcpProvider) PrepareSubmarinerClusterEnv(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
+	// In load balancer mode, the load balancer fronts the existing worker nodes so no gateway machine pool is created
+	if !g.loadBalancer {
+		if err := g.gatewayDeployer.Deploy(ctx, cpapi.GatewayDeployInput{Gateways: g.gateways}, g.reporter); err != nil {
+			return nil, errors.Wrap(err, "error deploying gateway")
+		}
+	}
+
+	if g.loadBalancer {
+		if err := gcp.OpenLoadBalancerPorts(g.gcpClient, g.projectID, g.infraID, g.vpcName,
+			provider.LoadBalancerPorts(g.nattPort, g.nattDiscoveryPort)); err != nil {
+			return nil, errors.Wrap(err, "error opening the load balancer ports")
+		}
+	}
+
+	if ports := gatewayPorts(g.nattPort, g.nattDiscoveryPort, "esp", "ah", g.cniType, g.loadBalancer); len(ports) > 0 {
+		if err := g.cloudPrepare.OpenPorts(ctx, ports, g.reporter); err != nil {
+			return nil, errors.Wrap(err, "error opening ports")
+		}
+	}
+
+	g.reporter.Success("The Submariner cluster environment has been set up on %s", g.product)
+
+	return g.inventory(ctx)
+}
+
+// inventory returns the cloud resources actually created by PrepareSubmarinerClusterEnv: the existing gateway machine
+// pools or the load balancer ingress rule, along with the existing internal firewall rule.
+func (g *gcpProvider) inventory(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
+	planned := g.planned()
+
+	resources, err := machinepool.Resources(ctx, g.ocmClient, planned)
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving the gateway machine pools")
+	}
+
+	if lbIngress := provider.FindResource(planned, configv1alpha1.CloudResourceFirewallRule,
+		provider.LoadBalancerIngressName(g.infraID)); lbIngress != nil {
+		resources = append(resources, *lbIngress)
+	}
+
+	if ports := gatewayPorts(g.nattPort, g.nattDiscoveryPort, "esp", "ah", g.cniType, g.loadBalancer); len(ports) > 0 {
+		rule, err := gcp.FirewallRuleResource(g.gcpClient, g.projectID, gcp.InternalFirewallRuleName(g.infraID), ports)
+		if err != nil {
+			return nil, errors.Wrap(err, "error retrieving the internal firewall rule")
+		}
+
+		if rule != nil {
+			resources = append(resources, *rule)
+		}
+	}
+
+	return resources, nil
+}
+
+// CleanUpSubmarinerClusterEnv clean up submariner cluster environment on OSD on GCP after the SubmarinerConfig was deleted.
+// Only the resources of the given inventory are cleaned up, unless it doesn't name them, see provider.LegacyCleanup.
+func (g *gcpProvider) CleanUpSubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	if provider.LegacyCleanup(inventory, configv1alpha1.CloudResourceMachinePool) {
+		if err := g.gatewayDeployer.Cleanup(ctx, g.reporter); err != nil {
+			return errors.Wrap(err, "error cleaning up gateway")
+		}
+	} else if err := machinepool.DeleteMachinePools(ctx, g.ocmClient, inventory, g.reporter); err != nil {
+		return errors.Wrap(err, "error cleaning up gateway")
+	}
+
+	if ports := provider.LoadBalancerIngressPorts(inventory, g.infraID, g.loadBalancer,
+		provider.LoadBalancerPorts(g.nattPort, g.nattDiscoveryPort)); len(ports) > 0 {
+		if err := gcp.CloseLoadBalancerPorts(g.gcpClient, g.projectID, g.infraID); err != nil {
+			return errors.Wrap(err, "error closing the load balancer ports")
+		}
+	}
+
+	if provider.InternalPortsCleanup(inventory, provider.LoadBalancerIngressName(g.infraID)) {
+		if err := g.cloudPrepare.ClosePorts(ctx, g.reporter); err != nil {
+			return errors.Wrap(err, "error closing ports")
+		}
+	}
+
+	g.reporter.Success("The Submariner cluster environment has been cleaned up on %s", g.product)
+
+	return nil
+}
+
+// PlanSubmarinerClusterEnv returns the cloud resources the given plan operation would change on OSD on GCP, compared with
+// the gateway machine pools and the firewall rules of the cluster.
+func (g *gcpProvider) PlanSubmarinerClusterEnv(ctx context.Context, operation string) ([]configv1alpha1.CloudResource, error) {
+	planned := g.planned()
+
+	pools, err := machinepool.Resources(ctx, g.ocmClient, planned)
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving the gateway machine pools")
+	}
+
+	plan := &provider.Plan{}
+	plan.AddGateways(provider.NamedResources(planned, configv1alpha1.CloudResourceMachinePool), pools)
+
+	if err := gcp.PlanFirewallRules(g.gcpClient, g.projectID, plan, planned); err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	return plan.Resources(operation), nil
+}
+
+// planned returns the cloud resources PrepareSubmarinerClusterEnv creates on OSD on GCP.
+func (g *gcpProvider) planned() []configv1alpha1.CloudResource {
+	var resources []configv1alpha1.CloudResource
+
+	if !g.loadBalancer {
+		resources = append(resources, provider.GatewayResources(configv1alpha1.CloudResourceMachinePool, machinepool.Name,
+			g.instanceType, g.gateways, g.gatewayNodes)...)
+	} else {
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule,
+			provider.LoadBalancerIngressName(g.infraID), provider.LoadBalancerPorts(g.nattPort, g.nattDiscoveryPort)))
+	}
+
+	return append(resources, provider.InternalPortsResources(gcp.InternalFirewallRuleName(g.infraID),
+		gatewayPorts(g.nattPort, g.nattDiscoveryPort, "esp", "ah", g.cniType, g.loadBalancer))...)
+}
+
+// VerifySubmarinerClusterEnv returns the drift of the cloud resources in the given inventory on OSD on GCP.
+func (g *gcpProvider) VerifySubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) ([]string, error) {
+	drift, err := verifyMachinePool(ctx, g.ocmClient, g.kubeClient, inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	rulesDrift, err := gcp.VerifyFirewallRules(g.gcpClient, g.projectID, inventory)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	return append(drift, rulesDrift...), nil
+}
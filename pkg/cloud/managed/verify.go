@@ -0,0 +1,41 @@
+package managed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/ocm"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"k8s.io/client-go/kubernetes"
+)
+
+// verifyMachinePool returns the drift of the gateway machine pool in the given inventory and of its nodes.
+func verifyMachinePool(ctx context.Context, ocmClient ocm.Client, kubeClient kubernetes.Interface,
+	inventory []configv1alpha1.CloudResource,
+) ([]string, error) {
+	var drift []string
+
+	for i := range inventory {
+		if inventory[i].Kind != configv1alpha1.CloudResourceMachinePool {
+			continue
+		}
+
+		pool, found, err := ocmClient.GetMachinePool(ctx, inventory[i].Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error retrieving machine pool %q", inventory[i].Name)
+		}
+
+		if !found {
+			drift = append(drift, fmt.Sprintf("machine pool %q is missing", inventory[i].Name))
+		} else if pool.Replicas < inventory[i].Replicas {
+			drift = append(drift, fmt.Sprintf("machine pool %q has %d of %d gateway node(s)", inventory[i].Name, pool.Replicas,
+				inventory[i].Replicas))
+		}
+	}
+
+	nodeDrift, err := provider.VerifyGatewayNodes(ctx, kubeClient, inventory)
+
+	return append(drift, nodeDrift...), err //nolint:wrapcheck // No need to wrap here
+}
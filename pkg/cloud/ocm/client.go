@@ -0,0 +1,254 @@
+package ocm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/clientcredentials"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	DefaultAPIURL   = "https://api.openshift.com"
+	DefaultTokenURL = "https://sso.redhat.com/auth/realms/redhat-external/protocol/openid-connect/token"
+
+	ClientIDSecretKey = "ocm_client_id"
+	//#nosec G101 -- This is the name of a key that will store a secret, but not a default secret
+	ClientSecretSecretKey = "ocm_client_secret"
+	APIURLSecretKey       = "ocm_api_url"
+	TokenURLSecretKey     = "ocm_token_url"
+
+	clustersPath = "/api/clusters_mgmt/v1/clusters"
+)
+
+// MachinePool is the subset of the OCM clusters_mgmt MachinePool resource used to manage gateway nodes.
+type MachinePool struct {
+	ID           string            `json:"id"`
+	InstanceType string            `json:"instance_type,omitempty"`
+	Replicas     int               `json:"replicas"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	// AvailabilityZones and Subnets can only be set when creating a machine pool.
+	AvailabilityZones []string `json:"availability_zones,omitempty"`
+	Subnets           []string `json:"subnets,omitempty"`
+}
+
+// Client manages the machine pools of a single cluster through the OCM API.
+type Client interface {
+	// GetMachinePool returns the machine pool with the given ID, and whether it was found.
+	GetMachinePool(ctx context.Context, id string) (*MachinePool, bool, error)
+	// CreateMachinePool creates the given machine pool.
+	CreateMachinePool(ctx context.Context, pool *MachinePool) error
+	// UpdateMachinePool updates the replicas and labels of the given machine pool.
+	UpdateMachinePool(ctx context.Context, pool *MachinePool) error
+	// DeleteMachinePool deletes the machine pool with the given ID, ignoring pools that don't exist.
+	DeleteMachinePool(ctx context.Context, id string) error
+}
+
+// Config contains the settings used to connect to the OCM API.
+type Config struct {
+	APIURL       string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// ClusterID is the OCM ID of the cluster. If empty, the cluster is looked up using ExternalID.
+	ClusterID string
+	// ExternalID is the OpenShift cluster ID, as found in the ClusterVersion resource.
+	ExternalID string
+}
+
+type client struct {
+	httpClient *http.Client
+	apiURL     string
+	clusterID  string
+}
+
+type apiError struct {
+	Kind   string `json:"kind"`
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// ConfigFromSecret builds a Config from the OCM service account credentials stored in the given Secret.
+func ConfigFromSecret(secret *corev1.Secret) (*Config, error) {
+	clientID, ok := secret.Data[ClientIDSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("the OCM credentials key %s is not in secret %s/%s", ClientIDSecretKey, secret.Namespace,
+			secret.Name)
+	}
+
+	clientSecret, ok := secret.Data[ClientSecretSecretKey]
+	if !ok {
+		return nil, fmt.Errorf("the OCM credentials key %s is not in secret %s/%s", ClientSecretSecretKey, secret.Namespace,
+			secret.Name)
+	}
+
+	config := &Config{
+		APIURL:       DefaultAPIURL,
+		TokenURL:     DefaultTokenURL,
+		ClientID:     string(clientID),
+		ClientSecret: string(clientSecret),
+	}
+
+	if apiURL, ok := secret.Data[APIURLSecretKey]; ok && len(apiURL) > 0 {
+		config.APIURL = string(apiURL)
+	}
+
+	if tokenURL, ok := secret.Data[TokenURLSecretKey]; ok && len(tokenURL) > 0 {
+		config.TokenURL = string(tokenURL)
+	}
+
+	return config, nil
+}
+
+// NewClient creates a Client for the cluster identified by the given Config. If the Config doesn't specify the OCM
+// cluster ID, it is looked up by external ID.
+func NewClient(ctx context.Context, config *Config) (Client, error) {
+	ccConfig := &clientcredentials.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		TokenURL:     config.TokenURL,
+		Scopes:       []string{"openid"},
+	}
+
+	c := &client{
+		httpClient: ccConfig.Client(ctx),
+		apiURL:     strings.TrimSuffix(config.APIURL, "/"),
+		clusterID:  config.ClusterID,
+	}
+
+	if c.clusterID != "" {
+		return c, nil
+	}
+
+	if config.ExternalID == "" {
+		return nil, errors.New("either the OCM cluster ID or the cluster external ID must be specified")
+	}
+
+	var list struct {
+		Items []struct {
+			ID string `json:"id"`
+		} `json:"items"`
+	}
+
+	query := url.Values{"search": []string{fmt.Sprintf("external_id = '%s'", config.ExternalID)}}
+
+	status, err := c.do(ctx, http.MethodGet, clustersPath+"?"+query.Encode(), nil, &list)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error looking up the OCM cluster with external ID %q", config.ExternalID)
+	}
+
+	if status != http.StatusOK || len(list.Items) == 0 {
+		return nil, fmt.Errorf("no OCM cluster found with external ID %q", config.ExternalID)
+	}
+
+	c.clusterID = list.Items[0].ID
+
+	return c, nil
+}
+
+func (c *client) GetMachinePool(ctx context.Context, id string) (*MachinePool, bool, error) {
+	pool := &MachinePool{}
+
+	status, err := c.do(ctx, http.MethodGet, c.machinePoolsPath()+"/"+url.PathEscape(id), nil, pool)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "error retrieving machine pool %q", id)
+	}
+
+	if status == http.StatusNotFound {
+		return nil, false, nil
+	}
+
+	return pool, true, nil
+}
+
+func (c *client) CreateMachinePool(ctx context.Context, pool *MachinePool) error {
+	status, err := c.do(ctx, http.MethodPost, c.machinePoolsPath(), pool, nil)
+	if err == nil && status == http.StatusNotFound {
+		err = fmt.Errorf("OCM cluster %q not found", c.clusterID)
+	}
+
+	return errors.Wrapf(err, "error creating machine pool %q", pool.ID)
+}
+
+func (c *client) UpdateMachinePool(ctx context.Context, pool *MachinePool) error {
+	patch := struct {
+		Replicas int               `json:"replicas"`
+		Labels   map[string]string `json:"labels,omitempty"`
+	}{Replicas: pool.Replicas, Labels: pool.Labels}
+
+	status, err := c.do(ctx, http.MethodPatch, c.machinePoolsPath()+"/"+url.PathEscape(pool.ID), patch, nil)
+	if err == nil && status == http.StatusNotFound {
+		err = fmt.Errorf("machine pool %q not found", pool.ID)
+	}
+
+	return errors.Wrapf(err, "error updating machine pool %q", pool.ID)
+}
+
+func (c *client) DeleteMachinePool(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, c.machinePoolsPath()+"/"+url.PathEscape(id), nil, nil)
+
+	return errors.Wrapf(err, "error deleting machine pool %q", id)
+}
+
+func (c *client) machinePoolsPath() string {
+	return clustersPath + "/" + url.PathEscape(c.clusterID) + "/machine_pools"
+}
+
+// do sends a request to the OCM API and decodes the response into out, if provided. A 404 response isn't treated as
+// an error; the status code is returned so callers can handle it.
+func (c *client) do(ctx context.Context, method, path string, in, out any) (int, error) {
+	var body io.Reader
+
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return 0, errors.Wrap(err, "error marshalling the request body")
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, body)
+	if err != nil {
+		return 0, errors.Wrap(err, "error creating the request")
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error sending %s request", method)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return resp.StatusCode, nil
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &apiError{}
+		_ = json.NewDecoder(resp.Body).Decode(apiErr)
+
+		return resp.StatusCode, fmt.Errorf("OCM API returned status %d: %s", resp.StatusCode, apiErr.Reason)
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, errors.Wrap(err, "error decoding the response body")
+		}
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,198 @@
+package ocm_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/submariner-addon/pkg/cloud/ocm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	clusterID  = "1a2b3c"
+	externalID = "f0e1d2c3"
+	poolsPath  = "/api/clusters_mgmt/v1/clusters/" + clusterID + "/machine_pools"
+)
+
+type fakeOCM struct {
+	sync.Mutex
+	pools map[string]*ocm.MachinePool
+}
+
+func (f *fakeOCM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if r.URL.Path == "/token" {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"token","token_type":"Bearer","expires_in":300}`))
+
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/api/clusters_mgmt/v1/clusters":
+		items := []map[string]string{}
+		if strings.Contains(r.URL.Query().Get("search"), externalID) {
+			items = append(items, map[string]string{"id": clusterID})
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"items": items})
+	case r.URL.Path == poolsPath && r.Method == http.MethodPost:
+		pool := &ocm.MachinePool{}
+		Expect(json.NewDecoder(r.Body).Decode(pool)).To(Succeed())
+		f.pools[pool.ID] = pool
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(pool)
+	case strings.HasPrefix(r.URL.Path, poolsPath+"/"):
+		id := strings.TrimPrefix(r.URL.Path, poolsPath+"/")
+
+		pool, found := f.pools[id]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"kind":"Error","reason":"not found"}`))
+
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(pool)
+		case http.MethodPatch:
+			Expect(json.NewDecoder(r.Body).Decode(pool)).To(Succeed())
+			_ = json.NewEncoder(w).Encode(pool)
+		case http.MethodDelete:
+			delete(f.pools, id)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"kind":"Error","reason":"bad request"}`))
+	}
+}
+
+var _ = Describe("Client", func() {
+	var (
+		fake   *fakeOCM
+		server *httptest.Server
+		config *ocm.Config
+		client ocm.Client
+	)
+
+	BeforeEach(func() {
+		fake = &fakeOCM{pools: map[string]*ocm.MachinePool{}}
+		server = httptest.NewServer(fake)
+
+		DeferCleanup(server.Close)
+
+		var err error
+
+		config, err = ocm.ConfigFromSecret(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "east"},
+			Data: map[string][]byte{
+				ocm.ClientIDSecretKey:     []byte("id"),
+				ocm.ClientSecretSecretKey: []byte("secret"),
+				ocm.APIURLSecretKey:       []byte(server.URL),
+				ocm.TokenURLSecretKey:     []byte(server.URL + "/token"),
+			},
+		})
+		Expect(err).To(Succeed())
+
+		config.ExternalID = externalID
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		client, err = ocm.NewClient(context.TODO(), config)
+		Expect(err).To(Succeed())
+	})
+
+	It("should manage the lifecycle of a machine pool", func() {
+		_, found, err := client.GetMachinePool(context.TODO(), "gw")
+		Expect(err).To(Succeed())
+		Expect(found).To(BeFalse())
+
+		Expect(client.CreateMachinePool(context.TODO(), &ocm.MachinePool{
+			ID:           "gw",
+			InstanceType: "m5.xlarge",
+			Replicas:     1,
+			Labels:       map[string]string{"submariner.io/gateway": "true"},
+		})).To(Succeed())
+
+		pool, found, err := client.GetMachinePool(context.TODO(), "gw")
+		Expect(err).To(Succeed())
+		Expect(found).To(BeTrue())
+		Expect(pool.InstanceType).To(Equal("m5.xlarge"))
+		Expect(pool.Labels).To(HaveKeyWithValue("submariner.io/gateway", "true"))
+
+		pool.Replicas = 2
+		Expect(client.UpdateMachinePool(context.TODO(), pool)).To(Succeed())
+		Expect(fake.pools["gw"].Replicas).To(Equal(2))
+
+		Expect(client.DeleteMachinePool(context.TODO(), "gw")).To(Succeed())
+		Expect(fake.pools).To(BeEmpty())
+
+		Expect(client.DeleteMachinePool(context.TODO(), "gw")).To(Succeed())
+	})
+
+	It("should return an error when updating a missing machine pool", func() {
+		Expect(client.UpdateMachinePool(context.TODO(), &ocm.MachinePool{ID: "missing"})).ToNot(Succeed())
+	})
+
+	When("the cluster isn't registered in OCM", func() {
+		It("should fail to create the client", func() {
+			config.ExternalID = "unknown"
+
+			_, err := ocm.NewClient(context.TODO(), config)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("the OCM cluster ID is provided", func() {
+		BeforeEach(func() {
+			config.ClusterID = clusterID
+			config.ExternalID = ""
+		})
+
+		It("should not require the external ID", func() {
+			_, found, err := client.GetMachinePool(context.TODO(), "gw")
+			Expect(err).To(Succeed())
+			Expect(found).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("ConfigFromSecret", func() {
+	It("should default the API and token URLs", func() {
+		config, err := ocm.ConfigFromSecret(&corev1.Secret{
+			Data: map[string][]byte{
+				ocm.ClientIDSecretKey:     []byte("id"),
+				ocm.ClientSecretSecretKey: []byte("secret"),
+			},
+		})
+		Expect(err).To(Succeed())
+		Expect(config.APIURL).To(Equal(ocm.DefaultAPIURL))
+		Expect(config.TokenURL).To(Equal(ocm.DefaultTokenURL))
+	})
+
+	It("should return an error if the client credentials are missing", func() {
+		_, err := ocm.ConfigFromSecret(&corev1.Secret{
+			Data: map[string][]byte{ocm.ClientIDSecretKey: []byte("id")},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client is the connection to a plugin listening on a local Unix socket, shared by the providers delegating to it.
+type Client struct {
+	socketPath string
+	conn       *grpc.ClientConn
+}
+
+// NewClient returns a client of the plugin listening on the given Unix socket. The plugin is only contacted when a provider
+// delegating to it is used, and the connection is then kept until the client is closed.
+func NewClient(socketPath string) (*Client, error) {
+	if socketPath == "" {
+		return nil, errors.New("no plugin socket provided")
+	}
+
+	conn, err := grpc.NewClient("unix:"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating the client of the cloud provider plugin at %q", socketPath)
+	}
+
+	return &Client{
+		socketPath: socketPath,
+		conn:       conn,
+	}, nil
+}
+
+// Close closes the connection to the plugin.
+func (c *Client) Close() error {
+	return errors.Wrapf(c.conn.Close(), "error closing the connection to the cloud provider plugin at %q", c.socketPath)
+}
+
+// Provider is a cloud provider delegating to a plugin.
+type Provider struct {
+	client *Client
+	info   Info
+}
+
+// NewProvider returns a cloud provider delegating to the plugin of the given client.
+func NewProvider(client *Client, info *provider.Info) *Provider {
+	return &Provider{
+		client: client,
+		info:   newInfo(info),
+	}
+}
+
+func (p *Provider) PrepareSubmarinerClusterEnv(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
+	response := &PrepareResponse{}
+
+	err := p.invoke(ctx, prepareMethod, &PrepareRequest{Info: p.info}, response)
+
+	return response.Resources, err
+}
+
+func (p *Provider) CleanUpSubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	return p.invoke(ctx, cleanUpMethod, &CleanUpRequest{Info: p.info, Inventory: inventory}, &CleanUpResponse{})
+}
+
+func (p *Provider) PlanSubmarinerClusterEnv(ctx context.Context, operation string) ([]configv1alpha1.CloudResource, error) {
+	response := &PlanResponse{}
+
+	err := p.invoke(ctx, planMethod, &PlanRequest{Info: p.info, Operation: operation}, response)
+
+	return response.Resources, err
+}
+
+func (p *Provider) VerifySubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) ([]string, error) {
+	response := &VerifyResponse{}
+
+	err := p.invoke(ctx, verifyMethod, &VerifyRequest{Info: p.info, Inventory: inventory}, response)
+
+	return response.Drift, err
+}
+
+func (p *Provider) invoke(ctx context.Context, method string, request, response any) error {
+	err := p.client.conn.Invoke(ctx, fullMethod(method), request, response, grpc.CallContentSubtype(codecName))
+
+	return errors.Wrapf(err, "error calling %s on the cloud provider plugin at %q", method, p.client.socketPath)
+}
@@ -0,0 +1,30 @@
+package plugin
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// codecName is the gRPC content subtype of the plugin protocol, i.e. messages are sent as "application/grpc+json".
+const codecName = "json"
+
+// jsonCodec marshals the plugin protocol messages as JSON. Their proto3 JSON mapping is defined in cloudprovider.proto,
+// which plugins written in other languages generate their stubs from.
+type jsonCodec struct{}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v) //nolint:wrapcheck // No need to wrap here
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v) //nolint:wrapcheck // No need to wrap here
+}
+
+func (jsonCodec) Name() string {
+	return codecName
+}
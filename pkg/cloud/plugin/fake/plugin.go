@@ -0,0 +1,94 @@
+package fake
+
+import (
+	"context"
+	"sync"
+
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/plugin"
+)
+
+// Plugin is a reference cloud provider plugin which records the requests it receives and returns the configured
+// resources, drift and error instead of changing a cloud environment.
+type Plugin struct {
+	mutex     sync.Mutex
+	resources []configv1alpha1.CloudResource
+	drift     []string
+	err       error
+	requests  []any
+}
+
+var _ plugin.Server = &Plugin{}
+
+// NewPlugin returns a fake plugin preparing the given resources.
+func NewPlugin(resources ...configv1alpha1.CloudResource) *Plugin {
+	return &Plugin{resources: resources}
+}
+
+// SetDrift sets the drift reported when verifying the cloud environment.
+func (p *Plugin) SetDrift(drift ...string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.drift = drift
+}
+
+// SetError sets the error returned by all subsequent requests.
+func (p *Plugin) SetError(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.err = err
+}
+
+// Requests returns the requests received so far, in order.
+func (p *Plugin) Requests() []any {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return append([]any(nil), p.requests...)
+}
+
+func (p *Plugin) PrepareSubmarinerClusterEnv(_ context.Context, request *plugin.PrepareRequest) (*plugin.PrepareResponse, error) {
+	if err := p.record(request); err != nil {
+		return nil, err
+	}
+
+	return &plugin.PrepareResponse{Resources: p.resources}, nil
+}
+
+func (p *Plugin) CleanUpSubmarinerClusterEnv(_ context.Context, request *plugin.CleanUpRequest) (*plugin.CleanUpResponse, error) {
+	if err := p.record(request); err != nil {
+		return nil, err
+	}
+
+	return &plugin.CleanUpResponse{}, nil
+}
+
+func (p *Plugin) PlanSubmarinerClusterEnv(_ context.Context, request *plugin.PlanRequest) (*plugin.PlanResponse, error) {
+	if err := p.record(request); err != nil {
+		return nil, err
+	}
+
+	return &plugin.PlanResponse{Resources: p.resources}, nil
+}
+
+func (p *Plugin) VerifySubmarinerClusterEnv(_ context.Context, request *plugin.VerifyRequest) (*plugin.VerifyResponse, error) {
+	if err := p.record(request); err != nil {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return &plugin.VerifyResponse{Drift: p.drift}, nil
+}
+
+func (p *Plugin) record(request any) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.requests = append(p.requests, request)
+
+	return p.err
+}
@@ -0,0 +1,190 @@
+package plugin_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/plugin"
+	"github.com/stolostron/submariner-addon/pkg/cloud/plugin/fake"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Plugin provider", func() {
+	var (
+		fakePlugin  *fake.Plugin
+		cloudPlugin *plugin.Provider
+		info        *provider.Info
+		resources   []configv1alpha1.CloudResource
+		socketPath  string
+	)
+
+	BeforeEach(func() {
+		resources = []configv1alpha1.CloudResource{
+			{
+				Kind:  configv1alpha1.CloudResourceFirewallRule,
+				Name:  "test-submariner-ingress",
+				Ports: []configv1alpha1.CloudPort{{Port: 4500, Protocol: "udp"}},
+			},
+			{
+				Kind:         configv1alpha1.CloudResourceMachineSet,
+				Name:         "test-submariner-gw",
+				InstanceType: "large",
+				Replicas:     1,
+			},
+		}
+
+		info = &provider.Info{
+			CredentialsSecret: &corev1.Secret{
+				Data: map[string][]byte{"token": []byte("secret")},
+			},
+			SubmarinerConfigSpec: configv1alpha1.SubmarinerConfigSpec{IPSecNATTPort: 4500},
+			ManagedClusterInfo: configv1alpha1.ManagedClusterInfo{
+				ClusterName: "east",
+				Platform:    "PrivateCloud",
+				InfraID:     "test",
+			},
+			SubmarinerConfigAnnotations: map[string]string{"foo": "bar"},
+		}
+
+		fakePlugin = fake.NewPlugin(resources...)
+		socketPath = filepath.Join(GinkgoT().TempDir(), "plugin.sock")
+
+		serverCtx, stop := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+
+		go func() {
+			done <- plugin.Serve(serverCtx, socketPath, fakePlugin)
+		}()
+
+		DeferCleanup(func() {
+			stop()
+			Eventually(done).Should(Receive(Succeed()))
+		})
+
+		Eventually(func() error {
+			_, err := os.Stat(socketPath)
+			return err
+		}).Should(Succeed())
+
+		client, err := plugin.NewClient(socketPath)
+		Expect(err).To(Succeed())
+
+		DeferCleanup(client.Close)
+
+		cloudPlugin = plugin.NewProvider(client, info)
+	})
+
+	expectedInfo := func() plugin.Info {
+		return plugin.Info{
+			ManagedClusterInfo:          info.ManagedClusterInfo,
+			SubmarinerConfigSpec:        info.SubmarinerConfigSpec,
+			SubmarinerConfigAnnotations: info.SubmarinerConfigAnnotations,
+			Credentials:                 info.CredentialsSecret.Data,
+		}
+	}
+
+	When("preparing the cluster environment", func() {
+		It("should pass the provider info to the plugin and return the prepared resources", func(ctx context.Context) {
+			prepared, err := cloudPlugin.PrepareSubmarinerClusterEnv(ctx)
+			Expect(err).To(Succeed())
+			Expect(prepared).To(Equal(resources))
+			Expect(fakePlugin.Requests()).To(Equal([]any{&plugin.PrepareRequest{Info: expectedInfo()}}))
+		})
+	})
+
+	When("cleaning up the cluster environment", func() {
+		It("should pass the inventory to the plugin", func(ctx context.Context) {
+			Expect(cloudPlugin.CleanUpSubmarinerClusterEnv(ctx, resources)).To(Succeed())
+			Expect(fakePlugin.Requests()).To(Equal([]any{&plugin.CleanUpRequest{Info: expectedInfo(), Inventory: resources}}))
+		})
+	})
+
+	When("planning the cluster environment", func() {
+		It("should return the planned resources", func(ctx context.Context) {
+			planned, err := cloudPlugin.PlanSubmarinerClusterEnv(ctx, configv1alpha1.CloudPreparePlanDelete)
+			Expect(err).To(Succeed())
+			Expect(planned).To(Equal(resources))
+			Expect(fakePlugin.Requests()).To(Equal([]any{
+				&plugin.PlanRequest{Info: expectedInfo(), Operation: configv1alpha1.CloudPreparePlanDelete},
+			}))
+		})
+	})
+
+	When("verifying the cluster environment", func() {
+		BeforeEach(func() {
+			fakePlugin.SetDrift("port 4500/udp is closed")
+		})
+
+		It("should return the drift reported by the plugin", func(ctx context.Context) {
+			drift, err := cloudPlugin.VerifySubmarinerClusterEnv(ctx, resources)
+			Expect(err).To(Succeed())
+			Expect(drift).To(Equal([]string{"port 4500/udp is closed"}))
+		})
+	})
+
+	When("the plugin fails", func() {
+		BeforeEach(func() {
+			fakePlugin.SetError(errors.New("fake error"))
+		})
+
+		It("should return an error", func(ctx context.Context) {
+			_, err := cloudPlugin.PrepareSubmarinerClusterEnv(ctx)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake error"))
+		})
+	})
+
+	When("the plugin isn't listening", func() {
+		It("should return an error", func(ctx context.Context) {
+			client, err := plugin.NewClient(filepath.Join(GinkgoT().TempDir(), "missing.sock"))
+			Expect(err).To(Succeed())
+
+			DeferCleanup(client.Close)
+
+			Expect(plugin.NewProvider(client, info).CleanUpSubmarinerClusterEnv(ctx, nil)).ToNot(Succeed())
+		})
+	})
+
+	When("several providers delegate to the same plugin", func() {
+		It("should share the connection of the client", func(ctx context.Context) {
+			client, err := plugin.NewClient(socketPath)
+			Expect(err).To(Succeed())
+
+			Expect(plugin.NewProvider(client, info).CleanUpSubmarinerClusterEnv(ctx, resources)).To(Succeed())
+			Expect(plugin.NewProvider(client, info).CleanUpSubmarinerClusterEnv(ctx, resources)).To(Succeed())
+
+			Expect(client.Close()).To(Succeed())
+			Expect(plugin.NewProvider(client, info).CleanUpSubmarinerClusterEnv(ctx, resources)).ToNot(Succeed())
+		})
+	})
+
+	When("no plugin socket is provided", func() {
+		It("should return an error", func() {
+			_, err := plugin.NewClient("")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Protocol definition", func() {
+	It("should define the service methods served by plugins", func() {
+		definition, err := os.ReadFile("cloudprovider.proto")
+		Expect(err).To(Succeed())
+
+		Expect(plugin.ServiceName).To(HaveSuffix(".CloudProvider"))
+		Expect(string(definition)).To(ContainSubstring("package submarineraddon.cloud.v1;"))
+		Expect(string(definition)).To(ContainSubstring("service CloudProvider {"))
+
+		for _, method := range []string{
+			"PrepareSubmarinerClusterEnv", "CleanUpSubmarinerClusterEnv", "PlanSubmarinerClusterEnv", "VerifySubmarinerClusterEnv",
+		} {
+			Expect(string(definition)).To(ContainSubstring("rpc "+method+"("), "method %q isn't defined", method)
+		}
+	})
+})
@@ -0,0 +1,130 @@
+package plugin
+
+import (
+	"context"
+
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"google.golang.org/grpc"
+)
+
+// ServiceName is the name of the gRPC service implemented by cloud provider plugins.
+const ServiceName = "submarineraddon.cloud.v1.CloudProvider"
+
+const (
+	prepareMethod = "PrepareSubmarinerClusterEnv"
+	cleanUpMethod = "CleanUpSubmarinerClusterEnv"
+	planMethod    = "PlanSubmarinerClusterEnv"
+	verifyMethod  = "VerifySubmarinerClusterEnv"
+)
+
+// Info is the part of the provider.Info passed to a plugin with each request.
+type Info struct {
+	ManagedClusterInfo          configv1alpha1.ManagedClusterInfo   `json:"managedClusterInfo"`
+	SubmarinerConfigSpec        configv1alpha1.SubmarinerConfigSpec `json:"submarinerConfigSpec"`
+	SubmarinerConfigAnnotations map[string]string                   `json:"submarinerConfigAnnotations,omitempty"`
+	// Credentials is the data of the cloud provider credentials Secret.
+	Credentials map[string][]byte `json:"credentials,omitempty"`
+}
+
+type PrepareRequest struct {
+	Info Info `json:"info"`
+}
+
+type PrepareResponse struct {
+	Resources []configv1alpha1.CloudResource `json:"resources,omitempty"`
+}
+
+type CleanUpRequest struct {
+	Info      Info                           `json:"info"`
+	Inventory []configv1alpha1.CloudResource `json:"inventory,omitempty"`
+}
+
+type CleanUpResponse struct{}
+
+type PlanRequest struct {
+	Info      Info   `json:"info"`
+	Operation string `json:"operation"`
+}
+
+type PlanResponse struct {
+	Resources []configv1alpha1.CloudResource `json:"resources,omitempty"`
+}
+
+type VerifyRequest struct {
+	Info      Info                           `json:"info"`
+	Inventory []configv1alpha1.CloudResource `json:"inventory,omitempty"`
+}
+
+type VerifyResponse struct {
+	Drift []string `json:"drift,omitempty"`
+}
+
+// Server is implemented by cloud provider plugins, it mirrors the cloud.Provider interface.
+type Server interface {
+	PrepareSubmarinerClusterEnv(ctx context.Context, request *PrepareRequest) (*PrepareResponse, error)
+	CleanUpSubmarinerClusterEnv(ctx context.Context, request *CleanUpRequest) (*CleanUpResponse, error)
+	PlanSubmarinerClusterEnv(ctx context.Context, request *PlanRequest) (*PlanResponse, error)
+	VerifySubmarinerClusterEnv(ctx context.Context, request *VerifyRequest) (*VerifyResponse, error)
+}
+
+// RegisterServer registers the given plugin implementation with the gRPC server.
+func RegisterServer(s grpc.ServiceRegistrar, srv Server) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+func newInfo(info *provider.Info) Info {
+	pluginInfo := Info{
+		ManagedClusterInfo:          info.ManagedClusterInfo,
+		SubmarinerConfigSpec:        info.SubmarinerConfigSpec,
+		SubmarinerConfigAnnotations: info.SubmarinerConfigAnnotations,
+	}
+
+	if info.CredentialsSecret != nil {
+		pluginInfo.Credentials = info.CredentialsSecret.Data
+	}
+
+	return pluginInfo
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*Server)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod(prepareMethod, Server.PrepareSubmarinerClusterEnv),
+		unaryMethod(cleanUpMethod, Server.CleanUpSubmarinerClusterEnv),
+		unaryMethod(planMethod, Server.PlanSubmarinerClusterEnv),
+		unaryMethod(verifyMethod, Server.VerifySubmarinerClusterEnv),
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// unaryMethod returns the description of a unary method of the service, as generated by protoc-gen-go-grpc.
+func unaryMethod[Req, Resp any](name string, call func(Server, context.Context, *Req) (*Resp, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			request := new(Req)
+			if err := dec(request); err != nil {
+				return nil, err
+			}
+
+			if interceptor == nil {
+				return call(srv.(Server), ctx, request)
+			}
+
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: fullMethod(name),
+			}
+
+			return interceptor(ctx, request, info, func(ctx context.Context, request any) (any, error) {
+				return call(srv.(Server), ctx, request.(*Req))
+			})
+		},
+	}
+}
+
+func fullMethod(name string) string {
+	return "/" + ServiceName + "/" + name
+}
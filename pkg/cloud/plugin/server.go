@@ -0,0 +1,33 @@
+package plugin
+
+import (
+	"context"
+	"net"
+	"os"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// Serve serves the given plugin implementation on the given Unix socket until the context is cancelled. A stale socket
+// left by a previous instance is removed first.
+func Serve(ctx context.Context, socketPath string, srv Server) error {
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "error removing the stale socket %q", socketPath)
+	}
+
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "unix", socketPath)
+	if err != nil {
+		return errors.Wrapf(err, "error listening on %q", socketPath)
+	}
+
+	server := grpc.NewServer()
+	RegisterServer(server, srv)
+
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	return errors.Wrap(server.Serve(listener), "error serving the cloud provider plugin")
+}
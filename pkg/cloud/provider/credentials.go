@@ -0,0 +1,20 @@
+package provider
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/stolostron/submariner-addon/pkg/constants"
+)
+
+// ServiceAccountTokenFile returns the token file to authenticate with using workload identity, given the override from the
+// credentials Secret, if any. Since the Secret can be written from the hub, it can't make the agent read other local
+// files: only the projected service account token of the agent is accepted.
+func ServiceAccountTokenFile(override string) (string, error) {
+	if override == "" || filepath.Clean(override) == constants.ServiceAccountTokenFile {
+		return constants.ServiceAccountTokenFile, nil
+	}
+
+	return "", errors.Errorf("the token file %q is not the projected service account token %q", override,
+		constants.ServiceAccountTokenFile)
+}
@@ -0,0 +1,26 @@
+package provider_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"github.com/stolostron/submariner-addon/pkg/constants"
+)
+
+var _ = Describe("ServiceAccountTokenFile", func() {
+	It("should return the projected service account token without an override", func() {
+		Expect(provider.ServiceAccountTokenFile("")).To(Equal(constants.ServiceAccountTokenFile))
+	})
+
+	It("should accept the projected service account token as override", func() {
+		Expect(provider.ServiceAccountTokenFile(constants.ServiceAccountTokenFile)).To(Equal(constants.ServiceAccountTokenFile))
+	})
+
+	It("should reject other files", func() {
+		_, err := provider.ServiceAccountTokenFile("/etc/shadow")
+		Expect(err).To(HaveOccurred())
+
+		_, err = provider.ServiceAccountTokenFile(constants.ServiceAccountTokenFile + "/../../../../../../etc/shadow")
+		Expect(err).To(HaveOccurred())
+	})
+})
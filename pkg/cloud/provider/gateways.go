@@ -0,0 +1,236 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/submariner-io/cloud-prepare/pkg/ocp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// GatewayMachineSetLabel is set on the MachineSets of the individually specified gateway nodes to the name of the MachineSet
+// deployed by cloud-prepare they were derived from.
+const GatewayMachineSetLabel = "submarineraddon.open-cluster-management.io/gateway-machineset"
+
+// GatewayNodeCustomizer sets the instance type, zone and subnet of the given gateway node, when specified, in the provider
+// spec of a gateway MachineSet.
+type GatewayNodeCustomizer func(providerSpec map[string]any, node *configv1alpha1.GatewayNode)
+
+// GatewayCount returns the count of gateway nodes in the given GatewayConfig, i.e. the count of the individually specified
+// gateway nodes if any, otherwise the configured count.
+func GatewayCount(config *configv1alpha1.GatewayConfig) int {
+	if len(config.GatewayNodes) > 0 {
+		return len(config.GatewayNodes)
+	}
+
+	return config.Gateways
+}
+
+// GatewayNodeName returns the name of the MachineSet or machine pool hosting the gateway node with the given index. The
+// first gateway node keeps the given name so that the gateways deployed before the nodes were specified individually are
+// reused.
+func GatewayNodeName(name string, index int) string {
+	if index == 0 {
+		return name
+	}
+
+	return fmt.Sprintf("%s-%d", name, index)
+}
+
+// GatewayResources returns the planned MachineSets or machine pools hosting the gateway nodes: one per individually
+// specified gateway node, using the given instance type for the nodes which don't specify one, otherwise a single one
+// hosting the given count of gateways.
+func GatewayResources(kind, name, instanceType string, gateways int, nodes []configv1alpha1.GatewayNode,
+) []configv1alpha1.CloudResource {
+	if len(nodes) == 0 {
+		return []configv1alpha1.CloudResource{GatewayResource(kind, name, instanceType, gateways)}
+	}
+
+	resources := make([]configv1alpha1.CloudResource, len(nodes))
+
+	for i := range nodes {
+		resources[i] = GatewayResource(kind, GatewayNodeName(name, i), GatewayNodeInstanceType(&nodes[i], instanceType), 1)
+	}
+
+	return resources
+}
+
+// GatewayNodeInstanceType returns the instance type of the given gateway node, or the given default if it doesn't specify
+// one.
+func GatewayNodeInstanceType(node *configv1alpha1.GatewayNode, defaultInstanceType string) string {
+	if node.InstanceType != "" {
+		return node.InstanceType
+	}
+
+	return defaultInstanceType
+}
+
+type gatewayNodesMachineSetDeployer struct {
+	ocp.MachineSetDeployer
+	dynamicClient dynamic.Interface
+	nodes         []configv1alpha1.GatewayNode
+	customize     GatewayNodeCustomizer
+	// The index of the first gateway node of each MachineSet deployed by cloud-prepare, by name
+	firstNodes map[string]int
+	assigned   int
+}
+
+// NewGatewayNodesMachineSetDeployer returns a MachineSetDeployer which deploys a MachineSet with a single replica for each
+// of the given gateway nodes, customized with the given GatewayNodeCustomizer. The gateway nodes are assigned in order to
+// the replicas of the MachineSets deployed by cloud-prepare, each MachineSet keeping the same nodes when it's deployed
+// again; deploying more replicas than there are gateway nodes fails. A MachineSet with more than one replica is split into
+// one MachineSet per replica, labeled with the GatewayMachineSetLabel so they're also deleted with it, or when the count
+// of gateway nodes shrinks. Without gateway nodes, the given deployer is returned as is.
+func NewGatewayNodesMachineSetDeployer(deployer ocp.MachineSetDeployer, dynamicClient dynamic.Interface,
+	nodes []configv1alpha1.GatewayNode, customize GatewayNodeCustomizer,
+) ocp.MachineSetDeployer {
+	if len(nodes) == 0 {
+		return deployer
+	}
+
+	return &gatewayNodesMachineSetDeployer{
+		MachineSetDeployer: deployer,
+		dynamicClient:      dynamicClient,
+		nodes:              nodes,
+		customize:          customize,
+		firstNodes:         map[string]int{},
+	}
+}
+
+func (d *gatewayNodesMachineSetDeployer) Deploy(ctx context.Context, machineSet *unstructured.Unstructured) error {
+	replicas, _, _ := unstructured.NestedInt64(machineSet.Object, "spec", "replicas")
+	count := max(int(replicas), 1)
+
+	first, err := d.firstNode(machineSet.GetName(), count)
+	if err != nil {
+		return err
+	}
+
+	deployed := map[string]bool{}
+
+	for i := range count {
+		gateway, err := d.gatewayMachineSet(machineSet, i, &d.nodes[first+i])
+		if err != nil {
+			return err
+		}
+
+		if err := d.MachineSetDeployer.Deploy(ctx, gateway); err != nil {
+			return err //nolint:wrapcheck // No need to wrap here
+		}
+
+		deployed[gateway.GetName()] = true
+	}
+
+	// The MachineSets of the gateway nodes which were removed since the previous deployment are deleted
+	return d.deleteSplitMachineSets(ctx, machineSet, deployed)
+}
+
+func (d *gatewayNodesMachineSetDeployer) Delete(ctx context.Context, machineSet *unstructured.Unstructured) error {
+	if err := d.deleteSplitMachineSets(ctx, machineSet, map[string]bool{machineSet.GetName(): true}); err != nil {
+		return err
+	}
+
+	return d.MachineSetDeployer.Delete(ctx, machineSet) //nolint:wrapcheck // No need to wrap here
+}
+
+// firstNode returns the index of the first of the given count of gateway nodes hosted by the MachineSet with the given
+// name. The nodes are assigned to the MachineSets in the order they're first deployed, and kept by them afterwards.
+func (d *gatewayNodesMachineSetDeployer) firstNode(name string, count int) (int, error) {
+	first, found := d.firstNodes[name]
+	if !found {
+		first = d.assigned
+	}
+
+	for other, otherFirst := range d.firstNodes {
+		if other != name && otherFirst > first && otherFirst < first+count {
+			return 0, errors.Errorf("the %d replicas of MachineSet %q overlap the gateway nodes of MachineSet %q", count, name, other)
+		}
+	}
+
+	if first+count > len(d.nodes) {
+		return 0, errors.Errorf("MachineSet %q needs %d gateway nodes but only %d of the %d gateway nodes are left", name, count,
+			len(d.nodes)-first, len(d.nodes))
+	}
+
+	d.firstNodes[name] = first
+	d.assigned = max(d.assigned, first+count)
+
+	return first, nil
+}
+
+// deleteSplitMachineSets deletes the MachineSets the given MachineSet was split into, except the given ones.
+func (d *gatewayNodesMachineSetDeployer) deleteSplitMachineSets(ctx context.Context, machineSet *unstructured.Unstructured,
+	keep map[string]bool,
+) error {
+	client := d.dynamicClient.Resource(machineSetGVR).Namespace(machineSet.GetNamespace())
+
+	split, err := client.List(ctx, metav1.ListOptions{LabelSelector: GatewayMachineSetLabel + "=" + machineSet.GetName()})
+	if err != nil {
+		return errors.Wrapf(err, "error listing the gateway MachineSets derived from MachineSet %q", machineSet.GetName())
+	}
+
+	for i := range split.Items {
+		if keep[split.Items[i].GetName()] {
+			continue
+		}
+
+		err := client.Delete(ctx, split.Items[i].GetName(), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "error deleting gateway MachineSet %q", split.Items[i].GetName())
+		}
+	}
+
+	return nil
+}
+
+// gatewayMachineSet returns the MachineSet hosting the given gateway node as the replica with the given index of the given
+// MachineSet.
+func (d *gatewayNodesMachineSetDeployer) gatewayMachineSet(machineSet *unstructured.Unstructured, index int,
+	node *configv1alpha1.GatewayNode,
+) (*unstructured.Unstructured, error) {
+	gateway := machineSet.DeepCopy()
+	name := GatewayNodeName(machineSet.GetName(), index)
+
+	gateway.SetName(name)
+
+	labels := gateway.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	labels[GatewayMachineSetLabel] = machineSet.GetName()
+	gateway.SetLabels(labels)
+
+	if err := unstructured.SetNestedField(gateway.Object, int64(1), "spec", "replicas"); err != nil {
+		return nil, errors.Wrapf(err, "error setting the replicas of MachineSet %q", name)
+	}
+
+	// The Machines of the split MachineSets must only be selected by their own MachineSet
+	for _, path := range [][]string{{"spec", "selector", "matchLabels"}, {"spec", "template", "metadata", "labels"}} {
+		if _, found, _ := unstructured.NestedString(gateway.Object, append(path, machineSetLabel)...); found {
+			if err := unstructured.SetNestedField(gateway.Object, name, append(path, machineSetLabel)...); err != nil {
+				return nil, errors.Wrapf(err, "error setting the Machine labels of MachineSet %q", name)
+			}
+		}
+	}
+
+	providerSpecPath := []string{"spec", "template", "spec", "providerSpec", "value"}
+
+	providerSpec, found, err := unstructured.NestedMap(gateway.Object, providerSpecPath...)
+	if err != nil || !found {
+		return nil, errors.Errorf("MachineSet %q has no provider spec", name)
+	}
+
+	d.customize(providerSpec, node)
+
+	if err := unstructured.SetNestedMap(gateway.Object, providerSpec, providerSpecPath...); err != nil {
+		return nil, errors.Wrapf(err, "error setting the provider spec of MachineSet %q", name)
+	}
+
+	return gateway, nil
+}
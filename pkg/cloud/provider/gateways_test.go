@@ -0,0 +1,199 @@
+package provider_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"github.com/submariner-io/cloud-prepare/pkg/ocp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var _ = Describe("GatewayCount", func() {
+	It("should return the configured count if the gateway nodes aren't specified individually", func() {
+		Expect(provider.GatewayCount(&configv1alpha1.GatewayConfig{Gateways: 2})).To(Equal(2))
+	})
+
+	It("should return the count of the individually specified gateway nodes", func() {
+		Expect(provider.GatewayCount(&configv1alpha1.GatewayConfig{
+			Gateways:     1,
+			GatewayNodes: []configv1alpha1.GatewayNode{{Zone: "a"}, {Zone: "b"}, {Zone: "c"}},
+		})).To(Equal(3))
+	})
+})
+
+var _ = Describe("GatewayResources", func() {
+	It("should return a single resource if the gateway nodes aren't specified individually", func() {
+		Expect(provider.GatewayResources(configv1alpha1.CloudResourceMachineSet, "gw", "m5.xlarge", 2, nil)).To(Equal(
+			[]configv1alpha1.CloudResource{{
+				Kind: configv1alpha1.CloudResourceMachineSet, Name: "gw", InstanceType: "m5.xlarge", Replicas: 2,
+			}}))
+	})
+
+	It("should return a resource per individually specified gateway node", func() {
+		Expect(provider.GatewayResources(configv1alpha1.CloudResourceMachinePool, "gw", "m5.xlarge", 1,
+			[]configv1alpha1.GatewayNode{{InstanceType: "m5.4xlarge"}, {Zone: "b"}})).To(Equal([]configv1alpha1.CloudResource{
+			{Kind: configv1alpha1.CloudResourceMachinePool, Name: "gw", InstanceType: "m5.4xlarge", Replicas: 1},
+			{Kind: configv1alpha1.CloudResourceMachinePool, Name: "gw-1", InstanceType: "m5.xlarge", Replicas: 1},
+		}))
+	})
+})
+
+var _ = Describe("GatewayNodesMachineSetDeployer", func() {
+	var (
+		deployer      *fakeMachineSetDeployer
+		dynamicClient *dynamicfake.FakeDynamicClient
+		objects       []runtime.Object
+		nodesDeployer ocp.MachineSetDeployer
+	)
+
+	nodes := []configv1alpha1.GatewayNode{{InstanceType: "m5.4xlarge"}, {InstanceType: "m5.large"}}
+
+	BeforeEach(func() {
+		deployer = &fakeMachineSetDeployer{}
+		objects = nil
+	})
+
+	JustBeforeEach(func() {
+		dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{machineSetGVR: "MachineSetList"}, objects...)
+
+		nodesDeployer = provider.NewGatewayNodesMachineSetDeployer(deployer, dynamicClient, nodes,
+			func(providerSpec map[string]any, node *configv1alpha1.GatewayNode) {
+				providerSpec["instanceType"] = node.InstanceType
+			})
+	})
+
+	When("a MachineSet is deployed for each gateway", func() {
+		It("should customize each for its gateway node", func(ctx context.Context) {
+			Expect(nodesDeployer.Deploy(ctx, newReplicatedMachineSet("gw-a", 1))).To(Succeed())
+			Expect(nodesDeployer.Deploy(ctx, newReplicatedMachineSet("gw-b", 1))).To(Succeed())
+
+			Expect(deployer.deployed).To(HaveLen(2))
+			Expect(deployer.deployed[0].GetName()).To(Equal("gw-a"))
+			Expect(instanceTypeOf(deployer.deployed[0])).To(Equal("m5.4xlarge"))
+			Expect(deployer.deployed[1].GetName()).To(Equal("gw-b"))
+			Expect(instanceTypeOf(deployer.deployed[1])).To(Equal("m5.large"))
+		})
+	})
+
+	When("a MachineSet is deployed again", func() {
+		It("should customize it for the same gateway node", func(ctx context.Context) {
+			Expect(nodesDeployer.Deploy(ctx, newReplicatedMachineSet("gw-a", 1))).To(Succeed())
+			Expect(nodesDeployer.Deploy(ctx, newReplicatedMachineSet("gw-b", 1))).To(Succeed())
+			Expect(nodesDeployer.Deploy(ctx, newReplicatedMachineSet("gw-a", 1))).To(Succeed())
+
+			Expect(deployer.deployed).To(HaveLen(3))
+			Expect(deployer.deployed[2].GetName()).To(Equal("gw-a"))
+			Expect(instanceTypeOf(deployer.deployed[2])).To(Equal("m5.4xlarge"))
+		})
+	})
+
+	When("more MachineSets are deployed than there are gateway nodes", func() {
+		It("should return an error", func(ctx context.Context) {
+			Expect(nodesDeployer.Deploy(ctx, newReplicatedMachineSet("gw-a", 1))).To(Succeed())
+			Expect(nodesDeployer.Deploy(ctx, newReplicatedMachineSet("gw-b", 1))).To(Succeed())
+			Expect(nodesDeployer.Deploy(ctx, newReplicatedMachineSet("gw-c", 1))).NotTo(Succeed())
+			Expect(deployer.deployed).To(HaveLen(2))
+		})
+	})
+
+	When("a MachineSet is deployed for more gateways than there are gateway nodes", func() {
+		It("should return an error", func(ctx context.Context) {
+			Expect(nodesDeployer.Deploy(ctx, newReplicatedMachineSet("gw", 3))).NotTo(Succeed())
+			Expect(deployer.deployed).To(BeEmpty())
+		})
+	})
+
+	When("a MachineSet is deployed for several gateways", func() {
+		It("should split it into a MachineSet per gateway node", func(ctx context.Context) {
+			Expect(nodesDeployer.Deploy(ctx, newReplicatedMachineSet("gw", 2))).To(Succeed())
+
+			Expect(deployer.deployed).To(HaveLen(2))
+
+			for i, name := range []string{"gw", "gw-1"} {
+				machineSet := deployer.deployed[i]
+				Expect(machineSet.GetName()).To(Equal(name))
+				Expect(machineSet.GetLabels()).To(HaveKeyWithValue(provider.GatewayMachineSetLabel, "gw"))
+				Expect(instanceTypeOf(machineSet)).To(Equal(nodes[i].InstanceType))
+
+				replicas, _, _ := unstructured.NestedInt64(machineSet.Object, "spec", "replicas")
+				Expect(replicas).To(Equal(int64(1)))
+
+				selector, _, _ := unstructured.NestedString(machineSet.Object, "spec", "selector", "matchLabels",
+					"machine.openshift.io/cluster-api-machineset")
+				Expect(selector).To(Equal(name))
+			}
+		})
+	})
+
+	When("a MachineSet is deployed for fewer gateways than it was split into", func() {
+		BeforeEach(func() {
+			objects = []runtime.Object{}
+
+			for _, name := range []string{"gw", "gw-1", "gw-2"} {
+				split := newReplicatedMachineSet(name, 1)
+				split.SetLabels(map[string]string{provider.GatewayMachineSetLabel: "gw"})
+				objects = append(objects, split)
+			}
+		})
+
+		It("should delete the MachineSets of the removed gateway nodes", func(ctx context.Context) {
+			Expect(nodesDeployer.Deploy(ctx, newReplicatedMachineSet("gw", 2))).To(Succeed())
+			Expect(deployer.deployed).To(HaveLen(2))
+
+			_, err := dynamicClient.Resource(machineSetGVR).Namespace(machineAPINamespace).Get(ctx, "gw-2", metav1.GetOptions{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+			_, err = dynamicClient.Resource(machineSetGVR).Namespace(machineAPINamespace).Get(ctx, "gw-1", metav1.GetOptions{})
+			Expect(err).To(Succeed())
+		})
+	})
+
+	When("there are no gateway nodes", func() {
+		It("should return the given deployer", func() {
+			Expect(provider.NewGatewayNodesMachineSetDeployer(deployer, dynamicClient, nil, nil)).To(BeIdenticalTo(deployer))
+		})
+	})
+
+	When("a split MachineSet is deleted", func() {
+		BeforeEach(func() {
+			split := newReplicatedMachineSet("gw-1", 1)
+			split.SetLabels(map[string]string{provider.GatewayMachineSetLabel: "gw"})
+			objects = []runtime.Object{split}
+		})
+
+		It("should delete the MachineSets it was split into", func(ctx context.Context) {
+			Expect(nodesDeployer.Delete(ctx, newReplicatedMachineSet("gw", 2))).To(Succeed())
+			Expect(deployer.deleted).To(Equal([]string{"gw"}))
+
+			_, err := dynamicClient.Resource(machineSetGVR).Namespace(machineAPINamespace).Get(ctx, "gw-1", metav1.GetOptions{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
+
+func newReplicatedMachineSet(name string, replicas int64) *unstructured.Unstructured {
+	machineSet := newMachineSet()
+	machineSet.SetName(name)
+
+	Expect(unstructured.SetNestedField(machineSet.Object, replicas, "spec", "replicas")).To(Succeed())
+	Expect(unstructured.SetNestedField(machineSet.Object, name, "spec", "selector", "matchLabels",
+		"machine.openshift.io/cluster-api-machineset")).To(Succeed())
+
+	return machineSet
+}
+
+func instanceTypeOf(machineSet *unstructured.Unstructured) string {
+	instanceType, _, _ := unstructured.NestedString(machineSet.Object, "spec", "template", "spec", "providerSpec", "value",
+		"instanceType")
+
+	return instanceType
+}
@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// Plan compares the planned cloud resources with the cloud environment: the resources which are missing or don't open all
+// their ports yet would be created by the preparation, and the existing ones would be deleted by the clean up.
+type Plan struct {
+	create []configv1alpha1.CloudResource
+	delete []configv1alpha1.CloudResource
+}
+
+// AddPorts adds the given planned security group or firewall rule. If it exists, it would be deleted and only the ports
+// for which isOpen returns false would be opened, otherwise it would be created.
+func (p *Plan) AddPorts(planned *configv1alpha1.CloudResource, exists bool, isOpen func(port *configv1alpha1.CloudPort) bool) {
+	if !exists {
+		p.create = append(p.create, *planned)
+		return
+	}
+
+	pending := *planned
+	pending.Ports = nil
+
+	for i := range planned.Ports {
+		if !isOpen(&planned.Ports[i]) {
+			pending.Ports = append(pending.Ports, planned.Ports[i])
+		}
+	}
+
+	if len(pending.Ports) > 0 {
+		p.create = append(p.create, pending)
+	}
+
+	p.delete = append(p.delete, *planned)
+}
+
+// AddGateways adds the given planned MachineSets or machine pools along with the existing ones, which would be deleted.
+// Unless the existing ones already provide the planned gateway nodes, the planned ones which don't exist with as many
+// replicas would be created or scaled.
+func (p *Plan) AddGateways(planned, existing []configv1alpha1.CloudResource) {
+	p.delete = append(p.delete, existing...)
+
+	if replicas(existing) >= replicas(planned) {
+		return
+	}
+
+	for i := range planned {
+		if current := FindResource(existing, planned[i].Kind, planned[i].Name); current == nil || current.Replicas < planned[i].Replicas {
+			p.create = append(p.create, planned[i])
+		}
+	}
+}
+
+// Resources returns the resources the given plan operation, Create or Delete, would create or delete.
+func (p *Plan) Resources(operation string) []configv1alpha1.CloudResource {
+	if operation == configv1alpha1.CloudPreparePlanDelete {
+		return p.delete
+	}
+
+	return p.create
+}
+
+// GatewayMachineSets returns the existing gateway MachineSets, i.e. those labeling their nodes as gateways. The instance
+// type of the gateway nodes is read from the given field of the provider spec of the MachineSets.
+func GatewayMachineSets(ctx context.Context, dynamicClient dynamic.Interface, instanceTypeField string,
+) ([]configv1alpha1.CloudResource, error) {
+	machineSets, err := dynamicClient.Resource(machineSetGVR).Namespace(machineAPINamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing the MachineSets")
+	}
+
+	var resources []configv1alpha1.CloudResource
+
+	for i := range machineSets.Items {
+		labels, _, _ := unstructured.NestedStringMap(machineSets.Items[i].Object, "spec", "template", "spec", "metadata", "labels")
+		if labels[gatewayLabel] != "true" {
+			continue
+		}
+
+		replicas, _, _ := unstructured.NestedInt64(machineSets.Items[i].Object, "spec", "replicas")
+		instanceType, _, _ := unstructured.NestedString(machineSets.Items[i].Object, "spec", "template", "spec", "providerSpec",
+			"value", instanceTypeField)
+
+		resources = append(resources, GatewayResource(configv1alpha1.CloudResourceMachineSet, machineSets.Items[i].GetName(),
+			instanceType, int(replicas)))
+	}
+
+	return resources, nil
+}
+
+func replicas(resources []configv1alpha1.CloudResource) int {
+	count := 0
+
+	for i := range resources {
+		count += resources[i].Replicas
+	}
+
+	return count
+}
@@ -0,0 +1,103 @@
+package provider_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var _ = Describe("Plan", func() {
+	var plan *provider.Plan
+
+	ports := []configv1alpha1.CloudPort{{Port: 4500, Protocol: "udp"}, {Port: 4490, Protocol: "udp"}}
+	securityGroup := configv1alpha1.CloudResource{Kind: configv1alpha1.CloudResourceSecurityGroup, Name: "gw", Ports: ports}
+
+	BeforeEach(func() {
+		plan = &provider.Plan{}
+	})
+
+	When("a security group doesn't exist", func() {
+		It("should plan to create it", func() {
+			plan.AddPorts(&securityGroup, false, nil)
+			Expect(plan.Resources(configv1alpha1.CloudPreparePlanCreate)).To(Equal([]configv1alpha1.CloudResource{securityGroup}))
+			Expect(plan.Resources(configv1alpha1.CloudPreparePlanDelete)).To(BeEmpty())
+		})
+	})
+
+	When("a security group exists without all its ports", func() {
+		It("should plan to open the missing ports and to delete it", func() {
+			plan.AddPorts(&securityGroup, true, func(port *configv1alpha1.CloudPort) bool {
+				return port.Port == 4500
+			})
+
+			Expect(plan.Resources(configv1alpha1.CloudPreparePlanCreate)).To(Equal([]configv1alpha1.CloudResource{{
+				Kind: configv1alpha1.CloudResourceSecurityGroup, Name: "gw", Ports: ports[1:],
+			}}))
+			Expect(plan.Resources(configv1alpha1.CloudPreparePlanDelete)).To(Equal([]configv1alpha1.CloudResource{securityGroup}))
+		})
+	})
+
+	When("a security group exists with all its ports", func() {
+		It("should only plan to delete it", func() {
+			plan.AddPorts(&securityGroup, true, func(_ *configv1alpha1.CloudPort) bool {
+				return true
+			})
+
+			Expect(plan.Resources(configv1alpha1.CloudPreparePlanCreate)).To(BeEmpty())
+			Expect(plan.Resources(configv1alpha1.CloudPreparePlanDelete)).To(Equal([]configv1alpha1.CloudResource{securityGroup}))
+		})
+	})
+
+	planned := []configv1alpha1.CloudResource{
+		provider.GatewayResource(configv1alpha1.CloudResourceMachineSet, "gw-a", "m5.xlarge", 1),
+		provider.GatewayResource(configv1alpha1.CloudResourceMachineSet, "gw-b", "m5.xlarge", 1),
+	}
+
+	When("some of the gateway MachineSets are missing", func() {
+		It("should plan to create the missing ones and to delete the existing ones", func() {
+			existing := planned[:1]
+
+			plan.AddGateways(planned, existing)
+			Expect(plan.Resources(configv1alpha1.CloudPreparePlanCreate)).To(Equal(planned[1:]))
+			Expect(plan.Resources(configv1alpha1.CloudPreparePlanDelete)).To(Equal(existing))
+		})
+	})
+
+	When("the existing gateway MachineSets already provide the gateway nodes", func() {
+		It("should only plan to delete them", func() {
+			existing := []configv1alpha1.CloudResource{
+				provider.GatewayResource(configv1alpha1.CloudResourceMachineSet, "gw", "m5.xlarge", 2),
+			}
+
+			plan.AddGateways(planned, existing)
+			Expect(plan.Resources(configv1alpha1.CloudPreparePlanCreate)).To(BeEmpty())
+			Expect(plan.Resources(configv1alpha1.CloudPreparePlanDelete)).To(Equal(existing))
+		})
+	})
+})
+
+var _ = Describe("GatewayMachineSets", func() {
+	It("should return the MachineSets of the gateway nodes", func(ctx context.Context) {
+		gateway := newMachineSet()
+		Expect(unstructured.SetNestedField(gateway.Object, int64(2), "spec", "replicas")).To(Succeed())
+		Expect(unstructured.SetNestedStringMap(gateway.Object, map[string]string{"submariner.io/gateway": "true"},
+			"spec", "template", "spec", "metadata", "labels")).To(Succeed())
+
+		worker := newMachineSet()
+		worker.SetName("worker")
+
+		dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{machineSetGVR: "MachineSetList"}, gateway, worker)
+
+		Expect(provider.GatewayMachineSets(ctx, dynamicClient, "instanceType")).To(Equal([]configv1alpha1.CloudResource{
+			provider.GatewayResource(configv1alpha1.CloudResourceMachineSet, "gw", "m5.xlarge", 2),
+		}))
+	})
+})
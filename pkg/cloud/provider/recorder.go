@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"slices"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/submariner-io/cloud-prepare/pkg/ocp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const machineAPINamespace = "openshift-machine-api"
+
+// MachineSetRecorder is a MachineSetDeployer which records the gateway MachineSets actually deployed, so that they're
+// recorded in the inventory of the cloud resources and exactly these are deleted by the clean up.
+type MachineSetRecorder struct {
+	ocp.MachineSetDeployer
+	instanceTypeField string
+	deployed          []configv1alpha1.CloudResource
+}
+
+// NewMachineSetRecorder returns a MachineSetRecorder wrapping the given deployer. It must wrap the deployer of the individual
+// MachineSets, i.e. be wrapped by the GatewayNodesMachineSetDeployer, if any. The instance type of the gateway nodes is read
+// from the given field of the provider spec of the MachineSets.
+func NewMachineSetRecorder(deployer ocp.MachineSetDeployer, instanceTypeField string) *MachineSetRecorder {
+	return &MachineSetRecorder{
+		MachineSetDeployer: deployer,
+		instanceTypeField:  instanceTypeField,
+	}
+}
+
+func (r *MachineSetRecorder) Deploy(ctx context.Context, machineSet *unstructured.Unstructured) error {
+	if err := r.MachineSetDeployer.Deploy(ctx, machineSet); err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	replicas, _, _ := unstructured.NestedInt64(machineSet.Object, "spec", "replicas")
+	instanceType, _, _ := unstructured.NestedString(machineSet.Object, "spec", "template", "spec", "providerSpec", "value",
+		r.instanceTypeField)
+
+	r.forget(machineSet.GetName())
+	r.deployed = append(r.deployed, GatewayResource(configv1alpha1.CloudResourceMachineSet, machineSet.GetName(), instanceType,
+		int(replicas)))
+
+	return nil
+}
+
+func (r *MachineSetRecorder) Delete(ctx context.Context, machineSet *unstructured.Unstructured) error {
+	if err := r.MachineSetDeployer.Delete(ctx, machineSet); err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	r.forget(machineSet.GetName())
+
+	return nil
+}
+
+// Resources returns the MachineSets deployed so far.
+func (r *MachineSetRecorder) Resources() []configv1alpha1.CloudResource {
+	return slices.Clone(r.deployed)
+}
+
+// DeleteMachineSets deletes exactly the named MachineSets of the given inventory. The recorder wraps the deployer of the
+// individual MachineSets, so the MachineSets split per gateway node are named in the inventory.
+func (r *MachineSetRecorder) DeleteMachineSets(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	for _, resource := range NamedResources(inventory, configv1alpha1.CloudResourceMachineSet) {
+		machineSet := &unstructured.Unstructured{}
+		machineSet.SetGroupVersionKind(machineSetGVR.GroupVersion().WithKind("MachineSet"))
+		machineSet.SetName(resource.Name)
+		machineSet.SetNamespace(machineAPINamespace)
+
+		if err := r.Delete(ctx, machineSet); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "error deleting MachineSet %q", resource.Name)
+		}
+	}
+
+	return nil
+}
+
+func (r *MachineSetRecorder) forget(name string) {
+	r.deployed = slices.DeleteFunc(r.deployed, func(resource configv1alpha1.CloudResource) bool {
+		return resource.Name == name
+	})
+}
@@ -0,0 +1,58 @@
+package provider_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+)
+
+var _ = Describe("MachineSetRecorder", func() {
+	var (
+		deployer *fakeMachineSetDeployer
+		recorder *provider.MachineSetRecorder
+	)
+
+	BeforeEach(func() {
+		deployer = &fakeMachineSetDeployer{}
+		recorder = provider.NewMachineSetRecorder(deployer, "instanceType")
+	})
+
+	When("MachineSets are deployed", func() {
+		It("should record them with their instance type and replicas", func(ctx context.Context) {
+			Expect(recorder.Deploy(ctx, newReplicatedMachineSet("gw", 1))).To(Succeed())
+			Expect(recorder.Deploy(ctx, newReplicatedMachineSet("gw-1", 2))).To(Succeed())
+			Expect(recorder.Deploy(ctx, newReplicatedMachineSet("gw", 1))).To(Succeed())
+
+			Expect(recorder.Resources()).To(ConsistOf(
+				configv1alpha1.CloudResource{
+					Kind: configv1alpha1.CloudResourceMachineSet, Name: "gw", InstanceType: "m5.xlarge", Replicas: 1,
+				},
+				configv1alpha1.CloudResource{
+					Kind: configv1alpha1.CloudResourceMachineSet, Name: "gw-1", InstanceType: "m5.xlarge", Replicas: 2,
+				}))
+		})
+	})
+
+	When("a MachineSet is deleted", func() {
+		It("should forget it", func(ctx context.Context) {
+			Expect(recorder.Deploy(ctx, newReplicatedMachineSet("gw", 1))).To(Succeed())
+			Expect(recorder.Delete(ctx, newReplicatedMachineSet("gw", 1))).To(Succeed())
+			Expect(recorder.Resources()).To(BeEmpty())
+		})
+	})
+
+	When("the MachineSets of an inventory are deleted", func() {
+		It("should delete exactly the named MachineSets", func(ctx context.Context) {
+			Expect(recorder.DeleteMachineSets(ctx, []configv1alpha1.CloudResource{
+				{Kind: configv1alpha1.CloudResourceMachineSet, Name: "gw"},
+				{Kind: configv1alpha1.CloudResourceMachineSet, Name: "gw-1"},
+				{Kind: configv1alpha1.CloudResourceMachineSet},
+				{Kind: configv1alpha1.CloudResourceSecurityGroup, Name: "sg-1"},
+			})).To(Succeed())
+			Expect(deployer.deleted).To(Equal([]string{"gw", "gw-1"}))
+		})
+	})
+})
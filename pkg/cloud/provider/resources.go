@@ -0,0 +1,157 @@
+package provider
+
+import (
+	"slices"
+	"strings"
+
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/constants"
+	"github.com/submariner-io/cloud-prepare/pkg/api"
+	"github.com/submariner-io/submariner/pkg/cni"
+)
+
+// GatewayResource returns the planned MachineSet or machine pool hosting the gateway nodes.
+func GatewayResource(kind, name, instanceType string, gateways int) configv1alpha1.CloudResource {
+	return configv1alpha1.CloudResource{
+		Kind:         kind,
+		Name:         name,
+		InstanceType: instanceType,
+		Replicas:     gateways,
+	}
+}
+
+// PortsResource returns the planned security group or firewall rule opening the given ports.
+func PortsResource(kind, name string, ports []api.PortSpec) configv1alpha1.CloudResource {
+	resource := configv1alpha1.CloudResource{
+		Kind:  kind,
+		Name:  name,
+		Ports: make([]configv1alpha1.CloudPort, len(ports)),
+	}
+
+	for i := range ports {
+		resource.Ports[i] = configv1alpha1.CloudPort{Port: int(ports[i].Port), Protocol: ports[i].Protocol}
+	}
+
+	return resource
+}
+
+// InternalPortsResources returns the planned firewall rule opening the given ports on the cluster nodes, if any.
+func InternalPortsResources(name string, ports []api.PortSpec) []configv1alpha1.CloudResource {
+	if len(ports) == 0 {
+		return nil
+	}
+
+	return []configv1alpha1.CloudResource{PortsResource(configv1alpha1.CloudResourceFirewallRule, name, ports)}
+}
+
+// InternalPorts returns the given ports along with the route port, which is only needed if the CNI isn't OVNKubernetes.
+func InternalPorts(cniType string, ports ...api.PortSpec) []api.PortSpec {
+	if strings.EqualFold(cniType, cni.OVNKubernetes) {
+		return ports
+	}
+
+	return append(ports, api.PortSpec{Port: constants.SubmarinerRoutePort, Protocol: "udp"})
+}
+
+// LoadBalancerPorts returns the ports that need to be opened on the worker nodes fronted by the gateway load balancer in
+// load balancer mode: the NAT-T and NAT discovery ports it forwards, and the port of its health checks. The load balancers
+// keep the addresses of the remote clusters, so these ports are opened to any source rather than within the cluster.
+func LoadBalancerPorts(nattPort, nattDiscoveryPort uint16) []api.PortSpec {
+	return []api.PortSpec{
+		{Port: nattPort, Protocol: "udp"},
+		{Port: nattDiscoveryPort, Protocol: "udp"},
+		{Port: constants.LoadBalancerHealthCheckPort, Protocol: "tcp"},
+	}
+}
+
+// LoadBalancerIngressName returns the name of the firewall rule opening the load balancer ports of the worker nodes to
+// external sources.
+func LoadBalancerIngressName(infraID string) string {
+	return infraID + "-submariner-lb-ingress"
+}
+
+// FindResource returns the resource of the given kind and name in the given inventory, or nil if there's none.
+func FindResource(inventory []configv1alpha1.CloudResource, kind, name string) *configv1alpha1.CloudResource {
+	for i := range inventory {
+		if inventory[i].Kind == kind && inventory[i].Name == name {
+			return &inventory[i]
+		}
+	}
+
+	return nil
+}
+
+// LoadBalancerIngressPorts returns the ports to close of the load balancer ingress rule of the given inventory, named
+// after the given infrastructure ID, if any. For a legacy clean up in load balancer mode, see LegacyCleanup, the given
+// ports are returned.
+func LoadBalancerIngressPorts(inventory []configv1alpha1.CloudResource, infraID string, loadBalancer bool, ports []api.PortSpec,
+) []api.PortSpec {
+	if lbIngress := FindResource(inventory, configv1alpha1.CloudResourceFirewallRule, LoadBalancerIngressName(infraID)); lbIngress != nil {
+		return ResourcePorts(lbIngress)
+	}
+
+	if loadBalancer && LegacyCleanup(inventory, configv1alpha1.CloudResourceFirewallRule) {
+		return ports
+	}
+
+	return nil
+}
+
+// ResourcePorts returns the ports opened by the given security group or firewall rule.
+func ResourcePorts(resource *configv1alpha1.CloudResource) []api.PortSpec {
+	ports := make([]api.PortSpec, len(resource.Ports))
+	for i := range resource.Ports {
+		//nolint:gosec // Usable port numbers fit
+		ports[i] = api.PortSpec{Port: uint16(resource.Ports[i].Port), Protocol: resource.Ports[i].Protocol}
+	}
+
+	return ports
+}
+
+// LegacyCleanup returns whether all the resources of the given kinds created by cloud-prepare must be cleaned up, rather
+// than the named resources of the given inventory: either the inventory is empty, e.g. for an environment prepared before
+// the inventory was recorded, or it includes an unnamed resource of one of the kinds, standing for the unknown resources
+// of that kind.
+func LegacyCleanup(inventory []configv1alpha1.CloudResource, kinds ...string) bool {
+	if len(inventory) == 0 {
+		return true
+	}
+
+	for i := range inventory {
+		if inventory[i].Name == "" && slices.Contains(kinds, inventory[i].Kind) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NamedResources returns the named resources of the given kinds in the given inventory.
+func NamedResources(inventory []configv1alpha1.CloudResource, kinds ...string) []configv1alpha1.CloudResource {
+	var resources []configv1alpha1.CloudResource
+
+	for i := range inventory {
+		if inventory[i].Name != "" && slices.Contains(kinds, inventory[i].Kind) {
+			resources = append(resources, inventory[i])
+		}
+	}
+
+	return resources
+}
+
+// InternalPortsCleanup returns whether the ports opened within the cluster by cloud-prepare must be closed: either the
+// given inventory includes a firewall rule other than the given ones, i.e. the one the ports were opened in, or the clean
+// up of the firewall rules is a legacy one, see LegacyCleanup.
+func InternalPortsCleanup(inventory []configv1alpha1.CloudResource, excluded ...string) bool {
+	if LegacyCleanup(inventory, configv1alpha1.CloudResourceFirewallRule) {
+		return true
+	}
+
+	for _, resource := range NamedResources(inventory, configv1alpha1.CloudResourceFirewallRule) {
+		if !slices.Contains(excluded, resource.Name) {
+			return true
+		}
+	}
+
+	return false
+}
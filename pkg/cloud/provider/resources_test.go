@@ -0,0 +1,62 @@
+package provider_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"github.com/submariner-io/cloud-prepare/pkg/api"
+)
+
+var _ = Describe("LegacyCleanup", func() {
+	It("should return true for an empty inventory", func() {
+		Expect(provider.LegacyCleanup(nil, configv1alpha1.CloudResourceMachineSet)).To(BeTrue())
+	})
+
+	It("should return true for an inventory with an unnamed resource of one of the kinds", func() {
+		Expect(provider.LegacyCleanup([]configv1alpha1.CloudResource{
+			{Kind: configv1alpha1.CloudResourceMachineSet, Name: "gw"},
+			{Kind: configv1alpha1.CloudResourceSecurityGroup},
+		}, configv1alpha1.CloudResourceMachineSet, configv1alpha1.CloudResourceSecurityGroup)).To(BeTrue())
+	})
+
+	It("should return false for an inventory naming the resources of the kinds", func() {
+		Expect(provider.LegacyCleanup([]configv1alpha1.CloudResource{
+			{Kind: configv1alpha1.CloudResourceMachineSet, Name: "gw"},
+			{Kind: configv1alpha1.CloudResourceFirewallRule},
+		}, configv1alpha1.CloudResourceMachineSet, configv1alpha1.CloudResourceSecurityGroup)).To(BeFalse())
+	})
+})
+
+var _ = Describe("InternalPortsCleanup", func() {
+	It("should return true for an inventory with a firewall rule other than the excluded ones", func() {
+		Expect(provider.InternalPortsCleanup([]configv1alpha1.CloudResource{
+			{Kind: configv1alpha1.CloudResourceFirewallRule, Name: "lb"},
+			{Kind: configv1alpha1.CloudResourceFirewallRule, Name: "sg-1"},
+		}, "lb")).To(BeTrue())
+	})
+
+	It("should return false for an inventory with only the excluded firewall rules", func() {
+		Expect(provider.InternalPortsCleanup([]configv1alpha1.CloudResource{
+			{Kind: configv1alpha1.CloudResourceMachineSet, Name: "gw"},
+			{Kind: configv1alpha1.CloudResourceFirewallRule, Name: "lb"},
+		}, "lb")).To(BeFalse())
+	})
+})
+
+var _ = Describe("LoadBalancerIngressPorts", func() {
+	ports := []api.PortSpec{{Port: 4500, Protocol: "udp"}}
+
+	It("should return the ports of the load balancer ingress rule of the inventory", func() {
+		Expect(provider.LoadBalancerIngressPorts([]configv1alpha1.CloudResource{{
+			Kind:  configv1alpha1.CloudResourceFirewallRule,
+			Name:  provider.LoadBalancerIngressName("infra"),
+			Ports: []configv1alpha1.CloudPort{{Port: 4490, Protocol: "udp"}},
+		}}, "infra", true, ports)).To(Equal([]api.PortSpec{{Port: 4490, Protocol: "udp"}}))
+	})
+
+	It("should return the given ports for a legacy clean up in load balancer mode", func() {
+		Expect(provider.LoadBalancerIngressPorts(nil, "infra", true, ports)).To(Equal(ports))
+		Expect(provider.LoadBalancerIngressPorts(nil, "infra", false, ports)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/submariner-io/cloud-prepare/pkg/ocp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	machineSetLabel = "machine.openshift.io/cluster-api-machineset"
+	// SpotFallbackAnnotation is set on the gateway MachineSets which were deployed on on-demand instances because spot
+	// instances couldn't be provisioned, to the reason why.
+	SpotFallbackAnnotation = "submarineraddon.open-cluster-management.io/spot-fallback"
+	machineFailedPhase     = "Failed"
+)
+
+// spotUnavailableMarkers are the lower-cased parts of the error messages of the spot instances which can't be
+// provisioned for lack of capacity or support, on AWS, Azure and GCP.
+var spotUnavailableMarkers = []string{
+	"capacity",
+	"spotmaxpricetoolow",
+	"maxspotinstancecountexceeded",
+	"skunotavailable",
+	"allocationfailed",
+	"overconstrainedallocationrequest",
+	"resource_pool_exhausted",
+	"unsupported",
+	"not supported",
+}
+
+var (
+	machineSetGVR = schema.GroupVersionResource{
+		Group:    "machine.openshift.io",
+		Version:  "v1beta1",
+		Resource: "machinesets",
+	}
+	machineGVR = schema.GroupVersionResource{
+		Group:    "machine.openshift.io",
+		Version:  "v1beta1",
+		Resource: "machines",
+	}
+)
+
+// SpotMachineSetDeployer is a MachineSetDeployer which deploys the gateway MachineSets on spot instances, falling back to
+// on-demand instances if spot instances can't be provisioned.
+type SpotMachineSetDeployer struct {
+	ocp.MachineSetDeployer
+	dynamicClient dynamic.Interface
+	option        string
+	value         any
+	fallbacks     map[string]string
+}
+
+// NewSpotMachineSetDeployer returns a MachineSetDeployer which deploys the gateway MachineSets on spot instances by setting
+// the given option in the provider spec of their Machines. If the spot instances can't be provisioned for lack of capacity
+// or because the instance type doesn't support them, i.e. the MachineSet is rejected or has Machines failed for these
+// reasons, the MachineSet is deployed on on-demand instances instead and the failed Machines are deleted so they're
+// replaced. Other failures, e.g. an invalid provider spec, are returned as is. A MachineSet which fell back to on-demand
+// instances is annotated with the SpotFallbackAnnotation and kept on on-demand instances until the annotation is removed,
+// spot instances are then tried again.
+func NewSpotMachineSetDeployer(deployer ocp.MachineSetDeployer, dynamicClient dynamic.Interface, option string, value any,
+) *SpotMachineSetDeployer {
+	return &SpotMachineSetDeployer{
+		MachineSetDeployer: deployer,
+		dynamicClient:      dynamicClient,
+		option:             option,
+		value:              value,
+		fallbacks:          map[string]string{},
+	}
+}
+
+func (d *SpotMachineSetDeployer) Deploy(ctx context.Context, machineSet *unstructured.Unstructured) error {
+	reason, failedMachines, err := d.spotUnavailable(ctx, machineSet)
+	if err != nil {
+		return err
+	}
+
+	if reason == "" {
+		spot := machineSet.DeepCopy()
+
+		err = unstructured.SetNestedField(spot.Object, d.value, "spec", "template", "spec", "providerSpec", "value", d.option)
+		if err != nil {
+			return errors.Wrapf(err, "error setting %q in MachineSet %q", d.option, machineSet.GetName())
+		}
+
+		err = d.MachineSetDeployer.Deploy(ctx, spot)
+		if err == nil {
+			delete(d.fallbacks, machineSet.GetName())
+			return nil
+		}
+
+		if !isSpotUnavailableMessage(err.Error()) {
+			return errors.Wrapf(err, "error deploying MachineSet %q on spot instances", machineSet.GetName())
+		}
+
+		reason = err.Error()
+	}
+
+	onDemandMachineSet := machineSet.DeepCopy()
+
+	annotations := onDemandMachineSet.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[SpotFallbackAnnotation] = reason
+	onDemandMachineSet.SetAnnotations(annotations)
+
+	if err := d.MachineSetDeployer.Deploy(ctx, onDemandMachineSet); err != nil {
+		return errors.Wrapf(err, "error deploying MachineSet %q on on-demand instances", machineSet.GetName())
+	}
+
+	d.fallbacks[machineSet.GetName()] = reason
+
+	for i := range failedMachines {
+		err := d.dynamicClient.Resource(machineGVR).Namespace(failedMachines[i].GetNamespace()).Delete(ctx,
+			failedMachines[i].GetName(), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "error deleting failed Machine %q", failedMachines[i].GetName())
+		}
+	}
+
+	return nil
+}
+
+// RecordFallbacks sets the reason why spot instances couldn't be provisioned on the MachineSets of the given inventory
+// which were deployed on on-demand instances instead. A nil deployer, i.e. when spot instances aren't requested, records nothing.
+func (d *SpotMachineSetDeployer) RecordFallbacks(inventory []configv1alpha1.CloudResource) {
+	if d == nil {
+		return
+	}
+
+	for i := range inventory {
+		if reason, found := d.fallbacks[inventory[i].Name]; found && inventory[i].Kind == configv1alpha1.CloudResourceMachineSet {
+			inventory[i].SpotFallbackReason = reason
+		}
+	}
+}
+
+// spotUnavailable returns why the given MachineSet must be deployed on on-demand instances, either because it already
+// fell back to them or because some of its spot Machines failed for lack of capacity or support, along with these failed
+// Machines. The reason is empty if spot instances can be used.
+func (d *SpotMachineSetDeployer) spotUnavailable(ctx context.Context, machineSet *unstructured.Unstructured,
+) (string, []unstructured.Unstructured, error) {
+	existing, err := d.dynamicClient.Resource(machineSetGVR).Namespace(machineSet.GetNamespace()).Get(ctx, machineSet.GetName(),
+		metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil, nil
+	}
+
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "error retrieving MachineSet %q", machineSet.GetName())
+	}
+
+	machines, err := d.dynamicClient.Resource(machineGVR).Namespace(machineSet.GetNamespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: machineSetLabel + "=" + machineSet.GetName(),
+	})
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "error listing the Machines of MachineSet %q", machineSet.GetName())
+	}
+
+	reason := existing.GetAnnotations()[SpotFallbackAnnotation]
+
+	var failed []unstructured.Unstructured
+
+	for i := range machines.Items {
+		phase, _, _ := unstructured.NestedString(machines.Items[i].Object, "status", "phase")
+		if phase != machineFailedPhase {
+			continue
+		}
+
+		message, _, _ := unstructured.NestedString(machines.Items[i].Object, "status", "errorMessage")
+		if message == "" {
+			message, _, _ = unstructured.NestedString(machines.Items[i].Object, "status", "errorReason")
+		}
+
+		if !isSpotUnavailableMessage(message) {
+			continue
+		}
+
+		failed = append(failed, machines.Items[i])
+
+		if reason == "" {
+			reason = message
+		}
+	}
+
+	return reason, failed, nil
+}
+
+// isSpotUnavailableMessage returns whether the given error message reports that spot instances can't be provisioned for
+// lack of capacity or because they aren't supported, as reported by the machine API on the supported clouds.
+func isSpotUnavailableMessage(message string) bool {
+	message = strings.ToLower(message)
+
+	for _, marker := range spotUnavailableMarkers {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,238 @@
+package provider_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"github.com/submariner-io/cloud-prepare/pkg/ocp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+const machineAPINamespace = "openshift-machine-api"
+
+var (
+	machineSetGVR = schema.GroupVersionResource{Group: "machine.openshift.io", Version: "v1beta1", Resource: "machinesets"}
+	machineGVR    = schema.GroupVersionResource{Group: "machine.openshift.io", Version: "v1beta1", Resource: "machines"}
+)
+
+var _ = Describe("SpotMachineSetDeployer", func() {
+	var (
+		deployer      *fakeMachineSetDeployer
+		dynamicClient *dynamicfake.FakeDynamicClient
+		objects       []runtime.Object
+		spotDeployer  *provider.SpotMachineSetDeployer
+	)
+
+	BeforeEach(func() {
+		deployer = &fakeMachineSetDeployer{}
+		objects = nil
+	})
+
+	JustBeforeEach(func() {
+		dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{
+				machineSetGVR: "MachineSetList",
+				machineGVR:    "MachineList",
+			}, objects...)
+
+		spotDeployer = provider.NewSpotMachineSetDeployer(deployer, dynamicClient, "spotMarketOptions", map[string]any{})
+	})
+
+	When("the MachineSet doesn't exist yet", func() {
+		It("should deploy it on spot instances", func(ctx context.Context) {
+			Expect(spotDeployer.Deploy(ctx, newMachineSet())).To(Succeed())
+			Expect(deployer.deployed).To(HaveLen(1))
+			Expect(isSpot(deployer.deployed[0])).To(BeTrue())
+		})
+	})
+
+	When("deploying on spot instances fails for lack of capacity", func() {
+		BeforeEach(func() {
+			deployer.spotErr = errors.New("spot capacity not available")
+		})
+
+		It("should deploy the MachineSet on on-demand instances and record the reason", func(ctx context.Context) {
+			Expect(spotDeployer.Deploy(ctx, newMachineSet())).To(Succeed())
+			Expect(deployer.deployed).To(HaveLen(2))
+			Expect(isSpot(deployer.deployed[1])).To(BeFalse())
+			Expect(deployer.deployed[1].GetAnnotations()).To(HaveKeyWithValue(provider.SpotFallbackAnnotation,
+				"spot capacity not available"))
+
+			inventory := []configv1alpha1.CloudResource{
+				{Kind: configv1alpha1.CloudResourceMachineSet, Name: "gw"},
+				{Kind: configv1alpha1.CloudResourceFirewallRule, Name: "gw"},
+			}
+
+			spotDeployer.RecordFallbacks(inventory)
+			Expect(inventory[0].SpotFallbackReason).To(Equal("spot capacity not available"))
+			Expect(inventory[1].SpotFallbackReason).To(BeEmpty())
+		})
+	})
+
+	When("the MachineSets sharing a name prefix are deployed and only one falls back to on-demand instances", func() {
+		It("should only record the reason on that MachineSet", func(ctx context.Context) {
+			machineSet := newMachineSet()
+			machineSet.SetName("gw-1a")
+			Expect(spotDeployer.Deploy(ctx, machineSet)).To(Succeed())
+
+			deployer.spotErr = errors.New("spot capacity not available")
+			machineSet = newMachineSet()
+			machineSet.SetName("gw-1a-1")
+			Expect(spotDeployer.Deploy(ctx, machineSet)).To(Succeed())
+
+			inventory := []configv1alpha1.CloudResource{
+				{Kind: configv1alpha1.CloudResourceMachineSet, Name: "gw-1a"},
+				{Kind: configv1alpha1.CloudResourceMachineSet, Name: "gw-1a-1"},
+			}
+
+			spotDeployer.RecordFallbacks(inventory)
+			Expect(inventory[0].SpotFallbackReason).To(BeEmpty())
+			Expect(inventory[1].SpotFallbackReason).To(Equal("spot capacity not available"))
+		})
+	})
+
+	When("deploying on spot instances is rejected for another reason", func() {
+		BeforeEach(func() {
+			deployer.spotErr = apierrors.NewBadRequest("spec.template.spec.providerSpec: invalid value")
+		})
+
+		It("should return the error and not deploy the MachineSet on on-demand instances", func(ctx context.Context) {
+			Expect(spotDeployer.Deploy(ctx, newMachineSet())).NotTo(Succeed())
+			Expect(deployer.deployed).To(HaveLen(1))
+		})
+	})
+
+	When("deploying on spot instances fails for another reason", func() {
+		BeforeEach(func() {
+			deployer.spotErr = errors.New("connection refused")
+		})
+
+		It("should return an error and not deploy the MachineSet on on-demand instances", func(ctx context.Context) {
+			Expect(spotDeployer.Deploy(ctx, newMachineSet())).NotTo(Succeed())
+			Expect(deployer.deployed).To(HaveLen(1))
+		})
+	})
+
+	When("the spot Machines of the MachineSet failed for lack of capacity", func() {
+		BeforeEach(func() {
+			objects = []runtime.Object{
+				newMachineSet(), newMachine("gw-1", "Running", ""), newMachine("gw-2", "Failed", "InsufficientInstanceCapacity"),
+			}
+		})
+
+		It("should deploy the MachineSet on on-demand instances and delete the failed Machines", func(ctx context.Context) {
+			Expect(spotDeployer.Deploy(ctx, newMachineSet())).To(Succeed())
+			Expect(deployer.deployed).To(HaveLen(1))
+			Expect(isSpot(deployer.deployed[0])).To(BeFalse())
+
+			_, err := dynamicClient.Resource(machineGVR).Namespace(machineAPINamespace).Get(ctx, "gw-2", metav1.GetOptions{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+			_, err = dynamicClient.Resource(machineGVR).Namespace(machineAPINamespace).Get(ctx, "gw-1", metav1.GetOptions{})
+			Expect(err).To(Succeed())
+		})
+	})
+
+	When("the spot Machines of the MachineSet failed for another reason", func() {
+		BeforeEach(func() {
+			objects = []runtime.Object{newMachineSet(), newMachine("gw-1", "Failed", "InvalidImageID.NotFound")}
+		})
+
+		It("should keep the MachineSet on spot instances and not delete the failed Machines", func(ctx context.Context) {
+			Expect(spotDeployer.Deploy(ctx, newMachineSet())).To(Succeed())
+			Expect(deployer.deployed).To(HaveLen(1))
+			Expect(isSpot(deployer.deployed[0])).To(BeTrue())
+
+			_, err := dynamicClient.Resource(machineGVR).Namespace(machineAPINamespace).Get(ctx, "gw-1", metav1.GetOptions{})
+			Expect(err).To(Succeed())
+		})
+	})
+
+	When("the MachineSet previously fell back to on-demand instances", func() {
+		BeforeEach(func() {
+			machineSet := newMachineSet()
+			machineSet.SetAnnotations(map[string]string{provider.SpotFallbackAnnotation: "spot capacity not available"})
+			objects = []runtime.Object{machineSet}
+		})
+
+		It("should keep it on on-demand instances", func(ctx context.Context) {
+			Expect(spotDeployer.Deploy(ctx, newMachineSet())).To(Succeed())
+			Expect(deployer.deployed).To(HaveLen(1))
+			Expect(isSpot(deployer.deployed[0])).To(BeFalse())
+		})
+	})
+})
+
+type fakeMachineSetDeployer struct {
+	ocp.MachineSetDeployer
+	deployed []*unstructured.Unstructured
+	deleted  []string
+	spotErr  error
+}
+
+func (f *fakeMachineSetDeployer) Deploy(_ context.Context, machineSet *unstructured.Unstructured) error {
+	f.deployed = append(f.deployed, machineSet)
+
+	if isSpot(machineSet) {
+		return f.spotErr
+	}
+
+	return nil
+}
+
+func (f *fakeMachineSetDeployer) Delete(_ context.Context, machineSet *unstructured.Unstructured) error {
+	f.deleted = append(f.deleted, machineSet.GetName())
+
+	return nil
+}
+
+func isSpot(machineSet *unstructured.Unstructured) bool {
+	_, found, _ := unstructured.NestedFieldNoCopy(machineSet.Object, "spec", "template", "spec", "providerSpec", "value",
+		"spotMarketOptions")
+
+	return found
+}
+
+func newMachineSet() *unstructured.Unstructured {
+	machineSet := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"template": map[string]any{
+				"spec": map[string]any{
+					"providerSpec": map[string]any{
+						"value": map[string]any{"instanceType": "m5.xlarge"},
+					},
+				},
+			},
+		},
+	}}
+
+	machineSet.SetAPIVersion("machine.openshift.io/v1beta1")
+	machineSet.SetKind("MachineSet")
+	machineSet.SetName("gw")
+	machineSet.SetNamespace(machineAPINamespace)
+
+	return machineSet
+}
+
+func newMachine(name, phase, errorReason string) *unstructured.Unstructured {
+	machine := &unstructured.Unstructured{Object: map[string]any{
+		"status": map[string]any{"phase": phase, "errorReason": errorReason},
+	}}
+
+	machine.SetAPIVersion("machine.openshift.io/v1beta1")
+	machine.SetKind("Machine")
+	machine.SetName(name)
+	machine.SetNamespace(machineAPINamespace)
+	machine.SetLabels(map[string]string{"machine.openshift.io/cluster-api-machineset": "gw"})
+
+	return machine
+}
@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const gatewayLabel = "submariner.io/gateway"
+
+// VerifyGatewayNodes returns the drift of the gateway nodes, i.e. a description of the missing nodes if there are fewer
+// ready gateway nodes than provided by the MachineSets and machine pools in the given inventory.
+func VerifyGatewayNodes(ctx context.Context, kubeClient kubernetes.Interface, inventory []configv1alpha1.CloudResource,
+) ([]string, error) {
+	expected := 0
+
+	for i := range inventory {
+		if inventory[i].Kind == configv1alpha1.CloudResourceMachineSet || inventory[i].Kind == configv1alpha1.CloudResourceMachinePool {
+			expected += inventory[i].Replicas
+		}
+	}
+
+	if expected == 0 {
+		return nil, nil
+	}
+
+	nodes, err := kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: gatewayLabel + "=true"})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing the gateway nodes")
+	}
+
+	ready := 0
+
+	for i := range nodes.Items {
+		if isNodeReady(&nodes.Items[i]) {
+			ready++
+		}
+	}
+
+	if ready < expected {
+		return []string{fmt.Sprintf("%d of %d gateway node(s) are ready", ready, expected)}, nil
+	}
+
+	return nil, nil
+}
+
+// MissingPorts returns the drift of the given security group or firewall rule, i.e. a description of each of its ports
+// for which isOpen returns false.
+func MissingPorts(resource *configv1alpha1.CloudResource, isOpen func(port *configv1alpha1.CloudPort) bool) []string {
+	var drift []string
+
+	for i := range resource.Ports {
+		if !isOpen(&resource.Ports[i]) {
+			drift = append(drift, fmt.Sprintf("port %d/%s is not open in %s %q", resource.Ports[i].Port, resource.Ports[i].Protocol,
+				resource.Kind, resource.Name))
+		}
+	}
+
+	return drift
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == corev1.NodeReady {
+			return node.Status.Conditions[i].Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+package provider_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("VerifyGatewayNodes", func() {
+	inventory := []configv1alpha1.CloudResource{
+		{Kind: configv1alpha1.CloudResourceSecurityGroup, Name: "sg"},
+		{Kind: configv1alpha1.CloudResourceMachineSet, Name: "gw", Replicas: 2},
+	}
+
+	When("enough gateway nodes are ready", func() {
+		It("should not report drift", func(ctx context.Context) {
+			drift, err := provider.VerifyGatewayNodes(ctx, fake.NewClientset(newGatewayNode("gw-1", true),
+				newGatewayNode("gw-2", true)), inventory)
+			Expect(err).To(Succeed())
+			Expect(drift).To(BeEmpty())
+		})
+	})
+
+	When("a gateway node isn't ready", func() {
+		It("should report drift", func(ctx context.Context) {
+			drift, err := provider.VerifyGatewayNodes(ctx, fake.NewClientset(newGatewayNode("gw-1", true),
+				newGatewayNode("gw-2", false)), inventory)
+			Expect(err).To(Succeed())
+			Expect(drift).To(HaveLen(1))
+		})
+	})
+
+	When("the inventory has no gateways", func() {
+		It("should not report drift", func(ctx context.Context) {
+			drift, err := provider.VerifyGatewayNodes(ctx, fake.NewClientset(), inventory[:1])
+			Expect(err).To(Succeed())
+			Expect(drift).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("MissingPorts", func() {
+	It("should report the closed ports", func() {
+		drift := provider.MissingPorts(&configv1alpha1.CloudResource{
+			Kind:  configv1alpha1.CloudResourceFirewallRule,
+			Name:  "rule",
+			Ports: []configv1alpha1.CloudPort{{Port: 4500, Protocol: "udp"}, {Port: 4800, Protocol: "udp"}},
+		}, func(port *configv1alpha1.CloudPort) bool {
+			return port.Port == 4500
+		})
+
+		Expect(drift).To(Equal([]string{`port 4800/udp is not open in FirewallRule "rule"`}))
+	})
+})
+
+func newGatewayNode(name string, ready bool) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"submariner.io/gateway": "true"},
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+		},
+	}
+}
@@ -0,0 +1,110 @@
+package rhos
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/security/groups"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/security/rules"
+	"github.com/pkg/errors"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
+	"github.com/submariner-io/cloud-prepare/pkg/api"
+)
+
+// openLoadBalancerPorts adds rules opening the given ports of the worker security group to any source, so that the load
+// balancer fronting the worker nodes in load balancer mode can forward the traffic of the remote clusters and probe the
+// nodes. The rules are described with the load balancer ingress name so that they can be told apart from the cluster's.
+func openLoadBalancerPorts(ctx context.Context, client *gophercloud.ServiceClient, infraID string, ports []api.PortSpec) error {
+	groupID, err := workerSecurityGroupID(ctx, client, infraID)
+	if err != nil {
+		return err
+	}
+
+	for i := range ports {
+		_, err := rules.Create(ctx, client, loadBalancerRule(infraID, groupID, &ports[i])).Extract()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusConflict) {
+			return errors.Wrapf(err, "error opening port %d/%s of security group %q", ports[i].Port, ports[i].Protocol, groupID)
+		}
+	}
+
+	return nil
+}
+
+// closeLoadBalancerPorts deletes the rules added by openLoadBalancerPorts.
+func closeLoadBalancerPorts(ctx context.Context, client *gophercloud.ServiceClient, infraID string) error {
+	groupID, err := workerSecurityGroupID(ctx, client, infraID)
+	if err != nil {
+		return err
+	}
+
+	pages, err := rules.List(client, rules.ListOpts{
+		SecGroupID:  groupID,
+		Description: provider.LoadBalancerIngressName(infraID),
+	}).AllPages(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "error listing the rules of security group %q", groupID)
+	}
+
+	groupRules, err := rules.ExtractRules(pages)
+	if err != nil {
+		return errors.Wrapf(err, "error listing the rules of security group %q", groupID)
+	}
+
+	for i := range groupRules {
+		err := rules.Delete(ctx, client, groupRules[i].ID).ExtractErr()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return errors.Wrapf(err, "error deleting rule %q of security group %q", groupRules[i].ID, groupID)
+		}
+	}
+
+	return nil
+}
+
+func loadBalancerRule(infraID, groupID string, port *api.PortSpec) rules.CreateOpts {
+	return rules.CreateOpts{
+		Direction:      rules.DirIngress,
+		Description:    provider.LoadBalancerIngressName(infraID),
+		EtherType:      rules.EtherType4,
+		SecGroupID:     groupID,
+		PortRangeMin:   int(port.Port),
+		PortRangeMax:   int(port.Port),
+		Protocol:       rules.RuleProtocol(port.Protocol),
+		RemoteIPPrefix: "0.0.0.0/0",
+	}
+}
+
+// workerSecurityGroupID returns the ID of the security group given to the worker nodes by the installer.
+func workerSecurityGroupID(ctx context.Context, client *gophercloud.ServiceClient, infraID string) (string, error) {
+	name := infraID + "-worker"
+
+	groupID, err := securityGroupID(ctx, client, name)
+	if err != nil {
+		return "", err
+	}
+
+	if groupID == "" {
+		return "", errors.Errorf("security group %q wasn't found", name)
+	}
+
+	return groupID, nil
+}
+
+// securityGroupID returns the ID of the security group with the given name, or an empty string if it doesn't exist.
+func securityGroupID(ctx context.Context, client *gophercloud.ServiceClient, name string) (string, error) {
+	pages, err := groups.List(client, groups.ListOpts{Name: name}).AllPages(ctx)
+	if err != nil {
+		return "", errors.Wrapf(err, "error retrieving security group %q", name)
+	}
+
+	securityGroups, err := groups.ExtractGroups(pages)
+	if err != nil {
+		return "", errors.Wrapf(err, "error retrieving security group %q", name)
+	}
+
+	if len(securityGroups) == 0 {
+		return "", nil
+	}
+
+	return securityGroups[0].ID, nil
+}
@@ -3,24 +3,28 @@ package rhos
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/security/groups"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/security/rules"
 	"github.com/gophercloud/utils/v2/openstack/clientconfig"
 	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
 	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
 	"github.com/stolostron/submariner-addon/pkg/cloud/reporter"
-	"github.com/stolostron/submariner-addon/pkg/constants"
 	submreporter "github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/cloud-prepare/pkg/api"
 	"github.com/submariner-io/cloud-prepare/pkg/k8s"
 	"github.com/submariner-io/cloud-prepare/pkg/ocp"
 	cloudpreparerhos "github.com/submariner-io/cloud-prepare/pkg/rhos"
-	"github.com/submariner-io/submariner/pkg/cni"
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
 )
@@ -33,13 +37,20 @@ const (
 
 type rhosProvider struct {
 	infraID           string
+	instanceType      string
+	kubeClient        kubernetes.Interface
+	dynamicClient     dynamic.Interface
 	nattPort          uint16
 	cniType           string
+	networkClient     *gophercloud.ServiceClient
 	cloudPrepare      api.Cloud
 	reporter          submreporter.Interface
 	gwDeployer        api.GatewayDeployer
 	gateways          int
+	gatewayNodes      []configv1alpha1.GatewayNode
+	loadBalancer      bool
 	nattDiscoveryPort int64
+	machineSets       *provider.MachineSetRecorder
 }
 
 func NewProvider(ctx context.Context, info *provider.Info) (*rhosProvider, error) {
@@ -52,7 +63,7 @@ func NewProvider(ctx context.Context, info *provider.Info) (*rhosProvider, error
 		instanceType = gwInstanceType
 	}
 
-	if info.Gateways < 1 {
+	if provider.GatewayCount(&info.GatewayConfig) < 1 {
 		return nil, errors.New("the count of gateways is less than 1")
 	}
 
@@ -84,20 +95,38 @@ func NewProvider(ctx context.Context, info *provider.Info) (*rhosProvider, error
 		}
 	}
 
+	networkClient, err := openstack.NewNetworkV2(providerClient, gophercloud.EndpointOpts{Region: info.Region})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the network client")
+	}
+
 	cloudPrepare := cloudpreparerhos.NewCloud(cloudInfo)
-	msDeployer := ocp.NewK8sMachinesetDeployer(info.RestMapper, info.DynamicClient)
+	machineSets := provider.NewMachineSetRecorder(ocp.NewK8sMachinesetDeployer(info.RestMapper, info.DynamicClient), "flavor")
+
+	var msDeployer ocp.MachineSetDeployer = machineSets
+
+	if len(info.GatewayNodes) > 0 {
+		msDeployer = provider.NewGatewayNodesMachineSetDeployer(msDeployer, info.DynamicClient, info.GatewayNodes, customizeGatewayNode)
+	}
 
 	gwDeployer := cloudpreparerhos.NewOcpGatewayDeployer(cloudInfo, msDeployer, projectID, instanceType, "", cloudEntry)
 
 	return &rhosProvider{
 		infraID:           info.InfraID,
+		instanceType:      instanceType,
+		kubeClient:        info.KubeClient,
+		dynamicClient:     info.DynamicClient,
 		nattPort:          uint16(info.IPSecNATTPort), //nolint:gosec // Usable port numbers fit
 		cniType:           info.NetworkType,
+		networkClient:     networkClient,
 		cloudPrepare:      cloudPrepare,
 		gwDeployer:        gwDeployer,
 		reporter:          reporter.NewEventRecorderWrapper("RHOSCloudProvider", info.EventRecorder),
 		nattDiscoveryPort: int64(info.NATTDiscoveryPort),
-		gateways:          info.Gateways,
+		gateways:          provider.GatewayCount(&info.GatewayConfig),
+		gatewayNodes:      info.GatewayNodes,
+		loadBalancer:      info.LoadBalancerEnable,
+		machineSets:       machineSets,
 	}, nil
 }
 
@@ -107,44 +136,93 @@ func NewProvider(ctx context.Context, info *provider.Info) (*rhosProvider, error
 //   - NAT traversal port (by default 4500/UDP)
 //   - 4800/UDP port to encapsulate Pod traffic from worker and master nodes to the Submariner Gateway nodes
 //   - ESP & AH protocols for private-ip to private-ip gateway communications
-func (r *rhosProvider) PrepareSubmarinerClusterEnv(ctx context.Context) error {
-	if err := r.gwDeployer.Deploy(ctx, api.GatewayDeployInput{
-		PublicPorts: []api.PortSpec{
-			{Port: r.nattPort, Protocol: "udp"},
-			{Port: uint16(r.nattDiscoveryPort), Protocol: "udp"}, //nolint:gosec // Usable port numbers fit
-			{Port: 0, Protocol: "esp"},
-			{Port: 0, Protocol: "ah"},
-		},
-		Gateways: r.gateways,
-	}, r.reporter); err != nil {
-		return errors.Wrap(err, "error deploying gateway")
-	}
-
-	if !strings.EqualFold(r.cniType, cni.OVNKubernetes) {
-		if err := r.cloudPrepare.OpenPorts(ctx, []api.PortSpec{
-			{Port: constants.SubmarinerRoutePort, Protocol: "udp"},
+func (r *rhosProvider) PrepareSubmarinerClusterEnv(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
+	// In load balancer mode, the load balancer fronts the existing worker nodes so no dedicated gateways are deployed
+	if !r.loadBalancer {
+		if err := r.gwDeployer.Deploy(ctx, api.GatewayDeployInput{
+			PublicPorts: r.publicPorts(),
+			Gateways:    r.gateways,
 		}, r.reporter); err != nil {
-			return errors.Wrap(err, "error opening ports")
+			return nil, errors.Wrap(err, "error deploying gateway")
+		}
+	}
+
+	if r.loadBalancer {
+		if err := openLoadBalancerPorts(ctx, r.networkClient, r.infraID, r.loadBalancerPorts()); err != nil {
+			return nil, err
+		}
+	}
+
+	if ports := provider.InternalPorts(r.cniType); len(ports) > 0 {
+		if err := r.cloudPrepare.OpenPorts(ctx, ports, r.reporter); err != nil {
+			return nil, errors.Wrap(err, "error opening ports")
 		}
 	}
 
 	r.reporter.Success("The Submariner cluster environment has been set up on RHOS")
 
-	return nil
+	return r.inventory(ctx)
+}
+
+// inventory returns the cloud resources actually created by PrepareSubmarinerClusterEnv: the existing gateway security
+// group and the deployed gateway MachineSets, or the load balancer ingress rules, along with the existing internal
+// security group. The security groups are recorded with their IDs.
+func (r *rhosProvider) inventory(ctx context.Context) ([]configv1alpha1.CloudResource, error) {
+	var resources []configv1alpha1.CloudResource
+
+	if !r.loadBalancer {
+		groupID, err := securityGroupID(ctx, r.networkClient, gatewaySecurityGroupName(r.infraID))
+		if err != nil {
+			return nil, err
+		}
+
+		if groupID != "" {
+			resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceSecurityGroup, groupID, r.publicPorts()))
+		}
+
+		resources = append(resources, r.machineSets.Resources()...)
+	} else {
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule,
+			provider.LoadBalancerIngressName(r.infraID), r.loadBalancerPorts()))
+	}
+
+	if ports := provider.InternalPorts(r.cniType); len(ports) > 0 {
+		groupID, err := securityGroupID(ctx, r.networkClient, internalSecurityGroupName(r.infraID))
+		if err != nil {
+			return nil, err
+		}
+
+		if groupID != "" {
+			resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule, groupID, ports))
+		}
+	}
+
+	return resources, nil
 }
 
 // CleanUpSubmarinerClusterEnv clean up submariner cluster environment on RHOS after the SubmarinerConfig was deleted
 // 1. delete any dedicated gateways that were previously deployed.
 // 2. delete the inbound and outbound firewall rules to close submariner ports.
-func (r *rhosProvider) CleanUpSubmarinerClusterEnv(ctx context.Context) error {
-	err := r.gwDeployer.Cleanup(ctx, r.reporter)
-	if err != nil {
-		return errors.Wrap(err, "error cleaning up gateway")
+// Only the resources of the given inventory are cleaned up, unless it doesn't name them, see provider.LegacyCleanup.
+func (r *rhosProvider) CleanUpSubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	if provider.LegacyCleanup(inventory, configv1alpha1.CloudResourceMachineSet, configv1alpha1.CloudResourceSecurityGroup) {
+		if err := r.gwDeployer.Cleanup(ctx, r.reporter); err != nil {
+			return errors.Wrap(err, "error cleaning up gateway")
+		}
+	} else if err := r.cleanUpGateways(ctx, inventory); err != nil {
+		return err
 	}
 
-	err = r.cloudPrepare.ClosePorts(ctx, r.reporter)
-	if err != nil {
-		return errors.Wrap(err, "error closing ports")
+	if len(provider.LoadBalancerIngressPorts(inventory, r.infraID, r.loadBalancer, r.loadBalancerPorts())) > 0 {
+		if err := closeLoadBalancerPorts(ctx, r.networkClient, r.infraID); err != nil {
+			return err
+		}
+	}
+
+	if provider.InternalPortsCleanup(inventory, provider.LoadBalancerIngressName(r.infraID)) {
+		if err := r.cloudPrepare.ClosePorts(ctx, r.reporter); err != nil {
+			return errors.Wrap(err, "error closing ports")
+		}
 	}
 
 	r.reporter.Success("The Submariner cluster environment has been cleaned up on RHOS")
@@ -152,6 +230,181 @@ func (r *rhosProvider) CleanUpSubmarinerClusterEnv(ctx context.Context) error {
 	return nil
 }
 
+// cleanUpGateways deletes exactly the gateway MachineSets and security group of the given inventory.
+func (r *rhosProvider) cleanUpGateways(ctx context.Context, inventory []configv1alpha1.CloudResource) error {
+	if err := r.machineSets.DeleteMachineSets(ctx, inventory); err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	for _, group := range provider.NamedResources(inventory, configv1alpha1.CloudResourceSecurityGroup) {
+		err := groups.Delete(ctx, r.networkClient, group.Name).ExtractErr()
+		if err != nil && !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			// The security group can't be deleted while the gateway ports use it, the clean up is retried
+			return errors.Wrapf(err, "error deleting security group %q", group.Name)
+		}
+	}
+
+	return nil
+}
+
+// PlanSubmarinerClusterEnv returns the cloud resources the given plan operation would change on RHOS, compared with the
+// gateway MachineSets and security groups of the cluster.
+func (r *rhosProvider) PlanSubmarinerClusterEnv(ctx context.Context, operation string) ([]configv1alpha1.CloudResource, error) {
+	planned := r.planned()
+	plan := &provider.Plan{}
+
+	if !r.loadBalancer {
+		machineSets, err := provider.GatewayMachineSets(ctx, r.dynamicClient, "flavor")
+		if err != nil {
+			return nil, err //nolint:wrapcheck // No need to wrap here
+		}
+
+		plan.AddGateways(provider.NamedResources(planned, configv1alpha1.CloudResourceMachineSet), machineSets)
+	}
+
+	for _, resource := range provider.NamedResources(planned, configv1alpha1.CloudResourceSecurityGroup,
+		configv1alpha1.CloudResourceFirewallRule) {
+		group, err := r.inventorySecurityGroup(ctx, &resource)
+		if err != nil {
+			return nil, err
+		}
+
+		plan.AddPorts(&resource, group != nil, func(port *configv1alpha1.CloudPort) bool {
+			return isIngressAllowed(group.Rules, port)
+		})
+	}
+
+	return plan.Resources(operation), nil
+}
+
+// planned returns the cloud resources PrepareSubmarinerClusterEnv creates on RHOS.
+func (r *rhosProvider) planned() []configv1alpha1.CloudResource {
+	var resources []configv1alpha1.CloudResource
+
+	if !r.loadBalancer {
+		resources = append(resources,
+			provider.PortsResource(configv1alpha1.CloudResourceSecurityGroup, gatewaySecurityGroupName(r.infraID), r.publicPorts()))
+		resources = append(resources, provider.GatewayResources(configv1alpha1.CloudResourceMachineSet, r.infraID+"-submariner-gw",
+			r.instanceType, r.gateways, r.gatewayNodes)...)
+	} else {
+		resources = append(resources, provider.PortsResource(configv1alpha1.CloudResourceFirewallRule,
+			provider.LoadBalancerIngressName(r.infraID), r.loadBalancerPorts()))
+	}
+
+	return append(resources, provider.InternalPortsResources(internalSecurityGroupName(r.infraID), provider.InternalPorts(r.cniType))...)
+}
+
+// gatewaySecurityGroupName returns the name of the security group opening the public ports of the gateways, created by
+// cloud-prepare.
+func gatewaySecurityGroupName(infraID string) string {
+	return infraID + "-submariner-gw-sg"
+}
+
+// internalSecurityGroupName returns the name of the security group opening the ports within the cluster, created by
+// cloud-prepare.
+func internalSecurityGroupName(infraID string) string {
+	return infraID + "-submariner-internal-sg"
+}
+
+// VerifySubmarinerClusterEnv returns the drift of the cloud resources in the given inventory on RHOS.
+func (r *rhosProvider) VerifySubmarinerClusterEnv(ctx context.Context, inventory []configv1alpha1.CloudResource) ([]string, error) {
+	drift, err := provider.VerifyGatewayNodes(ctx, r.kubeClient, inventory)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	for _, resource := range provider.NamedResources(inventory, configv1alpha1.CloudResourceSecurityGroup,
+		configv1alpha1.CloudResourceFirewallRule) {
+		group, err := r.inventorySecurityGroup(ctx, &resource)
+		if err != nil {
+			return nil, err
+		}
+
+		if group == nil {
+			drift = append(drift, fmt.Sprintf("the security group of %s %q is missing", resource.Kind, resource.Name))
+			continue
+		}
+
+		drift = append(drift, provider.MissingPorts(&resource, func(port *configv1alpha1.CloudPort) bool {
+			return isIngressAllowed(group.Rules, port)
+		})...)
+	}
+
+	return drift, nil
+}
+
+// inventorySecurityGroup returns the security group of the given security group or firewall rule, or nil if it doesn't
+// exist: the worker security group for the load balancer ingress rules, otherwise the security group recorded by its ID
+// or, in the plans and the inventories recorded before the IDs were, name.
+func (r *rhosProvider) inventorySecurityGroup(ctx context.Context, resource *configv1alpha1.CloudResource) (*groups.SecGroup, error) {
+	groupID := resource.Name
+
+	if resource.Name == provider.LoadBalancerIngressName(r.infraID) {
+		id, err := workerSecurityGroupID(ctx, r.networkClient, r.infraID)
+		if err != nil {
+			return nil, err
+		}
+
+		groupID = id
+	}
+
+	group, err := groups.Get(ctx, r.networkClient, groupID).Extract()
+	if err == nil {
+		return group, nil
+	}
+
+	if !gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+		return nil, errors.Wrapf(err, "error retrieving security group %q", groupID)
+	}
+
+	groupID, err = securityGroupID(ctx, r.networkClient, resource.Name)
+	if err != nil || groupID == "" {
+		return nil, err
+	}
+
+	group, err = groups.Get(ctx, r.networkClient, groupID).Extract()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving security group %q", groupID)
+	}
+
+	return group, nil
+}
+
+func isIngressAllowed(groupRules []rules.SecGroupRule, port *configv1alpha1.CloudPort) bool {
+	for i := range groupRules {
+		if groupRules[i].Direction != string(rules.DirIngress) {
+			continue
+		}
+
+		// An empty protocol stands for any protocol
+		if groupRules[i].Protocol != "" && groupRules[i].Protocol != port.Protocol {
+			continue
+		}
+
+		// Empty port ranges stand for any port
+		if port.Port == 0 || groupRules[i].PortRangeMin == 0 ||
+			(groupRules[i].PortRangeMin <= port.Port && port.Port <= groupRules[i].PortRangeMax) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadBalancerPorts returns the ports opened to external sources on the worker nodes in load balancer mode.
+func (r *rhosProvider) loadBalancerPorts() []api.PortSpec {
+	return provider.LoadBalancerPorts(r.nattPort, uint16(r.nattDiscoveryPort)) //nolint:gosec // Usable port numbers fit
+}
+
+func (r *rhosProvider) publicPorts() []api.PortSpec {
+	return []api.PortSpec{
+		{Port: r.nattPort, Protocol: "udp"},
+		{Port: uint16(r.nattDiscoveryPort), Protocol: "udp"}, //nolint:gosec // Usable port numbers fit
+		{Port: 0, Protocol: "esp"},
+		{Port: 0, Protocol: "ah"},
+	}
+}
+
 func newClient(ctx context.Context, credentialsSecret *corev1.Secret) (string, string, *gophercloud.ProviderClient, error) {
 	cloudsYAML, ok := credentialsSecret.Data[cloudsYAMLName]
 	if !ok {
@@ -199,3 +452,19 @@ func newClient(ctx context.Context, credentialsSecret *corev1.Secret) (string, s
 
 	return projectID, cloudNameStr, providerClient, nil
 }
+
+// customizeGatewayNode sets the flavor, availability zone and primary subnet of the given gateway node in the provider spec
+// of an OpenStack gateway MachineSet.
+func customizeGatewayNode(providerSpec map[string]any, node *configv1alpha1.GatewayNode) {
+	if node.InstanceType != "" {
+		providerSpec["flavor"] = node.InstanceType
+	}
+
+	if node.Zone != "" {
+		providerSpec["availabilityZone"] = node.Zone
+	}
+
+	if node.Subnet != "" {
+		providerSpec["primarySubnet"] = node.Subnet
+	}
+}
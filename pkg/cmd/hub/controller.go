@@ -12,6 +12,7 @@ import (
 	"github.com/openshift/library-go/pkg/serviceability"
 	"github.com/spf13/cobra"
 	"github.com/stolostron/submariner-addon/pkg/hub"
+	hubwebhook "github.com/stolostron/submariner-addon/pkg/hub/webhook"
 	"github.com/stolostron/submariner-addon/pkg/resource"
 	"github.com/stolostron/submariner-addon/pkg/version"
 	opwebhook "github.com/submariner-io/submariner-operator/pkg/webhook"
@@ -91,6 +92,9 @@ func startManager(ctx context.Context, addOnOptions *hub.AddOnOptions) error {
 	brokerValidator := opwebhook.NewBrokerValidator()
 	brokerValidator.SetupWithManager(mgr)
 
+	configValidator := hubwebhook.NewSubmarinerConfigValidator()
+	configValidator.SetupWithManager(mgr)
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		return fmt.Errorf("unable to add healthz check: %w", err)
 	}
@@ -0,0 +1,203 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarineragent"
+	brokerinfo "github.com/stolostron/submariner-addon/pkg/hub/submarinerbrokerinfo"
+	"github.com/stolostron/submariner-addon/pkg/redact"
+	"open-cluster-management.io/addon-framework/pkg/addonfactory"
+	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+)
+
+const (
+	outputYAML = "yaml"
+	outputJSON = "json"
+)
+
+type options struct {
+	configFile            string
+	managedClusterFile    string
+	deploymentConfigFiles []string
+	installationNamespace string
+	brokerNamespace       string
+	brokerAPIServer       string
+	brokerToken           string
+	brokerCA              string
+	ipSecPSK              string
+	showSecrets           bool
+	redactFields          []string
+	output                string
+	addOnFramework        bool
+}
+
+func NewRender() *cobra.Command {
+	o := &options{}
+
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Render the ManifestWorks, or add-on framework manifests, deployed to a managed cluster, without accessing any cluster",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.run(cmd.OutOrStdout())
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&o.configFile, "config", "", "The file containing the SubmarinerConfig")
+	flags.StringVar(&o.managedClusterFile, "managed-cluster", "", "The file containing the ManagedCluster")
+	flags.StringSliceVar(&o.deploymentConfigFiles, "addon-deployment-config", nil,
+		"The files containing the AddOnDeploymentConfigs of the addon")
+	flags.StringVar(&o.installationNamespace, "installation-namespace", addonfactory.AddonDefaultInstallNamespace,
+		"The namespace the addon is installed in on the managed cluster")
+	flags.StringVar(&o.brokerNamespace, "broker-namespace", "",
+		"The broker namespace recorded on the ManagedClusterSet, generated from the cluster set name by default")
+	flags.StringVar(&o.brokerAPIServer, "broker-api-server", "", "The API server of the broker")
+	flags.StringVar(&o.brokerToken, "broker-token", "", "The token of the broker ServiceAccount of the managed cluster")
+	flags.StringVar(&o.brokerCA, "broker-ca", "", "The CA of the broker API server")
+	flags.StringVar(&o.ipSecPSK, "ipsec-psk", "", "The IPsec PSK of the broker")
+	flags.BoolVar(&o.showSecrets, "show-secrets", false, "Don't redact the secrets in the rendered ManifestWorks")
+	flags.StringSliceVar(&o.redactFields, "redact-fields", nil,
+		"Additional JSONPath-like selectors of the fields redacted from the rendered ManifestWorks")
+	flags.StringVarP(&o.output, "output", "o", outputYAML, "The output format, yaml or json")
+	flags.BoolVar(&o.addOnFramework, "addon-framework-deployment", false,
+		"Render the manifests deployed along with the add-on agent by the add-on framework instead of the ManifestWorks")
+
+	_ = cmd.MarkFlagRequired("config")
+	_ = cmd.MarkFlagRequired("managed-cluster")
+
+	return cmd
+}
+
+func (o *options) run(out io.Writer) error {
+	if o.output != outputYAML && o.output != outputJSON {
+		return fmt.Errorf("unsupported output format %q", o.output)
+	}
+
+	if err := redact.SetFields(o.redactFields...); err != nil {
+		return errors.Wrap(err, "invalid redacted fields")
+	}
+
+	config := &configv1alpha1.SubmarinerConfig{}
+	if err := readObject(o.configFile, config); err != nil {
+		return err
+	}
+
+	managedCluster := &clusterv1.ManagedCluster{}
+	if err := readObject(o.managedClusterFile, managedCluster); err != nil {
+		return err
+	}
+
+	deploymentConfigs := make([]*addonv1beta1.AddOnDeploymentConfig, len(o.deploymentConfigFiles))
+
+	for i, file := range o.deploymentConfigFiles {
+		deploymentConfigs[i] = &addonv1beta1.AddOnDeploymentConfig{}
+		if err := readObject(file, deploymentConfigs[i]); err != nil {
+			return err
+		}
+	}
+
+	clusterSetName := managedCluster.Labels[clusterv1beta2.ClusterSetLabel]
+	if clusterSetName == "" {
+		return fmt.Errorf("ManagedCluster %q is missing the cluster set label", managedCluster.Name)
+	}
+
+	brokerNamespace := o.brokerNamespace
+	if brokerNamespace == "" {
+		brokerNamespace = brokerinfo.GenerateBrokerNamespace(clusterSetName)
+	}
+
+	brokerInfo := brokerinfo.GetOffline(managedCluster.Name, brokerNamespace, config, o.installationNamespace)
+	brokerInfo.BrokerAPIServer = o.brokerAPIServer
+	brokerInfo.BrokerToken = base64.StdEncoding.EncodeToString([]byte(o.brokerToken))
+	brokerInfo.BrokerCA = base64.StdEncoding.EncodeToString([]byte(o.brokerCA))
+	brokerInfo.IPSecPSK = base64.StdEncoding.EncodeToString([]byte(o.ipSecPSK))
+
+	objects, err := o.render(managedCluster, brokerInfo, config, deploymentConfigs)
+	if err != nil {
+		return err
+	}
+
+	for i, data := range objects {
+		if !o.showSecrets {
+			data = redact.JSON(data)
+		}
+
+		if o.output == outputJSON {
+			var indented bytes.Buffer
+
+			if err := json.Indent(&indented, data, "", "  "); err != nil {
+				return errors.Wrapf(err, "error formatting object %d", i)
+			}
+
+			data = append(indented.Bytes(), '\n')
+		} else {
+			if data, err = yaml.JSONToYAML(data); err != nil {
+				return errors.Wrapf(err, "error converting object %d to YAML", i)
+			}
+
+			if i > 0 {
+				data = append([]byte("---\n"), data...)
+			}
+		}
+
+		if _, err := out.Write(data); err != nil {
+			return errors.Wrap(err, "error writing the rendered objects")
+		}
+	}
+
+	return nil
+}
+
+// render returns the JSON of the ManifestWorks, or of the manifests deployed through the add-on framework if selected.
+func (o *options) render(managedCluster *clusterv1.ManagedCluster, brokerInfo *brokerinfo.SubmarinerBrokerInfo,
+	config *configv1alpha1.SubmarinerConfig, deploymentConfigs []*addonv1beta1.AddOnDeploymentConfig,
+) ([][]byte, error) {
+	if o.addOnFramework {
+		manifests, err := submarineragent.RenderAddOnFrameworkManifests(managedCluster, brokerInfo, config, deploymentConfigs)
+		if err != nil {
+			return nil, errors.Wrap(err, "error rendering the add-on framework manifests")
+		}
+
+		objects := make([][]byte, len(manifests))
+		for i := range manifests {
+			objects[i] = manifests[i].Raw
+		}
+
+		return objects, nil
+	}
+
+	works, err := submarineragent.RenderManifestWorks(managedCluster, brokerInfo, config, deploymentConfigs)
+	if err != nil {
+		return nil, errors.Wrap(err, "error rendering the ManifestWorks")
+	}
+
+	objects := make([][]byte, len(works))
+
+	for i, work := range works {
+		if objects[i], err = json.Marshal(work); err != nil {
+			return nil, errors.Wrapf(err, "error marshalling ManifestWork %q", work.Name)
+		}
+	}
+
+	return objects, nil
+}
+
+func readObject(file string, obj any) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return errors.Wrapf(err, "error reading %q", file)
+	}
+
+	return errors.Wrapf(yaml.Unmarshal(data, obj), "error parsing %q", file)
+}
@@ -0,0 +1,23 @@
+package spoke
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/stolostron/submariner-addon/pkg/spoke"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func NewPreDeleteHook() *cobra.Command {
+	options := spoke.NewPreDeleteHookOptions()
+
+	cmd := &cobra.Command{
+		Use:   "pre-delete-hook",
+		Short: "Wait for the Submariner resource to be removed before the add-on agent is removed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return options.Run(ctrl.SetupSignalHandler(), ctrl.GetConfigOrDie())
+		},
+	}
+
+	options.AddFlags(cmd)
+
+	return cmd
+}
@@ -0,0 +1,170 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	configclient "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/clientset/versioned"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
+	"github.com/stolostron/submariner-addon/pkg/hub/status"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
+	clusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	none        = "-"
+
+	defaultHubNamespace = "open-cluster-management"
+)
+
+type options struct {
+	clusterSet   string
+	output       string
+	hubNamespace string
+}
+
+func NewStatus() *cobra.Command {
+	o := &options{}
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Print the status of the submariner deployments on the hub, per cluster set",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return o.run(cmd.Context(), ctrl.GetConfigOrDie(), cmd.OutOrStdout())
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&o.clusterSet, "clusterset", "", "Only print the status of the given cluster set")
+	flags.StringVarP(&o.output, "output", "o", outputTable, "The output format, table, json or yaml")
+	flags.StringVar(&o.hubNamespace, "hub-namespace", defaultHubNamespace,
+		"The namespace of the hub controller, holding the kubeconfigs of the external brokers")
+
+	return cmd
+}
+
+func (o *options) run(ctx context.Context, config *rest.Config, out io.Writer) error {
+	if o.output != outputTable && o.output != outputJSON && o.output != outputYAML {
+		return fmt.Errorf("unsupported output format %q", o.output)
+	}
+
+	clients, err := o.newClients(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := status.Get(ctx, clients, o.clusterSet)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	switch o.output {
+	case outputJSON:
+		data, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "error marshalling the status")
+		}
+
+		_, err = fmt.Fprintln(out, string(data))
+
+		return err //nolint:wrapcheck // No need to wrap here
+	case outputYAML:
+		data, err := yaml.Marshal(statuses)
+		if err != nil {
+			return errors.Wrap(err, "error marshalling the status")
+		}
+
+		_, err = out.Write(data)
+
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	return printTables(out, statuses)
+}
+
+func (o *options) newClients(ctx context.Context, config *rest.Config) (*status.Clients, error) {
+	clusterClient, err := clusterclient.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the cluster client")
+	}
+
+	addOnClient, err := addonclient.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the addon client")
+	}
+
+	configClient, err := configclient.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the SubmarinerConfig client")
+	}
+
+	hubClients, err := brokercluster.NewClientsForConfig(config)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	// The broker clusters are resolved from the ManagedClusterSets, which are cached for the duration of the command.
+	clusterInformers := clusterinformers.NewSharedInformerFactory(clusterClient, 0)
+	clusterSets := clusterInformers.Cluster().V1beta2().ManagedClusterSets()
+	clusterSetLister := clusterSets.Lister()
+
+	clusterInformers.Start(ctx.Done())
+
+	if !cache.WaitForCacheSync(ctx.Done(), clusterSets.Informer().HasSynced) {
+		return nil, errors.New("error waiting for the ManagedClusterSets cache to sync")
+	}
+
+	return &status.Clients{
+		ClusterClient:  clusterClient,
+		AddOnClient:    addOnClient,
+		ConfigClient:   configClient,
+		BrokerClusters: brokercluster.NewResolver(hubClients, clusterSetLister, o.hubNamespace),
+	}, nil
+}
+
+func printTables(out io.Writer, statuses []status.ClusterSetStatus) error {
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	for i := range statuses {
+		if i > 0 {
+			fmt.Fprintln(writer)
+		}
+
+		fmt.Fprintf(writer, "Cluster set: %s\n", orNone(statuses[i].Name))
+		fmt.Fprintln(writer, "CLUSTER\tAGENT DEGRADED\tCONNECTION DEGRADED\tROUTE AGENT DEGRADED\tGATEWAYS LABELED\t"+
+			"ENV PREPARED\tGATEWAY NODES\tGLOBAL CIDR\tCONNECTIONS")
+
+		for j := range statuses[i].Clusters {
+			cluster := &statuses[i].Clusters[j]
+
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d/%d\n", cluster.Name, orNone(cluster.AgentDegraded),
+				orNone(cluster.ConnectionDegraded), orNone(cluster.RouteAgentConnectionDegraded), orNone(cluster.GatewayNodesLabeled),
+				orNone(cluster.ClusterEnvPrepared), orNone(strings.Join(cluster.GatewayNodes, ",")), orNone(cluster.GlobalCIDR),
+				cluster.Connections.Established, cluster.Connections.Total)
+		}
+	}
+
+	return writer.Flush() //nolint:wrapcheck // No need to wrap here
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return none
+	}
+
+	return value
+}
@@ -0,0 +1,183 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/validation"
+	"github.com/submariner-io/submariner-operator/pkg/discovery/globalnet"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+	stdin      = "-"
+)
+
+type options struct {
+	platform           string
+	globalnetCIDRRange string
+	strict             bool
+	output             string
+}
+
+// result holds the findings of a SubmarinerConfig.
+type result struct {
+	File      string               `json:"file"`
+	Namespace string               `json:"namespace,omitempty"`
+	Name      string               `json:"name"`
+	Findings  []validation.Finding `json:"findings"`
+}
+
+func NewValidate() *cobra.Command {
+	o := &options{}
+
+	cmd := &cobra.Command{
+		Use:   "validate FILE...",
+		Short: "Validate SubmarinerConfig manifests, without accessing any cluster",
+		Long: "Validate the SubmarinerConfigs in the given files, or in the standard input if the file is -, using the rules of " +
+			"the admission webhook. Other resources in the files are ignored. The command fails if any validation error is found.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			return o.run(args, cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&o.platform, "platform", "",
+		"The platform of the managed cluster, e.g. AWS, GCP, Azure or OpenStack, used to check the platform specific fields")
+	flags.StringVar(&o.globalnetCIDRRange, "globalnet-cidr-range", globalnet.DefaultGlobalnetCIDR,
+		"The globalnet CIDR range of the cluster set, the global CIDRs must be in")
+	flags.BoolVar(&o.strict, "strict", false, "Fail on validation warnings too")
+	flags.StringVarP(&o.output, "output", "o", outputText, "The output format, text or json")
+
+	return cmd
+}
+
+func (o *options) run(files []string, in io.Reader, out io.Writer) error {
+	if o.output != outputText && o.output != outputJSON {
+		return fmt.Errorf("unsupported output format %q", o.output)
+	}
+
+	validationOptions := validation.Options{
+		Platform:           o.platform,
+		GlobalnetCIDRRange: o.globalnetCIDRRange,
+	}
+
+	results := []result{}
+	errorCount := 0
+	warningCount := 0
+
+	for _, file := range files {
+		configs, err := readConfigs(file, in)
+		if err != nil {
+			return err
+		}
+
+		for _, config := range configs {
+			findings := validation.Validate(config, validationOptions)
+
+			for i := range findings {
+				if findings[i].Severity == validation.SeverityError {
+					errorCount++
+				} else {
+					warningCount++
+				}
+			}
+
+			results = append(results, result{
+				File:      file,
+				Namespace: config.Namespace,
+				Name:      config.Name,
+				Findings:  findings,
+			})
+		}
+	}
+
+	if err := o.print(results, out); err != nil {
+		return err
+	}
+
+	if errorCount > 0 || (o.strict && warningCount > 0) {
+		return fmt.Errorf("found %d validation errors and %d validation warnings", errorCount, warningCount)
+	}
+
+	return nil
+}
+
+func (o *options) print(results []result, out io.Writer) error {
+	if o.output == outputJSON {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "error marshalling the findings")
+		}
+
+		_, err = fmt.Fprintln(out, string(data))
+
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	for i := range results {
+		name := results[i].Name
+		if results[i].Namespace != "" {
+			name = results[i].Namespace + "/" + name
+		}
+
+		for _, finding := range results[i].Findings {
+			if _, err := fmt.Fprintf(out, "%s: %s: %s\n", results[i].File, name, finding); err != nil {
+				return err //nolint:wrapcheck // No need to wrap here
+			}
+		}
+	}
+
+	return nil
+}
+
+func readConfigs(file string, in io.Reader) ([]*configv1alpha1.SubmarinerConfig, error) {
+	reader := in
+
+	if file != stdin {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading %q", file)
+		}
+
+		defer f.Close()
+
+		reader = f
+	}
+
+	decoder := yaml.NewYAMLOrJSONDecoder(reader, 4096)
+
+	var configs []*configv1alpha1.SubmarinerConfig
+
+	for {
+		raw := json.RawMessage{}
+
+		err := decoder.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return configs, nil
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing %q", file)
+		}
+
+		config := &configv1alpha1.SubmarinerConfig{}
+		if err := json.Unmarshal(raw, config); err != nil {
+			return nil, errors.Wrapf(err, "error parsing %q", file)
+		}
+
+		if config.Kind == "SubmarinerConfig" {
+			configs = append(configs, config)
+		}
+	}
+}
@@ -15,7 +15,19 @@ const (
 	IPSecPSKSecretName  = "submariner-ipsec-psk"
 	BrokerK8sSecretName = "submariner-broker-secret"
 
+	// BackupLabelKey labels the hub resources which are backed up, so that Submariner can be restored on another hub.
+	BackupLabelKey   = "cluster.open-cluster-management.io/backup"
+	BackupLabelValue = "submariner"
+
 	SubmarinerNatTPort          = 4500
 	SubmarinerNatTDiscoveryPort = 4900
 	SubmarinerRoutePort         = 4800
+
+	// LoadBalancerHealthCheckPort is the kube-proxy health check port, probed by the cloud load balancers fronting the
+	// gateway nodes in load balancer mode.
+	LoadBalancerHealthCheckPort = 10256
+
+	// ServiceAccountTokenFile is the path of the projected service account token, with the "openshift" audience, that
+	// is mounted in the agent to authenticate to cloud providers using workload identity.
+	ServiceAccountTokenFile = "/var/run/secrets/openshift/serviceaccount/token"
 )
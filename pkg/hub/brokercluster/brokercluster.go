@@ -0,0 +1,263 @@
+package brokercluster
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/admiral/pkg/log"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// ExternalBrokerAnnotation names, on a ManagedClusterSet, the Secret holding the kubeconfig of the cluster hosting its
+	// broker, in the namespace of the hub controller. The broker is hosted on the hub if the annotation isn't set.
+	ExternalBrokerAnnotation = "submarineraddon.open-cluster-management.io/external-broker-kubeconfig"
+
+	// KubeConfigSecretKey is the key of the kubeconfig in the Secret named by the ExternalBrokerAnnotation.
+	KubeConfigSecretKey = "kubeconfig"
+
+	// BrokerAPIServerAnnotation overrides, on a ManagedClusterSet, the API server the managed clusters reach the broker
+	// with, e.g. a dedicated load balancer or private endpoint. Either a URL or a host and port.
+	BrokerAPIServerAnnotation = "submarineraddon.open-cluster-management.io/broker-api-server"
+
+	// BrokerCAAnnotation names, on a ManagedClusterSet, the ConfigMap holding the CA bundle the managed clusters verify
+	// the broker API server with, in the namespace of the hub controller.
+	BrokerCAAnnotation = "submarineraddon.open-cluster-management.io/broker-ca-configmap"
+
+	// CABundleConfigMapKey is the key of the CA bundle in the ConfigMap named by the BrokerCAAnnotation.
+	CABundleConfigMapKey = "ca-bundle.crt"
+)
+
+var logger = log.Logger{Logger: logf.Log.WithName("BrokerCluster")}
+
+// Clients access the cluster hosting the broker of a cluster set.
+type Clients struct {
+	KubeClient       kubernetes.Interface
+	DynamicClient    dynamic.Interface
+	ControllerClient controllerclient.Client
+	CRDClient        apiextensionsclientset.Interface
+
+	// APIServer is the host of the API server of the broker cluster, as the managed clusters reach it. It's empty if it's
+	// discovered from the hub configuration.
+	APIServer string
+
+	// CA is the CA bundle the managed clusters verify the broker API server with. It's empty if it's discovered from the
+	// broker cluster.
+	CA []byte
+
+	// External is set if the broker is hosted on another cluster than the hub.
+	External bool
+}
+
+// NewClientsForConfig creates the clients of an external broker cluster. It can be replaced in tests.
+var NewClientsForConfig = func(config *rest.Config) (*Clients, error) {
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating kube client")
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating dynamic client")
+	}
+
+	controllerClient, err := controllerclient.New(config, controllerclient.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating controller client")
+	}
+
+	crdClient, err := apiextensionsclientset.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating apiExtension client")
+	}
+
+	return &Clients{
+		KubeClient:       kubeClient,
+		DynamicClient:    dynamicClient,
+		ControllerClient: controllerClient,
+		CRDClient:        crdClient,
+	}, nil
+}
+
+type cachedClients struct {
+	secretName      string
+	resourceVersion string
+	clients         *Clients
+}
+
+// Resolver returns the clients of the cluster hosting the broker of a cluster set.
+type Resolver struct {
+	hub              *Clients
+	clusterSetLister clusterlisterv1beta2.ManagedClusterSetLister
+	namespace        string
+	mutex            sync.Mutex
+	cache            map[string]*cachedClients
+}
+
+// NewResolver returns a Resolver using the given hub clients for the cluster sets without an external broker, and the
+// kubeconfig Secrets in the given hub namespace for the others.
+func NewResolver(hub *Clients, clusterSetLister clusterlisterv1beta2.ManagedClusterSetLister, namespace string) *Resolver {
+	return &Resolver{
+		hub:              hub,
+		clusterSetLister: clusterSetLister,
+		namespace:        namespace,
+		cache:            map[string]*cachedClients{},
+	}
+}
+
+// ForClusterSet returns the clients of the cluster hosting the broker of the given cluster set. If the cluster set doesn't
+// exist anymore, the clients last returned for it are, so that its broker resources can still be cleaned up.
+func (r *Resolver) ForClusterSet(ctx context.Context, clusterSetName string) (*Clients, error) {
+	if clusterSetName == "" {
+		return r.hub, nil
+	}
+
+	clusterSet, err := r.clusterSetLister.Get(clusterSetName)
+	if apierrors.IsNotFound(err) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		if cached := r.cache[clusterSetName]; cached != nil {
+			return cached.clients, nil
+		}
+
+		return r.hub, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving ManagedClusterSet %q", clusterSetName)
+	}
+
+	clients, err := r.forClusterSet(ctx, clusterSet)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.withOverrides(ctx, clusterSet, clients)
+}
+
+func (r *Resolver) forClusterSet(ctx context.Context, clusterSet *clusterv1beta2.ManagedClusterSet) (*Clients, error) {
+	secretName := clusterSet.Annotations[ExternalBrokerAnnotation]
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if secretName == "" {
+		delete(r.cache, clusterSet.Name)
+
+		return r.hub, nil
+	}
+
+	secret, err := r.hub.KubeClient.CoreV1().Secrets(r.namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving the broker kubeconfig Secret %q of ManagedClusterSet %q", secretName,
+			clusterSet.Name)
+	}
+
+	cached := r.cache[clusterSet.Name]
+	if cached != nil && cached.secretName == secretName && cached.resourceVersion == secret.ResourceVersion {
+		return cached.clients, nil
+	}
+
+	config, err := clientcmd.RESTConfigFromKubeConfig(secret.Data[KubeConfigSecretKey])
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing the broker kubeconfig Secret %q of ManagedClusterSet %q", secretName,
+			clusterSet.Name)
+	}
+
+	host, err := apiServerHost(config.Host)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid server in the broker kubeconfig Secret %q of ManagedClusterSet %q", secretName,
+			clusterSet.Name)
+	}
+
+	clients, err := NewClientsForConfig(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating the broker clients of ManagedClusterSet %q", clusterSet.Name)
+	}
+
+	clients.APIServer = host
+	clients.External = true
+
+	r.cache[clusterSet.Name] = &cachedClients{
+		secretName:      secretName,
+		resourceVersion: secret.ResourceVersion,
+		clients:         clients,
+	}
+
+	logger.Infof("Using the external broker cluster %q for ManagedClusterSet %q", clients.APIServer, clusterSet.Name)
+
+	return clients, nil
+}
+
+// withOverrides applies the broker API server and CA overrides of the given cluster set to a copy of the given clients.
+func (r *Resolver) withOverrides(ctx context.Context, clusterSet *clusterv1beta2.ManagedClusterSet, clients *Clients,
+) (*Clients, error) {
+	apiServer := clusterSet.Annotations[BrokerAPIServerAnnotation]
+	configMapName := clusterSet.Annotations[BrokerCAAnnotation]
+
+	if apiServer == "" && configMapName == "" {
+		return clients, nil
+	}
+
+	overridden := *clients
+
+	if apiServer != "" {
+		host, err := apiServerHost(apiServer)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid broker API server of ManagedClusterSet %q", clusterSet.Name)
+		}
+
+		overridden.APIServer = host
+	}
+
+	if configMapName != "" {
+		configMap, err := r.hub.KubeClient.CoreV1().ConfigMaps(r.namespace).Get(ctx, configMapName, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "error retrieving the broker CA ConfigMap %q of ManagedClusterSet %q", configMapName,
+				clusterSet.Name)
+		}
+
+		ca := configMap.Data[CABundleConfigMapKey]
+		if ca == "" {
+			return nil, errors.Errorf("the broker CA ConfigMap %q of ManagedClusterSet %q has no %q", configMapName,
+				clusterSet.Name, CABundleConfigMapKey)
+		}
+
+		overridden.CA = []byte(ca)
+	}
+
+	return &overridden, nil
+}
+
+// apiServerHost returns the host and port of the given API server, given either as a URL or as a host and port.
+func apiServerHost(apiServer string) (string, error) {
+	if !strings.Contains(apiServer, "://") {
+		apiServer = "https://" + apiServer
+	}
+
+	serverURL, err := url.Parse(apiServer)
+	if err != nil {
+		return "", errors.Wrapf(err, "error parsing %q", apiServer)
+	}
+
+	if serverURL.Host == "" {
+		return "", errors.Errorf("no host in %q", apiServer)
+	}
+
+	return serverURL.Host, nil
+}
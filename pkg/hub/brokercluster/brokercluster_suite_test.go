@@ -0,0 +1,13 @@
+package brokercluster_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestBrokerCluster(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Broker Cluster Suite")
+}
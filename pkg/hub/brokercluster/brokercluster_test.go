@@ -0,0 +1,267 @@
+package brokercluster_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+)
+
+const (
+	namespace       = "open-cluster-management"
+	clusterSetName  = "east"
+	secretName      = "east-broker-kubeconfig"
+	caConfigMapName = "east-broker-ca"
+	caBundle        = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	kubeConfig      = `apiVersion: v1
+kind: Config
+clusters:
+- name: broker
+  cluster:
+    server: https://api.broker.example.com:6443
+contexts:
+- name: broker
+  context:
+    cluster: broker
+    user: broker
+current-context: broker
+users:
+- name: broker
+  user:
+    token: broker-token
+`
+)
+
+var _ = Describe("Resolver", func() {
+	var (
+		hub            *brokercluster.Clients
+		hubKubeClient  *kubefake.Clientset
+		clusterSet     *clusterv1beta2.ManagedClusterSet
+		clusterSets    cache.Indexer
+		resolver       *brokercluster.Resolver
+		createdConfigs []*rest.Config
+	)
+
+	BeforeEach(func() {
+		hubKubeClient = kubefake.NewClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            secretName,
+				Namespace:       namespace,
+				ResourceVersion: "1",
+			},
+			Data: map[string][]byte{
+				brokercluster.KubeConfigSecretKey: []byte(kubeConfig),
+			},
+		})
+
+		hub = &brokercluster.Clients{KubeClient: hubKubeClient}
+
+		clusterSet = &clusterv1beta2.ManagedClusterSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: clusterSetName,
+			},
+		}
+
+		clusterSets = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		createdConfigs = nil
+
+		newClientsForConfig := brokercluster.NewClientsForConfig
+		brokercluster.NewClientsForConfig = func(config *rest.Config) (*brokercluster.Clients, error) {
+			createdConfigs = append(createdConfigs, config)
+
+			return &brokercluster.Clients{KubeClient: kubefake.NewClientset()}, nil
+		}
+
+		DeferCleanup(func() {
+			brokercluster.NewClientsForConfig = newClientsForConfig
+		})
+
+		resolver = brokercluster.NewResolver(hub, clusterlisterv1beta2.NewManagedClusterSetLister(clusterSets), namespace)
+	})
+
+	JustBeforeEach(func() {
+		Expect(clusterSets.Add(clusterSet)).To(Succeed())
+	})
+
+	When("the ManagedClusterSet has no external broker", func() {
+		It("should return the hub clients", func(ctx context.Context) {
+			clients, err := resolver.ForClusterSet(ctx, clusterSetName)
+			Expect(err).To(Succeed())
+			Expect(clients).To(BeIdenticalTo(hub))
+			Expect(clients.External).To(BeFalse())
+		})
+	})
+
+	When("the ManagedClusterSet overrides the broker API server and CA", func() {
+		BeforeEach(func() {
+			clusterSet.Annotations = map[string]string{
+				brokercluster.BrokerAPIServerAnnotation: "https://broker.internal.example.com:6443/",
+				brokercluster.BrokerCAAnnotation:        caConfigMapName,
+			}
+
+			_, err := hubKubeClient.CoreV1().ConfigMaps(namespace).Create(context.Background(), &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      caConfigMapName,
+					Namespace: namespace,
+				},
+				Data: map[string]string{
+					brokercluster.CABundleConfigMapKey: caBundle,
+				},
+			}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+		})
+
+		It("should return the hub clients with the overrides", func(ctx context.Context) {
+			clients, err := resolver.ForClusterSet(ctx, clusterSetName)
+			Expect(err).To(Succeed())
+			Expect(clients).ToNot(BeIdenticalTo(hub))
+			Expect(clients.KubeClient).To(BeIdenticalTo(hub.KubeClient))
+			Expect(clients.External).To(BeFalse())
+			Expect(clients.APIServer).To(Equal("broker.internal.example.com:6443"))
+			Expect(string(clients.CA)).To(Equal(caBundle))
+			Expect(hub.APIServer).To(BeEmpty())
+			Expect(hub.CA).To(BeEmpty())
+		})
+
+		Context("and the CA ConfigMap doesn't exist", func() {
+			BeforeEach(func() {
+				clusterSet.Annotations[brokercluster.BrokerCAAnnotation] = "missing"
+			})
+
+			It("should return an error", func(ctx context.Context) {
+				_, err := resolver.ForClusterSet(ctx, clusterSetName)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("and the CA ConfigMap has no CA bundle", func() {
+			BeforeEach(func() {
+				_, err := hubKubeClient.CoreV1().ConfigMaps(namespace).Update(context.Background(), &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      caConfigMapName,
+						Namespace: namespace,
+					},
+				}, metav1.UpdateOptions{})
+				Expect(err).To(Succeed())
+			})
+
+			It("should return an error", func(ctx context.Context) {
+				_, err := resolver.ForClusterSet(ctx, clusterSetName)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	When("no ManagedClusterSet is given", func() {
+		It("should return the hub clients", func(ctx context.Context) {
+			clients, err := resolver.ForClusterSet(ctx, "")
+			Expect(err).To(Succeed())
+			Expect(clients).To(BeIdenticalTo(hub))
+		})
+	})
+
+	When("the ManagedClusterSet has an external broker", func() {
+		BeforeEach(func() {
+			clusterSet.Annotations = map[string]string{brokercluster.ExternalBrokerAnnotation: secretName}
+		})
+
+		It("should return the clients of the external cluster", func(ctx context.Context) {
+			clients, err := resolver.ForClusterSet(ctx, clusterSetName)
+			Expect(err).To(Succeed())
+			Expect(clients).ToNot(BeIdenticalTo(hub))
+			Expect(clients.External).To(BeTrue())
+			Expect(clients.APIServer).To(Equal("api.broker.example.com:6443"))
+			Expect(createdConfigs).To(HaveLen(1))
+			Expect(createdConfigs[0].BearerToken).To(Equal("broker-token"))
+		})
+
+		It("should reuse the clients while the kubeconfig Secret is unchanged", func(ctx context.Context) {
+			first, err := resolver.ForClusterSet(ctx, clusterSetName)
+			Expect(err).To(Succeed())
+
+			second, err := resolver.ForClusterSet(ctx, clusterSetName)
+			Expect(err).To(Succeed())
+			Expect(second).To(BeIdenticalTo(first))
+			Expect(createdConfigs).To(HaveLen(1))
+		})
+
+		It("should recreate the clients when the kubeconfig Secret changes", func(ctx context.Context) {
+			_, err := resolver.ForClusterSet(ctx, clusterSetName)
+			Expect(err).To(Succeed())
+
+			secret, err := hubKubeClient.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+			Expect(err).To(Succeed())
+
+			secret.ResourceVersion = "2"
+			_, err = hubKubeClient.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
+			Expect(err).To(Succeed())
+
+			_, err = resolver.ForClusterSet(ctx, clusterSetName)
+			Expect(err).To(Succeed())
+			Expect(createdConfigs).To(HaveLen(2))
+		})
+
+		It("should return the last clients once the ManagedClusterSet is deleted", func(ctx context.Context) {
+			clients, err := resolver.ForClusterSet(ctx, clusterSetName)
+			Expect(err).To(Succeed())
+
+			Expect(clusterSets.Delete(clusterSet)).To(Succeed())
+
+			afterDeletion, err := resolver.ForClusterSet(ctx, clusterSetName)
+			Expect(err).To(Succeed())
+			Expect(afterDeletion).To(BeIdenticalTo(clients))
+		})
+
+		Context("and a broker API server override", func() {
+			BeforeEach(func() {
+				clusterSet.Annotations[brokercluster.BrokerAPIServerAnnotation] = "broker-lb.example.com:443"
+			})
+
+			It("should return the overridden API server", func(ctx context.Context) {
+				clients, err := resolver.ForClusterSet(ctx, clusterSetName)
+				Expect(err).To(Succeed())
+				Expect(clients.External).To(BeTrue())
+				Expect(clients.APIServer).To(Equal("broker-lb.example.com:443"))
+			})
+		})
+
+		Context("and the kubeconfig Secret doesn't exist", func() {
+			BeforeEach(func() {
+				clusterSet.Annotations[brokercluster.ExternalBrokerAnnotation] = "missing"
+			})
+
+			It("should return an error", func(ctx context.Context) {
+				_, err := resolver.ForClusterSet(ctx, clusterSetName)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("and the kubeconfig Secret is invalid", func() {
+			BeforeEach(func() {
+				_, err := hubKubeClient.CoreV1().Secrets(namespace).Update(context.Background(), &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      secretName,
+						Namespace: namespace,
+					},
+					Data: map[string][]byte{
+						brokercluster.KubeConfigSecretKey: []byte("not a kubeconfig"),
+					},
+				}, metav1.UpdateOptions{})
+				Expect(err).To(Succeed())
+			})
+
+			It("should return an error", func(ctx context.Context) {
+				_, err := resolver.ForClusterSet(ctx, clusterSetName)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -11,9 +11,12 @@ import (
 	"github.com/spf13/cobra"
 	configclient "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/clientset/versioned"
 	configinformers "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/informers/externalversions"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
 	"github.com/stolostron/submariner-addon/pkg/hub/submarineraddonagent"
 	"github.com/stolostron/submariner-addon/pkg/hub/submarineragent"
 	"github.com/stolostron/submariner-addon/pkg/hub/submarinerbroker"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarinerglobalnet"
+	"github.com/stolostron/submariner-addon/pkg/redact"
 	"github.com/stolostron/submariner-addon/pkg/resource"
 	submarinerv1alpha1 "github.com/submariner-io/submariner-operator/api/v1alpha1"
 	submarinerv1 "github.com/submariner-io/submariner/pkg/apis/submariner.io/v1"
@@ -46,6 +49,8 @@ const (
 	containerName                = "submariner-addon"
 	DefaultNamespace             = "open-cluster-management"
 	accessToBrokerCRDClusterRole = "access-to-brokers-submariner-crd"
+
+	defaultGlobalnetQuarantinePeriod = 24 * time.Hour
 )
 
 type Clients struct {
@@ -60,12 +65,18 @@ type Clients struct {
 }
 
 type AddOnOptions struct {
-	AgentImage    string
-	EventRecorder events.Recorder // Optional: for test injection
+	AgentImage                string
+	GlobalnetQuarantinePeriod time.Duration
+	RestoreAware              bool
+	AddOnFrameworkDeployment  bool
+	RedactFields              []string
+	EventRecorder             events.Recorder // Optional: for test injection
 }
 
 func NewAddOnOptions() *AddOnOptions {
-	return &AddOnOptions{}
+	return &AddOnOptions{
+		GlobalnetQuarantinePeriod: defaultGlobalnetQuarantinePeriod,
+	}
 }
 
 func (o *AddOnOptions) AddFlags(cmd *cobra.Command) {
@@ -73,6 +84,14 @@ func (o *AddOnOptions) AddFlags(cmd *cobra.Command) {
 	// TODO if downstream building supports to set downstream image, we could use this flag
 	// to set agent image on building phase
 	flags.StringVar(&o.AgentImage, "agent-image", o.AgentImage, "The image of addon agent.")
+	flags.DurationVar(&o.GlobalnetQuarantinePeriod, "globalnet-quarantine-period", o.GlobalnetQuarantinePeriod,
+		"The period during which the global CIDRs of a cluster leaving its cluster set are not reused.")
+	flags.BoolVar(&o.RestoreAware, "restore-aware", o.RestoreAware,
+		"Wait for the IPsec PSKs and globalnet allocations of the cluster sets to be restored from a backup instead of regenerating them.")
+	flags.BoolVar(&o.AddOnFrameworkDeployment, "addon-framework-deployment", o.AddOnFrameworkDeployment,
+		"Deploy the submariner operator and Submariner resource through the add-on framework along with the add-on agent.")
+	flags.StringSliceVar(&o.RedactFields, "redact-fields", o.RedactFields,
+		"Additional JSONPath-like selectors of the fields redacted from the logged manifests, e.g. \"..apiKey\" or \"spec.remote.token\".")
 }
 
 func (o *AddOnOptions) Complete(ctx context.Context, kubeClient kubernetes.Interface) error {
@@ -111,6 +130,10 @@ func (o *AddOnOptions) RunControllerManager(ctx context.Context, kubeConfig *res
 	utilruntime.Must(submarinerv1.AddToScheme(scheme.Scheme))
 	utilruntime.Must(mcsv1a1.Install(scheme.Scheme))
 
+	if err := redact.SetFields(o.RedactFields...); err != nil {
+		return errors.Wrap(err, "invalid redacted fields")
+	}
+
 	clients, err := newClients(kubeConfig)
 	if err != nil {
 		return err
@@ -156,18 +179,35 @@ func (o *AddOnOptions) RunControllerManager(ctx context.Context, kubeConfig *res
 		return err
 	}
 
-	submarinerBrokerController := submarinerbroker.NewController(clients.kubeClient,
+	brokerClusters := brokercluster.NewResolver(&brokercluster.Clients{
+		KubeClient:       clients.kubeClient,
+		DynamicClient:    clients.dynamicClient,
+		ControllerClient: clients.controllerClient,
+		CRDClient:        clients.apiExtensionClient,
+	}, clusterInformers.Cluster().V1beta2().ManagedClusterSets().Lister(), resource.GetCurrentNamespace(DefaultNamespace))
+
+	submarinerBrokerController := submarinerbroker.NewController(brokerClusters,
 		clients.clusterClient.ClusterV1beta2().ManagedClusterSets(),
 		clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
 		clients.addOnClient,
 		addOnInformers.Addon().V1beta1(),
 		kubeConfig,
+		o.RestoreAware,
 		eventRecorder)
 
+	// In add-on framework mode, the agent controller renders the manifests served by the add-on agent.
+	var (
+		agentManifests  *submarineragent.AgentManifests
+		servedManifests submarineraddonagent.AgentManifests
+	)
+
+	if o.AddOnFrameworkDeployment {
+		agentManifests = submarineragent.NewAgentManifests(clients.kubeClient)
+		servedManifests = agentManifests
+	}
+
 	submarinerAgentController := submarineragent.NewSubmarinerAgentController(
-		clients.kubeClient,
-		clients.dynamicClient,
-		clients.controllerClient,
+		brokerClusters,
 		clients.clusterClient,
 		clients.workClient,
 		clients.configClient,
@@ -179,6 +219,18 @@ func (o *AddOnOptions) RunControllerManager(ctx context.Context, kubeConfig *res
 		addOnInformers.Addon().V1beta1().ClusterManagementAddOns(),
 		addOnInformers.Addon().V1beta1().ManagedClusterAddOns(),
 		addOnInformers.Addon().V1beta1().AddOnDeploymentConfigs(),
+		agentManifests,
+		o.RestoreAware,
+		eventRecorder,
+	)
+
+	submarinerGlobalnetController := submarinerglobalnet.NewController(
+		clients.clusterClient.ClusterV1beta2().ManagedClusterSets(),
+		clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		configInformers.Submarineraddon().V1alpha1().SubmarinerConfigs(),
+		brokerClusters,
+		o.GlobalnetQuarantinePeriod,
 		eventRecorder,
 	)
 
@@ -210,6 +262,7 @@ func (o *AddOnOptions) RunControllerManager(ctx context.Context, kubeConfig *res
 	go submarinerBrokerCRDsController.Run(ctx, 1)
 	go submarinerBrokerController.Run(ctx, 1)
 	go submarinerAgentController.Run(ctx, 1)
+	go submarinerGlobalnetController.Run(ctx, 1)
 
 	mgr, err := addonmanager.New(kubeConfig)
 	if err != nil {
@@ -217,7 +270,7 @@ func (o *AddOnOptions) RunControllerManager(ctx context.Context, kubeConfig *res
 	}
 
 	agent, err := submarineraddonagent.NewAddOnAgent(clients.kubeClient, clients.clusterClient, clients.addOnClient,
-		eventRecorder, o.AgentImage)
+		eventRecorder, o.AgentImage, servedManifests)
 	if err != nil {
 		return errors.Wrap(err, "error creating addon agent")
 	}
@@ -0,0 +1,245 @@
+package status
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	configclient "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/clientset/versioned"
+	"github.com/stolostron/submariner-addon/pkg/constants"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarinerbroker"
+	"github.com/submariner-io/submariner-operator/pkg/discovery/globalnet"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
+	clusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+)
+
+const (
+	submarinerAgentDegraded       = "SubmarinerAgentDegraded"
+	submarinerConnectionDegraded  = "SubmarinerConnectionDegraded"
+	routeAgentConnectionDegraded  = "RouteAgentConnectionDegraded"
+	submarinerGatewayNodesLabeled = "SubmarinerGatewayNodesLabeled"
+	connectionNotEstablished      = "is not established"
+	connectionEstablished         = "is established"
+)
+
+var gatewayNodesRegex = regexp.MustCompile(`^The nodes "([^"]*)" are labeled`)
+
+// ClusterSetStatus is the status of the submariner deployments of the managed clusters in a cluster set.
+type ClusterSetStatus struct {
+	Name     string          `json:"name"`
+	Clusters []ClusterStatus `json:"clusters"`
+}
+
+// ClusterStatus is the status of the submariner deployment of a managed cluster, as reported on the hub. The condition
+// fields hold the status of the corresponding condition, empty if it isn't reported.
+type ClusterStatus struct {
+	Name                         string             `json:"name"`
+	AgentDegraded                string             `json:"agentDegraded,omitempty"`
+	ConnectionDegraded           string             `json:"connectionDegraded,omitempty"`
+	RouteAgentConnectionDegraded string             `json:"routeAgentConnectionDegraded,omitempty"`
+	GatewayNodesLabeled          string             `json:"gatewayNodesLabeled,omitempty"`
+	ClusterEnvPrepared           string             `json:"clusterEnvPrepared,omitempty"`
+	GatewayNodes                 []string           `json:"gatewayNodes,omitempty"`
+	GlobalCIDR                   string             `json:"globalCIDR,omitempty"`
+	Connections                  ConnectionsSummary `json:"connections"`
+}
+
+// ConnectionsSummary summarizes the connections of the gateways of a managed cluster to the other clusters of its cluster set.
+type ConnectionsSummary struct {
+	Established int `json:"established"`
+	Total       int `json:"total"`
+}
+
+type Clients struct {
+	ClusterClient  clusterclient.Interface
+	AddOnClient    addonclient.Interface
+	ConfigClient   configclient.Interface
+	BrokerClusters *brokercluster.Resolver
+}
+
+// Get returns the status of the submariner deployments on the hub, per cluster set and sorted by name. Only the managed
+// clusters with the submariner addon are included; if clusterSetName isn't empty, only those in that cluster set.
+func Get(ctx context.Context, clients *Clients, clusterSetName string) ([]ClusterSetStatus, error) {
+	addOns, err := clients.AddOnClient.AddonV1beta1().ManagedClusterAddOns(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", constants.SubmarinerAddOnName).String(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing the submariner ManagedClusterAddOns")
+	}
+
+	clusterSets := map[string]*ClusterSetStatus{}
+	globalCIDRs := map[string]map[string]string{}
+
+	for i := range addOns.Items {
+		addOn := &addOns.Items[i]
+		if addOn.Name != constants.SubmarinerAddOnName {
+			continue
+		}
+
+		managedCluster, err := clients.ClusterClient.ClusterV1().ManagedClusters().Get(ctx, addOn.Namespace, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "error retrieving ManagedCluster %q", addOn.Namespace)
+		}
+
+		setName := managedCluster.Labels[clusterv1beta2.ClusterSetLabel]
+		if clusterSetName != "" && setName != clusterSetName {
+			continue
+		}
+
+		cluster := ClusterStatus{
+			Name:                         managedCluster.Name,
+			AgentDegraded:                conditionStatus(addOn.Status.Conditions, submarinerAgentDegraded),
+			ConnectionDegraded:           conditionStatus(addOn.Status.Conditions, submarinerConnectionDegraded),
+			RouteAgentConnectionDegraded: conditionStatus(addOn.Status.Conditions, routeAgentConnectionDegraded),
+			GatewayNodesLabeled:          conditionStatus(addOn.Status.Conditions, submarinerGatewayNodesLabeled),
+			GatewayNodes:                 gatewayNodes(addOn.Status.Conditions),
+			Connections:                  connectionsSummary(addOn.Status.Conditions),
+		}
+
+		config, err := clients.ConfigClient.SubmarineraddonV1alpha1().SubmarinerConfigs(managedCluster.Name).Get(ctx,
+			constants.SubmarinerConfigName, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "error retrieving the SubmarinerConfig of cluster %q", managedCluster.Name)
+		}
+
+		if err == nil {
+			cluster.ClusterEnvPrepared = conditionStatus(config.Status.Conditions, configv1alpha1.SubmarinerConfigConditionEnvPrepared)
+		}
+
+		if _, found := globalCIDRs[setName]; !found {
+			globalCIDRs[setName], err = allocatedGlobalCIDRs(ctx, clients, setName)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		cluster.GlobalCIDR = globalCIDRs[setName][managedCluster.Name]
+
+		if clusterSets[setName] == nil {
+			clusterSets[setName] = &ClusterSetStatus{Name: setName}
+		}
+
+		clusterSets[setName].Clusters = append(clusterSets[setName].Clusters, cluster)
+	}
+
+	statuses := make([]ClusterSetStatus, 0, len(clusterSets))
+
+	for _, clusterSet := range clusterSets {
+		sort.Slice(clusterSet.Clusters, func(i, j int) bool {
+			return clusterSet.Clusters[i].Name < clusterSet.Clusters[j].Name
+		})
+
+		statuses = append(statuses, *clusterSet)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses, nil
+}
+
+func conditionStatus(conditions []metav1.Condition, conditionType string) string {
+	condition := meta.FindStatusCondition(conditions, conditionType)
+	if condition == nil {
+		return ""
+	}
+
+	return string(condition.Status)
+}
+
+// gatewayNodes returns the gateway nodes listed in the message of the SubmarinerGatewayNodesLabeled condition.
+func gatewayNodes(conditions []metav1.Condition) []string {
+	condition := meta.FindStatusCondition(conditions, submarinerGatewayNodesLabeled)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return nil
+	}
+
+	match := gatewayNodesRegex.FindStringSubmatch(condition.Message)
+	if match == nil || match[1] == "" {
+		return nil
+	}
+
+	return strings.Split(match[1], ",")
+}
+
+// connectionsSummary counts the connections listed in the message of the SubmarinerConnectionDegraded condition, one per
+// line.
+func connectionsSummary(conditions []metav1.Condition) ConnectionsSummary {
+	summary := ConnectionsSummary{}
+
+	condition := meta.FindStatusCondition(conditions, submarinerConnectionDegraded)
+	if condition == nil {
+		return summary
+	}
+
+	for _, line := range strings.Split(condition.Message, "\n") {
+		switch {
+		case strings.Contains(line, connectionNotEstablished):
+			summary.Total++
+		case strings.Contains(line, connectionEstablished):
+			summary.Established++
+			summary.Total++
+		}
+	}
+
+	return summary
+}
+
+// allocatedGlobalCIDRs returns the global CIDRs allocated to the managed clusters of the given cluster set, as recorded
+// in the globalnet ConfigMap of its broker, keyed by cluster name. It's empty if globalnet isn't enabled or the broker
+// isn't set up yet.
+func allocatedGlobalCIDRs(ctx context.Context, clients *Clients, clusterSetName string) (map[string]string, error) {
+	if clusterSetName == "" {
+		return map[string]string{}, nil
+	}
+
+	clusterSet, err := clients.ClusterClient.ClusterV1beta2().ManagedClusterSets().Get(ctx, clusterSetName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return map[string]string{}, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving ManagedClusterSet %q", clusterSetName)
+	}
+
+	brokerNamespace := submarinerbroker.GetBrokerNamespace(clusterSet)
+	if brokerNamespace == "" {
+		return map[string]string{}, nil
+	}
+
+	broker, err := clients.BrokerClusters.ForClusterSet(ctx, clusterSetName)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap here
+	}
+
+	gnInfo, _, err := globalnet.GetGlobalNetworks(ctx, broker.ControllerClient, brokerNamespace)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, errors.Wrapf(err, "error reading globalnet configmap from namespace %q", brokerNamespace)
+	}
+
+	if gnInfo == nil || !gnInfo.Enabled {
+		return map[string]string{}, nil
+	}
+
+	cidrs := make(map[string]string, len(gnInfo.CidrInfo))
+
+	for cluster, network := range gnInfo.CidrInfo {
+		cidrs[cluster] = strings.Join(network.GlobalCIDRs, ",")
+	}
+
+	return cidrs, nil
+}
@@ -0,0 +1,170 @@
+package status_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	fakeconfigclient "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/clientset/versioned/fake"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
+	"github.com/stolostron/submariner-addon/pkg/hub/status"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarinerbroker"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/submariner-operator/pkg/discovery/globalnet"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
+	addonfake "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const brokerNamespace = "set1-broker"
+
+var _ = Describe("Get", func() {
+	var (
+		clusters []runtime.Object
+		addOns   []runtime.Object
+		configs  []runtime.Object
+		statuses []status.ClusterSetStatus
+	)
+
+	BeforeEach(func() {
+		clusters = []runtime.Object{
+			newManagedCluster("east", "set1"), newManagedCluster("west", "set1"), newManagedCluster("north", "set2"),
+		}
+
+		addOns = []runtime.Object{
+			newAddOn("west", metav1.Condition{
+				Type:    "SubmarinerConnectionDegraded",
+				Status:  metav1.ConditionTrue,
+				Message: "The connection between clusters \"west\" and \"east\" is not established (status=error)",
+			}),
+			newAddOn("east",
+				metav1.Condition{Type: "SubmarinerAgentDegraded", Status: metav1.ConditionFalse},
+				metav1.Condition{
+					Type:    "SubmarinerGatewayNodesLabeled",
+					Status:  metav1.ConditionTrue,
+					Message: "The nodes \"node-1,node-2\" are labeled with \"submariner.io/gateway\"",
+				},
+				metav1.Condition{
+					Type:   "SubmarinerConnectionDegraded",
+					Status: metav1.ConditionTrue,
+					Message: "The connection between clusters \"east\" and \"north\" is established\n" +
+						"The connection between clusters \"east\" and \"west\" is not established (status=error)",
+				}),
+			newAddOn("north"),
+		}
+
+		configs = []runtime.Object{&configv1alpha1.SubmarinerConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "submariner", Namespace: "east"},
+			Status: configv1alpha1.SubmarinerConfigStatus{
+				Conditions: []metav1.Condition{{
+					Type:   configv1alpha1.SubmarinerConfigConditionEnvPrepared,
+					Status: metav1.ConditionTrue,
+				}},
+			},
+		}}
+	})
+
+	get := func(clusterSetName string) {
+		ctx, stop := context.WithCancel(context.Background())
+		defer stop()
+
+		clusterClient := clusterfake.NewSimpleClientset(append(clusters,
+			&clusterv1beta2.ManagedClusterSet{ObjectMeta: metav1.ObjectMeta{
+				Name:        "set1",
+				Annotations: map[string]string{submarinerbroker.SubmBrokerNamespaceKey: brokerNamespace},
+			}},
+			&clusterv1beta2.ManagedClusterSet{ObjectMeta: metav1.ObjectMeta{Name: "set2"}})...)
+
+		// The global CIDR of east is allocated in the globalnet ConfigMap of the broker of set1
+		controllerClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+
+		configMap, err := globalnet.NewGlobalnetConfigMap(true, "242.0.0.0/8", 65536, brokerNamespace)
+		Expect(err).To(Succeed())
+		Expect(controllerClient.Create(ctx, configMap)).To(Succeed())
+		Expect(globalnet.AllocateAndUpdateGlobalCIDRConfigMap(ctx, controllerClient, brokerNamespace,
+			&globalnet.Config{ClusterID: "east", GlobalCIDR: "242.0.0.0/16"}, reporter.Silent())).To(Succeed())
+
+		clusterInformers := clusterinformers.NewSharedInformerFactory(clusterClient, 0)
+		clusterSets := clusterInformers.Cluster().V1beta2().ManagedClusterSets()
+		clusterSetLister := clusterSets.Lister()
+
+		clusterInformers.Start(ctx.Done())
+		Expect(cache.WaitForCacheSync(ctx.Done(), clusterSets.Informer().HasSynced)).To(BeTrue())
+
+		statuses, err = status.Get(ctx, &status.Clients{
+			ClusterClient:  clusterClient,
+			AddOnClient:    addonfake.NewSimpleClientset(addOns...),
+			ConfigClient:   fakeconfigclient.NewSimpleClientset(configs...),
+			BrokerClusters: brokercluster.NewResolver(&brokercluster.Clients{ControllerClient: controllerClient}, clusterSetLister, ""),
+		}, clusterSetName)
+		Expect(err).To(Succeed())
+	}
+
+	It("should return the status of each cluster, per cluster set", func() {
+		get("")
+
+		Expect(statuses).To(HaveLen(2))
+		Expect(statuses[0].Name).To(Equal("set1"))
+		Expect(statuses[0].Clusters).To(HaveLen(2))
+		Expect(statuses[0].Clusters[0]).To(Equal(status.ClusterStatus{
+			Name:                "east",
+			AgentDegraded:       "False",
+			ConnectionDegraded:  "True",
+			GatewayNodesLabeled: "True",
+			ClusterEnvPrepared:  "True",
+			GatewayNodes:        []string{"node-1", "node-2"},
+			GlobalCIDR:          "242.0.0.0/16",
+			Connections:         status.ConnectionsSummary{Established: 1, Total: 2},
+		}))
+		Expect(statuses[0].Clusters[1].Name).To(Equal("west"))
+		Expect(statuses[0].Clusters[1].Connections).To(Equal(status.ConnectionsSummary{Established: 0, Total: 1}))
+		Expect(statuses[1].Name).To(Equal("set2"))
+		Expect(statuses[1].Clusters).To(Equal([]status.ClusterStatus{{Name: "north"}}))
+	})
+
+	When("a cluster set is specified", func() {
+		It("should only return its status", func() {
+			get("set2")
+
+			Expect(statuses).To(HaveLen(1))
+			Expect(statuses[0].Name).To(Equal("set2"))
+		})
+	})
+
+	When("a managed cluster doesn't have the submariner addon", func() {
+		BeforeEach(func() {
+			clusters = append(clusters, newManagedCluster("south", "set2"))
+		})
+
+		It("should not return its status", func() {
+			get("set2")
+
+			Expect(statuses[0].Clusters).To(HaveLen(1))
+		})
+	})
+})
+
+func newManagedCluster(name, clusterSet string) *clusterv1.ManagedCluster {
+	return &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{clusterv1beta2.ClusterSetLabel: clusterSet},
+		},
+	}
+}
+
+func newAddOn(clusterName string, conditions ...metav1.Condition) *addonv1beta1.ManagedClusterAddOn {
+	return &addonv1beta1.ManagedClusterAddOn{
+		ObjectMeta: metav1.ObjectMeta{Name: "submariner", Namespace: clusterName},
+		Status:     addonv1beta1.ManagedClusterAddOnStatus{Conditions: conditions},
+	}
+}
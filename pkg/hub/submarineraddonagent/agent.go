@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/ghodss/yaml"
 	"github.com/openshift/library-go/pkg/assets"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
@@ -15,6 +16,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
@@ -25,12 +27,14 @@ import (
 	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
 	clusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
 )
 
 const (
 	agentName                  = "submariner-addon-agent"
 	selfManagedClusterLabelKey = "local-cluster"
 	clusterAddOnGroup          = "system:open-cluster-management:cluster:%s:addon:submariner"
+	preDeleteHookFile          = "manifests/hook/pre-delete-job.yaml"
 )
 
 var agentHubPermissionFiles = []string{
@@ -41,27 +45,37 @@ var agentHubPermissionFiles = []string{
 //go:embed manifests
 var manifestFiles embed.FS
 
+// AgentManifests provides the submariner operator and Submariner resource manifests rendered for the managed clusters.
+type AgentManifests interface {
+	// Get returns the manifests rendered for the given managed cluster, or false if they were never rendered.
+	Get(ctx context.Context, clusterName string) ([]workv1.Manifest, bool, error)
+}
+
 // addOnAgent monitors the Submariner agent status and configure Submariner cluster environment on the managed cluster.
 type addOnAgent struct {
 	agent.AgentAddon
-	kubeClient    kubernetes.Interface
-	clusterClient clusterclient.Interface
-	recorder      events.Recorder
-	agentImage    string
-	hubHost       string
-	resourceCache resourceapply.ResourceCache
+	kubeClient     kubernetes.Interface
+	clusterClient  clusterclient.Interface
+	recorder       events.Recorder
+	agentImage     string
+	hubHost        string
+	resourceCache  resourceapply.ResourceCache
+	agentManifests AgentManifests
 }
 
-// NewAddOnAgent returns an instance of addOnAgent.
+// NewAddOnAgent returns an instance of addOnAgent. If agentManifests is set, the submariner operator and Submariner
+// resource are deployed along with the add-on agent, with a pre-delete hook waiting for the Submariner resource to be
+// removed before the add-on framework removes the operator.
 func NewAddOnAgent(kubeClient kubernetes.Interface, clusterClient clusterclient.Interface,
-	addonClient addonclient.Interface, recorder events.Recorder, agentImage string,
+	addonClient addonclient.Interface, recorder events.Recorder, agentImage string, agentManifests AgentManifests,
 ) (agent.AgentAddon, error) {
 	a := &addOnAgent{
-		kubeClient:    kubeClient,
-		clusterClient: clusterClient,
-		recorder:      recorder,
-		agentImage:    agentImage,
-		resourceCache: resourceapply.NewResourceCache(),
+		kubeClient:     kubeClient,
+		clusterClient:  clusterClient,
+		recorder:       recorder,
+		agentImage:     agentImage,
+		resourceCache:  resourceapply.NewResourceCache(),
+		agentManifests: agentManifests,
 	}
 
 	registrationOption := &agent.RegistrationOption{
@@ -104,12 +118,16 @@ func (a *addOnAgent) Manifests(ctx context.Context, cluster *clusterv1.ManagedCl
 	// specifically add a Namespace object to the returned resources that has the "deletion-orphan" annotation set so the
 	// ManifestWorks doesn't delete the Namespace on uninstall to avoid a race condition where the Submariner operator
 	// pod is deleted before it is able to run cleanup and remove its finalizer from the Submariner resource.
+	installNamespace := addonfactory.AddonDefaultInstallNamespace
+
 	for _, o := range objs {
 		deployment, ok := o.(*appsv1.Deployment)
 		if !ok {
 			continue
 		}
 
+		installNamespace = deployment.Namespace
+
 		if deployment.Namespace != addonfactory.AddonDefaultInstallNamespace {
 			objs = append(objs, &corev1.Namespace{
 				TypeMeta: metav1.TypeMeta{
@@ -126,9 +144,72 @@ func (a *addOnAgent) Manifests(ctx context.Context, cluster *clusterv1.ManagedCl
 		break
 	}
 
+	if a.agentManifests == nil {
+		return objs, nil
+	}
+
+	submarinerObjs, err := a.submarinerManifests(ctx, cluster.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	preDeleteHook, err := a.preDeleteHook(installNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append(objs, submarinerObjs...), preDeleteHook), nil
+}
+
+// submarinerManifests returns the submariner operator and Submariner resource manifests rendered for the given cluster.
+// An error is returned while they were never rendered, so that the framework doesn't remove the deployed ones.
+func (a *addOnAgent) submarinerManifests(ctx context.Context, clusterName string) ([]runtime.Object, error) {
+	manifests, found, err := a.agentManifests.Get(ctx, clusterName)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // No need to wrap
+	}
+
+	if !found {
+		return nil, fmt.Errorf("the submariner manifests of cluster %q aren't rendered yet", clusterName)
+	}
+
+	objs := make([]runtime.Object, 0, len(manifests))
+
+	for i := range manifests {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(manifests[i].Raw); err != nil {
+			return nil, errors.Wrapf(err, "error decoding the submariner manifest %d of cluster %q", i, clusterName)
+		}
+
+		objs = append(objs, obj)
+	}
+
 	return objs, nil
 }
 
+// preDeleteHook returns the Job run by the add-on framework on removal of the add-on, which waits for the Submariner resource
+// to be removed so that the framework keeps the operator deployed until it cleaned up.
+func (a *addOnAgent) preDeleteHook(installNamespace string) (runtime.Object, error) {
+	config := struct {
+		AddonInstallNamespace string
+		Image                 string
+	}{
+		AddonInstallNamespace: installNamespace,
+		Image:                 a.agentImage,
+	}
+
+	template, err := manifestFiles.ReadFile(preDeleteHookFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading manifest file %q", preDeleteHookFile)
+	}
+
+	obj := &unstructured.Unstructured{}
+
+	err = yaml.Unmarshal(assets.MustCreateAssetFromTemplate(preDeleteHookFile, template, config).Data, &obj.Object)
+
+	return obj, errors.Wrap(err, "error decoding the pre-delete hook Job")
+}
+
 func (a *addOnAgent) getValues(cluster *clusterv1.ManagedCluster, _ *addonapiv1beta1.ManagedClusterAddOn) (addonfactory.Values, error) {
 	manifestConfig := struct {
 		Image                string
@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -43,6 +44,7 @@ import (
 	clusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned"
 	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
 )
 
 func TestSubmarinerAddOnAgent(t *testing.T) {
@@ -165,6 +167,86 @@ var _ = Describe("Manifests", func() {
 				t.testManifestsWithADConfig(ctx, adConfig)
 			})
 		})
+
+		Context("with a cloud provider plugin", func() {
+			BeforeEach(func() {
+				adConfig.Spec.CustomizedVariables = []addonapiv1beta1.CustomizedVariable{
+					{Name: "cloudProviderPluginPlatform", Value: "PrivateCloud"},
+					{Name: "cloudProviderPluginImage", Value: "plugin-image"},
+				}
+			})
+
+			It("should return the Deployment with the plugin sidecar", func(ctx context.Context) {
+				objs, err := t.addOnAgent.Manifests(ctx, &clusterv1.ManagedCluster{}, t.newManagedClusterAddOn(ctx, adConfig))
+				Expect(err).To(Succeed())
+
+				podSpec := getDeployment(objs).Spec.Template.Spec
+				Expect(podSpec.Containers).To(HaveLen(2))
+				Expect(podSpec.Containers[0].Args).To(ContainElement(
+					"--cloud-provider-plugin=PrivateCloud=/var/run/plugins/plugin.sock"))
+				Expect(podSpec.Containers[1].Image).To(Equal("plugin-image"))
+				Expect(podSpec.Containers[1].Env).To(ContainElement(corev1.EnvVar{
+					Name:  "PLUGIN_SOCKET",
+					Value: "/var/run/plugins/plugin.sock",
+				}))
+				Expect(podSpec.Volumes).To(ContainElement(HaveField("Name", "plugins")))
+			})
+		})
+	})
+})
+
+var _ = Describe("Manifests with the submariner manifests served through the add-on framework", func() {
+	t := newTestDriver()
+
+	cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: clusterName}}
+
+	BeforeEach(func() {
+		t.agentManifests = fakeAgentManifests{}
+	})
+
+	When("the submariner manifests of the cluster are rendered", func() {
+		BeforeEach(func() {
+			t.agentManifests = fakeAgentManifests{clusterName: {{RawExtension: runtime.RawExtension{
+				Raw: []byte(`{"apiVersion":"submariner.io/v1alpha1","kind":"Submariner","metadata":{"name":"submariner","namespace":"ns"}}`),
+			}}}}
+		})
+
+		It("should return them along with the agent resources", func(ctx context.Context) {
+			objs, err := t.addOnAgent.Manifests(ctx, cluster, &addonapiv1beta1.ManagedClusterAddOn{})
+			Expect(err).To(Succeed())
+
+			getDeployment(objs)
+
+			index := slices.IndexFunc(objs, func(obj runtime.Object) bool {
+				return obj.GetObjectKind().GroupVersionKind().Kind == "Submariner"
+			})
+			Expect(index).To(BeNumerically(">=", 0), "Submariner resource not found")
+			Expect(objs[index].(*unstructured.Unstructured).GetNamespace()).To(Equal("ns"))
+		})
+
+		It("should return the pre-delete hook Job", func(ctx context.Context) {
+			objs, err := t.addOnAgent.Manifests(ctx, cluster, &addonapiv1beta1.ManagedClusterAddOn{})
+			Expect(err).To(Succeed())
+
+			index := slices.IndexFunc(objs, func(obj runtime.Object) bool {
+				return obj.GetObjectKind().GroupVersionKind().Kind == "Job"
+			})
+			Expect(index).To(BeNumerically(">=", 0), "pre-delete hook Job not found")
+
+			job := objs[index].(*unstructured.Unstructured)
+			Expect(job.GetNamespace()).To(Equal(addonfactory.AddonDefaultInstallNamespace))
+			Expect(job.GetAnnotations()).To(HaveKey(addonapiv1beta1.AddonPreDeleteHookAnnotationKey))
+
+			containers, _, _ := unstructured.NestedSlice(job.Object, "spec", "template", "spec", "containers")
+			Expect(containers).To(ContainElement(HaveKeyWithValue("image", agentImage)))
+		})
+	})
+
+	When("the submariner manifests of the cluster aren't rendered yet", func() {
+		It("should return an error", func(ctx context.Context) {
+			_, err := t.addOnAgent.Manifests(ctx, cluster, &addonapiv1beta1.ManagedClusterAddOn{})
+			Expect(err).To(HaveOccurred())
+		})
 	})
 })
 
@@ -295,10 +377,19 @@ var _ = Describe("GetAgentAddonOptions", func() {
 })
 
 type testDriver struct {
-	addOnAgent    agent.AgentAddon
-	kubeClient    kubernetes.Interface
-	clusterClient clusterclient.Interface
-	addOnClient   addonclient.Interface
+	addOnAgent     agent.AgentAddon
+	kubeClient     kubernetes.Interface
+	clusterClient  clusterclient.Interface
+	addOnClient    addonclient.Interface
+	agentManifests submarineraddonagent.AgentManifests
+}
+
+type fakeAgentManifests map[string][]workv1.Manifest
+
+func (f fakeAgentManifests) Get(_ context.Context, clusterName string) ([]workv1.Manifest, bool, error) {
+	manifests, found := f[clusterName]
+
+	return manifests, found, nil
 }
 
 func newTestDriver() *testDriver {
@@ -323,11 +414,14 @@ func newTestDriver() *testDriver {
 			},
 		})
 		t.addOnClient = addonfake.NewSimpleClientset() //nolint:staticcheck // The non-deprecated function is not available
+		t.agentManifests = nil
+	})
 
+	JustBeforeEach(func() {
 		var err error
 
 		t.addOnAgent, err = submarineraddonagent.NewAddOnAgent(t.kubeClient, t.clusterClient, t.addOnClient,
-			events.NewLoggingEventRecorder("test", clock.RealClock{}), agentImage)
+			events.NewLoggingEventRecorder("test", clock.RealClock{}), agentImage, t.agentManifests)
 		Expect(err).NotTo(HaveOccurred())
 	})
 
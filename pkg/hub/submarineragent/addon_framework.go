@@ -0,0 +1,419 @@
+package submarineragent
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/stolostron/submariner-addon/pkg/constants"
+	"github.com/stolostron/submariner-addon/pkg/manifestwork"
+	"github.com/stolostron/submariner-addon/pkg/resource"
+	"github.com/submariner-io/admiral/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	addonconstants "open-cluster-management.io/addon-framework/pkg/addonmanager/constants"
+	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+// AgentManifestsHashAnnotation records on the ManagedClusterAddOn the hash of the submariner manifests served through the
+// add-on framework, so that the framework renders the add-on again when they change.
+const AgentManifestsHashAnnotation = "submarineraddon.open-cluster-management.io/agent-manifests-hash"
+
+// AgentManifestsSecretName is the name of the Secret, in the namespace of each managed cluster on the hub, persisting the
+// manifests served through the add-on framework. It's owned by the ManagedClusterAddOn.
+const AgentManifestsSecretName = "submariner-agent-manifests"
+
+const (
+	submarinerKind     = "Submariner"
+	agentManifestsData = "manifests"
+)
+
+// AgentManifests holds the submariner operator and Submariner resource manifests rendered by the controller for each
+// managed cluster, for the add-on framework to deploy them with the add-on agent. They're persisted in a Secret, as they
+// hold the broker credentials, so that they're still served after a restart until the controller renders them again.
+type AgentManifests struct {
+	mutex      sync.RWMutex
+	manifests  map[string][]workv1.Manifest
+	kubeClient kubernetes.Interface
+}
+
+func NewAgentManifests(kubeClient kubernetes.Interface) *AgentManifests {
+	return &AgentManifests{
+		manifests:  map[string][]workv1.Manifest{},
+		kubeClient: kubeClient,
+	}
+}
+
+// Get returns the manifests rendered for the given managed cluster. They're not found until the controller synced the
+// cluster once, in which case there's nothing to deploy yet.
+func (m *AgentManifests) Get(ctx context.Context, clusterName string) ([]workv1.Manifest, bool, error) {
+	m.mutex.RLock()
+	manifests, found := m.manifests[clusterName]
+	m.mutex.RUnlock()
+
+	if found {
+		return manifests, true, nil
+	}
+
+	secret, err := m.kubeClient.CoreV1().Secrets(clusterName).Get(ctx, AgentManifestsSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "error retrieving the agent manifests Secret of cluster %q", clusterName)
+	}
+
+	if err := json.Unmarshal(secret.Data[agentManifestsData], &manifests); err != nil {
+		return nil, false, errors.Wrapf(err, "error decoding the agent manifests of cluster %q", clusterName)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, found := m.manifests[clusterName]; !found {
+		m.manifests[clusterName] = manifests
+	}
+
+	return m.manifests[clusterName], true, nil
+}
+
+// set persists the manifests of the given managed cluster in a Secret owned by its ManagedClusterAddOn, then stores them.
+func (m *AgentManifests) set(ctx context.Context, addOn *addonv1beta1.ManagedClusterAddOn, manifests []workv1.Manifest) error {
+	data, err := json.Marshal(manifests)
+	if err != nil {
+		return errors.Wrapf(err, "error encoding the agent manifests of cluster %q", addOn.Namespace)
+	}
+
+	secrets := m.kubeClient.CoreV1().Secrets(addOn.Namespace)
+
+	secret, err := secrets.Get(ctx, AgentManifestsSecretName, metav1.GetOptions{})
+
+	switch {
+	case apierrors.IsNotFound(err):
+		_, err = secrets.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      AgentManifestsSecretName,
+				Namespace: addOn.Namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(addOn, addonv1beta1.GroupVersion.WithKind("ManagedClusterAddOn")),
+				},
+			},
+			Data: map[string][]byte{agentManifestsData: data},
+		}, metav1.CreateOptions{})
+	case err == nil && !bytes.Equal(secret.Data[agentManifestsData], data):
+		secret.Data = map[string][]byte{agentManifestsData: data}
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "error persisting the agent manifests of cluster %q", addOn.Namespace)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.manifests[addOn.Namespace] = manifests
+
+	return nil
+}
+
+// deployThroughAddOnFramework renders the submariner operator and Submariner resource manifests for the add-on framework
+// then, once it deployed them, retires the ManifestWorks previously deployed by the controller.
+func (c *submarinerAgentController) deployThroughAddOnFramework(ctx context.Context, managedCluster *clusterv1.ManagedCluster,
+	config any, skipOperatorGroup bool,
+) error {
+	manifests, err := renderManifests(config, addOnFrameworkManifestFiles(managedCluster, skipOperatorGroup)...)
+	if err != nil {
+		return err
+	}
+
+	if err := c.publishAgentManifests(ctx, managedCluster.Name, manifests); err != nil {
+		return err
+	}
+
+	return c.retireManifestWorks(ctx, managedCluster.Name)
+}
+
+// addOnFrameworkManifestFiles returns the manifest files of the submariner operator and Submariner resource deployed
+// through the add-on framework.
+func addOnFrameworkManifestFiles(managedCluster *clusterv1.ManagedCluster, skipOperatorGroup bool) []string {
+	// The add-on framework deploys the installation namespace along with the add-on agent.
+	files := slices.DeleteFunc(operatorManifestFiles(managedCluster, skipOperatorGroup), func(file string) bool {
+		return file == operatorNamespaceFile
+	})
+
+	return append(files, submarinerIPSecPSKSecretFile, submarinerBrokerSecretFile, submarinerCRFile)
+}
+
+// publishAgentManifests stores the manifests served by the add-on framework for the given managed cluster and records
+// their hash on the ManagedClusterAddOn, which triggers the framework. The framework keeps updating the ManifestWorks of a
+// deleting ManagedClusterAddOn until the pre-delete hook deployed with the add-on agent completed.
+func (c *submarinerAgentController) publishAgentManifests(ctx context.Context, clusterName string, manifests []workv1.Manifest,
+) error {
+	addOn, err := c.addOnLister.ManagedClusterAddOns(clusterName).Get(constants.SubmarinerAddOnName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving ManagedClusterAddon %q", clusterName)
+	}
+
+	if err := c.agentManifests.set(ctx, addOn, manifests); err != nil {
+		return err
+	}
+
+	hash := hashManifests(manifests)
+
+	if addOn.Annotations[AgentManifestsHashAnnotation] == hash {
+		return nil
+	}
+
+	err = util.Update(ctx, resource.ForAddon(c.addOnClient.AddonV1beta1().ManagedClusterAddOns(clusterName)), addOn,
+		func(existing *addonv1beta1.ManagedClusterAddOn) (*addonv1beta1.ManagedClusterAddOn, error) {
+			if existing.Annotations == nil {
+				existing.Annotations = map[string]string{}
+			}
+
+			existing.Annotations[AgentManifestsHashAnnotation] = hash
+
+			return existing, nil
+		})
+
+	return errors.Wrapf(err, "error updating the manifests hash annotation of ManagedClusterAddon %q", clusterName)
+}
+
+// retireManifestWorks removes the submariner-operator and submariner-resource ManifestWorks of a cluster migrated to the
+// add-on framework, once the framework applied the same resources. They're orphaned first so that removing them doesn't
+// delete the resources on the managed cluster.
+func (c *submarinerAgentController) retireManifestWorks(ctx context.Context, clusterName string) error {
+	var works []*workv1.ManifestWork
+
+	for _, name := range []string{SubmarinerCRManifestWorkName, OperatorManifestWorkName} {
+		work, err := c.manifestWorkLister.ManifestWorks(clusterName).Get(name)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "error retrieving ManifestWork %q", name)
+		}
+
+		works = append(works, work)
+	}
+
+	if len(works) == 0 {
+		return nil
+	}
+
+	deployed, err := c.isDeployedByAddOnFramework(clusterName)
+	if err != nil {
+		return err
+	}
+
+	if !deployed {
+		logger.Infof("Waiting for the add-on framework to deploy the submariner agent on cluster %q before removing its ManifestWorks",
+			clusterName)
+
+		return nil
+	}
+
+	for _, work := range works {
+		switch {
+		case !work.DeletionTimestamp.IsZero():
+		case work.Spec.DeleteOption == nil || work.Spec.DeleteOption.PropagationPolicy != workv1.DeletePropagationPolicyTypeOrphan:
+			err := util.Update(ctx, resource.ForManifestWork(c.manifestWorkClient.WorkV1().ManifestWorks(clusterName)), work,
+				func(existing *workv1.ManifestWork) (*workv1.ManifestWork, error) {
+					existing.Spec.DeleteOption = &workv1.DeleteOption{PropagationPolicy: workv1.DeletePropagationPolicyTypeOrphan}
+
+					return existing, nil
+				})
+			if err != nil {
+				return errors.Wrapf(err, "error orphaning the resources of ManifestWork %q", work.Name)
+			}
+
+			logger.Infof("Orphaned the resources of ManifestWork \"%s/%s\" handed over to the add-on framework", clusterName, work.Name)
+		case isApplied(work):
+			// The orphaning was observed by the work agent.
+			if err := c.deleteManifestWork(ctx, work.Name, clusterName); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// isDeployedByAddOnFramework returns whether the add-on framework applied its ManifestWorks with the Submariner resource.
+func (c *submarinerAgentController) isDeployedByAddOnFramework(clusterName string) (bool, error) {
+	deployWorks, err := c.addOnFrameworkManifestWorks(clusterName)
+	if err != nil {
+		return false, err
+	}
+
+	deployed := false
+
+	for _, work := range deployWorks {
+		if !isApplied(work) {
+			return false, nil
+		}
+
+		deployed = deployed || slices.ContainsFunc(work.Spec.Workload.Manifests, func(manifest workv1.Manifest) bool {
+			return manifestKind(&manifest) == submarinerKind
+		})
+	}
+
+	return deployed, nil
+}
+
+// handOverSubmarinerResource moves the Submariner resource and its secrets from the add-on framework ManifestWorks to the
+// submariner-resource ManifestWork, so that it's deleted before the operator, as when the controller deploys the
+// ManifestWorks. They're removed from the manifests served through the framework once the submariner-resource ManifestWork
+// was applied, the framework then updates its ManifestWorks. It returns true once there's nothing left to hand over.
+func (c *submarinerAgentController) handOverSubmarinerResource(ctx context.Context, clusterName string) (bool, error) {
+	if c.agentManifests == nil {
+		return true, nil
+	}
+
+	deployWorks, err := c.addOnFrameworkManifestWorks(clusterName)
+	if err != nil {
+		return false, err
+	}
+
+	var manifests []workv1.Manifest
+
+	for _, work := range deployWorks {
+		for i := range work.Spec.Workload.Manifests {
+			if isSubmarinerResourceManifest(&work.Spec.Workload.Manifests[i]) {
+				manifests = append(manifests, work.Spec.Workload.Manifests[i])
+			}
+		}
+	}
+
+	if len(manifests) == 0 {
+		return true, nil
+	}
+
+	work, err := c.manifestWorkLister.ManifestWorks(clusterName).Get(SubmarinerCRManifestWorkName)
+	if apierrors.IsNotFound(err) {
+		logger.Infof("Handing the Submariner resource of cluster %q over from the add-on framework for its removal", clusterName)
+
+		//nolint:wrapcheck // No need to wrap here
+		return false, manifestwork.Apply(ctx, c.manifestWorkClient, &workv1.ManifestWork{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      SubmarinerCRManifestWorkName,
+				Namespace: clusterName,
+			},
+			Spec: workv1.ManifestWorkSpec{
+				Workload: workv1.ManifestsTemplate{Manifests: manifests},
+			},
+		}, c.eventRecorder)
+	}
+
+	if err != nil {
+		return false, errors.Wrapf(err, "error retrieving ManifestWork %q", SubmarinerCRManifestWorkName)
+	}
+
+	if !isApplied(work) {
+		return false, nil
+	}
+
+	// The submariner-resource ManifestWork also owns the resources now, removing them from the add-on framework ManifestWorks
+	// doesn't delete them.
+	stored, found, err := c.agentManifests.Get(ctx, clusterName)
+	if err != nil || !found {
+		return false, err
+	}
+
+	return false, c.publishAgentManifests(ctx, clusterName, slices.DeleteFunc(slices.Clone(stored), func(manifest workv1.Manifest) bool {
+		return isSubmarinerResourceManifest(&manifest)
+	}))
+}
+
+// removeAgentManifests removes the manifests served by the add-on framework for the given managed cluster, once its
+// Submariner resource is gone, so that the framework removes the operator.
+func (c *submarinerAgentController) removeAgentManifests(ctx context.Context, clusterName string) error {
+	if c.agentManifests == nil {
+		return nil
+	}
+
+	return c.publishAgentManifests(ctx, clusterName, []workv1.Manifest{})
+}
+
+// addOnFrameworkManifestWorks returns the ManifestWorks deploying the add-on agent through the add-on framework.
+func (c *submarinerAgentController) addOnFrameworkManifestWorks(clusterName string) ([]*workv1.ManifestWork, error) {
+	works, err := c.manifestWorkLister.ManifestWorks(clusterName).List(labels.SelectorFromSet(map[string]string{
+		addonv1beta1.AddonLabelKey: constants.SubmarinerAddOnName,
+	}))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing the add-on framework ManifestWorks of cluster %q", clusterName)
+	}
+
+	return slices.DeleteFunc(works, func(work *workv1.ManifestWork) bool {
+		return !isAddOnFrameworkManifestWork(work)
+	}), nil
+}
+
+func isAddOnFrameworkManifestWork(work metav1.Object) bool {
+	return work.GetLabels()[addonv1beta1.AddonLabelKey] == constants.SubmarinerAddOnName &&
+		strings.HasPrefix(work.GetName(), addonconstants.DeployWorkNamePrefix(constants.SubmarinerAddOnName))
+}
+
+// isApplied returns whether the work agent applied the current generation of the given ManifestWork.
+func isApplied(work *workv1.ManifestWork) bool {
+	condition := meta.FindStatusCondition(work.Status.Conditions, workv1.WorkApplied)
+
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.ObservedGeneration == work.Generation
+}
+
+// isSubmarinerResourceManifest returns whether the given manifest is one of those deployed by the submariner-resource
+// ManifestWork.
+func isSubmarinerResourceManifest(manifest *workv1.Manifest) bool {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+		return false
+	}
+
+	switch obj.GetKind() {
+	case submarinerKind:
+		return true
+	case "Secret":
+		return obj.GetName() == constants.IPSecPSKSecretName || obj.GetName() == constants.BrokerK8sSecretName
+	}
+
+	return false
+}
+
+func manifestKind(manifest *workv1.Manifest) string {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+		return ""
+	}
+
+	return obj.GetKind()
+}
+
+func hashManifests(manifests []workv1.Manifest) string {
+	hash := sha256.New()
+
+	for i := range manifests {
+		hash.Write(manifests[i].Raw)
+		hash.Write([]byte{'\n'})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
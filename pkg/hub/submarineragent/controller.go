@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	goerrors "errors"
 	"fmt"
-	"maps"
 	"slices"
 	"time"
 
@@ -23,7 +22,10 @@ import (
 	configinformer "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/informers/externalversions/submarinerconfig/v1alpha1"
 	configlister "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/listers/submarinerconfig/v1alpha1"
 	"github.com/stolostron/submariner-addon/pkg/constants"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarinerbroker"
 	brokerinfo "github.com/stolostron/submariner-addon/pkg/hub/submarinerbrokerinfo"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarinerglobalnet"
 	"github.com/stolostron/submariner-addon/pkg/manifestwork"
 	"github.com/stolostron/submariner-addon/pkg/resource"
 	"github.com/submariner-io/admiral/pkg/federate"
@@ -43,8 +45,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/kubernetes"
 	"open-cluster-management.io/addon-framework/pkg/addonfactory"
 	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
 	addonclient "open-cluster-management.io/api/client/addon/clientset/versioned"
@@ -77,12 +77,31 @@ const (
 	operatorNamespaceFile         = "manifests/operator/submariner-operator-namespace.yaml"
 	BrokerCfgApplied              = "SubmarinerBrokerConfigApplied"
 	BrokerObjectName              = "submariner-broker"
-	BackupLabelKey                = "cluster.open-cluster-management.io/backup"
-	BackupLabelValue              = "submariner"
+	BackupLabelKey                = constants.BackupLabelKey
+	BackupLabelValue              = constants.BackupLabelValue
 	addonDeploymentConfigResource = "addondeploymentconfigs"
 	addonDeploymentConfigGroup    = "addon.open-cluster-management.io"
 )
 
+const (
+	// ClusterSetAnnotation records on the ManagedClusterAddOn the cluster set whose broker the submariner agent was deployed
+	// against, so that the resources in its broker namespace are cleaned up when the cluster moves to another cluster set.
+	ClusterSetAnnotation = "submarineraddon.open-cluster-management.io/cluster-set"
+
+	ClusterSetMigrated              = "SubmarinerClusterSetMigrated"
+	ClusterSetMigratingReason       = "Migrating"
+	ClusterSetMigrationFailedReason = "MigrationFailed"
+	ClusterSetMigratedReason        = "Migrated"
+)
+
+const (
+	// DeploymentConfigApplied reports on the ManagedClusterAddOn the effective values of the Submariner tunables which can be
+	// set with customized variables of the AddOnDeploymentConfigs.
+	DeploymentConfigApplied          = "SubmarinerDeploymentConfigApplied"
+	DeploymentConfigAppliedReason    = "DeploymentConfigApplied"
+	InvalidCustomizedVariablesReason = "InvalidCustomizedVariables"
+)
+
 var clusterRBACFiles = []string{
 	"manifests/rbac/broker-cluster-serviceaccount.yaml",
 	"manifests/rbac/broker-cluster-rolebinding.yaml",
@@ -125,9 +144,7 @@ type clusterRBACConfig struct {
 // submarinerAgentController reconciles instances of ManagedCluster on the hub to deploy/remove
 // corresponding submariner agent manifestworks.
 type submarinerAgentController struct {
-	kubeClient             kubernetes.Interface
-	dynamicClient          dynamic.Interface
-	controllerClient       client.Client
+	brokerClusters         *brokercluster.Resolver
 	clusterClient          clusterclient.Interface
 	manifestWorkClient     workclient.Interface
 	configClient           configclient.Interface
@@ -141,13 +158,14 @@ type submarinerAgentController struct {
 	deploymentConfigLister addonlisterv1beta1.AddOnDeploymentConfigLister
 	eventRecorder          events.Recorder
 	resourceCache          resourceapply.ResourceCache
+	agentManifests         *AgentManifests
+	restoreAware           bool
 }
 
-// NewSubmarinerAgentController returns a submarinerAgentController instance.
+// NewSubmarinerAgentController returns a submarinerAgentController instance. If agentManifests is set, the submariner
+// operator and Submariner resource are deployed through the add-on framework rather than by ManifestWorks of the controller.
 func NewSubmarinerAgentController(
-	kubeClient kubernetes.Interface,
-	dynamicClient dynamic.Interface,
-	controllerClient client.Client,
+	brokerClusters *brokercluster.Resolver,
 	clusterClient clusterclient.Interface,
 	manifestWorkClient workclient.Interface,
 	configClient configclient.Interface,
@@ -159,12 +177,12 @@ func NewSubmarinerAgentController(
 	clusterAddOnInformer addoninformerv1beta1.ClusterManagementAddOnInformer,
 	addOnInformer addoninformerv1beta1.ManagedClusterAddOnInformer,
 	deploymentConfigInformer addoninformerv1beta1.AddOnDeploymentConfigInformer,
+	agentManifests *AgentManifests,
+	restoreAware bool,
 	recorder events.Recorder,
 ) factory.Controller {
 	c := &submarinerAgentController{
-		kubeClient:             kubeClient,
-		dynamicClient:          dynamicClient,
-		controllerClient:       controllerClient,
+		brokerClusters:         brokerClusters,
 		clusterClient:          clusterClient,
 		manifestWorkClient:     manifestWorkClient,
 		configClient:           configClient,
@@ -178,6 +196,8 @@ func NewSubmarinerAgentController(
 		deploymentConfigLister: deploymentConfigInformer.Lister(),
 		eventRecorder:          recorder.WithComponentSuffix("submariner-agent-controller"),
 		resourceCache:          resourceapply.NewResourceCache(),
+		agentManifests:         agentManifests,
+		restoreAware:           restoreAware,
 	}
 
 	return factory.New().
@@ -188,10 +208,9 @@ func NewSubmarinerAgentController(
 			return accessor.GetName()
 		}, clusterInformer.Informer()).
 		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
-			// TODO: we may consider to use addon to deploy the submariner on the managed cluster instead of
-			// using manifestwork, one problem should be considered - how to get the IPSECPSK
 			accessor, _ := meta.Accessor(obj)
-			if accessor.GetName() != OperatorManifestWorkName && accessor.GetName() != SubmarinerCRManifestWorkName {
+			if accessor.GetName() != OperatorManifestWorkName && accessor.GetName() != SubmarinerCRManifestWorkName &&
+				(c.agentManifests == nil || !isAddOnFrameworkManifestWork(accessor)) {
 				return ""
 			}
 
@@ -300,10 +319,17 @@ func (c *submarinerAgentController) syncManagedCluster(
 		clusterSetName = managedCluster.Labels[clusterv1beta2.ClusterSetLabel]
 	}
 
+	// The cluster set the agent was deployed against, which may differ from the current one if the cluster set label changed.
+	deployedClusterSetName := addOn.Annotations[ClusterSetAnnotation]
+
 	// The ManagedClusterAddOn is deleting, clean up its related resources.
 	if !addOn.DeletionTimestamp.IsZero() {
 		logger.Infof("ManagedClusterAddOn %q in cluster %q is deleting", addOn.Name, clusterName)
 
+		if deployedClusterSetName != "" {
+			clusterSetName = deployedClusterSetName
+		}
+
 		return c.cleanUpSubmarinerAgent(ctx, clusterName, clusterSetName, syncCtx)
 	}
 
@@ -318,11 +344,11 @@ func (c *submarinerAgentController) syncManagedCluster(
 		// we do clean in case submariner was previously deployed.
 		logger.Infof("ManagedCluster %q is missing the cluster set label", managedCluster.Name)
 
-		return c.cleanUpSubmarinerAgent(ctx, clusterName, "", syncCtx)
+		return c.cleanUpSubmarinerAgent(ctx, clusterName, deployedClusterSetName, syncCtx)
 	}
 
 	// Find the ManagedClusterSet containing the managed cluster.
-	_, err = c.clusterSetLister.Get(clusterSetName)
+	clusterSet, err := c.clusterSetLister.Get(clusterSetName)
 
 	switch {
 	case apierrors.IsNotFound(err):
@@ -330,11 +356,18 @@ func (c *submarinerAgentController) syncManagedCluster(
 		// but we'll do clean up here just in case.
 		logger.Infof("ManagedClusterSet %q not found", clusterSetName)
 
-		return c.cleanUpSubmarinerAgent(ctx, clusterName, "", syncCtx)
+		return c.cleanUpSubmarinerAgent(ctx, clusterName, deployedClusterSetName, syncCtx)
 	case err != nil:
 		return errors.Wrapf(err, "error retrieving ManagedClusterSet %q", clusterSetName)
 	}
 
+	if submarinerbroker.GetBrokerNamespace(clusterSet) == "" {
+		// The broker of the cluster set isn't set up yet, recording its namespace on the cluster set triggers another sync.
+		logger.Infof("The broker namespace of ManagedClusterSet %q isn't recorded yet", clusterSetName)
+
+		return nil
+	}
+
 	// Add the finalizer to the ManagedClusterAddOn.
 	added, err := finalizer.Add(ctx, resource.ForAddon(c.addOnClient.AddonV1beta1().ManagedClusterAddOns(clusterName)),
 		addOn, constants.SubmarinerAddOnFinalizer)
@@ -346,13 +379,188 @@ func (c *submarinerAgentController) syncManagedCluster(
 		return errors.Wrapf(err, "error adding finalizer to ManagedClusterAddon %q", clusterName)
 	}
 
-	return c.deploySubmarinerAgent(ctx, clusterSetName, managedCluster, addOn, config)
+	switch deployedClusterSetName {
+	case clusterSetName:
+		// Already deployed against the broker of this cluster set.
+	case "":
+		if err := c.setDeployedClusterSet(ctx, addOn, clusterSetName); err != nil {
+			return err
+		}
+	default:
+		// The cluster moved to another cluster set, the resources of the previous broker are cleaned up before the agent is
+		// deployed against the new broker on the next sync, triggered by the annotation update.
+		return c.migrateClusterSet(ctx, addOn, deployedClusterSetName, clusterSetName)
+	}
+
+	err = c.deploySubmarinerAgent(ctx, clusterSet, managedCluster, addOn, config)
+	if errors.Is(err, submarinerbroker.ErrWaitingForRestore) {
+		logger.Infof("Waiting to deploy the submariner agent on cluster %q: %v", clusterName, err)
+		syncCtx.Queue().AddAfter(clusterName, submarinerbroker.RestoreRetryInterval)
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := c.setBrokerDeployed(ctx, clusterSet); err != nil {
+		return err
+	}
+
+	if condition := meta.FindStatusCondition(addOn.Status.Conditions, ClusterSetMigrated); condition != nil &&
+		condition.Reason != ClusterSetMigratedReason {
+		c.updateClusterSetMigrationStatus(ctx, addOn, metav1.ConditionTrue, ClusterSetMigratedReason,
+			fmt.Sprintf("The submariner agent was moved to the broker of cluster set %q", clusterSetName))
+	}
+
+	return nil
+}
+
+// migrateClusterSet cleans up the resources of the managed cluster in the broker namespace of the cluster set it left,
+// then records the cluster set it joined so that the agent gets deployed against its broker.
+func (c *submarinerAgentController) migrateClusterSet(ctx context.Context, addOn *addonv1beta1.ManagedClusterAddOn,
+	fromClusterSetName, toClusterSetName string,
+) error {
+	clusterName := addOn.Namespace
+
+	logger.Infof("ManagedCluster %q moved from cluster set %q to %q, cleaning up the previous broker resources", clusterName,
+		fromClusterSetName, toClusterSetName)
+
+	c.updateClusterSetMigrationStatus(ctx, addOn, metav1.ConditionFalse, ClusterSetMigratingReason,
+		fmt.Sprintf("Moving the submariner agent from the broker of cluster set %q to the broker of cluster set %q",
+			fromClusterSetName, toClusterSetName))
+
+	err := c.cleanUpClusterSetResources(ctx, clusterName, fromClusterSetName)
+	if err == nil {
+		err = c.setDeployedClusterSet(ctx, addOn, toClusterSetName)
+	}
+
+	if err != nil {
+		c.updateClusterSetMigrationStatus(ctx, addOn, metav1.ConditionFalse, ClusterSetMigrationFailedReason,
+			fmt.Sprintf("Failed to move the submariner agent from the broker of cluster set %q: %v", fromClusterSetName, err))
+	}
+
+	return err
+}
+
+// cleanUpClusterSetResources removes the resources of the managed cluster from the broker namespace of the given cluster
+// set. The access of the agent to the broker is revoked first, so that it doesn't recreate the resources being deleted.
+func (c *submarinerAgentController) cleanUpClusterSetResources(ctx context.Context, managedClusterName, clusterSetName string) error {
+	// remove service account and its rolebinding from broker namespace
+	if err := c.removeClusterRBACFiles(ctx, managedClusterName, clusterSetName); err != nil {
+		return err
+	}
+
+	if err := c.deleteClusterBrokerResources(ctx, managedClusterName, clusterSetName); err != nil {
+		return err
+	}
+
+	if err := c.quarantineGlobalCIDRs(ctx, managedClusterName, clusterSetName); err != nil {
+		return err
+	}
+
+	return c.deleteGlobalBrokerResourcesIfNecessary(ctx, managedClusterName, clusterSetName)
+}
+
+// setDeployedClusterSet records the cluster set the agent is deployed against, or removes the record if the name is empty.
+func (c *submarinerAgentController) setDeployedClusterSet(ctx context.Context, addOn *addonv1beta1.ManagedClusterAddOn,
+	clusterSetName string,
+) error {
+	err := util.Update(ctx, resource.ForAddon(c.addOnClient.AddonV1beta1().ManagedClusterAddOns(addOn.Namespace)), addOn,
+		func(existing *addonv1beta1.ManagedClusterAddOn) (*addonv1beta1.ManagedClusterAddOn, error) {
+			if clusterSetName == "" {
+				delete(existing.Annotations, ClusterSetAnnotation)
+
+				return existing, nil
+			}
+
+			if existing.Annotations == nil {
+				existing.Annotations = map[string]string{}
+			}
+
+			existing.Annotations[ClusterSetAnnotation] = clusterSetName
+
+			return existing, nil
+		})
+
+	return errors.Wrapf(err, "error updating the cluster set annotation of ManagedClusterAddon %q", addOn.Namespace)
+}
+
+func (c *submarinerAgentController) updateClusterSetMigrationStatus(ctx context.Context,
+	managedClusterAddon *addonv1beta1.ManagedClusterAddOn, status metav1.ConditionStatus, reason, message string,
+) {
+	condition := metav1.Condition{
+		Type:    ClusterSetMigrated,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+
+	_, updated, err := addon.UpdateStatus(ctx, c.addOnClient, managedClusterAddon.Namespace,
+		addon.UpdateConditionFn(&condition))
+	if err != nil {
+		logger.Errorf(err, "Error updating ManagedClusterAddOn status for cluster %q", managedClusterAddon.Namespace)
+		return
+	}
+
+	if updated {
+		c.eventRecorder.Event("SubmarinerClusterSet"+reason, message)
+	}
+}
+
+// applyAddonDeploymentConfigs applies the AddOnDeploymentConfigs of the ManagedClusterAddOn to the broker info and reports the
+// effective values of the customized variables in its status.
+func (c *submarinerAgentController) applyAddonDeploymentConfigs(ctx context.Context, managedClusterAddon *addonv1beta1.ManagedClusterAddOn,
+	brokerInfo *brokerinfo.SubmarinerBrokerInfo, submarinerConfig *configv1alpha1.SubmarinerConfig,
+) error {
+	deploymentConfigs, err := c.getAddonDeploymentConfigs(managedClusterAddon)
+	if err != nil {
+		return err
+	}
+
+	err = applyDeploymentConfigs(brokerInfo, submarinerConfig, deploymentConfigs)
+	c.updateDeploymentConfigStatus(ctx, managedClusterAddon, brokerInfo, err)
+
+	return err
+}
+
+func (c *submarinerAgentController) updateDeploymentConfigStatus(ctx context.Context,
+	managedClusterAddon *addonv1beta1.ManagedClusterAddOn, brokerInfo *brokerinfo.SubmarinerBrokerInfo, applyErr error,
+) {
+	condition := metav1.Condition{
+		Type:    DeploymentConfigApplied,
+		Status:  metav1.ConditionTrue,
+		Reason:  DeploymentConfigAppliedReason,
+		Message: "Effective values: " + brokerinfo.EffectiveCustomizedVariables(brokerInfo),
+	}
+
+	if applyErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = InvalidCustomizedVariablesReason
+		condition.Message = fmt.Sprintf("Error applying the AddOnDeploymentConfigs: %v", applyErr)
+	}
+
+	_, updated, err := addon.UpdateStatus(ctx, c.addOnClient, managedClusterAddon.Namespace,
+		addon.UpdateConditionFn(&condition))
+	if err != nil {
+		logger.Errorf(err, "Error updating ManagedClusterAddOn status for cluster %q", managedClusterAddon.Namespace)
+		return
+	}
+
+	if updated {
+		c.eventRecorder.Event("Submariner"+condition.Reason, condition.Message)
+	}
 }
 
 // clean up the submariner agent from this managedCluster.
 func (c *submarinerAgentController) cleanUpSubmarinerAgent(ctx context.Context, managedClusterName, clusterSetName string,
 	syncCtx factory.SyncContext,
 ) error {
+	if handedOver, err := c.handOverSubmarinerResource(ctx, managedClusterName); err != nil || !handedOver {
+		return err
+	}
+
 	submarinerManifestWork, err := c.manifestWorkLister.ManifestWorks(managedClusterName).Get(SubmarinerCRManifestWorkName)
 
 	switch {
@@ -378,21 +586,29 @@ func (c *submarinerAgentController) cleanUpSubmarinerAgent(ctx context.Context,
 			SubmarinerCRManifestWorkName, managedClusterName, elapsed)
 	}
 
-	if err := c.deleteClusterBrokerResources(ctx, managedClusterName, clusterSetName); err != nil {
-		return err
+	if c.isCloudCleanUpPlanned(managedClusterName) {
+		logger.Infof("The cloud resources of cluster %q are only planned for deletion - set cloudPreparePlanOnly to false in its "+
+			"SubmarinerConfig to clean them up and finish removing the add-on", managedClusterName)
+
+		return nil
 	}
 
-	// remove service account and its rolebinding from broker namespace
-	if err := c.removeClusterRBACFiles(ctx, managedClusterName); err != nil {
+	if err := c.removeAgentManifests(ctx, managedClusterName); err != nil {
 		return err
 	}
 
-	if err := c.deleteGlobalBrokerResourcesIfNecessary(ctx, clusterSetName); err != nil {
+	if err := c.cleanUpClusterSetResources(ctx, managedClusterName, clusterSetName); err != nil {
 		return err
 	}
 
 	addOn, err := c.addOnLister.ManagedClusterAddOns(managedClusterName).Get(constants.SubmarinerAddOnName)
 	if err == nil {
+		if _, found := addOn.Annotations[ClusterSetAnnotation]; found {
+			if err := c.setDeployedClusterSet(ctx, addOn, ""); err != nil {
+				return err
+			}
+		}
+
 		//nolint:wrapcheck // No need to wrap here
 		return finalizer.Remove(ctx, resource.ForAddon(c.addOnClient.AddonV1beta1().ManagedClusterAddOns(managedClusterName)),
 			addOn, constants.SubmarinerAddOnFinalizer)
@@ -401,31 +617,59 @@ func (c *submarinerAgentController) cleanUpSubmarinerAgent(ctx context.Context,
 	return nil
 }
 
+// isCloudCleanUpPlanned returns whether the agent of the given cluster, whose add-on is deleting, only publishes a plan to
+// delete the cloud resources it prepared. The agent, along with the add-on finalizer, is then kept until the plan mode is
+// turned off and it cleaned them up.
+func (c *submarinerAgentController) isCloudCleanUpPlanned(managedClusterName string) bool {
+	addOn, err := c.addOnLister.ManagedClusterAddOns(managedClusterName).Get(constants.SubmarinerAddOnName)
+	if err != nil || addOn.DeletionTimestamp.IsZero() {
+		return false
+	}
+
+	config, err := c.configLister.SubmarinerConfigs(managedClusterName).Get(constants.SubmarinerConfigName)
+
+	return err == nil && config.Spec.CloudPreparePlanOnly && len(config.Status.CloudResources) > 0
+}
+
 func (c *submarinerAgentController) deploySubmarinerAgent(
 	ctx context.Context,
-	clusterSetName string,
+	clusterSet *clusterv1beta2.ManagedClusterSet,
 	managedCluster *clusterv1.ManagedCluster,
 	managedClusterAddOn *addonv1beta1.ManagedClusterAddOn,
 	submarinerConfig *configv1alpha1.SubmarinerConfig,
 ) error {
+	broker, err := c.brokerClusters.ForClusterSet(ctx, clusterSet.Name)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	brokerNamespace := submarinerbroker.GetBrokerNamespace(clusterSet)
+
 	// generate service account and bind it to `submariner-k8s-broker-cluster` role
-	brokerNamespace := brokerinfo.GenerateBrokerName(clusterSetName)
-	if err := c.applyClusterRBACFiles(ctx, brokerNamespace, managedCluster.Name); err != nil {
+	if err := c.applyClusterRBACFiles(ctx, broker, brokerNamespace, managedCluster.Name); err != nil {
 		return err
 	}
 
-	err := c.createGNConfigMapIfNecessary(ctx, brokerNamespace)
+	// In restore-aware mode, the globalnet allocations of a broker which was deployed against are restored rather than
+	// regenerated.
+	restored := c.restoreAware && submarinerbroker.IsBrokerDeployed(clusterSet)
+
+	err = c.createGNConfigMapIfNecessary(ctx, broker, brokerNamespace, restored)
 	if err != nil && !apierrors.IsNotFound(err) {
 		return err
 	}
 
+	if err := submarinerglobalnet.Unquarantine(ctx, broker.ControllerClient, brokerNamespace, managedCluster.Name); err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
 	if apierrors.IsNotFound(err) {
 		c.updateManagedClusterAddOnStatus(ctx, managedClusterAddOn, brokerNamespace, true)
 		return fmt.Errorf("brokers.submariner.io object named %q missing in namespace %q", BrokerObjectName, brokerNamespace)
 	}
 
 	// broker object exists, add backup label if not already present
-	err = addBackupLabel(ctx, c.controllerClient, &submarinerv1a1.Broker{
+	err = addBackupLabel(ctx, broker.ControllerClient, &submarinerv1a1.Broker{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      BrokerObjectName,
 			Namespace: brokerNamespace,
@@ -445,9 +689,7 @@ func (c *submarinerAgentController) deploySubmarinerAgent(
 	// create submariner broker info with submariner config
 	brokerInfo, err := brokerinfo.Get(
 		ctx,
-		c.kubeClient,
-		c.dynamicClient,
-		c.controllerClient,
+		broker,
 		managedCluster.Name,
 		brokerNamespace,
 		submarinerConfig,
@@ -457,30 +699,23 @@ func (c *submarinerAgentController) deploySubmarinerAgent(
 		return fmt.Errorf("failed to create submariner brokerInfo of cluster %v : %w", managedCluster.Name, err)
 	}
 
-	nodePlacements, err := c.getAddonDeploymentConfigs(managedClusterAddOn)
-	if err != nil {
+	if err := c.applyAddonDeploymentConfigs(ctx, managedClusterAddOn, brokerInfo, submarinerConfig); err != nil {
 		return err
 	}
 
-	for _, nodePlacement := range nodePlacements {
-		maps.Copy(brokerInfo.NodeSelector, nodePlacement.NodeSelector)
-
-		brokerInfo.Tolerations = append(brokerInfo.Tolerations, nodePlacement.Tolerations...)
-	}
-
-	skipOperatorGroup := false
-
 	if submarinerConfig != nil {
 		err := c.updateSubmarinerConfigStatus(ctx, submarinerConfig, managedCluster)
 		if err != nil {
 			return err
 		}
+	}
 
-		_, skipOperatorGroup = submarinerConfig.GetAnnotations()["skipOperatorGroup"]
+	if c.agentManifests != nil {
+		return c.deployThroughAddOnFramework(ctx, managedCluster, brokerInfo, skipOperatorGroup(submarinerConfig))
 	}
 
 	// Apply submariner operator manifest work
-	operatorManifestWork, err := newOperatorManifestWork(managedCluster, brokerInfo, skipOperatorGroup)
+	operatorManifestWork, err := newOperatorManifestWork(managedCluster, brokerInfo, skipOperatorGroup(submarinerConfig))
 	if err != nil {
 		return err
 	}
@@ -578,19 +813,26 @@ func (c *submarinerAgentController) deleteManifestWork(ctx context.Context, name
 	return nil
 }
 
-func (c *submarinerAgentController) applyClusterRBACFiles(ctx context.Context, brokerNamespace, managedClusterName string) error {
+func (c *submarinerAgentController) applyClusterRBACFiles(ctx context.Context, broker *brokercluster.Clients,
+	brokerNamespace, managedClusterName string,
+) error {
 	config := &clusterRBACConfig{
 		ManagedClusterName:        managedClusterName,
 		SubmarinerBrokerNamespace: brokerNamespace,
 	}
 
 	//nolint:wrapcheck // No need to wrap here
-	return resource.ApplyManifests(ctx, c.kubeClient, c.eventRecorder, c.resourceCache, resource.AssetFromFile(manifestFiles, config),
+	return resource.ApplyManifests(ctx, broker.KubeClient, c.eventRecorder, c.resourceCache, resource.AssetFromFile(manifestFiles, config),
 		clusterRBACFiles...)
 }
 
-func (c *submarinerAgentController) removeClusterRBACFiles(ctx context.Context, managedClusterName string) error {
-	serviceAccounts, err := c.kubeClient.CoreV1().ServiceAccounts(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+func (c *submarinerAgentController) removeClusterRBACFiles(ctx context.Context, managedClusterName, clusterSetName string) error {
+	broker, err := c.brokerClusters.ForClusterSet(ctx, clusterSetName)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	serviceAccounts, err := broker.KubeClient.CoreV1().ServiceAccounts(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
 		LabelSelector: fmt.Sprintf("%s=%s", serviceAccountLabel, managedClusterName),
 	})
 	if err != nil {
@@ -609,7 +851,7 @@ func (c *submarinerAgentController) removeClusterRBACFiles(ctx context.Context,
 	// Delete created secret if present
 	brokerNamespace := serviceAccounts.Items[0].Namespace
 	secretName := brokerinfo.GenerateBrokerName(managedClusterName)
-	err = c.kubeClient.CoreV1().Secrets(brokerNamespace).Delete(ctx, secretName, metav1.DeleteOptions{})
+	err = broker.KubeClient.CoreV1().Secrets(brokerNamespace).Delete(ctx, secretName, metav1.DeleteOptions{})
 
 	if err != nil && !apierrors.IsNotFound(err) {
 		return errors.Wrapf(err, "error retrieving Secret %q", secretName)
@@ -621,7 +863,7 @@ func (c *submarinerAgentController) removeClusterRBACFiles(ctx context.Context,
 	}
 
 	//nolint:wrapcheck // No need to wrap here
-	return resource.DeleteFromManifests(ctx, c.kubeClient, c.eventRecorder, resource.AssetFromFile(manifestFiles, config),
+	return resource.DeleteFromManifests(ctx, broker.KubeClient, c.eventRecorder, resource.AssetFromFile(manifestFiles, config),
 		clusterRBACFiles...)
 }
 
@@ -634,6 +876,11 @@ func newSubmarinerManifestWork(managedCluster *clusterv1.ManagedCluster, config
 
 func newOperatorManifestWork(managedCluster *clusterv1.ManagedCluster, config any, skipOperatorGroup bool,
 ) (*workv1.ManifestWork, error) {
+	return newManifestWork(OperatorManifestWorkName, managedCluster.Name, config,
+		operatorManifestFiles(managedCluster, skipOperatorGroup)...)
+}
+
+func operatorManifestFiles(managedCluster *clusterv1.ManagedCluster, skipOperatorGroup bool) []string {
 	files := []string{operatorNamespaceFile, agentRBACFile}
 
 	clusterProduct := getClusterProduct(managedCluster)
@@ -649,10 +896,30 @@ func newOperatorManifestWork(managedCluster *clusterv1.ManagedCluster, config an
 		files = append(files, operatorAllFiles...)
 	}
 
-	return newManifestWork(OperatorManifestWorkName, managedCluster.Name, config, files...)
+	return files
 }
 
 func newManifestWork(name, namespace string, config any, files ...string) (*workv1.ManifestWork, error) {
+	manifests, err := renderManifests(config, files...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &workv1.ManifestWork{
+		TypeMeta: metav1.TypeMeta{},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: workv1.ManifestWorkSpec{
+			Workload: workv1.ManifestsTemplate{
+				Manifests: manifests,
+			},
+		},
+	}, nil
+}
+
+func renderManifests(config any, files ...string) ([]workv1.Manifest, error) {
 	manifests := []workv1.Manifest{}
 
 	for _, file := range files {
@@ -672,18 +939,7 @@ func newManifestWork(name, namespace string, config any, files ...string) (*work
 		manifests = append(manifests, manifest)
 	}
 
-	return &workv1.ManifestWork{
-		TypeMeta: metav1.TypeMeta{},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
-		},
-		Spec: workv1.ManifestWorkSpec{
-			Workload: workv1.ManifestsTemplate{
-				Manifests: manifests,
-			},
-		},
-	}, nil
+	return manifests, nil
 }
 
 func getClusterProduct(managedCluster *clusterv1.ManagedCluster) string {
@@ -729,20 +985,27 @@ func getManagedClusterInfo(managedCluster *clusterv1.ManagedCluster) *configv1al
 	return clusterInfo
 }
 
-func (c *submarinerAgentController) createGNConfigMapIfNecessary(ctx context.Context, brokerNamespace string) error {
-	gmConfigMap, gnCmErr := globalnet.GetConfigMap(ctx, c.controllerClient, brokerNamespace)
+func (c *submarinerAgentController) createGNConfigMapIfNecessary(ctx context.Context, broker *brokercluster.Clients,
+	brokerNamespace string, restored bool,
+) error {
+	gmConfigMap, gnCmErr := globalnet.GetConfigMap(ctx, broker.ControllerClient, brokerNamespace)
 	if gnCmErr != nil && !apierrors.IsNotFound(gnCmErr) {
 		return errors.Wrapf(gnCmErr, "error getting globalnet configmap from broker namespace %q", brokerNamespace)
 	}
 
 	if gnCmErr == nil {
 		// This should handle upgrade from a version that didn't add the label
-		return addBackupLabel(ctx, c.controllerClient, gmConfigMap)
+		return addBackupLabel(ctx, broker.ControllerClient, gmConfigMap)
+	}
+
+	if restored {
+		return errors.Wrapf(submarinerbroker.ErrWaitingForRestore, "the globalnet ConfigMap in broker namespace %q isn't restored yet",
+			brokerNamespace)
 	}
 
 	// globalnetConfig is missing in the broker-namespace, try creating it from submariner-broker object.
 
-	brokerObj, err := c.getBrokerObject(ctx, brokerNamespace)
+	brokerObj, err := getBrokerObject(ctx, broker, brokerNamespace)
 	if err != nil {
 		return err
 	}
@@ -765,23 +1028,27 @@ func (c *submarinerAgentController) createGNConfigMapIfNecessary(ctx context.Con
 		brokerObj.Spec.GlobalnetCIDRRange, brokerObj.Spec.DefaultGlobalnetClusterSize, brokerNamespace)
 	if err == nil {
 		configMap.Labels[BackupLabelKey] = BackupLabelValue
-		err = c.controllerClient.Create(ctx, configMap)
+		err = broker.ControllerClient.Create(ctx, configMap)
 	}
 
 	return errors.Wrapf(err, "error creating globalnet configmap on Broker")
 }
 
 func (c *submarinerAgentController) deleteClusterBrokerResources(ctx context.Context, clusterName, clusterSetName string) error {
-	if clusterSetName == "" {
-		return nil
+	brokerNamespace, err := c.brokerNamespace(clusterSetName)
+	if err != nil || brokerNamespace == "" {
+		return err
 	}
 
-	brokerNamespace := brokerinfo.GenerateBrokerName(clusterSetName)
+	broker, err := c.brokerClusters.ForClusterSet(ctx, clusterSetName)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
 
 	var errs []error
 
 	deleteCollection := func(gvr schema.GroupVersionResource) {
-		err := c.dynamicClient.Resource(gvr).Namespace(brokerNamespace).DeleteCollection(ctx, metav1.DeleteOptions{},
+		err := broker.DynamicClient.Resource(gvr).Namespace(brokerNamespace).DeleteCollection(ctx, metav1.DeleteOptions{},
 			metav1.ListOptions{
 				LabelSelector: labels.Set(map[string]string{federate.ClusterIDLabelKey: clusterName}).String(),
 			})
@@ -794,7 +1061,7 @@ func (c *submarinerAgentController) deleteClusterBrokerResources(ctx context.Con
 	deleteCollection(submarinerv1.ClusterGVR)
 	deleteCollection(discovery.SchemeGroupVersion.WithResource("endpointslices"))
 
-	serviceImportClient := c.dynamicClient.Resource(schema.GroupVersionResource{
+	serviceImportClient := broker.DynamicClient.Resource(schema.GroupVersionResource{
 		Group:    mcsv1a1.GroupName,
 		Version:  mcsv1a1.GroupVersion.Version,
 		Resource: "serviceimports",
@@ -843,9 +1110,31 @@ func (c *submarinerAgentController) deleteClusterBrokerResources(ctx context.Con
 	return errors.Wrapf(goerrors.Join(errs...), "error deleting broker resources for cluster %q", clusterName)
 }
 
-func (c *submarinerAgentController) deleteGlobalBrokerResourcesIfNecessary(ctx context.Context, clusterSetName string) error {
-	if clusterSetName == "" {
-		return nil
+// quarantineGlobalCIDRs releases the global CIDRs allocated to the cluster leaving the cluster set, they are reclaimed
+// by the globalnet controller after the quarantine period.
+func (c *submarinerAgentController) quarantineGlobalCIDRs(ctx context.Context, clusterName, clusterSetName string) error {
+	brokerNamespace, err := c.brokerNamespace(clusterSetName)
+	if err != nil || brokerNamespace == "" {
+		return err
+	}
+
+	broker, err := c.brokerClusters.ForClusterSet(ctx, clusterSetName)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	//nolint:wrapcheck // No need to wrap here
+	return submarinerglobalnet.Quarantine(ctx, broker.ControllerClient, brokerNamespace, clusterName, time.Now())
+}
+
+// deleteGlobalBrokerResourcesIfNecessary deletes the Globalnet ConfigMap and Broker resources of the cluster set once no
+// cluster other than the one being cleaned up, which may still be listed in the cluster set, uses its broker.
+func (c *submarinerAgentController) deleteGlobalBrokerResourcesIfNecessary(ctx context.Context, managedClusterName,
+	clusterSetName string,
+) error {
+	brokerNamespace, err := c.brokerNamespace(clusterSetName)
+	if err != nil || brokerNamespace == "" {
+		return err
 	}
 
 	clusters, err := c.clusterLister.List(labels.SelectorFromSet(labels.Set{clusterv1beta2.ClusterSetLabel: clusterSetName}))
@@ -854,6 +1143,10 @@ func (c *submarinerAgentController) deleteGlobalBrokerResourcesIfNecessary(ctx c
 	}
 
 	for _, cluster := range clusters {
+		if cluster.Name == managedClusterName {
+			continue
+		}
+
 		addOn, err := c.addOnLister.ManagedClusterAddOns(cluster.Name).Get(constants.SubmarinerAddOnName)
 		if apierrors.IsNotFound(err) {
 			continue
@@ -868,17 +1161,20 @@ func (c *submarinerAgentController) deleteGlobalBrokerResourcesIfNecessary(ctx c
 		}
 	}
 
-	brokerNamespace := brokerinfo.GenerateBrokerName(clusterSetName)
+	broker, err := c.brokerClusters.ForClusterSet(ctx, clusterSetName)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
 
 	logger.Infof("Deleting Globalnet ConfigMap and Broker resources from broker namespace %q in cluster set %q",
 		brokerNamespace, clusterSetName)
 
-	err = globalnet.DeleteConfigMap(ctx, c.controllerClient, brokerNamespace)
+	err = globalnet.DeleteConfigMap(ctx, broker.ControllerClient, brokerNamespace)
 	if err != nil && !apierrors.IsNotFound(err) {
 		return errors.Wrap(err, "error deleting globalnet ConfigMap")
 	}
 
-	err = c.controllerClient.Delete(ctx, &submarinerv1a1.Broker{ObjectMeta: metav1.ObjectMeta{
+	err = broker.ControllerClient.Delete(ctx, &submarinerv1a1.Broker{ObjectMeta: metav1.ObjectMeta{
 		Name:      BrokerObjectName,
 		Namespace: brokerNamespace,
 	}})
@@ -889,15 +1185,55 @@ func (c *submarinerAgentController) deleteGlobalBrokerResourcesIfNecessary(ctx c
 	return err
 }
 
-func (c *submarinerAgentController) getBrokerObject(ctx context.Context, brokerNamespace string) (*submarinerv1a1.Broker, error) {
-	broker := &submarinerv1a1.Broker{}
+// setBrokerDeployed records on the cluster set that an agent was deployed against its broker, whose state must then be
+// restored rather than regenerated on another hub.
+func (c *submarinerAgentController) setBrokerDeployed(ctx context.Context, clusterSet *clusterv1beta2.ManagedClusterSet) error {
+	if submarinerbroker.IsBrokerDeployed(clusterSet) {
+		return nil
+	}
+
+	err := util.Update(ctx, resource.ForManagedClusterSet(c.clusterClient.ClusterV1beta2().ManagedClusterSets()), clusterSet,
+		func(existing *clusterv1beta2.ManagedClusterSet) (*clusterv1beta2.ManagedClusterSet, error) {
+			if existing.Annotations == nil {
+				existing.Annotations = map[string]string{}
+			}
+
+			existing.Annotations[submarinerbroker.BrokerDeployedAnnotation] = "true"
+
+			return existing, nil
+		})
+
+	return errors.Wrapf(err, "error updating ManagedClusterSet %q", clusterSet.Name)
+}
+
+// brokerNamespace returns the broker namespace recorded on the given cluster set. It's empty if the cluster set doesn't
+// exist anymore, its broker namespace being deleted with it, or if its broker was never set up.
+func (c *submarinerAgentController) brokerNamespace(clusterSetName string) (string, error) {
+	if clusterSetName == "" {
+		return "", nil
+	}
+
+	clusterSet, err := c.clusterSetLister.Get(clusterSetName)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", errors.Wrapf(err, "error retrieving ManagedClusterSet %q", clusterSetName)
+	}
+
+	return submarinerbroker.GetBrokerNamespace(clusterSet), nil
+}
+
+func getBrokerObject(ctx context.Context, broker *brokercluster.Clients, brokerNamespace string) (*submarinerv1a1.Broker, error) {
+	brokerObj := &submarinerv1a1.Broker{}
 
-	err := c.controllerClient.Get(ctx, types.NamespacedName{Namespace: brokerNamespace, Name: BrokerObjectName}, broker)
+	err := broker.ControllerClient.Get(ctx, types.NamespacedName{Namespace: brokerNamespace, Name: BrokerObjectName}, brokerObj)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error getting broker object from namespace %q", brokerNamespace)
 	}
 
-	return broker, nil
+	return brokerObj, nil
 }
 
 func addBackupLabel[T client.Object](ctx context.Context, cl client.Client, to T) error {
@@ -928,9 +1264,9 @@ func addBackupLabel[T client.Object](ctx context.Context, cl client.Client, to T
 }
 
 func (c *submarinerAgentController) getAddonDeploymentConfigs(managedClusterAddon *addonv1beta1.ManagedClusterAddOn) (
-	[]*addonv1beta1.NodePlacement, error,
+	[]*addonv1beta1.AddOnDeploymentConfig, error,
 ) {
-	var nodePlacements []*addonv1beta1.NodePlacement
+	var deploymentConfigs []*addonv1beta1.AddOnDeploymentConfig
 
 	for _, config := range managedClusterAddon.Spec.Configs {
 		if config.Resource == addonDeploymentConfigResource && config.Group == addonDeploymentConfigGroup {
@@ -939,12 +1275,12 @@ func (c *submarinerAgentController) getAddonDeploymentConfigs(managedClusterAddo
 				return nil, errors.Wrapf(err, "error getting AddonDeploymentConfig \"%s/%s\"", config.Namespace, config.Name)
 			}
 
-			nodePlacements = append(nodePlacements, deploymentConfig.Spec.NodePlacement)
+			deploymentConfigs = append(deploymentConfigs, deploymentConfig)
 		}
 	}
 
-	if len(nodePlacements) > 0 {
-		return nodePlacements, nil
+	if len(deploymentConfigs) > 0 {
+		return deploymentConfigs, nil
 	}
 
 	/* No deployment config on managedclusteraddon, check default
@@ -959,7 +1295,7 @@ func (c *submarinerAgentController) getAddonDeploymentConfigs(managedClusterAddo
 	clusterAddOn, err := c.clusterAddOnLister.Get(constants.SubmarinerAddOnName)
 
 	if apierrors.IsNotFound(err) {
-		return nodePlacements, nil
+		return deploymentConfigs, nil
 	} else if err != nil {
 		return nil, errors.Wrapf(err, "error getting ClusterManagementAddon %q", constants.SubmarinerAddOnName)
 	}
@@ -974,9 +1310,9 @@ func (c *submarinerAgentController) getAddonDeploymentConfigs(managedClusterAddo
 				return nil, errors.Wrapf(err, "error getting AddonDeploymentConfig %q:%q", namespace, name)
 			}
 
-			nodePlacements = append(nodePlacements, deploymentConfig.Spec.NodePlacement)
+			deploymentConfigs = append(deploymentConfigs, deploymentConfig)
 		}
 	}
 
-	return nodePlacements, nil
+	return deploymentConfigs, nil
 }
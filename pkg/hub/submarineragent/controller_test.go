@@ -3,7 +3,9 @@ package submarineragent_test
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -15,7 +17,9 @@ import (
 	configinformers "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/informers/externalversions"
 	cloudFake "github.com/stolostron/submariner-addon/pkg/cloud/fake"
 	"github.com/stolostron/submariner-addon/pkg/constants"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
 	"github.com/stolostron/submariner-addon/pkg/hub/submarineragent"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarinerbroker"
 	"github.com/stolostron/submariner-addon/pkg/resource"
 	fakereactor "github.com/submariner-io/admiral/pkg/fake"
 	"github.com/submariner-io/admiral/pkg/federate"
@@ -30,6 +34,7 @@ import (
 	discovery "k8s.io/api/discovery/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -41,6 +46,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/utils/clock"
@@ -69,6 +75,8 @@ const (
 	ipsecPSK         = "test-psk"
 	brokerToken      = "broker-token"
 	brokerCA         = "broker-CA"
+
+	addOnFrameworkManifestWorkName = "addon-submariner-deploy-0"
 )
 
 func init() {
@@ -106,6 +114,14 @@ var _ = Describe("Controller", func() {
 				t.awaitBackupLabelOnConfigMap()
 			})
 
+			It("should mark the broker of the ManagedClusterSet as deployed", func(ctx context.Context) {
+				Eventually(func(g Gomega) {
+					cs, err := t.clusterClient.ClusterV1beta2().ManagedClusterSets().Get(ctx, clusterSetName, metav1.GetOptions{})
+					g.Expect(err).To(Succeed())
+					g.Expect(cs.Annotations).To(HaveKeyWithValue(submarinerbroker.BrokerDeployedAnnotation, "true"))
+				}).Should(Succeed())
+			})
+
 			t.testFinalizers()
 
 			Context("and the SubmarinerConfig is present", func() {
@@ -164,6 +180,37 @@ var _ = Describe("Controller", func() {
 				})
 			})
 
+			Context("and the AddOnDeploymentConfig specifies customized variables", func() {
+				BeforeEach(func() {
+					t.defaultADConfig.Spec.CustomizedVariables = []addonv1beta1.CustomizedVariable{
+						{Name: "cableDriver", Value: "wireguard"},
+						{Name: "catalogChannel", Value: "stable-0.25"},
+					}
+				})
+
+				It("should deploy the ManifestWorks with the customized variables", func(ctx context.Context) {
+					work := test.AwaitResource[*workv1.ManifestWork](ctx, resource.ForManifestWork(
+						t.manifestWorkClient.WorkV1().ManifestWorks(clusterName)), submarineragent.SubmarinerCRManifestWorkName)
+					assertNestedString(assertManifestObj(unmarshallManifestObjs(work), "Submariner", ""), "wireguard",
+						"spec", "cableDriver")
+
+					t.awaitDeploymentConfigCondition(ctx, metav1.ConditionTrue, submarineragent.DeploymentConfigAppliedReason,
+						"cableDriver=wireguard", "catalogChannel=stable-0.25")
+				})
+
+				Context("and an invalid value", func() {
+					BeforeEach(func() {
+						t.defaultADConfig.Spec.CustomizedVariables = []addonv1beta1.CustomizedVariable{{Name: "natEnabled", Value: "maybe"}}
+					})
+
+					It("should not deploy the ManifestWorks and report the error", func(ctx context.Context) {
+						t.awaitDeploymentConfigCondition(ctx, metav1.ConditionFalse, submarineragent.InvalidCustomizedVariablesReason,
+							"natEnabled")
+						t.ensureNoManifestWorks()
+					})
+				})
+			})
+
 			Context("and the SubmarinerConfig is present but the backup label on the broker config is missing", func() {
 				BeforeEach(func() {
 					t.createSubmarinerConfig(newSubmarinerConfig())
@@ -240,6 +287,63 @@ var _ = Describe("Controller", func() {
 			t.testFinalizers()
 		})
 
+		Context("before the broker namespace is recorded on the ManagedClusterSet", func() {
+			BeforeEach(func() {
+				t.clusterSet.Annotations = nil
+			})
+
+			JustBeforeEach(func(ctx context.Context) {
+				t.createManagedClusterSet(ctx)
+				t.createAddonDeploymentConfig(t.defaultADConfig, ctx)
+				t.createClusterManagementAddon(ctx)
+				t.createManagedCluster(ctx)
+				t.createGlobalnetConfigMap(ctx)
+				t.createAddon(ctx)
+			})
+
+			It("should deploy the ManifestWorks once it's recorded", func(ctx context.Context) {
+				t.ensureNoManifestWorks()
+
+				t.clusterSet.Annotations = map[string]string{submarinerbroker.SubmBrokerNamespaceKey: brokerNamespace}
+				_, err := t.clusterClient.ClusterV1beta2().ManagedClusterSets().Update(ctx, t.clusterSet, metav1.UpdateOptions{})
+				Expect(err).To(Succeed())
+
+				t.awaitManifestWorks(ctx)
+			})
+		})
+
+		Context("in restore-aware mode for a restored ManagedClusterSet whose broker was deployed", func() {
+			BeforeEach(func() {
+				t.restoreAware = true
+				t.clusterSet.Annotations[submarinerbroker.BrokerDeployedAnnotation] = "true"
+
+				restoreRetryInterval := submarinerbroker.RestoreRetryInterval
+				submarinerbroker.RestoreRetryInterval = 200 * time.Millisecond
+
+				DeferCleanup(func() {
+					submarinerbroker.RestoreRetryInterval = restoreRetryInterval
+				})
+			})
+
+			JustBeforeEach(func(ctx context.Context) {
+				t.createManagedClusterSet(ctx)
+				t.createAddonDeploymentConfig(t.defaultADConfig, ctx)
+				t.createClusterManagementAddon(ctx)
+				t.createManagedCluster(ctx)
+				t.createAddon(ctx)
+			})
+
+			It("should deploy the ManifestWorks once the globalnet ConfigMap is restored", func(ctx context.Context) {
+				t.ensureNoManifestWorks()
+
+				_, err := globalnet.GetConfigMap(ctx, t.controllerClient, brokerNamespace)
+				Expect(apierrors.IsNotFound(err)).To(BeTrue(), "The globalnet ConfigMap was generated")
+
+				t.createGlobalnetConfigMap(ctx)
+				t.awaitManifestWorks(ctx)
+			})
+		})
+
 		Context("with cluster specific AddonDeploymentConfig", func() {
 			JustBeforeEach(func(ctx context.Context) {
 				t.createAddonDeploymentConfigForCluster(ctx, t.managedCluster.Name)
@@ -264,6 +368,64 @@ var _ = Describe("Controller", func() {
 		})
 	})
 
+	When("the broker of the ManagedClusterSet is hosted on an external cluster", func() {
+		var externalClientsCreated atomic.Bool
+
+		BeforeEach(func(ctx context.Context) {
+			externalClientsCreated.Store(false)
+
+			newClientsForConfig := brokercluster.NewClientsForConfig
+			brokercluster.NewClientsForConfig = func(_ *rest.Config) (*brokercluster.Clients, error) {
+				externalClientsCreated.Store(true)
+
+				// The fake clients stand for the external broker cluster.
+				return &brokercluster.Clients{
+					KubeClient:       t.kubeClient,
+					DynamicClient:    t.dynamicClient,
+					ControllerClient: t.controllerClient,
+				}, nil
+			}
+
+			DeferCleanup(func() {
+				brokercluster.NewClientsForConfig = newClientsForConfig
+			})
+
+			_, err := t.kubeClient.CoreV1().Secrets("").Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "external-broker",
+				},
+				Data: map[string][]byte{
+					brokercluster.KubeConfigSecretKey: []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: broker
+  cluster:
+    server: https://api.broker.example.com:6443
+contexts:
+- name: broker
+  context:
+    cluster: broker
+    user: broker
+current-context: broker
+users:
+- name: broker
+  user:
+    token: broker-token
+`),
+				},
+			}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+
+			t.clusterSet.Annotations[brokercluster.ExternalBrokerAnnotation] = "external-broker"
+			t.brokerAPIServer = "api.broker.example.com:6443"
+		})
+
+		It("should deploy the ManifestWorks pointing at the external broker", func(ctx context.Context) {
+			t.initManifestWorks(ctx)
+			Expect(externalClientsCreated.Load()).To(BeTrue())
+		})
+	})
+
 	When("Globalnet is enabled", func() {
 		var submarinerConfig *configv1alpha1.SubmarinerConfig
 
@@ -299,11 +461,157 @@ var _ = Describe("Controller", func() {
 			t.createSubmarinerConfig(newSubmarinerConfig())
 		})
 
-		It("should update the ManifestWorks", func() {
-			t.assertOperatorManifestWork(test.AwaitUpdateAction(&t.manifestWorkClient.Fake, "manifestworks",
-				submarineragent.OperatorManifestWorkName).(*workv1.ManifestWork))
-			t.assertSubmarinerManifestWork(test.AwaitUpdateAction(&t.manifestWorkClient.Fake, "manifestworks",
-				submarineragent.SubmarinerCRManifestWorkName).(*workv1.ManifestWork))
+		It("should update the ManifestWorks", func(ctx context.Context) {
+			t.assertOperatorManifestWork(t.awaitAppliedManifestWork(ctx, submarineragent.OperatorManifestWorkName))
+			t.assertSubmarinerManifestWork(t.awaitAppliedManifestWork(ctx, submarineragent.SubmarinerCRManifestWorkName))
+		})
+	})
+
+	When("the submariner agent is deployed through the add-on framework", func() {
+		BeforeEach(func() {
+			t.agentManifests = submarineragent.NewAgentManifests(t.kubeClient)
+		})
+
+		JustBeforeEach(func(ctx context.Context) {
+			t.createManagedClusterSet(ctx)
+			t.createAddonDeploymentConfig(t.defaultADConfig, ctx)
+			t.createClusterManagementAddon(ctx)
+			t.createManagedCluster(ctx)
+			t.createGlobalnetConfigMap(ctx)
+			t.createAddon(ctx)
+		})
+
+		It("should render the manifests for the add-on framework instead of deploying ManifestWorks", func(ctx context.Context) {
+			work := &workv1.ManifestWork{Spec: workv1.ManifestWorkSpec{Workload: workv1.ManifestsTemplate{
+				Manifests: t.awaitAgentManifests(),
+			}}}
+
+			t.assertSubmarinerManifestWork(work)
+			assertManifestObj(unmarshallManifestObjs(work), "Subscription", "submariner")
+			assertNoManifestObj(unmarshallManifestObjs(work), "Namespace", installNamespace)
+
+			Eventually(func() map[string]string {
+				addOn, err := t.addOnClient.AddonV1beta1().ManagedClusterAddOns(clusterName).Get(ctx, constants.SubmarinerAddOnName,
+					metav1.GetOptions{})
+				Expect(err).To(Succeed())
+
+				return addOn.Annotations
+			}).Should(HaveKey(submarineragent.AgentManifestsHashAnnotation))
+
+			t.ensureNoManifestWorks()
+		})
+
+		It("should persist the manifests so that they're served after a restart", func(ctx context.Context) {
+			manifests := t.awaitAgentManifests()
+
+			persisted, found, err := submarineragent.NewAgentManifests(t.kubeClient).Get(ctx, clusterName)
+			Expect(err).To(Succeed())
+			Expect(found).To(BeTrue())
+			Expect(persisted).To(Equal(manifests))
+		})
+
+		Context("and the ManifestWorks were deployed by the controller", func() {
+			BeforeEach(func(ctx context.Context) {
+				for _, name := range []string{submarineragent.OperatorManifestWorkName, submarineragent.SubmarinerCRManifestWorkName} {
+					_, err := t.manifestWorkClient.WorkV1().ManifestWorks(clusterName).Create(ctx, newAppliedManifestWork(name, nil),
+						metav1.CreateOptions{})
+					Expect(err).To(Succeed())
+				}
+			})
+
+			Context("once the add-on framework applied the Submariner resource", func() {
+				BeforeEach(func(ctx context.Context) {
+					t.createAddOnFrameworkManifestWork(ctx, []workv1.Manifest{{RawExtension: runtime.RawExtension{
+						Raw: []byte(`{"apiVersion":"submariner.io/v1alpha1","kind":"Submariner","metadata":{"name":"submariner"}}`),
+					}}})
+				})
+
+				It("should orphan then delete the ManifestWorks", func() {
+					t.awaitNoManifestWork(submarineragent.SubmarinerCRManifestWorkName)
+					t.awaitNoManifestWork(submarineragent.OperatorManifestWorkName)
+
+					for _, name := range []string{submarineragent.OperatorManifestWorkName, submarineragent.SubmarinerCRManifestWorkName} {
+						Expect(slices.ContainsFunc(t.manifestWorkClient.Fake.Actions(), func(action testing.Action) bool {
+							update, ok := action.(testing.UpdateAction)
+							if !ok {
+								return false
+							}
+
+							work, ok := update.GetObject().(*workv1.ManifestWork)
+
+							return ok && work.Name == name && work.Spec.DeleteOption != nil &&
+								work.Spec.DeleteOption.PropagationPolicy == workv1.DeletePropagationPolicyTypeOrphan
+						})).To(BeTrue(), "ManifestWork %q was not orphaned", name)
+					}
+				})
+			})
+
+			Context("before the add-on framework applied the Submariner resource", func() {
+				It("should not delete the ManifestWorks", func(ctx context.Context) {
+					t.awaitAgentManifests()
+
+					Consistently(func() error {
+						_, err := t.manifestWorkClient.WorkV1().ManifestWorks(clusterName).Get(ctx,
+							submarineragent.SubmarinerCRManifestWorkName, metav1.GetOptions{})
+
+						return err
+					}).Should(Succeed())
+				})
+			})
+		})
+
+		Context("and the ManagedClusterAddon is being deleted", func() {
+			JustBeforeEach(func(ctx context.Context) {
+				t.createAddOnFrameworkManifestWork(ctx, t.awaitAgentManifests())
+
+				Expect(t.addOnClient.AddonV1beta1().ManagedClusterAddOns(clusterName).Delete(ctx, t.addOn.Name,
+					metav1.DeleteOptions{})).To(Succeed())
+			})
+
+			It("should remove the Submariner resource through the submariner-resource ManifestWork first", func(ctx context.Context) {
+				works := t.manifestWorkClient.WorkV1().ManifestWorks(clusterName)
+
+				work := test.AwaitResource[*workv1.ManifestWork](ctx, resource.ForManifestWork(works),
+					submarineragent.SubmarinerCRManifestWorkName)
+				t.assertSubmarinerManifestWork(work)
+
+				work.Status.Conditions = []metav1.Condition{{
+					Type:   workv1.WorkApplied,
+					Status: metav1.ConditionTrue,
+					Reason: "AppliedManifestWorkComplete",
+				}}
+
+				_, err := works.UpdateStatus(ctx, work, metav1.UpdateOptions{})
+				Expect(err).To(Succeed())
+
+				var manifests []workv1.Manifest
+
+				Eventually(func() []*unstructured.Unstructured {
+					manifests = t.awaitAgentManifests()
+
+					return unmarshallManifestObjs(&workv1.ManifestWork{Spec: workv1.ManifestWorkSpec{
+						Workload: workv1.ManifestsTemplate{Manifests: manifests},
+					}})
+				}).ShouldNot(ContainElement(WithTransform(func(obj *unstructured.Unstructured) string {
+					return obj.GetKind()
+				}, Equal("Submariner"))))
+
+				// The framework ManifestWorks aren't updated by the controller.
+				deployWork, err := works.Get(ctx, addOnFrameworkManifestWorkName, metav1.GetOptions{})
+				Expect(err).To(Succeed())
+				assertManifestObj(unmarshallManifestObjs(deployWork), "Submariner", "submariner")
+
+				// Simulate the framework applying the manifests without the Submariner resource.
+				deployWork.Spec.Workload.Manifests = manifests
+
+				_, err = works.Update(ctx, deployWork, metav1.UpdateOptions{})
+				Expect(err).To(Succeed())
+
+				t.awaitNoManifestWork(submarineragent.SubmarinerCRManifestWorkName)
+
+				test.AwaitNoResource(ctx, resource.ForAddon(t.addOnClient.AddonV1beta1().ManagedClusterAddOns(clusterName)),
+					constants.SubmarinerAddOnName)
+			})
 		})
 	})
 
@@ -510,6 +818,42 @@ var _ = Describe("Controller", func() {
 					constants.SubmarinerAddOnName)
 			})
 		})
+
+		Context("and the cloud preparation plan mode holds back the clean up of the prepared cloud resources", func() {
+			BeforeEach(func() {
+				config := newSubmarinerConfig()
+				config.Spec.CloudPreparePlanOnly = true
+				config.Status.CloudResources = []configv1alpha1.CloudResource{
+					{Kind: configv1alpha1.CloudResourceSecurityGroup, Name: "sg-1"},
+				}
+
+				t.createSubmarinerConfig(config)
+			})
+
+			It("should keep the ManagedClusterAddOn until the plan mode is turned off and the cloud resources are cleaned up",
+				func(ctx context.Context) {
+					Consistently(func() error {
+						_, err := t.addOnClient.AddonV1beta1().ManagedClusterAddOns(clusterName).Get(ctx, constants.SubmarinerAddOnName,
+							metav1.GetOptions{})
+
+						return err
+					}).Should(Succeed())
+
+					config, err := t.configClient.SubmarineraddonV1alpha1().SubmarinerConfigs(clusterName).Get(ctx,
+						constants.SubmarinerConfigName, metav1.GetOptions{})
+					Expect(err).To(Succeed())
+
+					config.Spec.CloudPreparePlanOnly = false
+					config.Status.CloudResources = nil
+
+					_, err = t.configClient.SubmarineraddonV1alpha1().SubmarinerConfigs(clusterName).Update(ctx, config,
+						metav1.UpdateOptions{})
+					Expect(err).To(Succeed())
+
+					test.AwaitNoResource(ctx, resource.ForAddon(t.addOnClient.AddonV1beta1().ManagedClusterAddOns(clusterName)),
+						constants.SubmarinerAddOnName)
+				})
+		})
 	})
 
 	When("the ManagedCluster is removed from the ManagedClusterSet", func() {
@@ -523,10 +867,168 @@ var _ = Describe("Controller", func() {
 
 		t.testAgentCleanup(false)
 	})
+
+	When("the ManagedCluster moves to another ManagedClusterSet", func() {
+		const (
+			newClusterSetName  = "south-america"
+			newBrokerNamespace = "south-america-broker"
+			otherClusterName   = "west"
+		)
+
+		var keepOtherClusterInSet bool
+
+		BeforeEach(func() {
+			keepOtherClusterInSet = false
+		})
+
+		JustBeforeEach(func(ctx context.Context) {
+			t.initManifestWorks(ctx)
+			t.createSubmarinerBroker(false)
+
+			if keepOtherClusterInSet {
+				_, err := t.clusterClient.ClusterV1().ManagedClusters().Create(ctx, &clusterv1.ManagedCluster{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   otherClusterName,
+						Labels: map[string]string{clusterv1beta2.ClusterSetLabel: clusterSetName},
+					},
+				}, metav1.CreateOptions{})
+				Expect(err).To(Succeed())
+
+				_, err = t.addOnClient.AddonV1beta1().ManagedClusterAddOns(otherClusterName).Create(ctx,
+					&addonv1beta1.ManagedClusterAddOn{ObjectMeta: metav1.ObjectMeta{Name: constants.SubmarinerAddOnName}},
+					metav1.CreateOptions{})
+				Expect(err).To(Succeed())
+			}
+
+			_, err := t.clusterClient.ClusterV1beta2().ManagedClusterSets().Create(ctx, &clusterv1beta2.ManagedClusterSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        newClusterSetName,
+					Annotations: map[string]string{submarinerbroker.SubmBrokerNamespaceKey: newBrokerNamespace},
+				},
+			}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+
+			Expect(t.controllerClient.Create(ctx, &submarinerv1alpha1.Broker{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      submarineragent.BrokerObjectName,
+					Namespace: newBrokerNamespace,
+				},
+			})).To(Succeed())
+
+			_, err = t.kubeClient.CoreV1().Secrets(newBrokerNamespace).Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "submariner-ipsec-psk",
+					Namespace: newBrokerNamespace,
+				},
+				Data: map[string][]byte{
+					"psk": []byte(ipsecPSK),
+				},
+			}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+
+			_, err = t.kubeClient.CoreV1().Secrets(newBrokerNamespace).Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        clusterName + "-token-8tg2x",
+					Namespace:   newBrokerNamespace,
+					Annotations: map[string]string{corev1.ServiceAccountNameKey: clusterName},
+				},
+				Data: map[string][]byte{
+					"ca.crt": []byte(brokerCA),
+					"token":  []byte(brokerToken),
+				},
+				Type: corev1.SecretTypeServiceAccountToken,
+			}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+
+			t.managedCluster.Labels = map[string]string{clusterv1beta2.ClusterSetLabel: newClusterSetName}
+			_, err = t.clusterClient.ClusterV1().ManagedClusters().Update(ctx, t.managedCluster, metav1.UpdateOptions{})
+			Expect(err).To(Succeed())
+		})
+
+		It("should delete the RBAC resources from the previous broker namespace", func(ctx context.Context) {
+			test.AwaitNoResource(ctx, coreresource.ForRoleBinding(t.kubeClient, brokerNamespace), "submariner-k8s-broker-cluster-"+clusterName)
+			test.AwaitNoResource(ctx, coreresource.ForServiceAccount(t.kubeClient, brokerNamespace), clusterName)
+		})
+
+		It("should create the RBAC resources in the new broker namespace", func(ctx context.Context) {
+			test.AwaitResource(ctx, coreresource.ForRoleBinding(t.kubeClient, newBrokerNamespace),
+				"submariner-k8s-broker-cluster-"+clusterName)
+			test.AwaitResource(ctx, coreresource.ForServiceAccount(t.kubeClient, newBrokerNamespace), clusterName)
+		})
+
+		It("should record the new ManagedClusterSet on the ManagedClusterAddOn", func(ctx context.Context) {
+			Eventually(func() string {
+				addOn, err := t.addOnClient.AddonV1beta1().ManagedClusterAddOns(clusterName).Get(ctx, constants.SubmarinerAddOnName,
+					metav1.GetOptions{})
+				Expect(err).To(Succeed())
+
+				return addOn.Annotations[submarineragent.ClusterSetAnnotation]
+			}).Should(Equal(newClusterSetName))
+		})
+
+		It("should update the status of the ManagedClusterAddOn once migrated", func(ctx context.Context) {
+			test.AwaitStatusCondition(&metav1.Condition{
+				Type:   submarineragent.ClusterSetMigrated,
+				Status: metav1.ConditionTrue,
+				Reason: submarineragent.ClusterSetMigratedReason,
+			}, func() ([]metav1.Condition, error) {
+				addOn, err := t.addOnClient.AddonV1beta1().ManagedClusterAddOns(clusterName).Get(ctx,
+					constants.SubmarinerAddOnName, metav1.GetOptions{})
+				if err != nil {
+					return nil, err
+				}
+
+				return addOn.Status.Conditions, nil
+			})
+		})
+
+		It("should deploy the ManifestWorks against the new broker", func(ctx context.Context) {
+			Eventually(func() string {
+				work, err := t.manifestWorkClient.WorkV1().ManifestWorks(clusterName).Get(ctx,
+					submarineragent.SubmarinerCRManifestWorkName, metav1.GetOptions{})
+				Expect(err).To(Succeed())
+
+				for _, obj := range unmarshallManifestObjs(work) {
+					if obj.GetKind() == "Submariner" {
+						namespace, _, _ := unstructured.NestedString(obj.Object, "spec", "brokerK8sRemoteNamespace")
+						return namespace
+					}
+				}
+
+				return ""
+			}).Should(Equal(newBrokerNamespace))
+		})
+
+		Context("and it was the last cluster in the previous ManagedClusterSet", func() {
+			It("should delete the Globalnet ConfigMap and Broker resources of the previous ManagedClusterSet", func(ctx context.Context) {
+				clusters, err := t.clusterClient.ClusterV1().ManagedClusters().List(ctx, metav1.ListOptions{
+					LabelSelector: clusterv1beta2.ClusterSetLabel + "=" + clusterSetName,
+				})
+				Expect(err).To(Succeed())
+				Expect(clusters.Items).To(BeEmpty())
+
+				t.awaitNoGlobalnetConfigMap()
+				t.awaitNoBrokerResource()
+			})
+		})
+
+		Context("and other clusters remain in the previous ManagedClusterSet", func() {
+			BeforeEach(func() {
+				keepOtherClusterInSet = true
+			})
+
+			It("should not delete the Globalnet ConfigMap and Broker resources of the previous ManagedClusterSet", func() {
+				t.ensureGlobalnetConfigMap()
+				t.ensureBrokerResource()
+			})
+		})
+	})
 })
 
 type testDriver struct {
 	managedCluster     *clusterv1.ManagedCluster
+	clusterSet         *clusterv1beta2.ManagedClusterSet
+	brokerAPIServer    string
 	addOn              *addonv1beta1.ManagedClusterAddOn
 	clusterMgmtAddon   *addonv1beta1.ClusterManagementAddOn
 	defaultADConfig    *addonv1beta1.AddOnDeploymentConfig
@@ -543,6 +1045,8 @@ type testDriver struct {
 	addOnClient        addonclient.Interface
 	mockCtrl           *gomock.Controller
 	cloudProvider      *cloudFake.MockProvider
+	agentManifests     *submarineragent.AgentManifests
+	restoreAware       bool
 }
 
 func newTestDriver() *testDriver {
@@ -556,6 +1060,17 @@ func newTestDriver() *testDriver {
 			},
 		}
 
+		t.clusterSet = &clusterv1beta2.ManagedClusterSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        clusterSetName,
+				Annotations: map[string]string{submarinerbroker.SubmBrokerNamespaceKey: brokerNamespace},
+			},
+		}
+
+		t.brokerAPIServer = "127.0.0.1"
+		t.restoreAware = false
+		t.agentManifests = nil
+
 		t.addOn = &addonv1beta1.ManagedClusterAddOn{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      constants.SubmarinerAddOnName,
@@ -672,7 +1187,13 @@ func newTestDriver() *testDriver {
 		configInformerFactory := configinformers.NewSharedInformerFactory(t.configClient, 0)
 		addOnInformerFactory := addoninformers.NewSharedInformerFactory(t.addOnClient, 0)
 
-		controller := submarineragent.NewSubmarinerAgentController(t.kubeClient, t.dynamicClient, t.controllerClient, t.clusterClient,
+		brokerClusters := brokercluster.NewResolver(&brokercluster.Clients{
+			KubeClient:       t.kubeClient,
+			DynamicClient:    t.dynamicClient,
+			ControllerClient: t.controllerClient,
+		}, clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(), "")
+
+		controller := submarineragent.NewSubmarinerAgentController(brokerClusters, t.clusterClient,
 			t.manifestWorkClient, t.configClient, t.addOnClient,
 			clusterInformerFactory.Cluster().V1().ManagedClusters(),
 			clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets(),
@@ -681,6 +1202,8 @@ func newTestDriver() *testDriver {
 			addOnInformerFactory.Addon().V1beta1().ClusterManagementAddOns(),
 			addOnInformerFactory.Addon().V1beta1().ManagedClusterAddOns(),
 			addOnInformerFactory.Addon().V1beta1().AddOnDeploymentConfigs(),
+			t.agentManifests,
+			t.restoreAware,
 			events.NewLoggingEventRecorder("test", clock.RealClock{}))
 
 		ctx, stop := context.WithCancel(context.TODO())
@@ -792,6 +1315,26 @@ func (t *testDriver) assertOperatorManifestWork(work *workv1.ManifestWork) []*un
 	return manifestObjs
 }
 
+// awaitAppliedManifestWork waits for the given ManifestWork to be updated with server-side apply and returns it.
+func (t *testDriver) awaitAppliedManifestWork(ctx context.Context, name string) *workv1.ManifestWork {
+	Eventually(func() bool {
+		for _, action := range t.manifestWorkClient.Fake.Actions() {
+			patch, ok := action.(testing.PatchAction)
+			if ok && patch.GetResource().Resource == "manifestworks" && patch.GetName() == name &&
+				patch.GetPatchType() == types.ApplyPatchType {
+				return true
+			}
+		}
+
+		return false
+	}).Should(BeTrue(), "ManifestWork %q was not applied", name)
+
+	work, err := t.manifestWorkClient.WorkV1().ManifestWorks(clusterName).Get(ctx, name, metav1.GetOptions{})
+	Expect(err).To(Succeed())
+
+	return work
+}
+
 func (t *testDriver) awaitSubmarinerManifestWork(ctx context.Context) {
 	t.assertSubmarinerManifestWork(test.AwaitResource[*workv1.ManifestWork](ctx, resource.ForManifestWork(
 		t.manifestWorkClient.WorkV1().ManifestWorks(clusterName)), submarineragent.SubmarinerCRManifestWorkName))
@@ -822,7 +1365,7 @@ func (t *testDriver) assertSubmarinerManifestWork(work *workv1.ManifestWork) {
 	Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(
 		assertManifestObj(manifestObjs, "Submariner", "").Object, submariner)).To(Succeed())
 	Expect(submariner.Namespace).To(Equal(installNamespace))
-	Expect(submariner.Spec.BrokerK8sApiServer).To(Equal("127.0.0.1"))
+	Expect(submariner.Spec.BrokerK8sApiServer).To(Equal(t.brokerAPIServer))
 	Expect(submariner.Spec.BrokerK8sSecret).To(Equal(constants.BrokerK8sSecretName))
 	Expect(submariner.Spec.BrokerK8sApiServerToken).To(BeEmpty())
 	Expect(submariner.Spec.BrokerK8sCA).To(BeEmpty())
@@ -856,6 +1399,66 @@ func (t *testDriver) assertSubmarinerManifestWork(work *workv1.ManifestWork) {
 	}
 }
 
+func (t *testDriver) awaitAgentManifests() []workv1.Manifest {
+	var manifests []workv1.Manifest
+
+	Eventually(func() bool {
+		var found bool
+
+		manifests, found, _ = t.agentManifests.Get(context.TODO(), clusterName)
+
+		return found && len(manifests) > 0
+	}).Should(BeTrue(), "The agent manifests were not rendered")
+
+	return manifests
+}
+
+func (t *testDriver) createAddOnFrameworkManifestWork(ctx context.Context, manifests []workv1.Manifest) {
+	work := newAppliedManifestWork(addOnFrameworkManifestWorkName, manifests)
+	work.Labels = map[string]string{addonv1beta1.AddonLabelKey: constants.SubmarinerAddOnName}
+
+	_, err := t.manifestWorkClient.WorkV1().ManifestWorks(clusterName).Create(ctx, work, metav1.CreateOptions{})
+	Expect(err).To(Succeed())
+}
+
+func newAppliedManifestWork(name string, manifests []workv1.Manifest) *workv1.ManifestWork {
+	return &workv1.ManifestWork{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: clusterName,
+		},
+		Spec: workv1.ManifestWorkSpec{
+			Workload: workv1.ManifestsTemplate{Manifests: manifests},
+		},
+		Status: workv1.ManifestWorkStatus{
+			Conditions: []metav1.Condition{{
+				Type:   workv1.WorkApplied,
+				Status: metav1.ConditionTrue,
+				Reason: "AppliedManifestWorkComplete",
+			}},
+		},
+	}
+}
+
+func (t *testDriver) awaitDeploymentConfigCondition(ctx context.Context, status metav1.ConditionStatus, reason string,
+	messageSubstrings ...string,
+) {
+	Eventually(func(g Gomega) {
+		addOn, err := t.addOnClient.AddonV1beta1().ManagedClusterAddOns(clusterName).Get(ctx, constants.SubmarinerAddOnName,
+			metav1.GetOptions{})
+		g.Expect(err).To(Succeed())
+
+		condition := meta.FindStatusCondition(addOn.Status.Conditions, submarineragent.DeploymentConfigApplied)
+		g.Expect(condition).ToNot(BeNil())
+		g.Expect(condition.Status).To(Equal(status))
+		g.Expect(condition.Reason).To(Equal(reason))
+
+		for _, substring := range messageSubstrings {
+			g.Expect(condition.Message).To(ContainSubstring(substring))
+		}
+	}).Should(Succeed())
+}
+
 func (t *testDriver) ensureNoManifestWorks() {
 	Consistently(func() []workv1.ManifestWork {
 		list, err := t.manifestWorkClient.WorkV1().ManifestWorks(clusterName).List(context.TODO(), metav1.ListOptions{})
@@ -1009,13 +1612,7 @@ func (t *testDriver) createClusterManagementAddon(ctx context.Context) {
 }
 
 func (t *testDriver) createManagedClusterSet(ctx context.Context) {
-	mcs := &clusterv1beta2.ManagedClusterSet{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: clusterSetName,
-		},
-	}
-
-	_, err := t.clusterClient.ClusterV1beta2().ManagedClusterSets().Create(ctx, mcs, metav1.CreateOptions{})
+	_, err := t.clusterClient.ClusterV1beta2().ManagedClusterSets().Create(ctx, t.clusterSet, metav1.CreateOptions{})
 	Expect(err).To(Succeed())
 }
 
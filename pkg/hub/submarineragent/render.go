@@ -0,0 +1,85 @@
+package submarineragent
+
+import (
+	"maps"
+
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	brokerinfo "github.com/stolostron/submariner-addon/pkg/hub/submarinerbrokerinfo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+// RenderManifestWorks returns the submariner-operator and submariner-resource ManifestWorks deployed to the given managed
+// cluster with the given broker information, submariner config and AddOnDeploymentConfigs, without accessing any cluster.
+func RenderManifestWorks(managedCluster *clusterv1.ManagedCluster, brokerInfo *brokerinfo.SubmarinerBrokerInfo,
+	submarinerConfig *configv1alpha1.SubmarinerConfig, deploymentConfigs []*addonv1beta1.AddOnDeploymentConfig,
+) ([]*workv1.ManifestWork, error) {
+	if err := applyDeploymentConfigs(brokerInfo, submarinerConfig, deploymentConfigs); err != nil {
+		return nil, err
+	}
+
+	operatorManifestWork, err := newOperatorManifestWork(managedCluster, brokerInfo, skipOperatorGroup(submarinerConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	submarinerManifestWork, err := newSubmarinerManifestWork(managedCluster, brokerInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	works := []*workv1.ManifestWork{operatorManifestWork, submarinerManifestWork}
+
+	for _, work := range works {
+		work.TypeMeta = metav1.TypeMeta{APIVersion: workv1.GroupVersion.String(), Kind: "ManifestWork"}
+	}
+
+	return works, nil
+}
+
+// RenderAddOnFrameworkManifests returns the submariner operator and Submariner resource manifests deployed to the given
+// managed cluster along with the add-on agent when the add-on framework deployment is enabled, without accessing any cluster.
+func RenderAddOnFrameworkManifests(managedCluster *clusterv1.ManagedCluster, brokerInfo *brokerinfo.SubmarinerBrokerInfo,
+	submarinerConfig *configv1alpha1.SubmarinerConfig, deploymentConfigs []*addonv1beta1.AddOnDeploymentConfig,
+) ([]workv1.Manifest, error) {
+	if err := applyDeploymentConfigs(brokerInfo, submarinerConfig, deploymentConfigs); err != nil {
+		return nil, err
+	}
+
+	return renderManifests(brokerInfo, addOnFrameworkManifestFiles(managedCluster, skipOperatorGroup(submarinerConfig))...)
+}
+
+// applyDeploymentConfigs applies the node placements and the customized variables of the given AddOnDeploymentConfigs
+// to the broker info, the values set in the submariner config taking precedence over the customized variables.
+func applyDeploymentConfigs(brokerInfo *brokerinfo.SubmarinerBrokerInfo, submarinerConfig *configv1alpha1.SubmarinerConfig,
+	deploymentConfigs []*addonv1beta1.AddOnDeploymentConfig,
+) error {
+	var variables []addonv1beta1.CustomizedVariable
+
+	for _, deploymentConfig := range deploymentConfigs {
+		variables = append(variables, deploymentConfig.Spec.CustomizedVariables...)
+
+		nodePlacement := deploymentConfig.Spec.NodePlacement
+		if nodePlacement == nil {
+			continue
+		}
+
+		maps.Copy(brokerInfo.NodeSelector, nodePlacement.NodeSelector)
+
+		brokerInfo.Tolerations = append(brokerInfo.Tolerations, nodePlacement.Tolerations...)
+	}
+
+	return brokerinfo.ApplyCustomizedVariables(brokerInfo, variables, submarinerConfig) //nolint:wrapcheck // No need to wrap here
+}
+
+func skipOperatorGroup(submarinerConfig *configv1alpha1.SubmarinerConfig) bool {
+	if submarinerConfig == nil {
+		return false
+	}
+
+	_, skip := submarinerConfig.GetAnnotations()["skipOperatorGroup"]
+
+	return skip
+}
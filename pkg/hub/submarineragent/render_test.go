@@ -0,0 +1,118 @@
+package submarineragent_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarineragent"
+	brokerinfo "github.com/stolostron/submariner-addon/pkg/hub/submarinerbrokerinfo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+var _ = Describe("RenderManifestWorks", func() {
+	var (
+		config            *configv1alpha1.SubmarinerConfig
+		deploymentConfigs []*addonv1beta1.AddOnDeploymentConfig
+		works             []*workv1.ManifestWork
+	)
+
+	managedCluster := &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "east"},
+		Status: clusterv1.ManagedClusterStatus{
+			ClusterClaims: []clusterv1.ManagedClusterClaim{{Name: "product.open-cluster-management.io", Value: "OpenShift"}},
+		},
+	}
+
+	BeforeEach(func() {
+		config = &configv1alpha1.SubmarinerConfig{
+			Spec: configv1alpha1.SubmarinerConfigSpec{CableDriver: "vxlan"},
+		}
+		deploymentConfigs = nil
+	})
+
+	JustBeforeEach(func() {
+		var err error
+
+		works, err = submarineragent.RenderManifestWorks(managedCluster,
+			brokerinfo.GetOffline(managedCluster.Name, "set1-broker", config, ""), config, deploymentConfigs)
+		Expect(err).To(Succeed())
+	})
+
+	It("should render the operator and resource ManifestWorks", func() {
+		Expect(works).To(HaveLen(2))
+		Expect(works[0].Name).To(Equal(submarineragent.OperatorManifestWorkName))
+		Expect(works[1].Name).To(Equal(submarineragent.SubmarinerCRManifestWorkName))
+
+		for _, work := range works {
+			Expect(work.Namespace).To(Equal(managedCluster.Name))
+			Expect(work.Kind).To(Equal("ManifestWork"))
+			Expect(work.APIVersion).To(Equal("work.open-cluster-management.io/v1"))
+		}
+
+		Expect(manifestsOf(works[1])).To(ContainElement(ContainSubstring(`"cableDriver":"vxlan"`)))
+	})
+
+	When("an AddOnDeploymentConfig specifies a node placement", func() {
+		BeforeEach(func() {
+			deploymentConfigs = []*addonv1beta1.AddOnDeploymentConfig{{
+				Spec: addonv1beta1.AddOnDeploymentConfigSpec{
+					NodePlacement: &addonv1beta1.NodePlacement{NodeSelector: map[string]string{"infra": "true"}},
+				},
+			}, {}}
+		})
+
+		It("should set it in the Submariner resource", func() {
+			Expect(manifestsOf(works[1])).To(ContainElement(ContainSubstring(`"nodeSelector":{"infra":"true"}`)))
+		})
+	})
+
+	When("an AddOnDeploymentConfig specifies customized variables", func() {
+		BeforeEach(func() {
+			deploymentConfigs = []*addonv1beta1.AddOnDeploymentConfig{{
+				Spec: addonv1beta1.AddOnDeploymentConfigSpec{
+					CustomizedVariables: []addonv1beta1.CustomizedVariable{
+						{Name: "cableDriver", Value: "wireguard"},
+						{Name: "catalogChannel", Value: "stable-0.25"},
+					},
+				},
+			}}
+		})
+
+		It("should apply those not set in the SubmarinerConfig", func() {
+			Expect(manifestsOf(works[0])).To(ContainElement(ContainSubstring(`"channel":"stable-0.25"`)))
+			Expect(manifestsOf(works[1])).To(ContainElement(ContainSubstring(`"cableDriver":"vxlan"`)))
+		})
+	})
+})
+
+var _ = Describe("RenderAddOnFrameworkManifests", func() {
+	It("should render the operator and resource manifests without the installation namespace", func() {
+		managedCluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "east"}}
+		config := &configv1alpha1.SubmarinerConfig{
+			Spec: configv1alpha1.SubmarinerConfigSpec{CableDriver: "vxlan"},
+		}
+
+		manifests, err := submarineragent.RenderAddOnFrameworkManifests(managedCluster,
+			brokerinfo.GetOffline(managedCluster.Name, "set1-broker", config, ""), config, nil)
+		Expect(err).To(Succeed())
+
+		rendered := manifestsOf(&workv1.ManifestWork{
+			Spec: workv1.ManifestWorkSpec{Workload: workv1.ManifestsTemplate{Manifests: manifests}},
+		})
+		Expect(rendered).To(ContainElement(ContainSubstring(`"kind":"Subscription"`)))
+		Expect(rendered).To(ContainElement(ContainSubstring(`"cableDriver":"vxlan"`)))
+		Expect(rendered).ToNot(ContainElement(ContainSubstring(`"kind":"Namespace"`)))
+	})
+})
+
+func manifestsOf(work *workv1.ManifestWork) []string {
+	manifests := make([]string, len(work.Spec.Workload.Manifests))
+	for i := range work.Spec.Workload.Manifests {
+		manifests[i] = string(work.Spec.Workload.Manifests[i].Raw)
+	}
+
+	return manifests
+}
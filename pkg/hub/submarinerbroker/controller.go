@@ -4,12 +4,15 @@ import (
 	"context"
 	"crypto/rand"
 	"embed"
+	"slices"
+	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	"github.com/pkg/errors"
 	"github.com/stolostron/submariner-addon/pkg/constants"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
 	brokerinfo "github.com/stolostron/submariner-addon/pkg/hub/submarinerbrokerinfo"
 	"github.com/stolostron/submariner-addon/pkg/resource"
 	"github.com/submariner-io/admiral/pkg/certificate"
@@ -40,8 +43,19 @@ const (
 	brokerFinalizer        = "cluster.open-cluster-management.io/submariner-cleanup"
 	SubmBrokerNamespaceKey = "cluster.open-cluster-management.io/submariner-broker-ns"
 	ipSecPSKSecretLength   = 48
+
+	// BrokerDeployedAnnotation records on a ManagedClusterSet that submariner agents were deployed against its broker. In
+	// restore-aware mode, the IPsec PSK and globalnet allocations of such a broker are restored from a backup rather than
+	// regenerated, so that the agents reconnect without dataplane disruption.
+	BrokerDeployedAnnotation = "submarineraddon.open-cluster-management.io/broker-deployed"
 )
 
+// ErrWaitingForRestore is returned while the state of a broker isn't restored from a backup yet, in restore-aware mode.
+var ErrWaitingForRestore = errors.New("waiting for the restore")
+
+// RestoreRetryInterval is the interval at which the state restored from a backup is checked for in restore-aware mode.
+var RestoreRetryInterval = 30 * time.Second
+
 var staticResourceFiles = []string{
 	"manifests/broker-namespace.yaml",
 	"manifests/broker-cluster-role.yaml",
@@ -53,7 +67,7 @@ var manifestFiles embed.FS
 var logger = log.Logger{Logger: logf.Log.WithName("SubmarinerBrokerController")}
 
 type submarinerBrokerController struct {
-	kubeClient         kubernetes.Interface
+	brokerClusters     *brokercluster.Resolver
 	clustersetClient   clientset.ManagedClusterSetInterface
 	clusterSetLister   clusterlisterv1beta2.ManagedClusterSetLister
 	addOnClient        addonclient.Interface
@@ -63,22 +77,24 @@ type submarinerBrokerController struct {
 	resourceCache      resourceapply.ResourceCache
 	restConfig         *rest.Config
 	signer             certificate.Signer
+	restoreAware       bool
 }
 
 type brokerConfig struct {
 	SubmarinerNamespace string
 }
 
-func NewController(kubeClient kubernetes.Interface,
+func NewController(brokerClusters *brokercluster.Resolver,
 	clustersetClient clientset.ManagedClusterSetInterface,
 	clusterSetInformer clusterinformerv1beta2.ManagedClusterSetInformer,
 	addOnClient addonclient.Interface,
 	addOnInformer addoninformerv1beta1.Interface,
 	restConfig *rest.Config,
+	restoreAware bool,
 	recorder events.Recorder,
 ) factory.Controller {
 	c := &submarinerBrokerController{
-		kubeClient:         kubeClient,
+		brokerClusters:     brokerClusters,
 		clustersetClient:   clustersetClient,
 		clusterSetLister:   clusterSetInformer.Lister(),
 		addOnClient:        addOnClient,
@@ -87,6 +103,7 @@ func NewController(kubeClient kubernetes.Interface,
 		eventRecorder:      recorder.WithComponentSuffix("submariner-broker-controller"),
 		resourceCache:      resourceapply.NewResourceCache(),
 		restConfig:         restConfig,
+		restoreAware:       restoreAware,
 	}
 
 	return factory.New().
@@ -167,7 +184,15 @@ func (c *submarinerBrokerController) sync(ctx context.Context, syncCtx factory.S
 		return nil
 	}
 
-	return c.reconcileManagedClusterSet(ctx, clusterSet, syncCtx.Recorder())
+	err = c.reconcileManagedClusterSet(ctx, clusterSet, syncCtx.Recorder())
+	if errors.Is(err, ErrWaitingForRestore) {
+		logger.Infof("Waiting to set up the broker of ManagedClusterSet %q: %v", clusterSetName, err)
+		syncCtx.Queue().AddAfter(clusterSetName, RestoreRetryInterval)
+
+		return nil
+	}
+
+	return err
 }
 
 func (c *submarinerBrokerController) reconcileManagedClusterSet(ctx context.Context, clusterSet *clusterv1beta2.ManagedClusterSet,
@@ -182,9 +207,26 @@ func (c *submarinerBrokerController) reconcileManagedClusterSet(ctx context.Cont
 		return c.doClusterSetCleanup(ctx, clusterSet, recorder)
 	}
 
-	brokerNS := brokerinfo.GenerateBrokerName(clusterSet.Name)
+	// The recorded broker namespace is kept as is, cluster sets set up with a former naming scheme keep their namespace.
+	brokerNS := GetBrokerNamespace(clusterSet)
+	if brokerNS == "" {
+		brokerNS = brokerinfo.GenerateBrokerNamespace(clusterSet.Name)
+	}
 
-	if !finalizer.IsPresent(clusterSet, brokerFinalizer) || clusterSet.GetAnnotations()[SubmBrokerNamespaceKey] != brokerNS {
+	owner, err := c.brokerNamespaceOwner(clusterSet, brokerNS)
+	if err != nil {
+		return err
+	}
+
+	if owner != "" {
+		recorder.Warningf("BrokerNamespaceConflict", "The broker namespace %q of ManagedClusterSet %q is used by ManagedClusterSet %q",
+			brokerNS, clusterSet.Name, owner)
+
+		return errors.Errorf("the broker namespace %q of ManagedClusterSet %q is used by ManagedClusterSet %q", brokerNS,
+			clusterSet.Name, owner)
+	}
+
+	if !finalizer.IsPresent(clusterSet, brokerFinalizer) || GetBrokerNamespace(clusterSet) != brokerNS {
 		err := util.Update(ctx, resource.ForManagedClusterSet(c.clustersetClient), clusterSet,
 			func(existing *clusterv1beta2.ManagedClusterSet) (*clusterv1beta2.ManagedClusterSet, error) {
 				objMeta := coreresource.MustToMeta(existing)
@@ -197,7 +239,9 @@ func (c *submarinerBrokerController) reconcileManagedClusterSet(ctx context.Cont
 				annotations[SubmBrokerNamespaceKey] = brokerNS
 				objMeta.SetAnnotations(annotations)
 
-				objMeta.SetFinalizers(append(objMeta.GetFinalizers(), brokerFinalizer))
+				if !slices.Contains(objMeta.GetFinalizers(), brokerFinalizer) {
+					objMeta.SetFinalizers(append(objMeta.GetFinalizers(), brokerFinalizer))
+				}
 
 				return existing, nil
 			})
@@ -210,45 +254,139 @@ func (c *submarinerBrokerController) reconcileManagedClusterSet(ctx context.Cont
 		return nil
 	}
 
+	broker, err := c.brokerClusters.ForClusterSet(ctx, clusterSet.Name)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	if broker.External {
+		// The CRDs controller only installs the broker CRDs on the hub.
+		err := resource.ApplyCRDs(ctx, broker.CRDClient, recorder, nil, func(yaml string) ([]byte, error) {
+			return []byte(yaml), nil
+		}, staticCRDFiles...)
+		if err != nil {
+			return err //nolint:wrapcheck // No need to wrap here
+		}
+	}
+
 	// Apply static files
-	err := resource.ApplyManifests(ctx, c.kubeClient, recorder, c.resourceCache, assetFunc(brokerNS), staticResourceFiles...)
+	err = resource.ApplyManifests(ctx, broker.KubeClient, recorder, c.resourceCache, assetFunc(brokerNS), staticResourceFiles...)
 	if err != nil {
 		return err //nolint:wrapcheck // No need to wrap here
 	}
 
-	if err := c.createIPSecPSKSecret(ctx, brokerNS); err != nil {
+	// In restore-aware mode, the IPsec PSK of a broker which was deployed against is restored rather than regenerated.
+	restored := c.restoreAware && IsBrokerDeployed(clusterSet)
+
+	if err := createIPSecPSKSecret(ctx, broker.KubeClient, brokerNS, restored); err != nil {
 		return err
 	}
 
+	if broker.External {
+		// The certificate signer only handles the certificate signing requests of the hub.
+		return nil
+	}
+
 	return c.setupCertificateManagement(ctx, brokerNS)
 }
 
-func (c *submarinerBrokerController) createIPSecPSKSecret(ctx context.Context, brokerNamespace string) error {
-	_, err := c.kubeClient.CoreV1().Secrets(brokerNamespace).Get(ctx, constants.IPSecPSKSecretName, metav1.GetOptions{})
-	if apierrors.IsNotFound(err) {
-		psk := make([]byte, ipSecPSKSecretLength)
-		if _, err := rand.Read(psk); err != nil {
-			return errors.Wrap(err, "error generating PSK secret")
-		}
+// GetBrokerNamespace returns the broker namespace recorded on the given cluster set, empty if its broker isn't set up yet.
+func GetBrokerNamespace(clusterSet *clusterv1beta2.ManagedClusterSet) string {
+	return clusterSet.GetAnnotations()[SubmBrokerNamespaceKey]
+}
+
+// IsBrokerDeployed returns whether submariner agents were deployed against the broker of the given cluster set.
+func IsBrokerDeployed(clusterSet *clusterv1beta2.ManagedClusterSet) bool {
+	_, found := clusterSet.GetAnnotations()[BrokerDeployedAnnotation]
+	return found
+}
 
-		pskSecret := &corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: constants.IPSecPSKSecretName,
-			},
-			Data: map[string][]byte{
-				"psk": psk,
-			},
+// brokerNamespaceOwner returns the other cluster set using the given broker namespace, if any. If several cluster sets
+// recorded the same broker namespace, e.g. with the former naming scheme, the oldest one keeps it.
+func (c *submarinerBrokerController) brokerNamespaceOwner(clusterSet *clusterv1beta2.ManagedClusterSet, brokerNS string,
+) (string, error) {
+	clusterSets, err := c.clusterSetLister.List(labels.Everything())
+	if err != nil {
+		return "", errors.Wrap(err, "error listing ManagedClusterSets")
+	}
+
+	recorded := GetBrokerNamespace(clusterSet) != ""
+
+	for _, other := range clusterSets {
+		if other.Name == clusterSet.Name || GetBrokerNamespace(other) != brokerNS {
+			continue
 		}
 
-		_, err = c.kubeClient.CoreV1().Secrets(brokerNamespace).Create(ctx, pskSecret, metav1.CreateOptions{})
-		if err == nil {
-			logger.Infof("Created IPSec PSK Secret %q in namespace %q", constants.IPSecPSKSecretName, brokerNamespace)
+		if !recorded || other.CreationTimestamp.Before(&clusterSet.CreationTimestamp) ||
+			(other.CreationTimestamp.Equal(&clusterSet.CreationTimestamp) && other.Name < clusterSet.Name) {
+			return other.Name, nil
 		}
 	}
 
+	return "", nil
+}
+
+func createIPSecPSKSecret(ctx context.Context, kubeClient kubernetes.Interface, brokerNamespace string, restored bool) error {
+	existing, err := kubeClient.CoreV1().Secrets(brokerNamespace).Get(ctx, constants.IPSecPSKSecretName, metav1.GetOptions{})
+	if err == nil {
+		// This handles the upgrade from a version that didn't add the label.
+		return addBackupLabel(ctx, kubeClient, existing)
+	}
+
+	if !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error retrieving IPSec PSK Secret %q", constants.IPSecPSKSecretName)
+	}
+
+	if restored {
+		return errors.Wrapf(ErrWaitingForRestore, "the IPSec PSK Secret %q in namespace %q isn't restored yet",
+			constants.IPSecPSKSecretName, brokerNamespace)
+	}
+
+	psk := make([]byte, ipSecPSKSecretLength)
+	if _, err := rand.Read(psk); err != nil {
+		return errors.Wrap(err, "error generating PSK secret")
+	}
+
+	pskSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   constants.IPSecPSKSecretName,
+			Labels: map[string]string{constants.BackupLabelKey: constants.BackupLabelValue},
+		},
+		Data: map[string][]byte{
+			"psk": psk,
+		},
+	}
+
+	_, err = kubeClient.CoreV1().Secrets(brokerNamespace).Create(ctx, pskSecret, metav1.CreateOptions{})
+	if err == nil {
+		logger.Infof("Created IPSec PSK Secret %q in namespace %q", constants.IPSecPSKSecretName, brokerNamespace)
+	}
+
 	return errors.Wrapf(err, "error creating IPSec PSK Secret %q", constants.IPSecPSKSecretName)
 }
 
+func addBackupLabel(ctx context.Context, kubeClient kubernetes.Interface, secret *corev1.Secret) error {
+	if _, ok := secret.Labels[constants.BackupLabelKey]; ok {
+		return nil
+	}
+
+	err := util.Update(ctx, coreresource.ForSecret(kubeClient, secret.Namespace), secret,
+		func(existing *corev1.Secret) (*corev1.Secret, error) {
+			if existing.Labels == nil {
+				existing.Labels = map[string]string{}
+			}
+
+			existing.Labels[constants.BackupLabelKey] = constants.BackupLabelValue
+
+			return existing, nil
+		})
+	if err == nil {
+		logger.Infof("Added backup label to Secret \"%s/%s\"", secret.Namespace, secret.Name)
+	}
+
+	return errors.Wrapf(err, "error adding backup label to Secret \"%s/%s\"", secret.Namespace, secret.Name)
+}
+
 func (c *submarinerBrokerController) doClusterSetCleanup(ctx context.Context, clusterSet *clusterv1beta2.ManagedClusterSet,
 	recorder events.Recorder,
 ) error {
@@ -257,11 +395,16 @@ func (c *submarinerBrokerController) doClusterSetCleanup(ctx context.Context, cl
 		return nil
 	}
 
+	broker, err := c.brokerClusters.ForClusterSet(ctx, clusterSet.Name)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
 	if c.signer != nil {
 		c.signer.Stop(brokerNS)
 	}
 
-	if err := resource.DeleteFromManifests(ctx, c.kubeClient, recorder, assetFunc(brokerNS), staticResourceFiles...); err != nil {
+	if err := resource.DeleteFromManifests(ctx, broker.KubeClient, recorder, assetFunc(brokerNS), staticResourceFiles...); err != nil {
 		return err //nolint:wrapcheck // No need to wrap here
 	}
 
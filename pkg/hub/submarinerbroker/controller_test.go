@@ -8,6 +8,7 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/stolostron/submariner-addon/pkg/constants"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
 	"github.com/stolostron/submariner-addon/pkg/hub/submarinerbroker"
 	"github.com/stolostron/submariner-addon/pkg/resource"
 	"github.com/submariner-io/admiral/pkg/certificate"
@@ -19,6 +20,7 @@ import (
 	"github.com/submariner-io/admiral/pkg/util"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,10 +41,29 @@ import (
 )
 
 const (
-	finalizerName  = "cluster.open-cluster-management.io/submariner-cleanup"
-	clusterSetName = "east"
-	brokerNS       = "east-broker"
-	brokerRoleName = "submariner-k8s-broker-cluster"
+	finalizerName        = "cluster.open-cluster-management.io/submariner-cleanup"
+	clusterSetName       = "east"
+	brokerNS             = "east-broker"
+	brokerRoleName       = "submariner-k8s-broker-cluster"
+	controllerNamespace  = "open-cluster-management"
+	externalBrokerSecret = "external-broker"
+	externalKubeConfig   = `apiVersion: v1
+kind: Config
+clusters:
+- name: broker
+  cluster:
+    server: https://api.broker.example.com:6443
+contexts:
+- name: broker
+  context:
+    cluster: broker
+    user: broker
+current-context: broker
+users:
+- name: broker
+  user:
+    token: broker-token
+`
 )
 
 var _ = Describe("Controller", func() {
@@ -75,6 +96,14 @@ func testManagedClusterSet() {
 			t.awaitSecret()
 		})
 
+		It("should label the IPsec PSK Secret resource for backup", func(ctx context.Context) {
+			t.awaitSecret()
+
+			secret, err := t.kubeClient.CoreV1().Secrets(brokerNS).Get(ctx, constants.IPSecPSKSecretName, metav1.GetOptions{})
+			Expect(err).To(Succeed())
+			Expect(secret.Labels).To(HaveKeyWithValue(constants.BackupLabelKey, constants.BackupLabelValue))
+		})
+
 		It("should create the CA certificate secret", func() {
 			t.awaitCASecret()
 		})
@@ -114,6 +143,179 @@ func testManagedClusterSet() {
 		})
 	})
 
+	When("a restored ManagedClusterSet whose broker was deployed is reconciled in restore-aware mode", func() {
+		BeforeEach(func() {
+			t.restoreAware = true
+			t.clusterSet.Annotations = map[string]string{
+				submarinerbroker.SubmBrokerNamespaceKey:   brokerNS,
+				submarinerbroker.BrokerDeployedAnnotation: "true",
+			}
+		})
+
+		It("should not generate a new IPsec PSK Secret resource", func(ctx context.Context) {
+			t.awaitNamespace()
+
+			Consistently(func() bool {
+				_, err := t.kubeClient.CoreV1().Secrets(brokerNS).Get(ctx, constants.IPSecPSKSecretName, metav1.GetOptions{})
+
+				return errors.IsNotFound(err)
+			}).Should(BeTrue(), "IPsec PSK Secret was generated")
+		})
+
+		Context("and the IPsec PSK Secret resource was restored", func() {
+			restoredPSK := []byte("restored-psk")
+
+			BeforeEach(func() {
+				t.kubeObjs = append(t.kubeObjs, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      constants.IPSecPSKSecretName,
+						Namespace: brokerNS,
+					},
+					Data: map[string][]byte{"psk": restoredPSK},
+				})
+			})
+
+			It("should reuse it and label it for backup", func(ctx context.Context) {
+				Eventually(func(g Gomega) {
+					secret, err := t.kubeClient.CoreV1().Secrets(brokerNS).Get(ctx, constants.IPSecPSKSecretName, metav1.GetOptions{})
+					g.Expect(err).To(Succeed())
+					g.Expect(secret.Labels).To(HaveKeyWithValue(constants.BackupLabelKey, constants.BackupLabelValue))
+					g.Expect(secret.Data).To(HaveKeyWithValue("psk", restoredPSK))
+				}).Should(Succeed())
+			})
+		})
+	})
+
+	When("a ManagedClusterSet with a previously recorded broker namespace is reconciled", func() {
+		const legacyBrokerNS = "ast-broker"
+
+		BeforeEach(func() {
+			t.clusterSet.Annotations = map[string]string{submarinerbroker.SubmBrokerNamespaceKey: legacyBrokerNS}
+		})
+
+		It("should keep using the recorded broker namespace", func(ctx context.Context) {
+			Eventually(func() error {
+				_, err := t.kubeClient.CoreV1().Namespaces().Get(ctx, legacyBrokerNS, metav1.GetOptions{})
+
+				return err
+			}).Should(Succeed(), "Broker Namespace not found")
+
+			t.ensureNoNamespace(ctx)
+
+			cs, err := t.clusterSetClient.ClusterV1beta2().ManagedClusterSets().Get(ctx, t.clusterSet.Name, metav1.GetOptions{})
+			Expect(err).To(Succeed())
+			Expect(cs.Annotations).To(HaveKeyWithValue(submarinerbroker.SubmBrokerNamespaceKey, legacyBrokerNS))
+		})
+	})
+
+	When("a ManagedClusterSet whose broker namespace is used by another ManagedClusterSet is created", func() {
+		BeforeEach(func() {
+			_, err := t.clusterSetClient.ClusterV1beta2().ManagedClusterSets().Create(context.Background(),
+				&clusterv1beta2.ManagedClusterSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "very-long-cluster-set-name-east",
+						Annotations: map[string]string{submarinerbroker.SubmBrokerNamespaceKey: brokerNS},
+					},
+				}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+		})
+
+		It("should not record the broker namespace on the ManagedClusterSet", func(ctx context.Context) {
+			Consistently(func(g Gomega) {
+				cs, err := t.clusterSetClient.ClusterV1beta2().ManagedClusterSets().Get(ctx, t.clusterSet.Name, metav1.GetOptions{})
+				g.Expect(err).To(Succeed())
+				g.Expect(cs.Annotations).ToNot(HaveKey(submarinerbroker.SubmBrokerNamespaceKey))
+				g.Expect(cs.Finalizers).ToNot(ContainElement(finalizerName))
+			}).Should(Succeed())
+		})
+	})
+
+	When("a ManagedClusterSet with an external broker is created", func() {
+		var (
+			externalKubeClient *kubeFake.Clientset
+			externalCRDClient  *apiextensionsfake.Clientset
+		)
+
+		BeforeEach(func() {
+			externalKubeClient = kubeFake.NewClientset()
+			externalCRDClient = apiextensionsfake.NewSimpleClientset() //nolint:staticcheck // The non-deprecated function is not available
+
+			newClientsForConfig := brokercluster.NewClientsForConfig
+			brokercluster.NewClientsForConfig = func(_ *rest.Config) (*brokercluster.Clients, error) {
+				return &brokercluster.Clients{KubeClient: externalKubeClient, CRDClient: externalCRDClient}, nil
+			}
+
+			DeferCleanup(func() {
+				brokercluster.NewClientsForConfig = newClientsForConfig
+			})
+
+			t.clusterSet.Annotations = map[string]string{brokercluster.ExternalBrokerAnnotation: externalBrokerSecret}
+			t.kubeObjs = append(t.kubeObjs, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      externalBrokerSecret,
+					Namespace: controllerNamespace,
+				},
+				Data: map[string][]byte{
+					brokercluster.KubeConfigSecretKey: []byte(externalKubeConfig),
+				},
+			})
+		})
+
+		It("should create the broker resources on the external cluster", func(ctx context.Context) {
+			Eventually(func() error {
+				_, err := externalKubeClient.CoreV1().Namespaces().Get(ctx, brokerNS, metav1.GetOptions{})
+
+				return err
+			}).Should(Succeed(), "Broker Namespace not found")
+
+			Eventually(func() error {
+				_, err := externalKubeClient.RbacV1().Roles(brokerNS).Get(ctx, brokerRoleName, metav1.GetOptions{})
+
+				return err
+			}).Should(Succeed(), "Broker Role not found")
+
+			Eventually(func() error {
+				_, err := externalKubeClient.CoreV1().Secrets(brokerNS).Get(ctx, "submariner-ipsec-psk", metav1.GetOptions{})
+
+				return err
+			}).Should(Succeed(), "IPsec PSK Secret not found")
+		})
+
+		It("should install the broker CRDs on the external cluster", func(ctx context.Context) {
+			Eventually(func() error {
+				_, err := externalCRDClient.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, "brokers.submariner.io",
+					metav1.GetOptions{})
+
+				return err
+			}).Should(Succeed(), "Broker CRD not found")
+		})
+
+		It("should not create the broker Namespace on the hub", func(ctx context.Context) {
+			t.ensureNoNamespace(ctx)
+		})
+
+		Context("and the ManagedClusterSet is deleted", func() {
+			JustBeforeEach(func(ctx context.Context) {
+				Eventually(func() error {
+					_, err := externalKubeClient.CoreV1().Namespaces().Get(ctx, brokerNS, metav1.GetOptions{})
+
+					return err
+				}).Should(Succeed())
+
+				Expect(t.clusterSetClient.ClusterV1beta2().ManagedClusterSets().Delete(ctx, t.clusterSet.Name,
+					metav1.DeleteOptions{})).To(Succeed())
+			})
+
+			It("should clean up the broker resources on the external cluster", func(ctx context.Context) {
+				Eventually(func() bool {
+					_, err := externalKubeClient.CoreV1().Namespaces().Get(ctx, brokerNS, metav1.GetOptions{})
+
+					return errors.IsNotFound(err)
+				}).Should(BeTrue(), "Broker Namespace still exists")
+			})
+		})
+	})
+
 	When("a ManagedClusterSet with SelectorType set to LabelSelector is created", func() {
 		BeforeEach(func() {
 			t.clusterSet.Spec.ClusterSelector.SelectorType = clusterv1beta2.LabelSelector
@@ -302,6 +504,7 @@ type brokerControllerTestDriver struct {
 	addOnClient      *addonfake.Clientset
 	clusterMgmtAddon *addonv1beta1.ClusterManagementAddOn
 	fakeDynClient    *dynamicfake.FakeDynamicClient
+	restoreAware     bool
 }
 
 func newBrokerControllerTestDriver() *brokerControllerTestDriver {
@@ -326,6 +529,7 @@ func newBrokerControllerTestDriver() *brokerControllerTestDriver {
 
 		t.kubeObjs = []runtime.Object{}
 		t.justBeforeRun = nil
+		t.restoreAware = false
 
 		t.clusterMgmtAddon = &addonv1beta1.ClusterManagementAddOn{
 			ObjectMeta: metav1.ObjectMeta{
@@ -362,7 +566,10 @@ func newBrokerControllerTestDriver() *brokerControllerTestDriver {
 			t.justBeforeRun()
 		}
 
-		controller := submarinerbroker.NewController(t.kubeClient,
+		brokerClusters := brokercluster.NewResolver(&brokercluster.Clients{KubeClient: t.kubeClient},
+			clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(), controllerNamespace)
+
+		controller := submarinerbroker.NewController(brokerClusters,
 			t.clusterSetClient.ClusterV1beta2().ManagedClusterSets(),
 			clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets(),
 			t.addOnClient,
@@ -370,6 +577,7 @@ func newBrokerControllerTestDriver() *brokerControllerTestDriver {
 			&rest.Config{
 				Host: "https://test-cluster",
 			},
+			t.restoreAware,
 			events.NewLoggingEventRecorder("test", clock.RealClock{}))
 
 		ctx, stop := context.WithCancel(context.TODO())
@@ -2,7 +2,9 @@ package submarinerbrokerinfo
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"net/url"
 	"os"
@@ -12,6 +14,7 @@ import (
 	"github.com/pkg/errors"
 	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
 	"github.com/stolostron/submariner-addon/pkg/constants"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
 	"github.com/submariner-io/admiral/pkg/log"
 	"github.com/submariner-io/admiral/pkg/reporter"
 	"github.com/submariner-io/submariner-operator/pkg/discovery/globalnet"
@@ -42,6 +45,7 @@ const (
 	ocpConfigNamespace            = "openshift-config"
 	brokerSuffix                  = "broker"
 	namespaceMaxLength            = 63
+	brokerNamespaceHashLength     = 8
 )
 
 var (
@@ -102,57 +106,40 @@ type SubmarinerBrokerInfo struct {
 	Tolerations               []corev1.Toleration
 }
 
-// Get retrieves submariner broker information consolidated with hub information.
+// Get retrieves submariner broker information consolidated with the information of the cluster hosting the broker.
 func Get(
 	ctx context.Context,
-	kubeClient kubernetes.Interface,
-	dynamicClient dynamic.Interface,
-	controllerClient controllerclient.Client,
+	broker *brokercluster.Clients,
 	clusterName string,
 	brokerNamespace string,
 	submarinerConfig *configv1alpha1.SubmarinerConfig,
 	installationNamespace string,
 ) (*SubmarinerBrokerInfo, error) {
-	brokerInfo := &SubmarinerBrokerInfo{
-		CableDriver:            defaultCableDriver,
-		IPSecNATTPort:          constants.SubmarinerNatTPort,
-		BrokerNamespace:        brokerNamespace,
-		ClusterName:            clusterName,
-		CatalogName:            catalogName,
-		CatalogSource:          defaultCatalogSource,
-		CatalogSourceNamespace: defaultCatalogSourceNamespace,
-		CatalogChannel:         defaultCatalogChannel,
-		InstallationNamespace:  defaultInstallationNamespace,
-		InstallPlanApproval:    "Automatic",
-		NodeSelector:           make(map[string]string),
-		Tolerations:            make([]corev1.Toleration, 0),
-		HaltOnCertificateError: true,
-	}
-
-	if installationNamespace != "" {
-		brokerInfo.InstallationNamespace = installationNamespace
-	}
+	brokerInfo := newBrokerInfo(clusterName, brokerNamespace, installationNamespace)
 
-	err := applyGlobalnetConfig(ctx, controllerClient, brokerNamespace, clusterName, brokerInfo, submarinerConfig)
+	err := applyGlobalnetConfig(ctx, broker.ControllerClient, brokerNamespace, clusterName, brokerInfo, submarinerConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	apiServer, err := getBrokerAPIServer(ctx, dynamicClient)
-	if err != nil {
-		return nil, err
+	apiServer := broker.APIServer
+	if apiServer == "" {
+		apiServer, err = getBrokerAPIServer(ctx, broker.DynamicClient)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	brokerInfo.BrokerAPIServer = apiServer
 
-	ipSecPSK, err := getIPSecPSK(ctx, kubeClient, brokerNamespace)
+	ipSecPSK, err := getIPSecPSK(ctx, broker.KubeClient, brokerNamespace)
 	if err != nil {
 		return nil, err
 	}
 
 	brokerInfo.IPSecPSK = ipSecPSK
 
-	token, ca, err := getBrokerTokenAndCA(ctx, kubeClient, dynamicClient, brokerNamespace, clusterName, apiServer)
+	token, ca, err := getBrokerTokenAndCA(ctx, broker, brokerNamespace, clusterName, apiServer)
 	if err != nil {
 		return nil, err
 	}
@@ -165,6 +152,47 @@ func Get(
 	return brokerInfo, nil
 }
 
+// GetOffline returns the submariner broker information derived from the given submariner config only, without accessing
+// the hub. The broker API server, token, CA and IPsec PSK are left empty and the global CIDR is only set if it's specified
+// in the config.
+func GetOffline(clusterName, brokerNamespace string, submarinerConfig *configv1alpha1.SubmarinerConfig,
+	installationNamespace string,
+) *SubmarinerBrokerInfo {
+	brokerInfo := newBrokerInfo(clusterName, brokerNamespace, installationNamespace)
+
+	if submarinerConfig != nil {
+		brokerInfo.GlobalCIDR = submarinerConfig.Spec.GlobalCIDR
+	}
+
+	applySubmarinerConfig(brokerInfo, submarinerConfig)
+
+	return brokerInfo
+}
+
+func newBrokerInfo(clusterName, brokerNamespace, installationNamespace string) *SubmarinerBrokerInfo {
+	brokerInfo := &SubmarinerBrokerInfo{
+		CableDriver:            defaultCableDriver,
+		IPSecNATTPort:          constants.SubmarinerNatTPort,
+		BrokerNamespace:        brokerNamespace,
+		ClusterName:            clusterName,
+		CatalogName:            catalogName,
+		CatalogSource:          defaultCatalogSource,
+		CatalogSourceNamespace: defaultCatalogSourceNamespace,
+		CatalogChannel:         defaultCatalogChannel,
+		InstallationNamespace:  defaultInstallationNamespace,
+		InstallPlanApproval:    "Automatic",
+		NodeSelector:           make(map[string]string),
+		Tolerations:            make([]corev1.Toleration, 0),
+		HaltOnCertificateError: true,
+	}
+
+	if installationNamespace != "" {
+		brokerInfo.InstallationNamespace = installationNamespace
+	}
+
+	return brokerInfo
+}
+
 func applyGlobalnetConfig(ctx context.Context, controllerClient controllerclient.Client, brokerNamespace,
 	clusterName string, brokerInfo *SubmarinerBrokerInfo, submarinerConfig *configv1alpha1.SubmarinerConfig,
 ) error {
@@ -339,20 +367,19 @@ func getKubeAPIServerCA(ctx context.Context, kubeAPIServer string, kubeClient ku
 	return nil, nil
 }
 
-func getBrokerTokenAndCA(ctx context.Context, kubeClient kubernetes.Interface, dynamicClient dynamic.Interface,
-	brokerNS, clusterName, kubeAPIServer string,
+func getBrokerTokenAndCA(ctx context.Context, broker *brokercluster.Clients, brokerNS, clusterName, kubeAPIServer string,
 ) (string, string, error) {
-	sa, err := kubeClient.CoreV1().ServiceAccounts(brokerNS).Get(ctx, clusterName, metav1.GetOptions{})
+	sa, err := broker.KubeClient.CoreV1().ServiceAccounts(brokerNS).Get(ctx, clusterName, metav1.GetOptions{})
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get agent ServiceAccount %v/%v: %w", brokerNS, clusterName, err)
 	}
 
-	tokenSecret, err := getTokenSecretForSA(ctx, kubeClient, sa)
+	tokenSecret, err := getTokenSecretForSA(ctx, broker.KubeClient, sa)
 	if err != nil {
 		return "", "", err
 	}
 
-	return getTokenAndCAFromSecret(ctx, tokenSecret, kubeAPIServer, kubeClient, dynamicClient)
+	return getTokenAndCAFromSecret(ctx, tokenSecret, kubeAPIServer, broker)
 }
 
 func getTokenSecretForSA(ctx context.Context, client kubernetes.Interface, sa *corev1.ServiceAccount,
@@ -367,9 +394,25 @@ func getTokenSecretForSA(ctx context.Context, client kubernetes.Interface, sa *c
 	}
 
 	for i := range saSecrets.Items {
-		if saSecrets.Items[i].Annotations[corev1.ServiceAccountNameKey] == sa.Name {
-			secret = &saSecrets.Items[i]
+		if saSecrets.Items[i].Annotations[corev1.ServiceAccountNameKey] != sa.Name {
+			continue
 		}
+
+		// A token issued for another ServiceAccount with the same name, e.g. restored from the backup of another hub, isn't
+		// valid anymore and is re-issued.
+		if uid := saSecrets.Items[i].Annotations[corev1.ServiceAccountUIDKey]; uid != "" && uid != string(sa.UID) {
+			err := client.CoreV1().Secrets(sa.Namespace).Delete(ctx, saSecrets.Items[i].Name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "failed to delete stale token secret %s/%s", sa.Namespace, saSecrets.Items[i].Name)
+			}
+
+			logger.Infof("Deleted the stale token secret %s/%s of service account %q", sa.Namespace, saSecrets.Items[i].Name,
+				sa.Name)
+
+			continue
+		}
+
+		secret = &saSecrets.Items[i]
 	}
 
 	// Secret not found, so create one and return.
@@ -392,15 +435,20 @@ func getTokenSecretForSA(ctx context.Context, client kubernetes.Interface, sa *c
 	return secret, nil
 }
 
-func getTokenAndCAFromSecret(ctx context.Context, tokenSecret *corev1.Secret, kubeAPIServer string,
-	kubeClient kubernetes.Interface, dynamicClient dynamic.Interface,
+func getTokenAndCAFromSecret(ctx context.Context, tokenSecret *corev1.Secret, kubeAPIServer string, broker *brokercluster.Clients,
 ) (string, string, error) {
 	if len(tokenSecret.Data) == 0 || tokenSecret.Data["token"] == nil {
 		return "", "", fmt.Errorf("token data not yet generated for secret %s/%s", tokenSecret.Namespace, tokenSecret.Name)
 	}
 
+	// use the ca configured for the cluster set if any
+	if len(broker.CA) > 0 {
+		return base64.StdEncoding.EncodeToString(tokenSecret.Data["token"]),
+			base64.StdEncoding.EncodeToString(broker.CA), nil
+	}
+
 	// try to get ca from apiserver secret firstly, if the ca cannot be found, get it from sa
-	kubeAPIServerCA, err := getKubeAPIServerCA(ctx, kubeAPIServer, kubeClient, dynamicClient)
+	kubeAPIServerCA, err := getKubeAPIServerCA(ctx, kubeAPIServer, broker.KubeClient, broker.DynamicClient)
 	if err != nil {
 		return "", "", err
 	}
@@ -414,6 +462,21 @@ func getTokenAndCAFromSecret(ctx context.Context, tokenSecret *corev1.Secret, ku
 		base64.StdEncoding.EncodeToString(kubeAPIServerCA), nil
 }
 
+// GenerateBrokerNamespace returns the broker namespace of the given cluster set. The cluster set name is suffixed with
+// "-broker" if the result is a valid namespace name, otherwise it's truncated and suffixed with a hash of the full name too,
+// so that long names sharing a prefix don't collide.
+func GenerateBrokerNamespace(clusterSetName string) string {
+	brokerNamespace := fmt.Sprintf("%s-%s", clusterSetName, brokerSuffix)
+	if len(brokerNamespace) <= namespaceMaxLength {
+		return brokerNamespace
+	}
+
+	hash := sha256.Sum256([]byte(clusterSetName))
+	suffix := fmt.Sprintf("-%s-%s", hex.EncodeToString(hash[:])[:brokerNamespaceHashLength], brokerSuffix)
+
+	return strings.TrimRight(clusterSetName[:namespaceMaxLength-len(suffix)], "-") + suffix
+}
+
 func GenerateBrokerName(name string) string {
 	brokerName := fmt.Sprintf("%s-%s", name, brokerSuffix)
 	if len(brokerName) > namespaceMaxLength {
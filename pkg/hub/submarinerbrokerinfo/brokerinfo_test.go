@@ -3,21 +3,26 @@ package submarinerbrokerinfo_test
 import (
 	"context"
 	"encoding/base64"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	apiconfigv1 "github.com/openshift/api/config/v1"
 	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
 	"github.com/stolostron/submariner-addon/pkg/hub/submarinerbrokerinfo"
 	"github.com/submariner-io/submariner-operator/pkg/discovery/globalnet"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/kubernetes/scheme"
+	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -47,13 +52,18 @@ var _ = Describe("Function Get", func() {
 		serviceAccountSecret  *corev1.Secret
 		gnConfigMap           *corev1.ConfigMap
 		kubeObjs              []runtime.Object
+		kubeClient            *kubefake.Clientset
 		dynamicObjs           []runtime.Object
+		externalAPIServer     string
+		brokerCAOverride      []byte
 		brokerInfo            *submarinerbrokerinfo.SubmarinerBrokerInfo
 		err                   error
 	)
 
 	BeforeEach(func() {
 		installationNamespace = ""
+		externalAPIServer = ""
+		brokerCAOverride = nil
 
 		infrastructure = &unstructured.Unstructured{
 			Object: map[string]any{
@@ -110,11 +120,17 @@ var _ = Describe("Function Get", func() {
 			brokerObjs = append(brokerObjs, gnConfigMap)
 		}
 
+		kubeClient = kubefake.NewClientset(kubeObjs...)
+
 		brokerInfo, err = submarinerbrokerinfo.Get(
 			context.TODO(),
-			kubefake.NewClientset(kubeObjs...),
-			dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), dynamicObjs...),
-			fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(brokerObjs...).Build(),
+			&brokercluster.Clients{
+				KubeClient:       kubeClient,
+				DynamicClient:    dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), dynamicObjs...),
+				ControllerClient: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(brokerObjs...).Build(),
+				APIServer:        externalAPIServer,
+				CA:               brokerCAOverride,
+			},
 			clusterName,
 			brokerNamespace,
 			submarinerConfig,
@@ -306,6 +322,29 @@ var _ = Describe("Function Get", func() {
 			It("should return the correct BrokerCA", func() {
 				Expect(brokerInfo.BrokerCA).To(Equal(base64.StdEncoding.EncodeToString(tlsData)))
 			})
+
+			Context("and the broker CA is overridden", func() {
+				BeforeEach(func() {
+					brokerCAOverride = []byte("override-ca")
+				})
+
+				It("should return the overridden BrokerCA", func() {
+					Expect(brokerInfo.BrokerCA).To(Equal(base64.StdEncoding.EncodeToString(brokerCAOverride)))
+				})
+			})
+		})
+
+		When("the broker API server and CA are overridden", func() {
+			BeforeEach(func() {
+				externalAPIServer = "broker-lb.example.com:443"
+				brokerCAOverride = []byte("override-ca")
+			})
+
+			It("should return the overridden broker API server and CA", func() {
+				Expect(brokerInfo.BrokerAPIServer).To(Equal(externalAPIServer))
+				Expect(brokerInfo.BrokerCA).To(Equal(base64.StdEncoding.EncodeToString(brokerCAOverride)))
+				Expect(brokerInfo.BrokerToken).To(Equal(base64.StdEncoding.EncodeToString([]byte(brokerToken))))
+			})
 		})
 	})
 
@@ -329,6 +368,19 @@ var _ = Describe("Function Get", func() {
 		})
 	})
 
+	When("the broker is hosted on an external cluster", func() {
+		BeforeEach(func() {
+			externalAPIServer = "api.broker.example.com:6443"
+			dynamicObjs = []runtime.Object{}
+		})
+
+		It("should return its API server", func() {
+			Expect(err).To(Succeed())
+			Expect(brokerInfo.BrokerAPIServer).To(Equal(externalAPIServer))
+			Expect(brokerInfo.BrokerCA).To(Equal(base64.StdEncoding.EncodeToString([]byte(brokerCA))))
+		})
+	})
+
 	When("the Infrastructure resource is missing the apiServerURL field", func() {
 		BeforeEach(func() {
 			Expect(unstructured.SetNestedMap(infrastructure.Object, map[string]any{}, "status")).To(Succeed())
@@ -359,6 +411,39 @@ var _ = Describe("Function Get", func() {
 		})
 	})
 
+	When("a token Secret of a previous cluster ServiceAccount remains", func() {
+		const staleSecretName = clusterName + "-token-zzzzz"
+
+		BeforeEach(func() {
+			serviceAccount.UID = "current-uid"
+			serviceAccountSecret.Annotations[corev1.ServiceAccountUIDKey] = "current-uid"
+
+			kubeObjs = append(kubeObjs, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      staleSecretName,
+					Namespace: brokerNamespace,
+					Annotations: map[string]string{
+						corev1.ServiceAccountNameKey: serviceAccount.Name,
+						corev1.ServiceAccountUIDKey:  "previous-uid",
+					},
+				},
+				Data: map[string][]byte{
+					"ca.crt": []byte("stale-CA"),
+					"token":  []byte("stale-token"),
+				},
+				Type: corev1.SecretTypeServiceAccountToken,
+			})
+		})
+
+		It("should use the token of the current ServiceAccount and delete the stale one", func(ctx context.Context) {
+			Expect(err).To(Succeed())
+			Expect(brokerInfo.BrokerToken).To(Equal(base64.StdEncoding.EncodeToString([]byte(brokerToken))))
+
+			_, err := kubeClient.CoreV1().Secrets(brokerNamespace).Get(ctx, staleSecretName, metav1.GetOptions{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
 	When("the cluster ServiceAccount Secret resource is missing", func() {
 		BeforeEach(func() {
 			kubeObjs = []runtime.Object{ipsecSecret, serviceAccount}
@@ -370,6 +455,175 @@ var _ = Describe("Function Get", func() {
 	})
 })
 
+var _ = Describe("Function GetOffline", func() {
+	It("should derive the broker information from the SubmarinerConfig only", func() {
+		brokerInfo := submarinerbrokerinfo.GetOffline(clusterName, brokerNamespace, &configv1alpha1.SubmarinerConfig{
+			Spec: configv1alpha1.SubmarinerConfigSpec{
+				CableDriver:   "vxlan",
+				IPSecNATTPort: 4501,
+				GlobalCIDR:    "242.0.0.0/16",
+			},
+		}, "submariner-ns")
+
+		Expect(brokerInfo.ClusterName).To(Equal(clusterName))
+		Expect(brokerInfo.BrokerNamespace).To(Equal(brokerNamespace))
+		Expect(brokerInfo.InstallationNamespace).To(Equal("submariner-ns"))
+		Expect(brokerInfo.CableDriver).To(Equal("vxlan"))
+		Expect(brokerInfo.IPSecNATTPort).To(Equal(4501))
+		Expect(brokerInfo.GlobalCIDR).To(Equal("242.0.0.0/16"))
+		Expect(brokerInfo.BrokerAPIServer).To(BeEmpty())
+		Expect(brokerInfo.BrokerToken).To(BeEmpty())
+		Expect(brokerInfo.IPSecPSK).To(BeEmpty())
+	})
+
+	It("should use the defaults without a SubmarinerConfig", func() {
+		brokerInfo := submarinerbrokerinfo.GetOffline(clusterName, brokerNamespace, nil, "")
+
+		Expect(brokerInfo.CableDriver).To(Equal("libreswan"))
+		Expect(brokerInfo.InstallationNamespace).To(Equal("open-cluster-management-agent-addon"))
+	})
+})
+
+var _ = Describe("Function ApplyCustomizedVariables", func() {
+	var (
+		brokerInfo       *submarinerbrokerinfo.SubmarinerBrokerInfo
+		submarinerConfig *configv1alpha1.SubmarinerConfig
+		variables        []addonv1beta1.CustomizedVariable
+		err              error
+	)
+
+	BeforeEach(func() {
+		submarinerConfig = nil
+		variables = []addonv1beta1.CustomizedVariable{
+			{Name: "cableDriver", Value: "wireguard"},
+			{Name: "natEnabled", Value: "true"},
+			{Name: "debug", Value: "true"},
+			{Name: "catalogChannel", Value: "stable-0.25"},
+			{Name: "nettestImage", Value: "quay.io/submariner/nettest:custom"},
+			{Name: "unrelated", Value: "value"},
+		}
+	})
+
+	JustBeforeEach(func() {
+		brokerInfo = submarinerbrokerinfo.GetOffline(clusterName, brokerNamespace, submarinerConfig, "")
+		err = submarinerbrokerinfo.ApplyCustomizedVariables(brokerInfo, variables, submarinerConfig)
+	})
+
+	It("should set the named fields", func() {
+		Expect(err).To(Succeed())
+		Expect(brokerInfo.CableDriver).To(Equal("wireguard"))
+		Expect(brokerInfo.NATEnabled).To(BeTrue())
+		Expect(brokerInfo.Debug).To(BeTrue())
+		Expect(brokerInfo.CatalogChannel).To(Equal("stable-0.25"))
+		Expect(brokerInfo.NettestImage).To(Equal("quay.io/submariner/nettest:custom"))
+		Expect(submarinerbrokerinfo.EffectiveCustomizedVariables(brokerInfo)).To(Equal("cableDriver=wireguard, natEnabled=true, " +
+			"debug=true, ipsecDebug=false, catalogChannel=stable-0.25, catalogSource=redhat-operators, " +
+			"catalogSourceNamespace=openshift-marketplace, installPlanApproval=Automatic, " +
+			"nettestImage=quay.io/submariner/nettest:custom"))
+	})
+
+	Context("with a SubmarinerConfig holding the CRD defaults", func() {
+		BeforeEach(func() {
+			submarinerConfig = newDefaultedSubmarinerConfig()
+			variables = append(variables,
+				addonv1beta1.CustomizedVariable{Name: "catalogSource", Value: "custom-operators"},
+				addonv1beta1.CustomizedVariable{Name: "catalogSourceNamespace", Value: "custom-marketplace"},
+				addonv1beta1.CustomizedVariable{Name: "ipsecDebug", Value: "true"})
+		})
+
+		It("should set the named fields", func() {
+			Expect(err).To(Succeed())
+			Expect(brokerInfo.CableDriver).To(Equal("wireguard"))
+			Expect(brokerInfo.NATEnabled).To(BeTrue())
+			Expect(brokerInfo.Debug).To(BeTrue())
+			Expect(brokerInfo.IPSecDebug).To(BeTrue())
+			Expect(brokerInfo.CatalogSource).To(Equal("custom-operators"))
+			Expect(brokerInfo.CatalogSourceNamespace).To(Equal("custom-marketplace"))
+		})
+
+		Context("and a variable disabling NAT", func() {
+			BeforeEach(func() {
+				variables = []addonv1beta1.CustomizedVariable{{Name: "natEnabled", Value: "false"}}
+			})
+
+			It("should disable NAT", func() {
+				Expect(err).To(Succeed())
+				Expect(brokerInfo.NATEnabled).To(BeFalse())
+			})
+		})
+	})
+
+	Context("with a SubmarinerConfig overriding the CRD defaults", func() {
+		BeforeEach(func() {
+			submarinerConfig = newDefaultedSubmarinerConfig()
+			submarinerConfig.Spec.CableDriver = "vxlan"
+			submarinerConfig.Spec.NATTEnable = false
+			submarinerConfig.Spec.Debug = true
+			variables = append(variables, addonv1beta1.CustomizedVariable{Name: "debug", Value: "false"})
+		})
+
+		It("should give precedence to the SubmarinerConfig", func() {
+			Expect(err).To(Succeed())
+			Expect(brokerInfo.CableDriver).To(Equal("vxlan"))
+			Expect(brokerInfo.NATEnabled).To(BeFalse())
+			Expect(brokerInfo.Debug).To(BeTrue())
+			Expect(brokerInfo.CatalogChannel).To(Equal("stable-0.25"))
+			Expect(brokerInfo.NettestImage).To(Equal("quay.io/submariner/nettest:custom"))
+		})
+	})
+
+	Context("with an invalid boolean value", func() {
+		BeforeEach(func() {
+			variables = []addonv1beta1.CustomizedVariable{{Name: "natEnabled", Value: "maybe"}}
+		})
+
+		It("should return an error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("natEnabled"))
+		})
+	})
+})
+
+var _ = Describe("Function GenerateBrokerNamespace", func() {
+	It("should suffix short cluster set names", func() {
+		Expect(submarinerbrokerinfo.GenerateBrokerNamespace("east")).To(Equal("east-broker"))
+	})
+
+	It("should generate distinct valid names for long cluster set names sharing a prefix", func() {
+		prefix := strings.Repeat("a", 60)
+
+		first := submarinerbrokerinfo.GenerateBrokerNamespace(prefix + "-first")
+		second := submarinerbrokerinfo.GenerateBrokerNamespace(prefix + "-second")
+
+		Expect(first).ToNot(Equal(second))
+		Expect(first).To(HaveLen(63))
+		Expect(first).To(HavePrefix(prefix[:46]))
+		Expect(first).To(HaveSuffix("-broker"))
+		Expect(validation.IsDNS1123Label(first)).To(BeEmpty())
+		Expect(validation.IsDNS1123Label(second)).To(BeEmpty())
+		Expect(submarinerbrokerinfo.GenerateBrokerNamespace(prefix + "-first")).To(Equal(first))
+	})
+})
+
+// newDefaultedSubmarinerConfig returns a SubmarinerConfig as stored by the API server, i.e. with the CRD defaults set.
+func newDefaultedSubmarinerConfig() *configv1alpha1.SubmarinerConfig {
+	return &configv1alpha1.SubmarinerConfig{
+		Spec: configv1alpha1.SubmarinerConfigSpec{
+			CableDriver:            "libreswan",
+			IPSecIKEPort:           500,
+			IPSecNATTPort:          4500,
+			NATTDiscoveryPort:      4900,
+			NATTEnable:             true,
+			HaltOnCertificateError: true,
+			CloudDriftRemediation:  configv1alpha1.CloudDriftRemediationNone,
+			SubscriptionConfig: configv1alpha1.SubscriptionConfig{
+				Source:          "redhat-operators",
+				SourceNamespace: "openshift-marketplace",
+			},
+		},
+	}
+}
+
 func newGlobalnetConfigMap(globalnetEnabled bool, cidrRange string, clusterSize uint) *corev1.ConfigMap {
 	configMap, err := globalnet.NewGlobalnetConfigMap(globalnetEnabled, cidrRange, clusterSize, brokerNamespace)
 	Expect(err).To(Succeed())
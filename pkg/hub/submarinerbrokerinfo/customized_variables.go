@@ -0,0 +1,172 @@
+package submarinerbrokerinfo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	addonv1beta1 "open-cluster-management.io/api/addon/v1beta1"
+)
+
+// customizedVariables maps the names of the AddOnDeploymentConfig customized variables honored for Submariner to the
+// SubmarinerBrokerInfo field they set, either a *string or a *bool, and to a function which reports whether the
+// corresponding SubmarinerConfig field was set to something other than its CRD default.
+var customizedVariables = []struct {
+	name       string
+	field      func(*SubmarinerBrokerInfo) any
+	configured func(*configv1alpha1.SubmarinerConfigSpec) bool
+}{
+	{
+		"cableDriver", func(b *SubmarinerBrokerInfo) any { return &b.CableDriver },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool { return isSet(s.CableDriver, defaultCableDriver) },
+	},
+	{
+		"natEnabled", func(b *SubmarinerBrokerInfo) any { return &b.NATEnabled },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool { return !s.NATTEnable },
+	},
+	{
+		"debug", func(b *SubmarinerBrokerInfo) any { return &b.Debug },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool { return s.Debug },
+	},
+	{
+		"ipsecDebug", func(b *SubmarinerBrokerInfo) any { return &b.IPSecDebug },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool { return s.IPSecDebug },
+	},
+	{
+		"catalogChannel", func(b *SubmarinerBrokerInfo) any { return &b.CatalogChannel },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool { return isSet(s.SubscriptionConfig.Channel, "") },
+	},
+	{
+		"catalogSource", func(b *SubmarinerBrokerInfo) any { return &b.CatalogSource },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool {
+			return isSet(s.SubscriptionConfig.Source, defaultCatalogSource)
+		},
+	},
+	{
+		"catalogSourceNamespace", func(b *SubmarinerBrokerInfo) any { return &b.CatalogSourceNamespace },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool {
+			return isSet(s.SubscriptionConfig.SourceNamespace, defaultCatalogSourceNamespace)
+		},
+	},
+	{
+		"catalogStartingCSV", func(b *SubmarinerBrokerInfo) any { return &b.CatalogStartingCSV },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool { return isSet(s.SubscriptionConfig.StartingCSV, "") },
+	},
+	{
+		"installPlanApproval", func(b *SubmarinerBrokerInfo) any { return &b.InstallPlanApproval },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool {
+			return isSet(s.SubscriptionConfig.InstallPlanApproval, "")
+		},
+	},
+	{
+		"submarinerImage", func(b *SubmarinerBrokerInfo) any { return &b.SubmarinerGatewayImage },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool {
+			return isSet(s.ImagePullSpecs.SubmarinerImagePullSpec, "")
+		},
+	},
+	{
+		"submarinerRouteAgentImage", func(b *SubmarinerBrokerInfo) any { return &b.SubmarinerRouteAgentImage },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool {
+			return isSet(s.ImagePullSpecs.SubmarinerRouteAgentImagePullSpec, "")
+		},
+	},
+	{
+		"submarinerGlobalnetImage", func(b *SubmarinerBrokerInfo) any { return &b.SubmarinerGlobalnetImage },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool {
+			return isSet(s.ImagePullSpecs.SubmarinerGlobalnetImagePullSpec, "")
+		},
+	},
+	{
+		"lighthouseAgentImage", func(b *SubmarinerBrokerInfo) any { return &b.LighthouseAgentImage },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool {
+			return isSet(s.ImagePullSpecs.LighthouseAgentImagePullSpec, "")
+		},
+	},
+	{
+		"lighthouseCoreDNSImage", func(b *SubmarinerBrokerInfo) any { return &b.LighthouseCoreDNSImage },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool {
+			return isSet(s.ImagePullSpecs.LighthouseCoreDNSImagePullSpec, "")
+		},
+	},
+	{
+		"metricsProxyImage", func(b *SubmarinerBrokerInfo) any { return &b.MetricsProxyImage },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool {
+			return isSet(s.ImagePullSpecs.MetricsProxyImagePullSpec, "")
+		},
+	},
+	{
+		"nettestImage", func(b *SubmarinerBrokerInfo) any { return &b.NettestImage },
+		func(s *configv1alpha1.SubmarinerConfigSpec) bool {
+			return isSet(s.ImagePullSpecs.NettestImagePullSpec, "")
+		},
+	},
+}
+
+// ApplyCustomizedVariables sets the fields of the broker info named by the given AddOnDeploymentConfig customized
+// variables, ignoring the variables which aren't Submariner tunables. A field which the given SubmarinerConfig sets to
+// something other than its CRD default keeps the SubmarinerConfig value, so that the per-cluster configuration takes
+// precedence over the AddOnDeploymentConfigs.
+func ApplyCustomizedVariables(brokerInfo *SubmarinerBrokerInfo, variables []addonv1beta1.CustomizedVariable,
+	submarinerConfig *configv1alpha1.SubmarinerConfig,
+) error {
+	for _, variable := range variables {
+		for i := range customizedVariables {
+			if customizedVariables[i].name != variable.Name {
+				continue
+			}
+
+			switch field := customizedVariables[i].field(brokerInfo).(type) {
+			case *string:
+				if !configuredInSubmarinerConfig(submarinerConfig, customizedVariables[i].configured) {
+					*field = variable.Value
+				}
+			case *bool:
+				value, err := strconv.ParseBool(variable.Value)
+				if err != nil {
+					return errors.Wrapf(err, "invalid value %q of customized variable %q", variable.Value, variable.Name)
+				}
+
+				if !configuredInSubmarinerConfig(submarinerConfig, customizedVariables[i].configured) {
+					*field = value
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func configuredInSubmarinerConfig(submarinerConfig *configv1alpha1.SubmarinerConfig,
+	configured func(*configv1alpha1.SubmarinerConfigSpec) bool,
+) bool {
+	return submarinerConfig != nil && configured(&submarinerConfig.Spec)
+}
+
+func isSet(value, defaultValue string) bool {
+	return value != "" && value != defaultValue
+}
+
+// EffectiveCustomizedVariables returns the values of the broker info fields which can be set by customized variables,
+// as a comma-separated list of name=value pairs. Unset values, e.g. of the image overrides, are omitted.
+func EffectiveCustomizedVariables(brokerInfo *SubmarinerBrokerInfo) string {
+	values := make([]string, 0, len(customizedVariables))
+
+	for i := range customizedVariables {
+		var value string
+
+		switch field := customizedVariables[i].field(brokerInfo).(type) {
+		case *string:
+			value = *field
+		case *bool:
+			value = strconv.FormatBool(*field)
+		}
+
+		if value != "" {
+			values = append(values, fmt.Sprintf("%s=%s", customizedVariables[i].name, value))
+		}
+	}
+
+	return strings.Join(values, ", ")
+}
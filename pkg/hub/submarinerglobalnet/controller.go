@@ -0,0 +1,484 @@
+package submarinerglobalnet
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/pkg/errors"
+	configinformer "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/informers/externalversions/submarinerconfig/v1alpha1"
+	configlister "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/listers/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/constants"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarinerbroker"
+	"github.com/submariner-io/admiral/pkg/log"
+	"github.com/submariner-io/submariner-operator/pkg/discovery/globalnet"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/retry"
+	clientset "open-cluster-management.io/api/client/cluster/clientset/versioned/typed/cluster/v1beta2"
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
+	clusterinformerv1beta2 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta2"
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// ConditionGlobalCIDRsConflicting is set on the ManagedClusterSets with globalnet enabled. It is true if global
+	// CIDRs of the cluster set overlap or are outside the globalnet CIDR range.
+	ConditionGlobalCIDRsConflicting = "SubmarinerGlobalnetCIDRsConflicting"
+	// ConditionCapacityLow is set on the ManagedClusterSets with globalnet enabled. It is true if the globalnet CIDR
+	// range has room for few or no more clusters.
+	ConditionCapacityLow = "SubmarinerGlobalnetCapacityLow"
+	// ConditionCIDRsQuarantined is set on the ManagedClusterSets with globalnet enabled. It is true if global CIDRs
+	// released by clusters which left the cluster set are quarantined, i.e. not reused yet.
+	ConditionCIDRsQuarantined = "SubmarinerGlobalnetCIDRsQuarantined"
+
+	ReasonNoConflicts            = "NoConflicts"
+	ReasonOverlappingGlobalCIDRs = "OverlappingGlobalCIDRs"
+	ReasonGlobalCIDROutOfRange   = "GlobalCIDROutOfRange"
+	ReasonCapacitySufficient     = "CapacitySufficient"
+	ReasonCapacityLow            = "CapacityLow"
+	ReasonCapacityExhausted      = "CapacityExhausted"
+	ReasonCIDRsQuarantined       = "CIDRsQuarantined"
+	ReasonNoQuarantinedCIDRs     = "NoQuarantinedCIDRs"
+
+	// lowCapacityPercentage is the percentage of the globalnet CIDR range left, under which the capacity is reported as
+	// low.
+	lowCapacityPercentage = 10
+
+	resyncInterval = 5 * time.Minute
+)
+
+var logger = log.Logger{Logger: logf.Log.WithName("SubmarinerGlobalnetController")}
+
+// submarinerGlobalnetController inspects the globalnet allocations of each cluster set, i.e. the global CIDRs recorded
+// in the globalnet ConfigMap of the broker namespace and the global CIDRs specified in the SubmarinerConfigs of the
+// clusters of the set, and reports the conflicts and the remaining capacity as conditions of the ManagedClusterSet.
+type submarinerGlobalnetController struct {
+	clusterSetClient clientset.ManagedClusterSetInterface
+	clusterSetLister clusterlisterv1beta2.ManagedClusterSetLister
+	clusterLister    clusterlisterv1.ManagedClusterLister
+	configLister     configlister.SubmarinerConfigLister
+	brokerClusters   *brokercluster.Resolver
+	eventRecorder    events.Recorder
+	quarantinePeriod time.Duration
+}
+
+// globalCIDR is a global CIDR of a cluster, either allocated in the globalnet ConfigMap or specified in its
+// SubmarinerConfig.
+type globalCIDR struct {
+	cluster string
+	cidr    string
+	network *net.IPNet
+}
+
+func NewController(clusterSetClient clientset.ManagedClusterSetInterface,
+	clusterSetInformer clusterinformerv1beta2.ManagedClusterSetInformer,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	configInformer configinformer.SubmarinerConfigInformer,
+	brokerClusters *brokercluster.Resolver,
+	quarantinePeriod time.Duration,
+	recorder events.Recorder,
+) factory.Controller {
+	c := &submarinerGlobalnetController{
+		clusterSetClient: clusterSetClient,
+		clusterSetLister: clusterSetInformer.Lister(),
+		clusterLister:    clusterInformer.Lister(),
+		configLister:     configInformer.Lister(),
+		brokerClusters:   brokerClusters,
+		eventRecorder:    recorder.WithComponentSuffix("submariner-globalnet-controller"),
+		quarantinePeriod: quarantinePeriod,
+	}
+
+	// The globalnet ConfigMaps are updated on every allocation, the cluster sets are periodically resynced to pick the
+	// changes up.
+	return factory.New().
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+
+			return accessor.GetName()
+		}, clusterSetInformer.Informer()).
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+
+			return accessor.GetLabels()[clusterv1beta2.ClusterSetLabel]
+		}, clusterInformer.Informer()).
+		WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			accessor, _ := meta.Accessor(obj)
+			if accessor.GetName() != constants.SubmarinerConfigName {
+				return ""
+			}
+
+			cluster, err := c.clusterLister.Get(accessor.GetNamespace())
+			if err != nil {
+				return ""
+			}
+
+			return cluster.Labels[clusterv1beta2.ClusterSetLabel]
+		}, configInformer.Informer()).
+		ResyncEvery(resyncInterval).
+		WithSync(c.sync).
+		ToController("SubmarinerGlobalnetController", recorder)
+}
+
+func (c *submarinerGlobalnetController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	if syncCtx.QueueKey() == "" {
+		return nil
+	}
+
+	if syncCtx.QueueKey() != factory.DefaultQueueKey {
+		return c.syncClusterSet(ctx, syncCtx, syncCtx.QueueKey())
+	}
+
+	clusterSets, err := c.clusterSetLister.List(labels.Everything())
+	if err != nil {
+		return errors.Wrap(err, "error listing ManagedClusterSets")
+	}
+
+	var errs []error
+
+	for _, clusterSet := range clusterSets {
+		if err := c.syncClusterSet(ctx, syncCtx, clusterSet.Name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs) //nolint:wrapcheck // No need to wrap here
+}
+
+func (c *submarinerGlobalnetController) syncClusterSet(ctx context.Context, syncCtx factory.SyncContext,
+	clusterSetName string,
+) error {
+	clusterSet, err := c.clusterSetLister.Get(clusterSetName)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving ManagedClusterSet %q", clusterSetName)
+	}
+
+	if !clusterSet.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	brokerNamespace := submarinerbroker.GetBrokerNamespace(clusterSet)
+	if brokerNamespace == "" {
+		return nil
+	}
+
+	broker, err := c.brokerClusters.ForClusterSet(ctx, clusterSetName)
+	if err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	now := time.Now()
+
+	quarantinedClusters, err := reclaimExpired(ctx, broker.ControllerClient, brokerNamespace, c.quarantinePeriod, now)
+	if err != nil {
+		return err
+	}
+
+	if len(quarantinedClusters) > 0 {
+		// Reclaim the first expiring quarantine on time.
+		syncCtx.Queue().AddAfter(clusterSetName, quarantinedClusters[0].until.Sub(now))
+	}
+
+	gnInfo, _, err := globalnet.GetGlobalNetworks(ctx, broker.ControllerClient, brokerNamespace)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error reading globalnet configmap from namespace %q", brokerNamespace)
+	}
+
+	if gnInfo == nil || !gnInfo.Enabled {
+		return c.updateConditions(ctx, clusterSetName, nil)
+	}
+
+	allocated := make([]globalCIDR, 0, len(gnInfo.CidrInfo))
+
+	for cluster, network := range gnInfo.CidrInfo {
+		for _, cidr := range network.GlobalCIDRs {
+			allocated = append(allocated, newGlobalCIDR(cluster, cidr))
+		}
+	}
+
+	specified, err := c.specifiedGlobalCIDRs(clusterSetName)
+	if err != nil {
+		return err
+	}
+
+	conflicting := conflictsCondition(gnInfo.CidrRange, allocated, specified)
+	capacity := capacityCondition(gnInfo.CidrRange, gnInfo.ClusterSize, allocated)
+
+	return c.updateConditions(ctx, clusterSetName, map[string]*metav1.Condition{
+		ConditionGlobalCIDRsConflicting: conflicting,
+		ConditionCapacityLow:            capacity,
+		ConditionCIDRsQuarantined:       quarantineCondition(quarantinedClusters),
+	})
+}
+
+// specifiedGlobalCIDRs returns the global CIDRs specified in the SubmarinerConfigs of the clusters of the given cluster
+// set.
+func (c *submarinerGlobalnetController) specifiedGlobalCIDRs(clusterSetName string) ([]globalCIDR, error) {
+	clusters, err := c.clusterLister.List(labels.SelectorFromSet(labels.Set{clusterv1beta2.ClusterSetLabel: clusterSetName}))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing the ManagedClusters of ManagedClusterSet %q", clusterSetName)
+	}
+
+	specified := []globalCIDR{}
+
+	for _, cluster := range clusters {
+		config, err := c.configLister.SubmarinerConfigs(cluster.Name).Get(constants.SubmarinerConfigName)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "error retrieving the SubmarinerConfig of cluster %q", cluster.Name)
+		}
+
+		if config.Spec.GlobalCIDR != "" {
+			specified = append(specified, newGlobalCIDR(cluster.Name, config.Spec.GlobalCIDR))
+		}
+	}
+
+	return specified, nil
+}
+
+// updateConditions sets the given conditions of the ManagedClusterSet, by type. The conditions not given are removed.
+func (c *submarinerGlobalnetController) updateConditions(ctx context.Context, clusterSetName string,
+	conditions map[string]*metav1.Condition,
+) error {
+	conflicting := conditions[ConditionGlobalCIDRsConflicting]
+
+	//nolint:wrapcheck // No need to wrap here
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		clusterSet, err := c.clusterSetClient.Get(ctx, clusterSetName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "error retrieving ManagedClusterSet %q", clusterSetName)
+		}
+
+		oldConflicting := meta.FindStatusCondition(clusterSet.Status.Conditions, ConditionGlobalCIDRsConflicting)
+		wasConflicting := oldConflicting != nil && oldConflicting.Status == metav1.ConditionTrue
+
+		newStatus := clusterSet.Status.DeepCopy()
+
+		for _, conditionType := range []string{ConditionGlobalCIDRsConflicting, ConditionCapacityLow, ConditionCIDRsQuarantined} {
+			if conditions[conditionType] == nil {
+				meta.RemoveStatusCondition(&newStatus.Conditions, conditionType)
+			} else {
+				meta.SetStatusCondition(&newStatus.Conditions, *conditions[conditionType])
+			}
+		}
+
+		if equality.Semantic.DeepEqual(&clusterSet.Status, newStatus) {
+			return nil
+		}
+
+		clusterSet.Status = *newStatus
+
+		_, err = c.clusterSetClient.UpdateStatus(ctx, clusterSet, metav1.UpdateOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "error updating the status of ManagedClusterSet %q", clusterSetName)
+		}
+
+		if conflicting != nil && conflicting.Status == metav1.ConditionTrue && !wasConflicting {
+			logger.Warningf("The global CIDRs of ManagedClusterSet %q conflict: %s", clusterSetName, conflicting.Message)
+			c.eventRecorder.Warningf(conflicting.Reason, "ManagedClusterSet %q: %s", clusterSetName, conflicting.Message)
+		}
+
+		return nil
+	})
+}
+
+func newGlobalCIDR(cluster, cidr string) globalCIDR {
+	_, network, _ := net.ParseCIDR(cidr)
+
+	return globalCIDR{cluster: cluster, cidr: cidr, network: network}
+}
+
+// conflictsCondition reports the invalid global CIDRs, the global CIDRs outside the globalnet CIDR range, and the global
+// CIDRs of different clusters which overlap.
+func conflictsCondition(cidrRange string, allocated, specified []globalCIDR) *metav1.Condition {
+	_, rangeNetwork, _ := net.ParseCIDR(cidrRange)
+
+	var outOfRange, overlapping []string
+
+	all := append(append([]globalCIDR{}, allocated...), specified...)
+
+	for i := range all {
+		switch {
+		case all[i].network == nil:
+			outOfRange = append(outOfRange, fmt.Sprintf("%q of cluster %q is not a valid CIDR", all[i].cidr, all[i].cluster))
+		case rangeNetwork != nil && !containsNetwork(rangeNetwork, all[i].network):
+			outOfRange = append(outOfRange, fmt.Sprintf("%q of cluster %q is outside the globalnet CIDR range %q",
+				all[i].cidr, all[i].cluster, cidrRange))
+		}
+	}
+
+	for i := range all {
+		for j := i + 1; j < len(all); j++ {
+			if all[i].cluster == all[j].cluster || all[i].network == nil || all[j].network == nil {
+				continue
+			}
+
+			if all[i].network.Contains(all[j].network.IP) || all[j].network.Contains(all[i].network.IP) {
+				overlapping = append(overlapping, fmt.Sprintf("%q of cluster %q overlaps %q of cluster %q",
+					all[i].cidr, all[i].cluster, all[j].cidr, all[j].cluster))
+			}
+		}
+	}
+
+	overlapping = dedup(overlapping)
+	outOfRange = dedup(outOfRange)
+
+	switch {
+	case len(overlapping) > 0:
+		return &metav1.Condition{
+			Type:    ConditionGlobalCIDRsConflicting,
+			Status:  metav1.ConditionTrue,
+			Reason:  ReasonOverlappingGlobalCIDRs,
+			Message: strings.Join(append(overlapping, outOfRange...), "\n"),
+		}
+	case len(outOfRange) > 0:
+		return &metav1.Condition{
+			Type:    ConditionGlobalCIDRsConflicting,
+			Status:  metav1.ConditionTrue,
+			Reason:  ReasonGlobalCIDROutOfRange,
+			Message: strings.Join(outOfRange, "\n"),
+		}
+	}
+
+	return &metav1.Condition{
+		Type:    ConditionGlobalCIDRsConflicting,
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonNoConflicts,
+		Message: "The global CIDRs of the clusters don't overlap and are in the globalnet CIDR range",
+	}
+}
+
+// capacityCondition reports how many more clusters, with global CIDRs of the default cluster size, the globalnet CIDR
+// range has room for.
+func capacityCondition(cidrRange string, clusterSize uint, allocated []globalCIDR) *metav1.Condition {
+	_, rangeNetwork, err := net.ParseCIDR(cidrRange)
+	if err != nil || clusterSize == 0 {
+		return nil
+	}
+
+	total := networkSize(rangeNetwork)
+	used := new(big.Int)
+
+	for i := range allocated {
+		if allocated[i].network != nil && containsNetwork(rangeNetwork, allocated[i].network) {
+			used.Add(used, networkSize(allocated[i].network))
+		}
+	}
+
+	remaining := new(big.Int).Sub(total, used)
+	if remaining.Sign() < 0 {
+		remaining.SetInt64(0)
+	}
+
+	// Allocations are aligned, the remaining addresses may be fragmented so this is an upper bound.
+	remainingClusters := new(big.Int).Div(remaining, new(big.Int).SetUint64(uint64(clusterSize)))
+	remainingPercentage := new(big.Int).Div(new(big.Int).Mul(remaining, big.NewInt(100)), total).Int64()
+
+	message := fmt.Sprintf("%s of the %s addresses of the globalnet CIDR range %q are available (%d%%), enough for %s more "+
+		"clusters with the default cluster size of %d", remaining, total, cidrRange, remainingPercentage, remainingClusters,
+		clusterSize)
+
+	switch {
+	case remainingClusters.Sign() == 0:
+		return &metav1.Condition{
+			Type:    ConditionCapacityLow,
+			Status:  metav1.ConditionTrue,
+			Reason:  ReasonCapacityExhausted,
+			Message: message,
+		}
+	case remainingPercentage < lowCapacityPercentage:
+		return &metav1.Condition{
+			Type:    ConditionCapacityLow,
+			Status:  metav1.ConditionTrue,
+			Reason:  ReasonCapacityLow,
+			Message: message,
+		}
+	}
+
+	return &metav1.Condition{
+		Type:    ConditionCapacityLow,
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonCapacitySufficient,
+		Message: message,
+	}
+}
+
+// quarantineCondition reports the global CIDRs released by clusters which left the cluster set, and when they are
+// reclaimed.
+func quarantineCondition(quarantined []quarantinedCluster) *metav1.Condition {
+	if len(quarantined) == 0 {
+		return &metav1.Condition{
+			Type:    ConditionCIDRsQuarantined,
+			Status:  metav1.ConditionFalse,
+			Reason:  ReasonNoQuarantinedCIDRs,
+			Message: "No global CIDRs are quarantined",
+		}
+	}
+
+	messages := make([]string, len(quarantined))
+
+	for i := range quarantined {
+		messages[i] = fmt.Sprintf("%v of cluster %q are quarantined until %s", quarantined[i].globalCIDRs,
+			quarantined[i].cluster, quarantined[i].until.UTC().Format(time.RFC3339))
+	}
+
+	return &metav1.Condition{
+		Type:    ConditionCIDRsQuarantined,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonCIDRsQuarantined,
+		Message: strings.Join(messages, "\n"),
+	}
+}
+
+func containsNetwork(outer, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+
+	return outerBits == innerBits && innerOnes >= outerOnes && outer.Contains(inner.IP)
+}
+
+func networkSize(network *net.IPNet) *big.Int {
+	ones, bits := network.Mask.Size()
+
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+}
+
+func dedup(s []string) []string {
+	sort.Strings(s)
+
+	result := s[:0]
+
+	for i := range s {
+		if i == 0 || s[i] != s[i-1] {
+			result = append(result, s[i])
+		}
+	}
+
+	return result
+}
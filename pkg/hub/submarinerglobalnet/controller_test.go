@@ -0,0 +1,344 @@
+package submarinerglobalnet_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/library-go/pkg/operator/events"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	fakeconfigclient "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/clientset/versioned/fake"
+	configinformers "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/informers/externalversions"
+	"github.com/stolostron/submariner-addon/pkg/constants"
+	"github.com/stolostron/submariner-addon/pkg/hub/brokercluster"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarinerbroker"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarinerglobalnet"
+	fakereactor "github.com/submariner-io/admiral/pkg/fake"
+	"github.com/submariner-io/admiral/pkg/reporter"
+	"github.com/submariner-io/submariner-operator/pkg/discovery/globalnet"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	clusterv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	clusterSetName   = "east"
+	brokerNamespace  = "east-broker"
+	quarantinePeriod = time.Hour
+)
+
+var _ = Describe("Controller", func() {
+	t := newGlobalnetControllerTestDriver()
+
+	When("globalnet is disabled in the cluster set", func() {
+		BeforeEach(func() {
+			t.globalnetEnabled = false
+		})
+
+		It("should not set the globalnet conditions", func() {
+			Consistently(func() []metav1.Condition {
+				return t.getClusterSet().Status.Conditions
+			}).Should(BeEmpty())
+		})
+	})
+
+	When("the global CIDRs are allocated by submariner-addon", func() {
+		BeforeEach(func() {
+			t.allocated = []string{"cluster1", "cluster2"}
+		})
+
+		It("should report no conflicts and sufficient capacity", func() {
+			t.awaitCondition(submarinerglobalnet.ConditionGlobalCIDRsConflicting, metav1.ConditionFalse,
+				submarinerglobalnet.ReasonNoConflicts)
+			t.awaitCondition(submarinerglobalnet.ConditionCapacityLow, metav1.ConditionFalse,
+				submarinerglobalnet.ReasonCapacitySufficient)
+		})
+	})
+
+	When("a manually specified global CIDR overlaps the allocation of another cluster", func() {
+		BeforeEach(func() {
+			t.allocated = []string{"cluster1"}
+			t.specified = map[string]string{"cluster2": "242.0.0.0/24"}
+		})
+
+		It("should report the overlap", func() {
+			t.awaitCondition(submarinerglobalnet.ConditionGlobalCIDRsConflicting, metav1.ConditionTrue,
+				submarinerglobalnet.ReasonOverlappingGlobalCIDRs)
+		})
+	})
+
+	When("a manually specified global CIDR is outside the globalnet CIDR range", func() {
+		BeforeEach(func() {
+			t.allocated = []string{"cluster1"}
+			t.specified = map[string]string{"cluster2": "243.0.0.0/24"}
+		})
+
+		It("should report it", func() {
+			t.awaitCondition(submarinerglobalnet.ConditionGlobalCIDRsConflicting, metav1.ConditionTrue,
+				submarinerglobalnet.ReasonGlobalCIDROutOfRange)
+		})
+	})
+
+	When("the global CIDRs of a cluster which left the cluster set are quarantined", func() {
+		BeforeEach(func() {
+			t.allocated = []string{"cluster1", "cluster2"}
+			t.quarantined = map[string]time.Time{"cluster2": time.Now()}
+		})
+
+		It("should keep them allocated and report them", func() {
+			t.awaitCondition(submarinerglobalnet.ConditionCIDRsQuarantined, metav1.ConditionTrue,
+				submarinerglobalnet.ReasonCIDRsQuarantined)
+			Expect(t.getAllocatedClusters()).To(ConsistOf("cluster1", "cluster2"))
+		})
+
+		Context("and the cluster joins the cluster set again", func() {
+			It("should no longer report them", func() {
+				t.awaitCondition(submarinerglobalnet.ConditionCIDRsQuarantined, metav1.ConditionTrue,
+					submarinerglobalnet.ReasonCIDRsQuarantined)
+
+				Expect(submarinerglobalnet.Unquarantine(context.Background(), t.controllerClient, brokerNamespace,
+					"cluster2")).To(Succeed())
+				Expect(t.getGlobalnetConfigMap().Annotations).ToNot(HaveKey(submarinerglobalnet.QuarantineAnnotation))
+
+				t.triggerResync()
+				t.awaitCondition(submarinerglobalnet.ConditionCIDRsQuarantined, metav1.ConditionFalse,
+					submarinerglobalnet.ReasonNoQuarantinedCIDRs)
+				Expect(t.getAllocatedClusters()).To(ConsistOf("cluster1", "cluster2"))
+			})
+		})
+	})
+
+	When("the global CIDRs of a cluster with a name longer than an annotation name are quarantined", func() {
+		longName := strings.Repeat("a", 100)
+
+		BeforeEach(func() {
+			t.allocated = []string{"cluster1", longName}
+			t.quarantined = map[string]time.Time{longName: time.Now()}
+		})
+
+		It("should record the quarantine in the quarantine annotation", func() {
+			t.awaitCondition(submarinerglobalnet.ConditionCIDRsQuarantined, metav1.ConditionTrue,
+				submarinerglobalnet.ReasonCIDRsQuarantined)
+			Expect(t.getGlobalnetConfigMap().Annotations).To(HaveKeyWithValue(submarinerglobalnet.QuarantineAnnotation,
+				ContainSubstring(longName)))
+		})
+	})
+
+	When("the quarantine period of released global CIDRs has elapsed", func() {
+		BeforeEach(func() {
+			t.allocated = []string{"cluster1", "cluster2"}
+			t.quarantined = map[string]time.Time{"cluster2": time.Now().Add(-2 * quarantinePeriod)}
+		})
+
+		It("should reclaim them", func() {
+			Eventually(t.getAllocatedClusters, 3).Should(ConsistOf("cluster1"))
+			Expect(t.getGlobalnetConfigMap().Annotations).ToNot(HaveKey(submarinerglobalnet.QuarantineAnnotation))
+			t.awaitCondition(submarinerglobalnet.ConditionCIDRsQuarantined, metav1.ConditionFalse,
+				submarinerglobalnet.ReasonNoQuarantinedCIDRs)
+		})
+	})
+
+	When("less than 10 percent of the globalnet CIDR range is left", func() {
+		BeforeEach(func() {
+			t.allocated = clusterNames(15)
+		})
+
+		It("should report low capacity", func() {
+			t.awaitCondition(submarinerglobalnet.ConditionCapacityLow, metav1.ConditionTrue,
+				submarinerglobalnet.ReasonCapacityLow)
+		})
+	})
+
+	When("the globalnet CIDR range is exhausted", func() {
+		BeforeEach(func() {
+			t.allocated = clusterNames(16)
+		})
+
+		It("should report exhausted capacity", func() {
+			t.awaitCondition(submarinerglobalnet.ConditionCapacityLow, metav1.ConditionTrue,
+				submarinerglobalnet.ReasonCapacityExhausted)
+		})
+	})
+})
+
+type globalnetControllerTestDriver struct {
+	clusterSetClient *fakeclusterclient.Clientset
+	configClient     *fakeconfigclient.Clientset
+	controllerClient client.Client
+	globalnetEnabled bool
+	allocated        []string
+	specified        map[string]string
+	quarantined      map[string]time.Time
+}
+
+func newGlobalnetControllerTestDriver() *globalnetControllerTestDriver {
+	t := &globalnetControllerTestDriver{}
+
+	BeforeEach(func() {
+		t.globalnetEnabled = true
+		t.allocated = nil
+		t.specified = nil
+		t.quarantined = nil
+
+		t.clusterSetClient = fakeclusterclient.NewSimpleClientset() //nolint:staticcheck // The non-deprecated function is not available
+		fakereactor.AddBasicReactors(&t.clusterSetClient.Fake)
+
+		t.configClient = fakeconfigclient.NewSimpleClientset()
+		t.controllerClient = fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	})
+
+	JustBeforeEach(func() {
+		ctx := context.Background()
+
+		_, err := t.clusterSetClient.ClusterV1beta2().ManagedClusterSets().Create(ctx, &clusterv1beta2.ManagedClusterSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        clusterSetName,
+				Annotations: map[string]string{submarinerbroker.SubmBrokerNamespaceKey: brokerNamespace},
+			},
+		}, metav1.CreateOptions{})
+		Expect(err).To(Succeed())
+
+		// A /16 range with the default cluster size of 4096 has room for 16 clusters.
+		configMap, err := globalnet.NewGlobalnetConfigMap(t.globalnetEnabled, "242.0.0.0/16", 4096, brokerNamespace)
+		Expect(err).To(Succeed())
+		Expect(t.controllerClient.Create(ctx, configMap)).To(Succeed())
+
+		for _, clusterName := range t.allocated {
+			t.createManagedCluster(clusterName)
+			Expect(globalnet.AllocateAndUpdateGlobalCIDRConfigMap(ctx, t.controllerClient, brokerNamespace,
+				&globalnet.Config{ClusterID: clusterName}, reporter.Silent())).To(Succeed())
+		}
+
+		for clusterName, releasedAt := range t.quarantined {
+			Expect(submarinerglobalnet.Quarantine(ctx, t.controllerClient, brokerNamespace, clusterName, releasedAt)).To(Succeed())
+		}
+
+		for clusterName, globalCIDR := range t.specified {
+			t.createManagedCluster(clusterName)
+
+			_, err := t.configClient.SubmarineraddonV1alpha1().SubmarinerConfigs(clusterName).Create(ctx,
+				&configv1alpha1.SubmarinerConfig{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      constants.SubmarinerConfigName,
+						Namespace: clusterName,
+					},
+					Spec: configv1alpha1.SubmarinerConfigSpec{
+						GlobalCIDR: globalCIDR,
+					},
+				}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+		}
+
+		clusterInformerFactory := clusterinformers.NewSharedInformerFactory(t.clusterSetClient, 0)
+		configInformerFactory := configinformers.NewSharedInformerFactory(t.configClient, 0)
+
+		controller := submarinerglobalnet.NewController(
+			t.clusterSetClient.ClusterV1beta2().ManagedClusterSets(),
+			clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets(),
+			clusterInformerFactory.Cluster().V1().ManagedClusters(),
+			configInformerFactory.Submarineraddon().V1alpha1().SubmarinerConfigs(),
+			brokercluster.NewResolver(&brokercluster.Clients{ControllerClient: t.controllerClient},
+				clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Lister(), ""),
+			quarantinePeriod,
+			events.NewLoggingEventRecorder("test", clock.RealClock{}))
+
+		ctx, stop := context.WithCancel(context.TODO())
+
+		done := make(chan struct{})
+
+		DeferCleanup(func() {
+			stop()
+			Eventually(done).Within(3 * time.Second).Should(BeClosed())
+		})
+
+		clusterInformerFactory.Start(ctx.Done())
+		configInformerFactory.Start(ctx.Done())
+
+		cache.WaitForCacheSync(ctx.Done(),
+			clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets().Informer().HasSynced,
+			clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().HasSynced,
+			configInformerFactory.Submarineraddon().V1alpha1().SubmarinerConfigs().Informer().HasSynced)
+
+		go func() {
+			controller.Run(ctx, 1)
+			close(done)
+		}()
+	})
+
+	return t
+}
+
+func (t *globalnetControllerTestDriver) createManagedCluster(name string) {
+	_, err := t.clusterSetClient.ClusterV1().ManagedClusters().Create(context.Background(), &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{clusterv1beta2.ClusterSetLabel: clusterSetName},
+		},
+	}, metav1.CreateOptions{})
+	Expect(err).To(Succeed())
+}
+
+func (t *globalnetControllerTestDriver) getClusterSet() *clusterv1beta2.ManagedClusterSet {
+	clusterSet, err := t.clusterSetClient.ClusterV1beta2().ManagedClusterSets().Get(context.Background(), clusterSetName,
+		metav1.GetOptions{})
+	Expect(err).To(Succeed())
+
+	return clusterSet
+}
+
+func (t *globalnetControllerTestDriver) awaitCondition(conditionType string, status metav1.ConditionStatus, reason string) {
+	Eventually(func() *metav1.Condition {
+		return meta.FindStatusCondition(t.getClusterSet().Status.Conditions, conditionType)
+	}, 3).Should(And(Not(BeNil()), HaveField("Status", status), HaveField("Reason", reason)))
+}
+
+func (t *globalnetControllerTestDriver) getGlobalnetConfigMap() *corev1.ConfigMap {
+	configMap, err := globalnet.GetConfigMap(context.Background(), t.controllerClient, brokerNamespace)
+	Expect(err).To(Succeed())
+
+	return configMap
+}
+
+func (t *globalnetControllerTestDriver) getAllocatedClusters() []string {
+	gnInfo, _, err := globalnet.GetGlobalNetworks(context.Background(), t.controllerClient, brokerNamespace)
+	Expect(err).To(Succeed())
+
+	clusters := []string{}
+	for cluster := range gnInfo.CidrInfo {
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters
+}
+
+// triggerResync queues the cluster set, by updating it, instead of waiting for the periodic resync.
+func (t *globalnetControllerTestDriver) triggerResync() {
+	clusterSet := t.getClusterSet()
+	clusterSet.Labels = map[string]string{"resync": time.Now().Format("150405.000000")}
+
+	_, err := t.clusterSetClient.ClusterV1beta2().ManagedClusterSets().Update(context.Background(), clusterSet,
+		metav1.UpdateOptions{})
+	Expect(err).To(Succeed())
+}
+
+func clusterNames(count int) []string {
+	names := make([]string, count)
+
+	for i := range names {
+		names[i] = fmt.Sprintf("cluster%d", i+1)
+	}
+
+	return names
+}
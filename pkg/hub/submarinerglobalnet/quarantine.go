@@ -0,0 +1,275 @@
+package submarinerglobalnet
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/submariner-io/submariner-operator/pkg/discovery/globalnet"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	controllerclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// QuarantineAnnotation is the annotation of the globalnet ConfigMap recording when the global CIDRs of the clusters
+	// were released, as a JSON map of the release times by cluster name. The global CIDRs stay allocated, so they aren't
+	// reused, until the quarantine period has elapsed.
+	QuarantineAnnotation = "submarineraddon.open-cluster-management.io/globalnet-quarantine"
+
+	// These mirror the format of the globalnet ConfigMap maintained by the submariner-operator globalnet package.
+	clusterInfoKey    = "clusterinfo"
+	clusterIDInfoKey  = "cluster_id"
+	globalCIDRInfoKey = "global_cidr"
+)
+
+// quarantinedCluster is a cluster whose global CIDRs were released but not reclaimed yet.
+type quarantinedCluster struct {
+	cluster     string
+	globalCIDRs []string
+	until       time.Time
+}
+
+// Quarantine releases the global CIDRs allocated to the given cluster in the globalnet ConfigMap of the given broker
+// namespace. They are reclaimed once the quarantine period has elapsed, unless the cluster joins the cluster set again
+// in the meantime.
+func Quarantine(ctx context.Context, client controllerclient.Client, brokerNamespace, clusterName string, now time.Time) error {
+	return updateConfigMap(ctx, client, brokerNamespace, func(configMap *corev1.ConfigMap) (bool, error) {
+		releaseTimes, err := getReleaseTimes(configMap)
+		if err != nil {
+			return false, err
+		}
+
+		if _, found := releaseTimes[clusterName]; found {
+			return false, nil
+		}
+
+		clusterInfo, err := getClusterInfo(configMap)
+		if err != nil || findClusterInfo(clusterInfo, clusterName) < 0 {
+			return false, err
+		}
+
+		releaseTimes[clusterName] = now.UTC().Format(time.RFC3339)
+
+		if err := setReleaseTimes(configMap, releaseTimes); err != nil {
+			return false, err
+		}
+
+		logger.Infof("Quarantined the global CIDRs of cluster %q in broker namespace %q", clusterName, brokerNamespace)
+
+		return true, nil
+	})
+}
+
+// Unquarantine keeps the global CIDRs of the given cluster allocated, when the cluster joins the cluster set again
+// before they are reclaimed.
+func Unquarantine(ctx context.Context, client controllerclient.Client, brokerNamespace, clusterName string) error {
+	return updateConfigMap(ctx, client, brokerNamespace, func(configMap *corev1.ConfigMap) (bool, error) {
+		releaseTimes, err := getReleaseTimes(configMap)
+		if err != nil {
+			return false, err
+		}
+
+		if _, found := releaseTimes[clusterName]; !found {
+			return false, nil
+		}
+
+		delete(releaseTimes, clusterName)
+
+		if err := setReleaseTimes(configMap, releaseTimes); err != nil {
+			return false, err
+		}
+
+		logger.Infof("Cluster %q rejoined broker namespace %q, its global CIDRs are no longer quarantined", clusterName,
+			brokerNamespace)
+
+		return true, nil
+	})
+}
+
+// reclaimExpired removes the allocations of the clusters whose quarantine period has elapsed from the globalnet ConfigMap
+// of the given broker namespace, and returns the clusters still in quarantine, the first to expire first.
+func reclaimExpired(ctx context.Context, client controllerclient.Client, brokerNamespace string, period time.Duration,
+	now time.Time,
+) ([]quarantinedCluster, error) {
+	var quarantined []quarantinedCluster
+
+	err := updateConfigMap(ctx, client, brokerNamespace, func(configMap *corev1.ConfigMap) (bool, error) {
+		quarantined = nil
+
+		releaseTimes, err := getReleaseTimes(configMap)
+		if err != nil {
+			return false, err
+		}
+
+		clusterInfo, err := getClusterInfo(configMap)
+		if err != nil {
+			return false, err
+		}
+
+		updated := false
+
+		for clusterName, value := range releaseTimes {
+			releasedAt, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				logger.Warningf("Invalid release time %q of cluster %q in broker namespace %q, reclaiming its global CIDRs",
+					value, clusterName, brokerNamespace)
+			}
+
+			index := findClusterInfo(clusterInfo, clusterName)
+
+			until := releasedAt.Add(period)
+			if err == nil && index >= 0 && now.Before(until) {
+				quarantined = append(quarantined, quarantinedCluster{
+					cluster:     clusterName,
+					globalCIDRs: globalCIDRsOf(clusterInfo[index]),
+					until:       until,
+				})
+
+				continue
+			}
+
+			if index >= 0 {
+				logger.Infof("Reclaiming the global CIDRs %v of cluster %q in broker namespace %q",
+					globalCIDRsOf(clusterInfo[index]), clusterName, brokerNamespace)
+
+				clusterInfo = append(clusterInfo[:index], clusterInfo[index+1:]...)
+			}
+
+			delete(releaseTimes, clusterName)
+
+			updated = true
+		}
+
+		if !updated {
+			return false, nil
+		}
+
+		if err := setReleaseTimes(configMap, releaseTimes); err != nil {
+			return false, err
+		}
+
+		data, err := json.Marshal(clusterInfo)
+		if err != nil {
+			return false, errors.Wrap(err, "error marshalling the globalnet cluster info")
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+
+		configMap.Data[clusterInfoKey] = string(data)
+
+		return true, nil
+	})
+
+	sort.Slice(quarantined, func(i, j int) bool {
+		if !quarantined[i].until.Equal(quarantined[j].until) {
+			return quarantined[i].until.Before(quarantined[j].until)
+		}
+
+		return quarantined[i].cluster < quarantined[j].cluster
+	})
+
+	return quarantined, err
+}
+
+func updateConfigMap(ctx context.Context, client controllerclient.Client, brokerNamespace string,
+	mutate func(configMap *corev1.ConfigMap) (bool, error),
+) error {
+	//nolint:wrapcheck // No need to wrap here
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		configMap, err := globalnet.GetConfigMap(ctx, client, brokerNamespace)
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "error retrieving the globalnet ConfigMap from namespace %q", brokerNamespace)
+		}
+
+		updated, err := mutate(configMap)
+		if err != nil || !updated {
+			return err
+		}
+
+		return errors.Wrapf(client.Update(ctx, configMap), "error updating the globalnet ConfigMap in namespace %q",
+			brokerNamespace)
+	})
+}
+
+// getReleaseTimes returns the release times of the quarantined clusters recorded in the globalnet ConfigMap, by cluster name.
+func getReleaseTimes(configMap *corev1.ConfigMap) (map[string]string, error) {
+	releaseTimes := map[string]string{}
+
+	if value := configMap.Annotations[QuarantineAnnotation]; value != "" {
+		if err := json.Unmarshal([]byte(value), &releaseTimes); err != nil {
+			return nil, errors.Wrapf(err, "error parsing the %q annotation", QuarantineAnnotation)
+		}
+	}
+
+	return releaseTimes, nil
+}
+
+// setReleaseTimes records the given release times of the quarantined clusters in the globalnet ConfigMap, removing the
+// annotation if there are none.
+func setReleaseTimes(configMap *corev1.ConfigMap, releaseTimes map[string]string) error {
+	if len(releaseTimes) == 0 {
+		delete(configMap.Annotations, QuarantineAnnotation)
+		return nil
+	}
+
+	value, err := json.Marshal(releaseTimes)
+	if err != nil {
+		return errors.Wrapf(err, "error marshalling the %q annotation", QuarantineAnnotation)
+	}
+
+	if configMap.Annotations == nil {
+		configMap.Annotations = map[string]string{}
+	}
+
+	configMap.Annotations[QuarantineAnnotation] = string(value)
+
+	return nil
+}
+
+// getClusterInfo returns the allocations of the globalnet ConfigMap, as generic maps so that their content is preserved
+// when they are written back.
+func getClusterInfo(configMap *corev1.ConfigMap) ([]map[string]any, error) {
+	clusterInfo := []map[string]any{}
+
+	if data := configMap.Data[clusterInfoKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), &clusterInfo); err != nil {
+			return nil, errors.Wrap(err, "error parsing the globalnet cluster info")
+		}
+	}
+
+	return clusterInfo, nil
+}
+
+func findClusterInfo(clusterInfo []map[string]any, clusterName string) int {
+	for i := range clusterInfo {
+		if clusterInfo[i][clusterIDInfoKey] == clusterName {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func globalCIDRsOf(info map[string]any) []string {
+	cidrs, _ := info[globalCIDRInfoKey].([]any)
+
+	globalCIDRs := make([]string, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		if s, ok := cidr.(string); ok {
+			globalCIDRs = append(globalCIDRs, s)
+		}
+	}
+
+	return globalCIDRs
+}
@@ -0,0 +1,13 @@
+package submarinerglobalnet_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSubmarinerGlobalnet(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Submariner Globalnet Suite")
+}
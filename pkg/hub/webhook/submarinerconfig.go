@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/validation"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SubmarinerConfigValidatorPath is the path the SubmarinerConfig validator is served on.
+const SubmarinerConfigValidatorPath = "/validate-submarinerconfig"
+
+// SubmarinerConfigValidator rejects the SubmarinerConfigs with validation errors, using the rules of the validate
+// command. The validation warnings are returned to the client as admission warnings.
+type SubmarinerConfigValidator struct{}
+
+func NewSubmarinerConfigValidator() *SubmarinerConfigValidator {
+	return &SubmarinerConfigValidator{}
+}
+
+func (v *SubmarinerConfigValidator) SetupWithManager(mgr ctrl.Manager) {
+	mgr.GetWebhookServer().Register(SubmarinerConfigValidatorPath, &webhook.Admission{Handler: v})
+}
+
+func (v *SubmarinerConfigValidator) Handle(_ context.Context, req admission.Request) admission.Response {
+	config := &configv1alpha1.SubmarinerConfig{}
+	if err := json.Unmarshal(req.Object.Raw, config); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// Updates which don't change the validated fields, e.g. the finalizers added by the controller, are always allowed
+	// so that existing SubmarinerConfigs keep being processed.
+	if req.Operation == admissionv1.Update {
+		oldConfig := &configv1alpha1.SubmarinerConfig{}
+		if err := json.Unmarshal(req.OldObject.Raw, oldConfig); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		if equality.Semantic.DeepEqual(oldConfig.Spec, config.Spec) &&
+			equality.Semantic.DeepEqual(oldConfig.Annotations, config.Annotations) {
+			return admission.Allowed("")
+		}
+	}
+
+	var errs, warnings []string
+
+	for _, finding := range validation.Validate(config, validation.Options{}) {
+		if finding.Severity == validation.SeverityError {
+			errs = append(errs, finding.Field+": "+finding.Message)
+		} else {
+			warnings = append(warnings, finding.Field+": "+finding.Message)
+		}
+	}
+
+	if len(errs) > 0 {
+		return admission.Denied(strings.Join(errs, "; ")).WithWarnings(warnings...)
+	}
+
+	return admission.Allowed("").WithWarnings(warnings...)
+}
@@ -0,0 +1,134 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	configv1alpha1 "github.com/stolostron/submariner-addon/pkg/apis/submarinerconfig/v1alpha1"
+	"github.com/stolostron/submariner-addon/pkg/hub/webhook"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var _ = Describe("SubmarinerConfigValidator", func() {
+	var (
+		config    *configv1alpha1.SubmarinerConfig
+		oldConfig *configv1alpha1.SubmarinerConfig
+		response  admission.Response
+	)
+
+	BeforeEach(func() {
+		config = &configv1alpha1.SubmarinerConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "submariner",
+				Namespace: "cluster1",
+			},
+			Spec: configv1alpha1.SubmarinerConfigSpec{
+				CableDriver: "libreswan",
+			},
+		}
+
+		oldConfig = nil
+	})
+
+	JustBeforeEach(func() {
+		raw, err := json.Marshal(config)
+		Expect(err).To(Succeed())
+
+		request := admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		}
+
+		if oldConfig != nil {
+			request.Operation = admissionv1.Update
+
+			request.OldObject.Raw, err = json.Marshal(oldConfig)
+			Expect(err).To(Succeed())
+		}
+
+		response = webhook.NewSubmarinerConfigValidator().Handle(context.TODO(), request)
+	})
+
+	When("the SubmarinerConfig is valid", func() {
+		It("should allow it", func() {
+			Expect(response.Allowed).To(BeTrue())
+			Expect(response.Warnings).To(BeEmpty())
+		})
+	})
+
+	When("the SubmarinerConfig has validation errors", func() {
+		BeforeEach(func() {
+			config.Spec.CableDriver = "openvpn"
+			config.Annotations = map[string]string{"submariner.io/something-else": "true"}
+		})
+
+		It("should deny it", func() {
+			Expect(response.Allowed).To(BeFalse())
+			Expect(response.Result.Message).To(ContainSubstring("spec.cableDriver"))
+			Expect(response.Warnings).To(HaveLen(1))
+		})
+
+		Context("and the update doesn't change the validated fields", func() {
+			BeforeEach(func() {
+				oldConfig = config.DeepCopy()
+				config.Finalizers = []string{"test-finalizer"}
+			})
+
+			It("should allow it", func() {
+				Expect(response.Allowed).To(BeTrue())
+			})
+		})
+
+		Context("and the update changes the validated fields", func() {
+			BeforeEach(func() {
+				oldConfig = config.DeepCopy()
+				oldConfig.Spec.CableDriver = "libreswan"
+			})
+
+			It("should deny it", func() {
+				Expect(response.Allowed).To(BeFalse())
+			})
+		})
+	})
+
+	When("the SubmarinerConfig only has validation warnings", func() {
+		BeforeEach(func() {
+			config.Annotations = map[string]string{"submariner.io/something-else": "true"}
+		})
+
+		It("should allow it with warnings", func() {
+			Expect(response.Allowed).To(BeTrue())
+			Expect(response.Warnings).To(HaveLen(1))
+		})
+	})
+
+	When("an annotation of the SubmarinerConfig looks like a typo", func() {
+		BeforeEach(func() {
+			config.Annotations = map[string]string{"submariner.io/vpcid": "vpc-1"}
+		})
+
+		It("should allow it with a warning", func() {
+			Expect(response.Allowed).To(BeTrue())
+			Expect(response.Warnings).To(ConsistOf(ContainSubstring(`"submariner.io/vpc-id"`)))
+		})
+	})
+
+	When("an existing SubmarinerConfig with the strongswan cable driver is updated", func() {
+		BeforeEach(func() {
+			config.Spec.CableDriver = "strongswan"
+			oldConfig = config.DeepCopy()
+			config.Spec.Debug = true
+		})
+
+		It("should allow it", func() {
+			Expect(response.Allowed).To(BeTrue())
+		})
+	})
+})
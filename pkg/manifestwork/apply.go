@@ -5,39 +5,137 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/pkg/errors"
 	"github.com/stolostron/submariner-addon/pkg/redact"
-	"github.com/stolostron/submariner-addon/pkg/resource"
 	"github.com/submariner-io/admiral/pkg/log"
-	"github.com/submariner-io/admiral/pkg/util"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	workclient "open-cluster-management.io/api/client/work/clientset/versioned"
 	workv1 "open-cluster-management.io/api/work/v1"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// FieldManager is the field manager of the ManifestWork fields applied by the addon. Fields owned by other field
+// managers, e.g. annotations added by other controllers, are preserved.
+const FieldManager = "submariner-addon"
+
 var logger = log.Logger{Logger: logf.Log.WithName("ManifestWork")}
 
+// Apply creates the given ManifestWork or updates its spec with server-side apply. The update is skipped if the spec
+// is semantically unchanged, otherwise the redacted changes of its manifests are logged and recorded.
 func Apply(ctx context.Context, client workclient.Interface, toApply *workv1.ManifestWork, recorder events.Recorder) error {
-	resourceInterface := resource.ForManifestWork(client.WorkV1().ManifestWorks(toApply.Namespace))
-
-	result, err := util.CreateOrUpdate[*workv1.ManifestWork](ctx, resourceInterface, toApply,
-		func(existing *workv1.ManifestWork) (*workv1.ManifestWork, error) {
-			existing.Spec = toApply.Spec
+	works := client.WorkV1().ManifestWorks(toApply.Namespace)
 
-			return existing, nil
-		})
+	existing, err := works.Get(ctx, toApply.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = works.Create(ctx, toApply, metav1.CreateOptions{FieldManager: FieldManager})
+		if err != nil {
+			return errors.Wrapf(err, "error creating ManifestWork %q", toApply.Name)
+		}
 
-	if result == util.OperationResultCreated {
 		recorder.Event("ManifestWorkApplied", fmt.Sprintf("manifestwork %s/%s was created", toApply.Namespace, toApply.Name))
 		logger.Infof("Created ManifestWork \"%s/%s\": %s", toApply.Namespace, toApply.Name, manifestsToString(toApply.Spec.Workload.Manifests))
-	} else if result == util.OperationResultUpdated {
-		recorder.Event("ManifestWorkApplied", fmt.Sprintf("manifestwork %s/%s was updated", toApply.Namespace, toApply.Name))
-		logger.Infof("Updated ManifestWork \"%s/%s\"", toApply.Namespace, toApply.Name)
+
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving ManifestWork %q", toApply.Name)
+	}
+
+	diffs, err := DiffManifests(existing.Spec.Workload.Manifests, toApply.Spec.Workload.Manifests)
+	if err != nil {
+		return errors.Wrapf(err, "error comparing ManifestWork %q", toApply.Name)
+	}
+
+	if len(diffs) == 0 && equality.Semantic.DeepEqual(withoutManifests(&existing.Spec), withoutManifests(&toApply.Spec)) {
+		return nil
+	}
+
+	patch, err := json.Marshal(applyConfiguration(toApply))
+	if err != nil {
+		return errors.Wrapf(err, "error marshalling ManifestWork %q", toApply.Name)
+	}
+
+	_, err = works.Patch(ctx, toApply.Name, types.ApplyPatchType, patch, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        ptr.To(true),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error applying ManifestWork %q", toApply.Name)
+	}
+
+	recorder.Event("ManifestWorkApplied", fmt.Sprintf("manifestwork %s/%s was updated: %s", toApply.Namespace, toApply.Name,
+		diffSummary(diffs)))
+	logger.Infof("Updated ManifestWork \"%s/%s\": %s", toApply.Namespace, toApply.Name, diffsToString(diffs))
+
+	return nil
+}
+
+// applyConfiguration returns the fields of the ManifestWork owned by the addon.
+func applyConfiguration(work *workv1.ManifestWork) *workv1.ManifestWork {
+	return &workv1.ManifestWork{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: workv1.GroupVersion.String(),
+			Kind:       "ManifestWork",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      work.Name,
+			Namespace: work.Namespace,
+		},
+		Spec: work.Spec,
+	}
+}
+
+func withoutManifests(spec *workv1.ManifestWorkSpec) *workv1.ManifestWorkSpec {
+	spec = spec.DeepCopy()
+	spec.Workload.Manifests = nil
+
+	return spec
+}
+
+// diffSummary lists the changed manifests and fields, without values, for events.
+func diffSummary(diffs []ManifestDiff) string {
+	if len(diffs) == 0 {
+		return "the spec changed"
+	}
+
+	summary := make([]string, len(diffs))
+
+	for i := range diffs {
+		paths := make([]string, len(diffs[i].Fields))
+		for j := range diffs[i].Fields {
+			paths[j] = diffs[i].Fields[j].Path
+		}
+
+		summary[i] = fmt.Sprintf("%s %s", diffs[i].Operation, diffs[i].Manifest)
+		if len(paths) > 0 {
+			summary[i] += ": " + strings.Join(paths, ", ")
+		}
+	}
+
+	return strings.Join(summary, "; ")
+}
+
+func diffsToString(diffs []ManifestDiff) string {
+	if len(diffs) == 0 {
+		return "the spec changed"
+	}
+
+	var out strings.Builder
+
+	for i := range diffs {
+		out.WriteString("\n  ")
+		out.WriteString(diffs[i].String())
 	}
 
-	return errors.Wrapf(err, "error applying ManifestWork %q", toApply.Name)
+	return out.String()
 }
 
 func manifestsToString(manifests []workv1.Manifest) string {
@@ -45,8 +143,8 @@ func manifestsToString(manifests []workv1.Manifest) string {
 
 	for i := range manifests {
 		out.WriteByte('\n')
-		_ = json.Indent(&out, manifests[i].Raw, "", "  ")
+		_ = json.Indent(&out, redact.JSON(manifests[i].Raw), "", "  ")
 	}
 
-	return redact.JSON(out.String())
+	return out.String()
 }
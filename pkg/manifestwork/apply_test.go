@@ -11,6 +11,8 @@ import (
 	"github.com/submariner-io/admiral/pkg/test"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/testing"
 	"k8s.io/utils/clock"
 	"open-cluster-management.io/api/client/work/clientset/versioned/fake"
 	workv1 "open-cluster-management.io/api/work/v1"
@@ -92,24 +94,28 @@ var _ = Describe("Apply", func() {
 				ensureWork()
 			})
 
-			Context("and update fails", func() {
-				JustBeforeEach(func() {
-					fakereactor.FailOnAction(&workClient.Fake, "manifestworks", "update", nil, false)
+			Context("and the Work was annotated by another controller", func() {
+				BeforeEach(func() {
+					existingWorks[0].(*workv1.ManifestWork).Annotations = map[string]string{"other": "value"}
 				})
 
-				It("should return an error", func() {
-					Expect(doApply()).ToNot(Succeed())
+				It("should preserve the annotation", func() {
+					Expect(doApply()).To(Succeed())
+					ensureWork()
+
+					actual, err := workClient.WorkV1().ManifestWorks(work.Namespace).Get(context.TODO(), work.Name, metav1.GetOptions{})
+					Expect(err).To(Succeed())
+					Expect(actual.Annotations).To(HaveKeyWithValue("other", "value"))
 				})
 			})
 
-			Context("and update initially fails with a conflict error", func() {
-				BeforeEach(func() {
-					fakereactor.ConflictOnUpdateReactor(&workClient.Fake, "manifestworks")
+			Context("and update fails", func() {
+				JustBeforeEach(func() {
+					fakereactor.FailOnAction(&workClient.Fake, "manifestworks", "patch", nil, false)
 				})
 
-				It("should eventually update it", func() {
-					Expect(doApply()).To(Succeed())
-					ensureWork()
+				It("should return an error", func() {
+					Expect(doApply()).ToNot(Succeed())
 				})
 			})
 		})
@@ -130,7 +136,7 @@ var _ = Describe("Apply", func() {
 
 			Context("and update fails", func() {
 				JustBeforeEach(func() {
-					fakereactor.FailOnAction(&workClient.Fake, "manifestworks", "update", nil, false)
+					fakereactor.FailOnAction(&workClient.Fake, "manifestworks", "patch", nil, false)
 				})
 
 				It("should return an error", func() {
@@ -138,22 +144,32 @@ var _ = Describe("Apply", func() {
 				})
 			})
 
-			Context("and update initially fails with a conflict error", func() {
-				BeforeEach(func() {
-					fakereactor.ConflictOnUpdateReactor(&workClient.Fake, "manifestworks")
-				})
+			It("should apply it with the addon field manager", func() {
+				Expect(doApply()).To(Succeed())
 
-				It("should eventually update it", func() {
-					Expect(doApply()).To(Succeed())
-					ensureWork()
-				})
+				actions := workClient.Fake.Actions()
+				patch, ok := actions[len(actions)-1].(testing.PatchAction)
+				Expect(ok).To(BeTrue(), "Expected a patch action")
+				Expect(patch.GetPatchType()).To(Equal(types.ApplyPatchType))
+				Expect(patch.GetPatchOptions().FieldManager).To(Equal(manifestwork.FieldManager))
 			})
 		})
 
 		Context("and the Work Spec has not changed", func() {
 			It("should not update it", func() {
 				Expect(doApply()).To(Succeed())
-				test.EnsureNoActionsForResource(&workClient.Fake, "manifestworks", "update")
+				test.EnsureNoActionsForResource(&workClient.Fake, "manifestworks", "patch")
+			})
+		})
+
+		Context("and the workload manifest was only re-serialized", func() {
+			BeforeEach(func() {
+				work.Spec.Workload.Manifests[0].RawExtension.Raw = []byte("{\n  \"foo\":   \"bar\"\n}")
+			})
+
+			It("should not update it", func() {
+				Expect(doApply()).To(Succeed())
+				test.EnsureNoActionsForResource(&workClient.Fake, "manifestworks", "patch")
 			})
 		})
 	})
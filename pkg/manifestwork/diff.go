@@ -0,0 +1,226 @@
+package manifestwork
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/stolostron/submariner-addon/pkg/redact"
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	ManifestAdded   = "added"
+	ManifestRemoved = "removed"
+	ManifestChanged = "changed"
+)
+
+// ManifestDiff describes the change of a manifest of a ManifestWork. The values of the changed fields are redacted.
+type ManifestDiff struct {
+	// Manifest identifies the manifest by its API version, kind, namespace and name.
+	Manifest string
+	// Operation is one of ManifestAdded, ManifestRemoved or ManifestChanged.
+	Operation string
+	// Fields lists the changed fields of a changed manifest.
+	Fields []FieldDiff
+}
+
+// FieldDiff describes the change of a field of a manifest, with its redacted old and new values.
+type FieldDiff struct {
+	Path string
+	Old  string
+	New  string
+}
+
+func (d ManifestDiff) String() string {
+	if len(d.Fields) == 0 {
+		return fmt.Sprintf("%s %s", d.Operation, d.Manifest)
+	}
+
+	fields := make([]string, len(d.Fields))
+	for i := range d.Fields {
+		fields[i] = d.Fields[i].String()
+	}
+
+	return fmt.Sprintf("%s %s: %s", d.Operation, d.Manifest, strings.Join(fields, ", "))
+}
+
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: %s -> %s", d.Path, d.Old, d.New)
+}
+
+// DiffManifests returns the changes from the existing manifests to the desired ones, in the order of the desired
+// manifests followed by the removed ones. Manifests are matched by identity rather than position, and their content is
+// compared semantically, so re-serialized but otherwise unchanged manifests don't produce a diff.
+func DiffManifests(existing, desired []workv1.Manifest) ([]ManifestDiff, error) {
+	existingObjs, existingIDs, err := decodeManifests(existing)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding the existing manifests")
+	}
+
+	desiredObjs, desiredIDs, err := decodeManifests(desired)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding the desired manifests")
+	}
+
+	var diffs []ManifestDiff
+
+	for _, id := range desiredIDs {
+		existingObj, found := existingObjs[id]
+		if !found {
+			diffs = append(diffs, ManifestDiff{Manifest: id, Operation: ManifestAdded})
+			continue
+		}
+
+		fields := diffFields("", existingObj, desiredObjs[id], redact.Value(existingObj),
+			redact.Value(desiredObjs[id]))
+		if len(fields) > 0 {
+			diffs = append(diffs, ManifestDiff{Manifest: id, Operation: ManifestChanged, Fields: fields})
+		}
+	}
+
+	for _, id := range existingIDs {
+		if _, found := desiredObjs[id]; !found {
+			diffs = append(diffs, ManifestDiff{Manifest: id, Operation: ManifestRemoved})
+		}
+	}
+
+	return diffs, nil
+}
+
+func decodeManifests(manifests []workv1.Manifest) (map[string]any, []string, error) {
+	objs := make(map[string]any, len(manifests))
+	ids := make([]string, 0, len(manifests))
+
+	for i := range manifests {
+		var obj any
+
+		if err := json.Unmarshal(manifests[i].Raw, &obj); err != nil {
+			return nil, nil, errors.Wrapf(err, "error decoding manifest %d", i)
+		}
+
+		id := manifestID(obj, i)
+		objs[id] = obj
+		ids = append(ids, id)
+	}
+
+	return objs, ids, nil
+}
+
+func manifestID(obj any, index int) string {
+	m, _ := obj.(map[string]any)
+	metadata, _ := m["metadata"].(map[string]any)
+
+	kind, _ := m["kind"].(string)
+	name, _ := metadata["name"].(string)
+
+	if kind == "" || name == "" {
+		return fmt.Sprintf("manifest[%d]", index)
+	}
+
+	apiVersion, _ := m["apiVersion"].(string)
+
+	if namespace, _ := metadata["namespace"].(string); namespace != "" {
+		name = namespace + "/" + name
+	}
+
+	return fmt.Sprintf("%s/%s %s", apiVersion, kind, name)
+}
+
+// diffFields compares the old and new values field by field, in a deterministic order, and returns the changed fields
+// with their values taken from the redacted copies.
+func diffFields(path string, oldValue, newValue, redactedOld, redactedNew any) []FieldDiff {
+	// A redacted field is compared as a whole, without revealing its structure.
+	if redactedOld == redact.Redacted || redactedNew == redact.Redacted {
+		if reflect.DeepEqual(oldValue, newValue) {
+			return nil
+		}
+
+		return []FieldDiff{{Path: path, Old: renderValue(oldValue, redactedOld), New: renderValue(newValue, redactedNew)}}
+	}
+
+	oldMap, oldIsMap := oldValue.(map[string]any)
+	newMap, newIsMap := newValue.(map[string]any)
+
+	if oldIsMap && newIsMap {
+		redactedOldMap, _ := redactedOld.(map[string]any)
+		redactedNewMap, _ := redactedNew.(map[string]any)
+
+		keys := make([]string, 0, len(oldMap)+len(newMap))
+
+		for k := range oldMap {
+			keys = append(keys, k)
+		}
+
+		for k := range newMap {
+			if _, found := oldMap[k]; !found {
+				keys = append(keys, k)
+			}
+		}
+
+		sort.Strings(keys)
+
+		var fields []FieldDiff
+
+		for _, k := range keys {
+			fields = append(fields, diffFields(joinPath(path, k), oldMap[k], newMap[k], redactedOldMap[k], redactedNewMap[k])...)
+		}
+
+		return fields
+	}
+
+	oldSlice, oldIsSlice := oldValue.([]any)
+	newSlice, newIsSlice := newValue.([]any)
+
+	if oldIsSlice && newIsSlice && len(oldSlice) == len(newSlice) {
+		redactedOldSlice, _ := redactedOld.([]any)
+		redactedNewSlice, _ := redactedNew.([]any)
+
+		var fields []FieldDiff
+
+		for i := range oldSlice {
+			fields = append(fields, diffFields(fmt.Sprintf("%s[%d]", path, i), oldSlice[i], newSlice[i],
+				sliceElem(redactedOldSlice, i), sliceElem(redactedNewSlice, i))...)
+		}
+
+		return fields
+	}
+
+	if reflect.DeepEqual(oldValue, newValue) {
+		return nil
+	}
+
+	return []FieldDiff{{Path: path, Old: renderValue(oldValue, redactedOld), New: renderValue(newValue, redactedNew)}}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func sliceElem(s []any, i int) any {
+	if i < len(s) {
+		return s[i]
+	}
+
+	return nil
+}
+
+func renderValue(value, redacted any) string {
+	if value == nil {
+		return "<none>"
+	}
+
+	out, err := json.Marshal(redacted)
+	if err != nil {
+		return "<invalid>"
+	}
+
+	return string(out)
+}
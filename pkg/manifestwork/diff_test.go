@@ -0,0 +1,78 @@
+package manifestwork_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/submariner-addon/pkg/manifestwork"
+	"k8s.io/apimachinery/pkg/runtime"
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+var _ = Describe("DiffManifests", func() {
+	const (
+		configMap   = `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"config","namespace":"ns"},"data":{"a":"1","b":"2"}}`
+		clusterRole = `{"apiVersion":"rbac.authorization.k8s.io/v1","kind":"ClusterRole","metadata":{"name":"role"}}`
+	)
+
+	manifest := func(raw string) workv1.Manifest {
+		return workv1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(raw)}}
+	}
+
+	When("the manifests are unchanged", func() {
+		It("should return no diff", func() {
+			diffs, err := manifestwork.DiffManifests([]workv1.Manifest{manifest(configMap)},
+				[]workv1.Manifest{manifest(`{"kind":"ConfigMap","apiVersion":"v1",` +
+					`"data":{"b":"2","a":"1"},"metadata":{"namespace":"ns","name":"config"}}`)})
+			Expect(err).To(Succeed())
+			Expect(diffs).To(BeEmpty())
+		})
+	})
+
+	When("manifests are added, removed and changed", func() {
+		It("should return the diff of each manifest", func() {
+			diffs, err := manifestwork.DiffManifests(
+				[]workv1.Manifest{manifest(configMap), manifest(clusterRole)},
+				[]workv1.Manifest{
+					manifest(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"config","namespace":"ns"},"data":{"b":"3","c":"4"}}`),
+					manifest(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"psk","namespace":"ns"},"data":{"psk":"secret"}}`),
+				})
+			Expect(err).To(Succeed())
+			Expect(diffs).To(Equal([]manifestwork.ManifestDiff{
+				{
+					Manifest:  "v1/ConfigMap ns/config",
+					Operation: manifestwork.ManifestChanged,
+					Fields: []manifestwork.FieldDiff{
+						{Path: "data.a", Old: `"1"`, New: "<none>"},
+						{Path: "data.b", Old: `"2"`, New: `"3"`},
+						{Path: "data.c", Old: "<none>", New: `"4"`},
+					},
+				},
+				{Manifest: "v1/Secret ns/psk", Operation: manifestwork.ManifestAdded},
+				{Manifest: "rbac.authorization.k8s.io/v1/ClusterRole role", Operation: manifestwork.ManifestRemoved},
+			}))
+		})
+	})
+
+	When("a secret field changes", func() {
+		It("should redact its values", func() {
+			diffs, err := manifestwork.DiffManifests(
+				[]workv1.Manifest{manifest(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"psk","namespace":"ns"},` +
+					`"data":{"psk":"old-secret"}}`)},
+				[]workv1.Manifest{manifest(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"psk","namespace":"ns"},` +
+					`"data":{"psk":"new-secret"}}`)})
+			Expect(err).To(Succeed())
+			Expect(diffs).To(HaveLen(1))
+			Expect(diffs[0].Fields).To(HaveLen(1))
+			Expect(diffs[0].Fields[0].Path).To(Equal("data.psk"))
+			Expect(diffs[0].String()).ToNot(ContainSubstring("old-secret"))
+			Expect(diffs[0].String()).ToNot(ContainSubstring("new-secret"))
+		})
+	})
+
+	When("a manifest is invalid", func() {
+		It("should return an error", func() {
+			_, err := manifestwork.DiffManifests(nil, []workv1.Manifest{manifest("{")})
+			Expect(err).ToNot(Succeed())
+		})
+	})
+})
@@ -1,35 +1,373 @@
 package redact
 
 import (
-	"regexp"
+	"encoding/json"
 	"strings"
+	"sync/atomic"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 )
 
-var fieldsToRedact = []string{
-	"brokerK8sApiServer",
-	"token",
-	"ca.crt",
-	"psk",
+const Redacted = "##redacted##"
+
+// DefaultFields selects the secret-bearing fields redacted in addition to the data of Secrets. The selectors are
+// JSONPath-like:
+//   - "a.b" selects the field b of the field a of an object,
+//   - "..a" selects the fields a at any depth,
+//   - "['a.b']" selects a field whose name contains dots,
+//   - "*" and "[*]" select all the fields of an object or all the items of a list.
+//
+// Selectors are evaluated against the root of the redacted value and against every Kubernetes object nested in it,
+// e.g. the manifests of a ManifestWork.
+var DefaultFields = []string{
+	"..brokerK8sApiServer",
+	"..brokerK8sApiServerToken",
+	"..brokerK8sCA",
+	"..token",
+	"..['ca.crt']",
+	"..psk",
+	"..credentials",
+	"..clientSecret",
+	"..['client-key-data']",
 }
 
-var regex *regexp.Regexp
+// Redactor redacts the secret-bearing fields of structured values.
+type Redactor struct {
+	selectors [][]step
+}
+
+type step struct {
+	recursive bool
+	wildcard  bool
+	key       string
+}
+
+var defaultRedactor atomic.Pointer[Redactor]
 
 func init() {
-	var sb strings.Builder
+	defaultRedactor.Store(MustNew(DefaultFields...))
+}
+
+// New returns a Redactor for the given field selectors, see DefaultFields for their syntax.
+func New(fields ...string) (*Redactor, error) {
+	r := &Redactor{selectors: make([][]step, 0, len(fields))}
+
+	for _, field := range fields {
+		steps, err := parse(field)
+		if err != nil {
+			return nil, err
+		}
+
+		r.selectors = append(r.selectors, steps)
+	}
+
+	return r, nil
+}
+
+func MustNew(fields ...string) *Redactor {
+	r, err := New(fields...)
+	if err != nil {
+		panic(err)
+	}
+
+	return r
+}
+
+// SetFields configures the package-level redactor with the default fields and the given additional fields.
+func SetFields(fields ...string) error {
+	r, err := New(append(append([]string{}, DefaultFields...), fields...)...)
+	if err != nil {
+		return err
+	}
+
+	defaultRedactor.Store(r)
+
+	return nil
+}
+
+// Value returns a redacted copy of the given value, decoded from JSON or YAML.
+func (r *Redactor) Value(value any) any {
+	value = deepCopy(value)
+
+	r.redact(value, true)
+
+	return value
+}
+
+// JSON returns a redacted copy of the given JSON document. An invalid document is redacted altogether.
+func (r *Redactor) JSON(data []byte) []byte {
+	var value any
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []byte(`"` + Redacted + `"`)
+	}
+
+	redacted, err := json.Marshal(r.Value(value))
+	if err != nil {
+		return []byte(`"` + Redacted + `"`)
+	}
+
+	return redacted
+}
+
+// YAML returns a redacted copy of the given YAML document. An invalid document is redacted altogether.
+func (r *Redactor) YAML(data []byte) []byte {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return []byte(Redacted + "\n")
+	}
+
+	redacted, err := yaml.JSONToYAML(r.JSON(jsonData))
+	if err != nil {
+		return []byte(Redacted + "\n")
+	}
+
+	return redacted
+}
+
+// ToJSON returns the redacted JSON representation of the given object, for logging.
+func (r *Redactor) ToJSON(obj any) string {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return Redacted
+	}
+
+	var value any
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return Redacted
+	}
+
+	// Typed Secrets don't necessarily carry their type meta.
+	if m, ok := value.(map[string]any); ok {
+		if _, isSecret := obj.(*corev1.Secret); isSecret {
+			m["apiVersion"] = "v1"
+			m["kind"] = "Secret"
+		}
+	}
+
+	r.redact(value, true)
+
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return Redacted
+	}
+
+	return string(redacted)
+}
+
+// redact redacts the given value in place.
+func (r *Redactor) redact(value any, root bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		if root || isObject(v) {
+			for _, steps := range r.selectors {
+				apply(v, steps)
+			}
+
+			if isSecret(v) {
+				redactAll(v["data"])
+				redactAll(v["stringData"])
+			}
+		}
+
+		for _, child := range v {
+			r.redact(child, false)
+		}
+	case []any:
+		for _, child := range v {
+			r.redact(child, false)
+		}
+	}
+}
+
+func apply(value any, steps []step) {
+	if len(steps) == 0 {
+		return
+	}
+
+	current, rest := steps[0], steps[1:]
+
+	if current.recursive {
+		for _, child := range children(value) {
+			apply(child, steps)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if !current.wildcard && key != current.key {
+				continue
+			}
+
+			if len(rest) == 0 {
+				v[key] = Redacted
+			} else {
+				apply(child, rest)
+			}
+		}
+	case []any:
+		if !current.wildcard {
+			return
+		}
+
+		for i := range v {
+			if len(rest) == 0 {
+				v[i] = Redacted
+			} else {
+				apply(v[i], rest)
+			}
+		}
+	}
+}
+
+func children(value any) []any {
+	switch v := value.(type) {
+	case map[string]any:
+		c := make([]any, 0, len(v))
+		for _, child := range v {
+			c = append(c, child)
+		}
+
+		return c
+	case []any:
+		return v
+	}
+
+	return nil
+}
+
+func redactAll(value any) {
+	if m, ok := value.(map[string]any); ok {
+		for key := range m {
+			m[key] = Redacted
+		}
+	}
+}
+
+func isObject(m map[string]any) bool {
+	_, hasAPIVersion := m["apiVersion"].(string)
+	_, hasKind := m["kind"].(string)
+
+	return hasAPIVersion && hasKind
+}
+
+func isSecret(m map[string]any) bool {
+	return m["kind"] == "Secret" && (m["apiVersion"] == "v1" || m["apiVersion"] == nil)
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for key, child := range v {
+			c[key] = deepCopy(child)
+		}
+
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i := range v {
+			c[i] = deepCopy(v[i])
+		}
 
-	sb.WriteString("(\"(")
-	sb.WriteString(regexp.QuoteMeta(fieldsToRedact[0]))
+		return c
+	}
+
+	return value
+}
+
+// parse parses a field selector into its steps.
+func parse(field string) ([]step, error) {
+	var steps []step
+
+	s := strings.TrimPrefix(field, "$")
+	if s != "" && s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+
+	for s != "" {
+		recursive := false
 
-	for i := 1; i < len(fieldsToRedact); i++ {
-		sb.WriteString("|")
-		sb.WriteString(regexp.QuoteMeta(fieldsToRedact[i]))
+		switch {
+		case strings.HasPrefix(s, ".."):
+			recursive = true
+			s = s[2:]
+		case s[0] == '.':
+			s = s[1:]
+		}
+
+		var (
+			current step
+			err     error
+		)
+
+		current, s, err = parseStep(field, s)
+		if err != nil {
+			return nil, err
+		}
+
+		current.recursive = recursive
+		steps = append(steps, current)
 	}
 
-	sb.WriteString(")\"\\s*:\\s*)\"(\\\\.|[^\"\\\\])*\"")
+	if len(steps) == 0 {
+		return nil, errors.Errorf("empty field selector %q", field)
+	}
+
+	return steps, nil
+}
+
+func parseStep(field, s string) (step, string, error) {
+	switch {
+	case strings.HasPrefix(s, "[*]"):
+		return step{wildcard: true}, s[3:], nil
+	case strings.HasPrefix(s, "['"):
+		end := strings.Index(s, "']")
+		if end < 0 {
+			return step{}, "", errors.Errorf("unterminated bracket in field selector %q", field)
+		}
+
+		if end == 2 {
+			return step{}, "", errors.Errorf("empty field name in field selector %q", field)
+		}
+
+		return step{key: s[2:end]}, s[end+2:], nil
+	case strings.HasPrefix(s, "*"):
+		return step{wildcard: true}, s[1:], nil
+	}
+
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		end = len(s)
+	}
+
+	if end == 0 {
+		return step{}, "", errors.Errorf("invalid field selector %q", field)
+	}
+
+	return step{key: s[:end]}, s[end:], nil
+}
+
+// Value returns a copy of the given value, decoded from JSON or YAML, redacted by the package-level redactor.
+func Value(value any) any {
+	return defaultRedactor.Load().Value(value)
+}
+
+// JSON returns a copy of the given JSON document redacted by the package-level redactor.
+func JSON(data []byte) []byte {
+	return defaultRedactor.Load().JSON(data)
+}
 
-	regex = regexp.MustCompile(sb.String())
+// YAML returns a copy of the given YAML document redacted by the package-level redactor.
+func YAML(data []byte) []byte {
+	return defaultRedactor.Load().YAML(data)
 }
 
-func JSON(s string) string {
-	return regex.ReplaceAllString(s, "$1\"##redacted##\"")
+// ToJSON returns the JSON representation of the given object redacted by the package-level redactor, for logging.
+func ToJSON(obj any) string {
+	return defaultRedactor.Load().ToJSON(obj)
 }
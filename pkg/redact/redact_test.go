@@ -0,0 +1,107 @@
+package redact_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/submariner-addon/pkg/redact"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Redactor", func() {
+	decode := func(s string) any {
+		var value any
+
+		Expect(json.Unmarshal([]byte(s), &value)).To(Succeed())
+
+		return value
+	}
+
+	When("a Secret is redacted", func() {
+		It("should redact all its data and stringData", func() {
+			Expect(redact.Value(decode(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s"},` +
+				`"data":{"key":"dmFsdWU="},"stringData":{"other":"value"}}`))).To(Equal(decode(
+				`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"s"},` +
+					`"data":{"key":"##redacted##"},"stringData":{"other":"##redacted##"}}`)))
+		})
+	})
+
+	When("Secrets are nested in another object", func() {
+		It("should redact their data", func() {
+			Expect(redact.Value(decode(`{"apiVersion":"work.open-cluster-management.io/v1","kind":"ManifestWork",` +
+				`"spec":{"workload":{"manifests":[{"apiVersion":"v1","kind":"Secret","data":{"key":"dmFsdWU="}},` +
+				`{"apiVersion":"v1","kind":"ConfigMap","data":{"key":"value"}}]}}}`))).To(Equal(decode(
+				`{"apiVersion":"work.open-cluster-management.io/v1","kind":"ManifestWork",` +
+					`"spec":{"workload":{"manifests":[{"apiVersion":"v1","kind":"Secret","data":{"key":"##redacted##"}},` +
+					`{"apiVersion":"v1","kind":"ConfigMap","data":{"key":"value"}}]}}}`)))
+		})
+	})
+
+	When("default fields are present at any depth", func() {
+		It("should redact them, whatever their type", func() {
+			Expect(redact.Value(decode(`{"spec":{"brokerK8sApiServerToken":"token","nested":{"credentials":{"key":"value"}},` +
+				`"list":[{"psk":"psk","name":"n"}],"ca.crt":"ca"}}`))).To(Equal(decode(
+				`{"spec":{"brokerK8sApiServerToken":"##redacted##","nested":{"credentials":"##redacted##"},` +
+					`"list":[{"psk":"##redacted##","name":"n"}],"ca.crt":"##redacted##"}}`)))
+		})
+	})
+
+	When("the value is redacted", func() {
+		It("should not modify the original value", func() {
+			value := decode(`{"token":"token"}`)
+			redact.Value(value)
+			Expect(value).To(Equal(decode(`{"token":"token"}`)))
+		})
+	})
+
+	When("custom field selectors are configured", func() {
+		It("should redact the selected fields", func() {
+			r, err := redact.New("spec.remote", "items[*].key", "['a.b'].*")
+			Expect(err).To(Succeed())
+
+			Expect(r.Value(decode(`{"spec":{"remote":"value","local":"value"},"items":[{"key":"1"},{"key":"2","other":"3"}],` +
+				`"a.b":{"c":"1","d":"2"},"remote":"value"}`))).To(Equal(decode(
+				`{"spec":{"remote":"##redacted##","local":"value"},"items":[{"key":"##redacted##"},{"key":"##redacted##","other":"3"}],` +
+					`"a.b":{"c":"##redacted##","d":"##redacted##"},"remote":"value"}`)))
+		})
+	})
+
+	When("an invalid field selector is configured", func() {
+		It("should return an error", func() {
+			for _, field := range []string{"", "a.", "a...b", "['a", "['']"} {
+				_, err := redact.New(field)
+				Expect(err).ToNot(Succeed(), "Expected an error for %q", field)
+			}
+		})
+	})
+
+	When("a JSON document is redacted", func() {
+		It("should return the redacted document", func() {
+			Expect(redact.JSON([]byte(`{"token":"token","name":"n"}`))).To(MatchJSON(`{"token":"##redacted##","name":"n"}`))
+		})
+
+		Context("and it's invalid", func() {
+			It("should redact it altogether", func() {
+				Expect(string(redact.JSON([]byte(`{"token":`)))).To(Equal(`"##redacted##"`))
+			})
+		})
+	})
+
+	When("a YAML document is redacted", func() {
+		It("should return the redacted document", func() {
+			Expect(redact.YAML([]byte("apiVersion: v1\nkind: Secret\ndata:\n  key: dmFsdWU=\n"))).To(MatchYAML(
+				"apiVersion: v1\nkind: Secret\ndata:\n  key: '##redacted##'\n"))
+		})
+	})
+
+	When("a typed Secret is converted to JSON", func() {
+		It("should redact its data", func() {
+			Expect(redact.ToJSON(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "s"},
+				Data:       map[string][]byte{"key": []byte("value")},
+			})).ToNot(ContainSubstring("dmFsdWU="))
+		})
+	})
+})
@@ -53,7 +53,7 @@ func ApplyManifests(ctx context.Context, kubeClient kubernetes.Interface, record
 		if result.Error != nil {
 			errs = append(errs, fmt.Errorf("error applying %q (%T): %w", result.File, result.Type, result.Error))
 		} else if result.Changed {
-			logger.Infof("%s from file %q created/updated: %s", result.Type, result.File, redact.JSON(resource.ToJSON(result.Result)))
+			logger.Infof("%s from file %q created/updated: %s", result.Type, result.File, redact.ToJSON(result.Result))
 		}
 	}
 
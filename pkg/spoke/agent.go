@@ -56,6 +56,7 @@ type AgentOptions struct {
 	HubKubeconfigFile     string
 	HubRestConfig         *rest.Config
 	ClusterName           string
+	CloudProviderPlugins  map[string]string
 }
 
 func NewAgentOptions() *AgentOptions {
@@ -66,6 +67,8 @@ func (o *AgentOptions) AddFlags(cmd *cobra.Command) {
 	flags := cmd.Flags()
 	flags.StringVar(&o.HubKubeconfigFile, "hub-kubeconfig", o.HubKubeconfigFile, "Location of kubeconfig file to connect to hub cluster.")
 	flags.StringVar(&o.ClusterName, "cluster-name", o.ClusterName, "Name of managed cluster.")
+	flags.StringToStringVar(&o.CloudProviderPlugins, "cloud-provider-plugin", o.CloudProviderPlugins,
+		"Cloud provider plugins, as platform=socket pairs, used to prepare the self-managed OpenShift clusters on the given platforms.")
 }
 
 func (o *AgentOptions) Complete() {
@@ -147,6 +150,8 @@ func (o *AgentOptions) RunAgent(ctx context.Context, spokeConfig *rest.Config) e
 		addoninformers.WithNamespace(o.ClusterName), addoninformers.WithTransform(trim))
 	configInformers := configinformers.NewSharedInformerFactoryWithOptions(configHubKubeClient, 10*time.Minute,
 		configinformers.WithNamespace(o.ClusterName), configinformers.WithTransform(trim))
+	hubKubeInformers := informers.NewSharedInformerFactoryWithOptions(hubClient, 10*time.Minute,
+		informers.WithNamespace(o.ClusterName), informers.WithTransform(trim))
 
 	spokeKubeInformers := informers.NewSharedInformerFactoryWithOptions(spokeKubeClient, 10*time.Minute,
 		informers.WithNamespace(o.InstallationNamespace), informers.WithTransform(trim))
@@ -156,6 +161,13 @@ func (o *AgentOptions) RunAgent(ctx context.Context, spokeConfig *rest.Config) e
 	submarinerInformer := dynamicInformers.ForResource(submarinerGVR)
 	routeAgentInformer := dynamicInformers.ForResource(routeAgentGVR)
 
+	// The plugins prepare self-managed OpenShift clusters, the managed offerings use their built-in providers.
+	for platform, socketPath := range o.CloudProviderPlugins {
+		if err := cloud.RegisterPluginProvider(constants.ProductOCP, platform, socketPath); err != nil {
+			return fmt.Errorf("error registering the cloud provider plugin for platform %q: %w", platform, err)
+		}
+	}
+
 	submarinerConfigController := submarineragent.NewSubmarinerConfigController(&submarineragent.SubmarinerConfigControllerInput{
 		ClusterName:          o.ClusterName,
 		Namespace:            o.InstallationNamespace,
@@ -167,6 +179,7 @@ func (o *AgentOptions) RunAgent(ctx context.Context, spokeConfig *rest.Config) e
 		AddOnInformer:        addOnInformers.Addon().V1beta1().ManagedClusterAddOns(),
 		ConfigInformer:       configInformers.Submarineraddon().V1alpha1().SubmarinerConfigs(),
 		SubmarinerInformer:   submarinerInformer,
+		SecretInformer:       hubKubeInformers.Core().V1().Secrets(),
 		CloudProviderFactory: cloud.NewProviderFactory(restMapper, spokeKubeClient, spokeDynamicClient, hubClient),
 		Recorder:             eventRecorder,
 	})
@@ -187,6 +200,7 @@ func (o *AgentOptions) RunAgent(ctx context.Context, spokeConfig *rest.Config) e
 
 	go addOnInformers.Start(ctx.Done())
 	go configInformers.Start(ctx.Done())
+	go hubKubeInformers.Start(ctx.Done())
 	go spokeKubeInformers.Start(ctx.Done())
 	go dynamicInformers.Start(ctx.Done())
 
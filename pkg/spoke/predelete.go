@@ -0,0 +1,75 @@
+package spoke
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stolostron/submariner-addon/pkg/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+const preDeleteHookPollInterval = 5 * time.Second
+
+// PreDeleteHookOptions are the options of the pre-delete hook Job deployed with the add-on agent when the submariner
+// operator is deployed through the add-on framework.
+type PreDeleteHookOptions struct {
+	InstallationNamespace string
+	Timeout               time.Duration
+}
+
+func NewPreDeleteHookOptions() *PreDeleteHookOptions {
+	return &PreDeleteHookOptions{
+		Timeout: 5 * time.Minute,
+	}
+}
+
+func (o *PreDeleteHookOptions) AddFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.DurationVar(&o.Timeout, "timeout", o.Timeout,
+		"How long to wait for the Submariner resource to be removed before letting the add-on removal proceed.")
+}
+
+// Run waits for the Submariner resource to be removed from the installation namespace, so that the add-on framework doesn't
+// remove the submariner operator before it cleaned up. It doesn't fail once the timeout elapsed, so that a stuck clean up
+// doesn't block the removal of the add-on.
+func (o *PreDeleteHookOptions) Run(ctx context.Context, config *rest.Config) error {
+	o.InstallationNamespace = resource.GetCurrentNamespace(defaultInstallationNamespace)
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error creating dynamic client: %w", err)
+	}
+
+	submariners := dynamicClient.Resource(submarinerGVR).Namespace(o.InstallationNamespace)
+
+	err = wait.PollUntilContextTimeout(ctx, preDeleteHookPollInterval, o.Timeout, true, func(ctx context.Context) (bool, error) {
+		list, err := submariners.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			klog.Warningf("Error listing the Submariner resources in namespace %q: %v", o.InstallationNamespace, err)
+			return false, nil
+		}
+
+		return len(list.Items) == 0, nil
+	})
+
+	if wait.Interrupted(err) {
+		klog.Warningf("The Submariner resource in namespace %q wasn't removed after %v, letting the add-on removal proceed",
+			o.InstallationNamespace, o.Timeout)
+
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("error waiting for the Submariner resource to be removed: %w", err)
+	}
+
+	klog.Infof("The Submariner resource in namespace %q was removed", o.InstallationNamespace)
+
+	return nil
+}
@@ -2,12 +2,16 @@ package submarineragent
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	goerrors "errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -21,6 +25,7 @@ import (
 	configinformer "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/informers/externalversions/submarinerconfig/v1alpha1"
 	configlister "github.com/stolostron/submariner-addon/pkg/client/submarinerconfig/listers/submarinerconfig/v1alpha1"
 	"github.com/stolostron/submariner-addon/pkg/cloud"
+	"github.com/stolostron/submariner-addon/pkg/cloud/provider"
 	"github.com/stolostron/submariner-addon/pkg/constants"
 	"github.com/submariner-io/admiral/pkg/log"
 	"github.com/submariner-io/admiral/pkg/resource"
@@ -28,12 +33,14 @@ import (
 	"github.com/submariner-io/submariner/pkg/cni"
 	corev1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/dynamic"
@@ -60,12 +67,28 @@ var (
 
 const submarinerGatewayCondition = "SubmarinerGatewaysLabeled"
 
+const (
+	defaultClusterEnvCheckInterval = 10 * time.Minute
+	clusterEnvDriftedReason        = "SubmarinerClusterEnvDrifted"
+)
+
 const (
 	submarinerUDPPortLabel = "gateway.submariner.io/udp-port"
 	workerNodeLabel        = "node-role.kubernetes.io/worker"
 	networksConfigName     = "cluster"
+	// The OpenShift machine API termination handler sets this condition on nodes whose spot or preemptible instance
+	// received a termination notice.
+	nodeTerminatingCondition = "Terminating"
 )
 
+// cloudPreparePlanKey identifies the config and credentials a cloud preparation plan was published for.
+type cloudPreparePlanKey struct {
+	uid             types.UID
+	generation      int64
+	operation       string
+	credentialsHash string
+}
+
 type nodeLabelSelector struct {
 	label string
 	op    selection.Operator
@@ -81,12 +104,18 @@ type submarinerConfigController struct {
 	nodeLister           corev1lister.NodeLister
 	addOnLister          addonlisterv1beta1.ManagedClusterAddOnLister
 	configLister         configlister.SubmarinerConfigLister
+	secretLister         corev1lister.SecretLister
 	submarinerLister     cache.GenericLister
 	clusterName          string
 	namespace            string
 	cloudProviderFactory cloud.ProviderFactory
 	onSyncDefer          func()
 	lastKnownConfig      *configv1alpha1.SubmarinerConfig
+	lastCredentialsHash  string
+	lastPlan             cloudPreparePlanKey
+	cloudProviderFound   bool
+	envCheckInterval     time.Duration
+	lastEnvCheck         time.Time
 	logger               log.Logger
 }
 
@@ -101,8 +130,12 @@ type SubmarinerConfigControllerInput struct {
 	AddOnInformer        addoninformerv1beta1.ManagedClusterAddOnInformer
 	ConfigInformer       configinformer.SubmarinerConfigInformer
 	SubmarinerInformer   informers.GenericInformer
+	SecretInformer       corev1informers.SecretInformer
 	CloudProviderFactory cloud.ProviderFactory
 	Recorder             events.Recorder
+	// ClusterEnvCheckInterval is the interval at which the prepared cloud resources are verified for drift, 10 minutes if
+	// not set.
+	ClusterEnvCheckInterval time.Duration
 	// This is a hook for unit tests to invoke a defer (specifically GinkgoRecover) when the sync function is called.
 	OnSyncDefer func()
 }
@@ -118,14 +151,20 @@ func NewSubmarinerConfigController(input *SubmarinerConfigControllerInput) facto
 		nodeLister:           input.NodeInformer.Lister(),
 		addOnLister:          input.AddOnInformer.Lister(),
 		configLister:         input.ConfigInformer.Lister(),
+		secretLister:         input.SecretInformer.Lister(),
 		submarinerLister:     input.SubmarinerInformer.Lister(),
 		clusterName:          input.ClusterName,
 		namespace:            input.Namespace,
 		cloudProviderFactory: input.CloudProviderFactory,
 		onSyncDefer:          input.OnSyncDefer,
+		envCheckInterval:     input.ClusterEnvCheckInterval,
 		logger:               log.Logger{Logger: logf.Log.WithName(name)},
 	}
 
+	if c.envCheckInterval == 0 {
+		c.envCheckInterval = defaultClusterEnvCheckInterval
+	}
+
 	return factory.New().
 		WithFilteredEventsInformers(func(obj any) bool {
 			metaObj := obj.(metav1.Object)
@@ -137,6 +176,17 @@ func NewSubmarinerConfigController(input *SubmarinerConfigControllerInput) facto
 
 			return metaObj.GetName() == constants.SubmarinerConfigName
 		}, input.ConfigInformer.Informer()).
+		WithFilteredEventsInformers(func(obj any) bool {
+			metaObj := obj.(metav1.Object)
+
+			// only handle the changes of the credentials Secret referenced by the config
+			config, err := c.configLister.SubmarinerConfigs(c.clusterName).Get(constants.SubmarinerConfigName)
+			if err != nil || config.Spec.CredentialsSecret == nil {
+				return false
+			}
+
+			return metaObj.GetName() == config.Spec.CredentialsSecret.Name
+		}, input.SecretInformer.Informer()).
 		WithFilteredEventsInformers(func(obj any) bool {
 			metaObj := obj.(metav1.Object)
 			// only handle the changes of worker nodes
@@ -151,6 +201,7 @@ func NewSubmarinerConfigController(input *SubmarinerConfigControllerInput) facto
 			return key
 		}, input.SubmarinerInformer.Informer()).
 		WithSync(c.sync).
+		ResyncEvery(c.envCheckInterval).
 		ToController(name, input.Recorder)
 }
 
@@ -225,7 +276,12 @@ func (c *submarinerConfigController) sync(ctx context.Context, syncCtx factory.S
 		return updateErr
 	}
 
-	return c.syncConfig(ctx, recorder, config)
+	if err := c.syncConfig(ctx, recorder, config); err != nil {
+		return err
+	}
+
+	// The drift is verified on its own schedule, whether the config changed or the gateways were reconciled, or not
+	return c.verifyClusterEnvironment(ctx, config, recorder)
 }
 
 func (c *submarinerConfigController) syncConfig(ctx context.Context, recorder events.Recorder,
@@ -239,17 +295,27 @@ func (c *submarinerConfigController) syncConfig(ctx context.Context, recorder ev
 		return errors.Wrapf(err, "error checking if gateway reconciliation is needed")
 	}
 
-	if !needsGatewayReconciliation && c.skipSyncingUnchangedConfig(config) {
+	credentialsHash, err := c.credentialsHash(config)
+	if err != nil {
+		return err
+	}
+
+	if !needsGatewayReconciliation && c.skipSyncingUnchangedConfig(config, credentialsHash) {
 		c.logger.V(log.DEBUG).Info("Skip syncing submariner config as it didn't change",
 			"config", config.Namespace+"/"+config.Name)
 
-		return nil
+		return c.moveTerminatingGateways(ctx, config, recorder)
 	}
 
 	if needsGatewayReconciliation {
 		c.logger.Info("Gateway reconciliation needed for config", "config", config.Namespace+"/"+config.Name)
 	}
 
+	if c.lastKnownConfig != nil && c.lastCredentialsHash != credentialsHash {
+		c.logger.Info("The cloud provider credentials changed, preparing the cloud environment again",
+			"config", config.Namespace+"/"+config.Name)
+	}
+
 	isValid, err := c.validateOCPVersion(ctx, config, recorder)
 
 	if !isValid || err != nil {
@@ -259,28 +325,85 @@ func (c *submarinerConfigController) syncConfig(ctx context.Context, recorder ev
 	return c.prepareForSubmariner(ctx, config, recorder)
 }
 
-// skipSyncingUnchangedConfig if last submariner config is known and is equal to the given config.
-func (c *submarinerConfigController) skipSyncingUnchangedConfig(config *configv1alpha1.SubmarinerConfig) bool {
-	return c.lastKnownConfig != nil && reflect.DeepEqual(c.lastKnownConfig.Spec, config.Spec)
+// skipSyncingUnchangedConfig if last submariner config is known and is equal to the given config, and the credentials
+// didn't change since.
+func (c *submarinerConfigController) skipSyncingUnchangedConfig(config *configv1alpha1.SubmarinerConfig,
+	credentialsHash string,
+) bool {
+	return c.lastKnownConfig != nil && reflect.DeepEqual(c.lastKnownConfig.Spec, config.Spec) &&
+		c.lastCredentialsHash == credentialsHash
+}
+
+// credentialsHash returns a hash of the content of the credentials Secret referenced by the given config, or an empty
+// string if there's none.
+func (c *submarinerConfigController) credentialsHash(config *configv1alpha1.SubmarinerConfig) (string, error) {
+	if config.Spec.CredentialsSecret == nil {
+		return "", nil
+	}
+
+	secret, err := c.secretLister.Secrets(config.Namespace).Get(config.Spec.CredentialsSecret.Name)
+	if apiErrors.IsNotFound(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", errors.Wrapf(err, "error retrieving credentials Secret %q", config.Spec.CredentialsSecret.Name)
+	}
+
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	hash := sha256.New()
+
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(secret.Data[key])
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
 // needsGatewayReconciliation checks if the actual number of labeled gateway nodes
 // matches the desired count specified in the config. Returns true if reconciliation
-// is needed (e.g., during node replacement scenarios).
+// is needed (e.g., during node replacement scenarios). The gateways on nodes which
+// received a termination notice are still counted: they're moved by moveTerminatingGateways
+// without preparing the cloud environment again.
 func (c *submarinerConfigController) needsGatewayReconciliation(config *configv1alpha1.SubmarinerConfig) (bool, error) {
-	if config.Spec.Gateways < 1 {
+	gateways := provider.GatewayCount(&config.Spec.GatewayConfig)
+	if gateways < 1 {
 		return false, nil
 	}
 
-	currentGateways, err := c.getLabeledNodes(
-		nodeLabelSelector{submarinerGatewayLabel, selection.Exists},
-	)
+	currentGateways, terminatingGateways, err := c.getGatewayNodes()
 	if err != nil {
 		return false, errors.Wrap(err, "error retrieving gateway nodes")
 	}
 
-	// Need reconciliation if the actual count doesn't match the desired count
-	return len(currentGateways) != config.Spec.Gateways, nil
+	return len(currentGateways)+len(terminatingGateways) != gateways, nil
+}
+
+// moveTerminatingGateways moves the gateways off the nodes which received a termination notice, if any.
+func (c *submarinerConfigController) moveTerminatingGateways(ctx context.Context, config *configv1alpha1.SubmarinerConfig,
+	recorder events.Recorder,
+) error {
+	_, terminatingGateways, err := c.getGatewayNodes()
+	if err != nil {
+		return errors.Wrap(err, "error retrieving gateway nodes")
+	}
+
+	if len(terminatingGateways) == 0 {
+		return nil
+	}
+
+	c.logger.Infof("Moving the gateways off the terminating nodes %q", nodeNames(terminatingGateways))
+
+	return c.reconcileGateways(ctx, config, recorder)
 }
 
 func (c *submarinerConfigController) prepareForSubmariner(ctx context.Context, config *configv1alpha1.SubmarinerConfig,
@@ -289,8 +412,19 @@ func (c *submarinerConfigController) prepareForSubmariner(ctx context.Context, c
 	cloudProvider, providerFound, preparedErr := c.cloudProviderFactory.Get(config, recorder)
 	errs := []error{}
 
+	if providerFound && preparedErr == nil && config.Spec.CloudPreparePlanOnly {
+		if err := c.planCloudPreparation(ctx, config, cloudProvider, configv1alpha1.CloudPreparePlanCreate); err != nil {
+			return err
+		}
+
+		// Labeling the gateway nodes doesn't change the cloud environment
+		return c.reconcileGateways(ctx, config, recorder)
+	}
+
+	var inventory []configv1alpha1.CloudResource
+
 	if providerFound && preparedErr == nil {
-		preparedErr = cloudProvider.PrepareSubmarinerClusterEnv(ctx)
+		inventory, preparedErr = cloudProvider.PrepareSubmarinerClusterEnv(ctx)
 	}
 
 	condition := metav1.Condition{
@@ -307,9 +441,23 @@ func (c *submarinerConfigController) prepareForSubmariner(ctx context.Context, c
 		errs = append(errs, preparedErr)
 	}
 
+	updateFuncs := []submarinerconfig.UpdateStatusFunc{
+		submarinerconfig.UpdateConditionFn(&condition), submarinerconfig.UpdateCloudPreparePlanFn(nil),
+	}
+
+	// Keep the previous inventory on failure since we don't know which resources were created. Resources orphaned by a
+	// previous clean up are adopted so they're cleaned up along with the inventory.
+	if providerFound && preparedErr == nil {
+		if orphans := config.Status.OrphanedCloudResources; orphans != nil {
+			inventory = mergeCloudResources(inventory, orphans.Resources)
+		}
+
+		updateFuncs = append(updateFuncs, submarinerconfig.UpdateCloudResourcesFn(inventory),
+			submarinerconfig.UpdateOrphanedCloudResourcesFn(nil))
+	}
+
 	_, updated, updatedErr := submarinerconfig.UpdateStatus(ctx,
-		c.configClient.SubmarineraddonV1alpha1().SubmarinerConfigs(config.Namespace), config.Name,
-		submarinerconfig.UpdateConditionFn(&condition))
+		c.configClient.SubmarineraddonV1alpha1().SubmarinerConfigs(config.Namespace), config.Name, updateFuncs...)
 	if updatedErr != nil {
 		errs = append(errs, updatedErr)
 	}
@@ -323,15 +471,36 @@ func (c *submarinerConfigController) prepareForSubmariner(ctx context.Context, c
 		return goerrors.Join(errs...)
 	}
 
+	c.cloudProviderFound = providerFound
+
 	if providerFound {
+		c.lastEnvCheck = time.Now()
+
+		// The cloud environment was prepared with the current credentials, so there's no need to prepare it again for them
+		// even if the gateway status condition doesn't change.
+		c.lastCredentialsHash, _ = c.credentialsHash(config)
+
 		if updated {
 			c.logger.Infof("Submariner environment was prepared for cluster %q: %#v", config.Namespace, config.Status.ManagedClusterInfo)
 		}
+	}
+
+	return c.reconcileGateways(ctx, config, recorder)
+}
 
-		return c.updateGatewayStatus(ctx, recorder, config)
+// reconcileGateways ensures the expected gateways are labeled and updates the gateway status condition accordingly.
+func (c *submarinerConfigController) reconcileGateways(ctx context.Context, config *configv1alpha1.SubmarinerConfig,
+	recorder events.Recorder,
+) error {
+	// The cloud provider deploys and labels dedicated gateway nodes, except in load balancer mode where the load balancer
+	// fronts existing worker nodes which are labeled like without a cloud provider.
+	if c.cloudProviderFound && !config.Spec.LoadBalancerEnable {
+		err := c.reconcileDedicatedGateways(ctx, config)
+
+		return goerrors.Join(err, c.updateGatewayStatus(ctx, recorder, config))
 	}
 
-	// No provider - ensure the expected count of gateways
+	// No provider or load balancer mode - ensure the expected count of gateways
 	condition, err := c.ensureGateways(ctx, config)
 
 	updateErr := c.updateSubmarinerConfigStatus(ctx, recorder, config, &condition)
@@ -343,6 +512,32 @@ func (c *submarinerConfigController) prepareForSubmariner(ctx context.Context, c
 	return updateErr
 }
 
+// reconcileDedicatedGateways moves the gateways on dedicated gateway nodes which received a termination notice, e.g. spot
+// instances about to be reclaimed, to other worker nodes until the cloud provider replaces them. Once it did, the worker
+// nodes labeled in their place are released.
+func (c *submarinerConfigController) reconcileDedicatedGateways(ctx context.Context, config *configv1alpha1.SubmarinerConfig) error {
+	gateways := provider.GatewayCount(&config.Spec.GatewayConfig)
+
+	currentGateways, terminatingGateways, err := c.getGatewayNodes()
+	if err != nil {
+		return errors.Wrap(err, "error retrieving gateway nodes")
+	}
+
+	switch {
+	case len(terminatingGateways) > 0 && len(currentGateways) < gateways:
+		_, err = c.addGateways(ctx, config, currentGateways, gateways-len(currentGateways))
+	case len(currentGateways) > gateways:
+		// Only the worker nodes labeled by the controller are unlabeled
+		labeledWorkers := slices.DeleteFunc(slices.Clone(currentGateways), func(node *corev1.Node) bool {
+			return node.Annotations[gatewayLabeledBySubmariner] == ""
+		})
+
+		_, err = c.removeGateways(ctx, labeledWorkers, len(currentGateways)-gateways)
+	}
+
+	return goerrors.Join(err, c.unlabelTerminatingNodes(ctx, terminatingGateways))
+}
+
 func (c *submarinerConfigController) cleanupClusterEnvironment(ctx context.Context, config *configv1alpha1.SubmarinerConfig,
 	recorder events.Recorder,
 ) error {
@@ -351,13 +546,214 @@ func (c *submarinerConfigController) cleanupClusterEnvironment(ctx context.Conte
 		return errors.WithMessagef(c.removeAllGateways(ctx), "failed to unlabel the gateway nodes")
 	}
 
+	// Snapshot what the clean up needs before attempting it, so the resources can be recorded as orphaned if it fails.
+	orphans := snapshotCloudResources(config)
+
+	// The hub keeps the agent until the plan mode is turned off and the inventoried resources are cleaned up.
+	if err == nil && config.Spec.CloudPreparePlanOnly {
+		c.logger.Infof("Plan mode is enabled - not cleaning up the submariner cluster environment")
+
+		return c.planCloudPreparation(ctx, config, cloudProvider, configv1alpha1.CloudPreparePlanDelete)
+	}
+
 	if err == nil {
-		c.logger.Infof("Cleaning up the submariner cluster environment")
+		c.logger.Infof("Cleaning up the submariner cluster environment: %s", resource.ToJSON(orphans.Resources))
 
-		err = cloudProvider.CleanUpSubmarinerClusterEnv(ctx)
+		err = cloudProvider.CleanUpSubmarinerClusterEnv(ctx, orphans.Resources)
 	}
 
-	return errors.WithMessagef(err, "failed to clean up the submariner cluster environment")
+	if err == nil {
+		_, _, err = submarinerconfig.UpdateStatus(ctx,
+			c.configClient.SubmarineraddonV1alpha1().SubmarinerConfigs(config.Namespace), config.Name,
+			submarinerconfig.UpdateCloudResourcesFn(nil), submarinerconfig.UpdateOrphanedCloudResourcesFn(nil))
+
+		return errors.WithMessagef(err, "failed to clean up the submariner cluster environment")
+	}
+
+	// Without an inventory, e.g. for an environment prepared before the inventory was recorded, the clean up covers every
+	// kind of cloud resource, so they're all recorded as orphaned.
+	if len(orphans.Resources) == 0 {
+		orphans.Resources = unknownCloudResources()
+	}
+
+	orphans.Message = err.Error()
+
+	_, updated, updateErr := submarinerconfig.UpdateStatus(ctx,
+		c.configClient.SubmarineraddonV1alpha1().SubmarinerConfigs(config.Namespace), config.Name,
+		submarinerconfig.UpdateOrphanedCloudResourcesFn(orphans))
+	if updated {
+		recorder.Warningf("CloudResourcesOrphaned", "%d cloud resources of managed cluster %s couldn't be cleaned up: %v",
+			len(orphans.Resources), config.Namespace, err)
+	}
+
+	return errors.WithMessagef(goerrors.Join(err, updateErr), "failed to clean up the submariner cluster environment")
+}
+
+// snapshotCloudResources returns the cloud resources to clean up, i.e. the inventory along with the resources orphaned by a
+// previous clean up, and the credentials needed to do so.
+func snapshotCloudResources(config *configv1alpha1.SubmarinerConfig) *configv1alpha1.OrphanedCloudResources {
+	orphans := &configv1alpha1.OrphanedCloudResources{
+		Since: metav1.Now(),
+	}
+
+	if previous := config.Status.OrphanedCloudResources; previous != nil {
+		orphans.Resources = previous.Resources
+		orphans.Since = previous.Since
+	}
+
+	orphans.Resources = mergeCloudResources(config.Status.CloudResources, orphans.Resources)
+
+	if config.Spec.CredentialsSecret != nil {
+		orphans.CredentialsSecret = config.Spec.CredentialsSecret.Name
+	}
+
+	return orphans
+}
+
+// unknownCloudResources returns an unnamed cloud resource of every kind, standing for the unknown resources of an
+// environment without an inventory.
+func unknownCloudResources() []configv1alpha1.CloudResource {
+	return []configv1alpha1.CloudResource{
+		{Kind: configv1alpha1.CloudResourceSecurityGroup},
+		{Kind: configv1alpha1.CloudResourceFirewallRule},
+		{Kind: configv1alpha1.CloudResourceMachineSet},
+		{Kind: configv1alpha1.CloudResourceMachinePool},
+	}
+}
+
+// mergeCloudResources returns the given inventory along with the given additional resources it doesn't already contain.
+// An unnamed additional resource, standing for the unknown resources of its kind, is only kept if the inventory doesn't
+// contain a resource of that kind.
+func mergeCloudResources(inventory, additional []configv1alpha1.CloudResource) []configv1alpha1.CloudResource {
+	merged := slices.Clone(inventory)
+
+	for i := range additional {
+		if !slices.ContainsFunc(merged, func(r configv1alpha1.CloudResource) bool {
+			return r.Kind == additional[i].Kind && (r.Name == additional[i].Name || additional[i].Name == "")
+		}) {
+			merged = append(merged, additional[i])
+		}
+	}
+
+	return merged
+}
+
+// verifyClusterEnvironment periodically verifies that the cloud resources in the inventory haven't drifted from their
+// prepared state. Any drift is reported in the SubmarinerClusterEnvironmentPrepared condition and, if the remediation
+// policy allows it, the cluster environment is prepared again.
+func (c *submarinerConfigController) verifyClusterEnvironment(ctx context.Context, config *configv1alpha1.SubmarinerConfig,
+	recorder events.Recorder,
+) error {
+	if len(config.Status.CloudResources) == 0 || config.Spec.CloudPreparePlanOnly || time.Since(c.lastEnvCheck) < c.envCheckInterval {
+		return nil
+	}
+
+	cloudProvider, found, err := c.cloudProviderFactory.Get(config, recorder)
+	if !found || err != nil {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	c.lastEnvCheck = time.Now()
+
+	drift, err := cloudProvider.VerifySubmarinerClusterEnv(ctx, config.Status.CloudResources)
+	if err != nil {
+		return errors.Wrap(err, "error verifying the submariner cluster environment")
+	}
+
+	condition := metav1.Condition{
+		Type:    configv1alpha1.SubmarinerConfigConditionEnvPrepared,
+		Status:  metav1.ConditionTrue,
+		Reason:  "SubmarinerClusterEnvPrepared",
+		Message: "Submariner cluster environment was prepared",
+	}
+
+	if len(drift) == 0 {
+		// Only restore the condition if it reports a drift that was fixed externally.
+		if current := meta.FindStatusCondition(config.Status.Conditions, condition.Type); current == nil ||
+			current.Reason != clusterEnvDriftedReason {
+			return nil
+		}
+	} else {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = clusterEnvDriftedReason
+		condition.Message = "The submariner cluster environment drifted: " + strings.Join(drift, "; ")
+
+		c.logger.Infof("The submariner cluster environment of cluster %q drifted: %s", config.Namespace, strings.Join(drift, "; "))
+		recorder.Warningf(clusterEnvDriftedReason, "The submariner cluster environment drifted: %s", strings.Join(drift, "; "))
+	}
+
+	_, _, err = submarinerconfig.UpdateStatus(ctx,
+		c.configClient.SubmarineraddonV1alpha1().SubmarinerConfigs(config.Namespace), config.Name,
+		submarinerconfig.UpdateConditionFn(&condition))
+	if err != nil || len(drift) == 0 || config.Spec.CloudDriftRemediation != configv1alpha1.CloudDriftRemediationReapply {
+		return err //nolint:wrapcheck // No need to wrap here
+	}
+
+	c.logger.Infof("Preparing the submariner cluster environment of cluster %q again", config.Namespace)
+
+	return c.prepareForSubmariner(ctx, config, recorder)
+}
+
+// planCloudPreparation publishes the cloud resources the given operation would create or delete in the SubmarinerConfig
+// status, without changing the cloud environment. A clean up would delete exactly the inventoried resources, if any. The
+// published plan is kept until the config or the credentials change.
+func (c *submarinerConfigController) planCloudPreparation(ctx context.Context, config *configv1alpha1.SubmarinerConfig,
+	cloudProvider cloud.Provider, operation string,
+) error {
+	credentialsHash, err := c.credentialsHash(config)
+	if err != nil {
+		return err
+	}
+
+	key := cloudPreparePlanKey{
+		uid:             config.UID,
+		generation:      config.Generation,
+		operation:       operation,
+		credentialsHash: credentialsHash,
+	}
+
+	if c.lastPlan == key {
+		return nil
+	}
+
+	var resources []configv1alpha1.CloudResource
+
+	if operation == configv1alpha1.CloudPreparePlanDelete {
+		resources = snapshotCloudResources(config).Resources
+	}
+
+	if len(resources) == 0 {
+		resources, err = cloudProvider.PlanSubmarinerClusterEnv(ctx, operation)
+		if err != nil {
+			return errors.Wrap(err, "error planning the submariner cluster environment")
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:   configv1alpha1.SubmarinerConfigConditionEnvPrepared,
+		Status: metav1.ConditionFalse,
+		Reason: "SubmarinerClusterEnvPlanned",
+		Message: fmt.Sprintf("The cloud preparation plan to %s %d resource(s) is in the status, set cloudPreparePlanOnly "+
+			"to false to apply it", strings.ToLower(operation), len(resources)),
+	}
+
+	_, updated, err := submarinerconfig.UpdateStatus(ctx,
+		c.configClient.SubmarineraddonV1alpha1().SubmarinerConfigs(config.Namespace), config.Name,
+		submarinerconfig.UpdateConditionFn(&condition), submarinerconfig.UpdateCloudPreparePlanFn(&configv1alpha1.CloudPreparePlan{
+			Operation:          operation,
+			ObservedGeneration: config.Generation,
+			Resources:          resources,
+		}))
+
+	if updated {
+		c.logger.Infof("Published the cloud preparation plan for cluster %q: %s", config.Namespace, resource.ToJSON(resources))
+	}
+
+	if err == nil {
+		c.lastPlan = key
+	}
+
+	return err //nolint:wrapcheck // No need to wrap here
 }
 
 func (c *submarinerConfigController) updateSubmarinerConfigStatus(ctx context.Context, recorder events.Recorder,
@@ -375,6 +771,7 @@ func (c *submarinerConfigController) updateSubmarinerConfigStatus(ctx context.Co
 		// When all is well, the status is eventually updated with a "true" condition, allowing us to cache latest good known config
 		if condition.Status == metav1.ConditionTrue {
 			c.lastKnownConfig = config
+			c.lastCredentialsHash, _ = c.credentialsHash(config)
 		}
 	}
 
@@ -384,7 +781,8 @@ func (c *submarinerConfigController) updateSubmarinerConfigStatus(ctx context.Co
 func (c *submarinerConfigController) ensureGateways(ctx context.Context,
 	config *configv1alpha1.SubmarinerConfig,
 ) (metav1.Condition, error) {
-	if config.Spec.Gateways < 1 {
+	gateways := provider.GatewayCount(&config.Spec.GatewayConfig)
+	if gateways < 1 {
 		return metav1.Condition{
 			Type:    submarinerGatewayCondition,
 			Status:  metav1.ConditionFalse,
@@ -393,9 +791,7 @@ func (c *submarinerConfigController) ensureGateways(ctx context.Context,
 		}, nil
 	}
 
-	currentGateways, err := c.getLabeledNodes(
-		nodeLabelSelector{submarinerGatewayLabel, selection.Exists},
-	)
+	currentGateways, terminatingGateways, err := c.getGatewayNodes()
 	if err != nil {
 		return failedConditionf("Error retrieving nodes: %v", err), err
 	}
@@ -405,9 +801,9 @@ func (c *submarinerConfigController) ensureGateways(ctx context.Context,
 		currentGatewayNames = append(currentGatewayNames, gateway.Name)
 	}
 
-	updatedGatewayNames := make([]string, 0, config.Spec.Gateways)
+	updatedGatewayNames := make([]string, 0, gateways)
 
-	requiredGateways := config.Spec.Gateways - len(currentGateways)
+	requiredGateways := gateways - len(currentGateways)
 
 	switch {
 	case requiredGateways == 0:
@@ -421,7 +817,7 @@ func (c *submarinerConfigController) ensureGateways(ctx context.Context,
 		err = operatorhelpers.NewMultiLineAggregate(errs)
 	case requiredGateways > 0:
 		// gateways increased, need to label new ones
-		updatedGatewayNames, err = c.addGateways(ctx, config, requiredGateways)
+		updatedGatewayNames, err = c.addGateways(ctx, config, currentGateways, requiredGateways)
 	default:
 		// gateways decreased, need to unlabel some
 		var removed []string
@@ -437,6 +833,9 @@ func (c *submarinerConfigController) ensureGateways(ctx context.Context,
 		}
 	}
 
+	// the terminating gateways were replaced above so move the labels off them before their nodes go away
+	err = goerrors.Join(err, c.unlabelTerminatingNodes(ctx, terminatingGateways))
+
 	if err != nil {
 		return failedConditionf("Unable to label the gateway nodes: %v", err), err
 	}
@@ -455,6 +854,27 @@ func (c *submarinerConfigController) ensureGateways(ctx context.Context,
 	return successCondition(updatedGatewayNames), nil
 }
 
+// getGatewayNodes returns the nodes labeled as gateways, split into those which are usable and those which received a
+// termination notice.
+func (c *submarinerConfigController) getGatewayNodes() ([]*corev1.Node, []*corev1.Node, error) {
+	gateways, err := c.getLabeledNodes(nodeLabelSelector{submarinerGatewayLabel, selection.Exists})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var current, terminating []*corev1.Node
+
+	for _, gateway := range gateways {
+		if isNodeTerminating(gateway) {
+			terminating = append(terminating, gateway)
+		} else {
+			current = append(current, gateway)
+		}
+	}
+
+	return current, terminating, nil
+}
+
 func (c *submarinerConfigController) getLabeledNodes(nodeLabelSelectors ...nodeLabelSelector) ([]*corev1.Node, error) {
 	requirements := []labels.Requirement{}
 
@@ -539,13 +959,41 @@ func (c *submarinerConfigController) unlabelNode(ctx context.Context, node *core
 	})
 }
 
+// unlabelTerminatingNodes removes the gateway labels from the given nodes which received a termination notice, whoever
+// labeled them, so that the gateway fails over before the nodes go away.
+func (c *submarinerConfigController) unlabelTerminatingNodes(ctx context.Context, nodes []*corev1.Node) error {
+	errs := make([]error, 0, len(nodes))
+
+	for _, node := range nodes {
+		c.logger.Infof("Unlabeling terminating gateway node %q", node.Name)
+
+		errs = append(errs, c.updateNode(ctx, node, func(node *corev1.Node) {
+			delete(node.Labels, submarinerGatewayLabel)
+			delete(node.Labels, submarinerUDPPortLabel)
+			delete(node.Annotations, gatewayLabeledBySubmariner)
+		}))
+	}
+
+	return goerrors.Join(errs...)
+}
+
 func (c *submarinerConfigController) addGateways(ctx context.Context, config *configv1alpha1.SubmarinerConfig,
-	expectedGateways int,
+	currentGateways []*corev1.Node, expectedGateways int,
 ) ([]string, error) {
 	// for other non-public cloud platform (vsphere) or native k8s
 	zoneLabel := defaultZoneLabel
 
-	gateways, err := c.findGatewaysWithZone(expectedGateways, zoneLabel)
+	var (
+		gateways []*corev1.Node
+		err      error
+	)
+
+	if len(config.Spec.GatewayNodes) > 0 {
+		gateways, err = c.findGatewaysForNodes(currentGateways, config.Spec.GatewayNodes)
+	} else {
+		gateways, err = c.findGatewaysWithZone(expectedGateways, zoneLabel)
+	}
+
 	if err != nil {
 		return []string{}, err
 	}
@@ -597,6 +1045,8 @@ func (c *submarinerConfigController) findGatewaysWithZone(expected int, zoneLabe
 		return nil, err
 	}
 
+	workers = slices.DeleteFunc(workers, isNodeTerminating)
+
 	if len(workers) < expected {
 		return []*corev1.Node{}, nil
 	}
@@ -645,6 +1095,46 @@ func (c *submarinerConfigController) findGatewaysWithZone(expected int, zoneLabe
 	return gateways, nil
 }
 
+// findGatewaysForNodes returns a worker node for each of the given individually specified gateway nodes which isn't
+// satisfied by one of the current gateways. A worker node satisfies a gateway node if it's in its zone and has its
+// instance type, when specified; gateway nodes which no worker node satisfies are skipped.
+func (c *submarinerConfigController) findGatewaysForNodes(currentGateways []*corev1.Node, nodes []configv1alpha1.GatewayNode,
+) ([]*corev1.Node, error) {
+	workers, err := c.getLabeledNodes(
+		nodeLabelSelector{workerNodeLabel, selection.Exists},
+		nodeLabelSelector{submarinerGatewayLabel, selection.DoesNotExist},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	workers = slices.DeleteFunc(workers, isNodeTerminating)
+	remaining := slices.Clone(currentGateways)
+	gateways := []*corev1.Node{}
+
+	for i := range nodes {
+		// A current gateway satisfying the gateway node is kept
+		if j := indexOfSatisfyingNode(remaining, &nodes[i]); j >= 0 {
+			remaining = slices.Delete(remaining, j, j+1)
+			continue
+		}
+
+		if j := indexOfSatisfyingNode(workers, &nodes[i]); j >= 0 {
+			gateways = append(gateways, workers[j])
+			workers = slices.Delete(workers, j, j+1)
+		}
+	}
+
+	return gateways, nil
+}
+
+func indexOfSatisfyingNode(nodes []*corev1.Node, gatewayNode *configv1alpha1.GatewayNode) int {
+	return slices.IndexFunc(nodes, func(node *corev1.Node) bool {
+		return (gatewayNode.Zone == "" || node.Labels[corev1.LabelTopologyZone] == gatewayNode.Zone) &&
+			(gatewayNode.InstanceType == "" || node.Labels[corev1.LabelInstanceTypeStable] == gatewayNode.InstanceType)
+	})
+}
+
 func (c *submarinerConfigController) updateGatewayStatus(ctx context.Context, recorder events.Recorder,
 	config *configv1alpha1.SubmarinerConfig,
 ) error {
@@ -663,13 +1153,14 @@ func (c *submarinerConfigController) updateGatewayStatus(ctx context.Context, re
 
 	var condition metav1.Condition
 
-	if config.Spec.Gateways != len(gateways) {
+	expectedGateways := provider.GatewayCount(&config.Spec.GatewayConfig)
+	if expectedGateways != len(gateways) {
 		condition = metav1.Condition{
 			Type:   submarinerGatewayCondition,
 			Status: metav1.ConditionFalse,
 			Reason: "InsufficientNodes",
 			Message: fmt.Sprintf("The %d worker nodes labeled as gateways (%q) does not match the desired number %d",
-				len(gatewayNames), strings.Join(gatewayNames, ","), config.Spec.Gateways),
+				len(gatewayNames), strings.Join(gatewayNames, ","), expectedGateways),
 		}
 	} else {
 		condition = successCondition(gatewayNames)
@@ -793,3 +1284,22 @@ func successCondition(gatewayNames []string) metav1.Condition {
 			strings.Join(gatewayNames, ",")),
 	}
 }
+
+func nodeNames(nodes []*corev1.Node) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+
+	return names
+}
+
+func isNodeTerminating(node *corev1.Node) bool {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == nodeTerminatingCondition {
+			return node.Status.Conditions[i].Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
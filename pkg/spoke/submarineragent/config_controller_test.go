@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -332,6 +333,64 @@ func testWorkerNodeLabeling(t *configControllerTestDriver) { //nolint:maintidx /
 			})
 		})
 	})
+
+	When("the gateway nodes are specified individually", func() {
+		BeforeEach(func() {
+			t.nodes = []*corev1.Node{newWorkerNode("worker-1"), newWorkerNode("worker-2"), newWorkerNode("worker-3")}
+			t.nodes[0].Labels[corev1.LabelTopologyZone] = "zone-a"
+			t.nodes[1].Labels[corev1.LabelTopologyZone] = "zone-b"
+			t.nodes[2].Labels[corev1.LabelTopologyZone] = "zone-b"
+			t.nodes[2].Labels[corev1.LabelInstanceTypeStable] = "large"
+
+			t.config.Spec.GatewayNodes = []configv1alpha1.GatewayNode{{Zone: "zone-b", InstanceType: "large"}, {Zone: "zone-a"}}
+		})
+
+		It("should label the worker nodes satisfying them", func(ctx context.Context) {
+			Eventually(func() []string {
+				var names []string
+
+				for _, node := range t.getLabeledWorkerNodes(ctx) {
+					names = append(names, node.Name)
+				}
+
+				return names
+			}, 2).Should(ConsistOf("worker-1", "worker-3"))
+
+			t.awaitGatewaysLabeledSuccessCondition(ctx)
+		})
+	})
+
+	When("a gateway node receives a termination notice", func() {
+		BeforeEach(func() {
+			t.nodes = []*corev1.Node{newWorkerNode("worker-1"), newWorkerNode("worker-2")}
+			labelGateway(t.nodes[0], true)
+			t.nodes[0].Labels["gateway.submariner.io/udp-port"] = strconv.Itoa(t.config.Spec.IPSecNATTPort)
+			t.nodes[0].Annotations = map[string]string{gatewayLabeledAnnotation: strconv.FormatBool(true)}
+		})
+
+		It("should move the gateway label to another worker node", func(ctx context.Context) {
+			t.awaitGatewaysLabeledSuccessCondition(ctx)
+
+			node, err := t.kubeClient.CoreV1().Nodes().Get(ctx, "worker-1", metav1.GetOptions{})
+			Expect(err).To(Succeed())
+
+			node.Status.Conditions = []corev1.NodeCondition{{Type: "Terminating", Status: corev1.ConditionTrue}}
+			_, err = t.kubeClient.CoreV1().Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{})
+			Expect(err).To(Succeed())
+
+			Eventually(func() []string {
+				var names []string
+
+				for _, node := range t.getLabeledWorkerNodes(ctx) {
+					names = append(names, node.Name)
+				}
+
+				return names
+			}, 2).Should(Equal([]string{"worker-2"}))
+
+			t.awaitGatewaysLabeledSuccessCondition(ctx)
+		})
+	})
 }
 
 func testSubmarinerConfig(t *configControllerTestDriver) {
@@ -376,12 +435,210 @@ func testSubmarinerConfig(t *configControllerTestDriver) {
 	When("the SubmarinerConfig's Platform field is set to AWS", func() {
 		BeforeEach(func() {
 			t.config.Status.ManagedClusterInfo.Platform = aws
-			t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(nil).MinTimes(1)
+			t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(plannedCloudResources(), nil).MinTimes(1)
 		})
 
 		It("should invoke the cloud provider and update the SubmarinerConfig status condition", func(ctx context.Context) {
 			t.awaitClusterEnvPreparedSuccessCondition(ctx)
 		})
+
+		It("should record the inventory of the created cloud resources", func(ctx context.Context) {
+			Eventually(func() []configv1alpha1.CloudResource {
+				return t.getCloudResources(ctx)
+			}, 3).Should(Equal(plannedCloudResources()))
+		})
+
+		Context("and the load balancer mode is enabled", func() {
+			BeforeEach(func() {
+				t.config.Spec.LoadBalancerEnable = true
+			})
+
+			It("should label the desired number of existing worker nodes as gateways", func(ctx context.Context) {
+				t.awaitLabeledNodes(ctx)
+				t.awaitGatewaysLabeledSuccessCondition(ctx)
+			})
+		})
+
+		Context("and cloud resources were previously orphaned", func() {
+			orphan := configv1alpha1.CloudResource{Kind: configv1alpha1.CloudResourceMachineSet, Name: "test-submariner-gw-1"}
+
+			BeforeEach(func() {
+				t.config.Status.OrphanedCloudResources = &configv1alpha1.OrphanedCloudResources{
+					Resources: []configv1alpha1.CloudResource{orphan, plannedCloudResources()[0]},
+				}
+			})
+
+			It("should adopt them into the inventory and clear the record", func(ctx context.Context) {
+				Eventually(func() []configv1alpha1.CloudResource {
+					return t.getCloudResources(ctx)
+				}, 3).Should(Equal(append(plannedCloudResources(), orphan)))
+				Expect(t.getOrphanedCloudResources(ctx)).To(BeNil())
+			})
+		})
+	})
+
+	When("the cloud provider credentials change", func() {
+		var prepareCount atomic.Int32
+
+		BeforeEach(func(ctx context.Context) {
+			prepareCount.Store(0)
+			t.config.Status.ManagedClusterInfo.Platform = aws
+			t.config.Spec.CredentialsSecret = &corev1.LocalObjectReference{Name: "aws-creds"}
+			labelGateway(t.nodes[0], true)
+
+			_, err := t.kubeClient.CoreV1().Secrets(clusterName).Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws-creds", Namespace: clusterName},
+				Data:       map[string][]byte{"aws_access_key_id": []byte("old")},
+			}, metav1.CreateOptions{})
+			Expect(err).To(Succeed())
+
+			t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).DoAndReturn(
+				func(context.Context) ([]configv1alpha1.CloudResource, error) {
+					prepareCount.Add(1)
+
+					return plannedCloudResources(), nil
+				}).MinTimes(1)
+		})
+
+		It("should prepare the cloud environment again", func(ctx context.Context) {
+			t.awaitClusterEnvPreparedSuccessCondition(ctx)
+
+			prepared := prepareCount.Load()
+
+			_, err := t.kubeClient.CoreV1().Secrets(clusterName).Update(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws-creds", Namespace: clusterName},
+				Data:       map[string][]byte{"aws_access_key_id": []byte("new")},
+			}, metav1.UpdateOptions{})
+			Expect(err).To(Succeed())
+
+			Eventually(prepareCount.Load, 3).Should(BeNumerically(">", prepared))
+		})
+
+		It("should not prepare the cloud environment again on subsequent syncs", func(ctx context.Context) {
+			t.awaitClusterEnvPreparedSuccessCondition(ctx)
+			t.awaitGatewaysLabeledSuccessCondition(ctx)
+
+			prepared := prepareCount.Load()
+
+			_, err := t.kubeClient.CoreV1().Secrets(clusterName).Update(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "aws-creds", Namespace: clusterName},
+				Data:       map[string][]byte{"aws_access_key_id": []byte("new")},
+			}, metav1.UpdateOptions{})
+			Expect(err).To(Succeed())
+
+			Eventually(prepareCount.Load, 3).Should(Equal(prepared + 1))
+
+			// Trigger another sync
+			node, err := t.kubeClient.CoreV1().Nodes().Get(ctx, "worker-2", metav1.GetOptions{})
+			Expect(err).To(Succeed())
+
+			node.Labels["touched"] = "true"
+			_, err = t.kubeClient.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+			Expect(err).To(Succeed())
+
+			Consistently(prepareCount.Load, 300*time.Millisecond).Should(Equal(prepared + 1))
+		})
+	})
+
+	When("a dedicated gateway node deployed by the cloud provider receives a termination notice", func() {
+		var prepareCount atomic.Int32
+
+		BeforeEach(func() {
+			prepareCount.Store(0)
+			t.config.Status.ManagedClusterInfo.Platform = aws
+			t.nodes = []*corev1.Node{newWorkerNode("worker-1"), newWorkerNode("worker-2")}
+			labelGateway(t.nodes[0], true)
+
+			t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).DoAndReturn(
+				func(context.Context) ([]configv1alpha1.CloudResource, error) {
+					prepareCount.Add(1)
+
+					return plannedCloudResources(), nil
+				}).MinTimes(1)
+		})
+
+		It("should move the gateway label to another worker node without preparing the cloud environment again",
+			func(ctx context.Context) {
+				t.awaitClusterEnvPreparedSuccessCondition(ctx)
+				t.awaitGatewaysLabeledSuccessCondition(ctx)
+
+				prepared := prepareCount.Load()
+
+				node, err := t.kubeClient.CoreV1().Nodes().Get(ctx, "worker-1", metav1.GetOptions{})
+				Expect(err).To(Succeed())
+
+				node.Status.Conditions = []corev1.NodeCondition{{Type: "Terminating", Status: corev1.ConditionTrue}}
+				_, err = t.kubeClient.CoreV1().Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{})
+				Expect(err).To(Succeed())
+
+				Eventually(func() []string {
+					var names []string
+
+					for _, node := range t.getLabeledWorkerNodes(ctx) {
+						names = append(names, node.Name)
+					}
+
+					return names
+				}, 2).Should(Equal([]string{"worker-2"}))
+
+				Consistently(prepareCount.Load, 300*time.Millisecond).Should(Equal(prepared))
+			})
+	})
+
+	When("the prepared cloud environment drifts", func() {
+		var prepareCount atomic.Int32
+
+		BeforeEach(func() {
+			prepareCount.Store(0)
+			t.envCheckPeriod = 100 * time.Millisecond
+			t.config.Status.ManagedClusterInfo.Platform = aws
+			labelGateway(t.nodes[0], true)
+
+			t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).DoAndReturn(
+				func(context.Context) ([]configv1alpha1.CloudResource, error) {
+					prepareCount.Add(1)
+
+					return plannedCloudResources(), nil
+				}).MinTimes(1)
+			t.cloudProvider.EXPECT().VerifySubmarinerClusterEnv(gomock.Any(), gomock.Any()).Return(
+				[]string{"port 4500/udp is not open in SecurityGroup \"test-submariner-gw-sg\""}, nil).AnyTimes()
+		})
+
+		Context("and the remediation policy is None", func() {
+			It("should report the drift in the SubmarinerConfig status condition", func(ctx context.Context) {
+				t.awaitSubmarinerConfigStatusCondition(ctx, &metav1.Condition{
+					Type:   configv1alpha1.SubmarinerConfigConditionEnvPrepared,
+					Status: metav1.ConditionFalse,
+					Reason: "SubmarinerClusterEnvDrifted",
+				})
+
+				Expect(prepareCount.Load()).To(Equal(int32(1)))
+			})
+		})
+
+		Context("and the remediation policy is Reapply", func() {
+			BeforeEach(func() {
+				t.config.Spec.CloudDriftRemediation = configv1alpha1.CloudDriftRemediationReapply
+			})
+
+			It("should prepare the cluster environment again", func() {
+				Eventually(prepareCount.Load, 3).Should(BeNumerically(">=", 2))
+			})
+		})
+
+		Context("and the gateway nodes can't be reconciled", func() {
+			BeforeEach(func() {
+				t.config.Spec.Gateways = 3
+			})
+
+			It("should still report the drift in the SubmarinerConfig status condition", func(ctx context.Context) {
+				t.awaitSubmarinerConfigStatusCondition(ctx, &metav1.Condition{
+					Type:   configv1alpha1.SubmarinerConfigConditionEnvPrepared,
+					Status: metav1.ConditionFalse,
+					Reason: "SubmarinerClusterEnvDrifted",
+				})
+			})
+		})
 	})
 
 	When("the SubmarinerConfig's Platform field is set to GCP", func() {
@@ -391,7 +648,7 @@ func testSubmarinerConfig(t *configControllerTestDriver) {
 
 		Context("", func() {
 			BeforeEach(func() {
-				t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(nil).MinTimes(1)
+				t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(plannedCloudResources(), nil).MinTimes(1)
 			})
 
 			It("should invoke the cloud provider and update the SubmarinerConfig status condition", func(ctx context.Context) {
@@ -426,12 +683,13 @@ func testSubmarinerConfig(t *configControllerTestDriver) {
 				waitCh = make(chan struct{})
 
 				gomock.InOrder(
-					t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(errors.New("fake error")).Times(1),
-					t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).DoAndReturn(func(context.Context) error {
-						<-waitCh
+					t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(nil, errors.New("fake error")).Times(1),
+					t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).DoAndReturn(
+						func(context.Context) ([]configv1alpha1.CloudResource, error) {
+							<-waitCh
 
-						return nil
-					}).AnyTimes(),
+							return plannedCloudResources(), nil
+						}).AnyTimes(),
 				)
 			})
 
@@ -453,6 +711,46 @@ func testSubmarinerConfig(t *configControllerTestDriver) {
 		})
 	})
 
+	When("the cloud preparation plan mode is enabled", func() {
+		BeforeEach(func() {
+			t.config.Status.ManagedClusterInfo.Platform = aws
+			t.config.Spec.CloudPreparePlanOnly = true
+		})
+
+		Context("", func() {
+			BeforeEach(func() {
+				t.cloudProvider.EXPECT().PlanSubmarinerClusterEnv(gomock.Any(), configv1alpha1.CloudPreparePlanCreate).Return(
+					plannedCloudResources(), nil).Times(1)
+			})
+
+			It("should publish the plan once without preparing the cluster environment", func(ctx context.Context) {
+				t.awaitSubmarinerConfigStatusCondition(ctx, &metav1.Condition{
+					Type:   configv1alpha1.SubmarinerConfigConditionEnvPrepared,
+					Status: metav1.ConditionFalse,
+					Reason: "SubmarinerClusterEnvPlanned",
+				})
+
+				t.awaitCloudPreparePlan(ctx, configv1alpha1.CloudPreparePlanCreate)
+			})
+
+			It("should label the gateway nodes", func(ctx context.Context) {
+				t.awaitGatewaysLabeledSuccessCondition(ctx)
+			})
+		})
+
+		Context("and planning fails", func() {
+			BeforeEach(func() {
+				t.cloudProvider.EXPECT().PlanSubmarinerClusterEnv(gomock.Any(), gomock.Any()).Return(nil, errors.New("fake error")).MinTimes(1)
+			})
+
+			It("should not publish a plan", func(ctx context.Context) {
+				Consistently(func() *configv1alpha1.CloudPreparePlan {
+					return t.getCloudPreparePlan(ctx)
+				}, 300*time.Millisecond).Should(BeNil())
+			})
+		})
+	})
+
 	When("updating the SubmarinerConfig status initially fails", func() {
 		BeforeEach(func() {
 			fake.FailOnAction(&t.configClient.Fake, "*", "update", nil, true)
@@ -579,8 +877,15 @@ func testManagedClusterAddOn(t *configControllerTestDriver) {
 		Context("the SubmarinerConfig's Platform field is set to AWS", func() {
 			BeforeEach(func() {
 				t.config.Status.ManagedClusterInfo.Platform = aws
-				t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(nil).AnyTimes()
-				t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any()).Return(nil).MinTimes(1)
+				t.config.Status.CloudResources = plannedCloudResources()
+				t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(plannedCloudResources(), nil).AnyTimes()
+				t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any(), plannedCloudResources()).Return(nil).MinTimes(1)
+			})
+
+			It("should clean up the inventoried cloud resources and clear the inventory", func(ctx context.Context) {
+				Eventually(func() []configv1alpha1.CloudResource {
+					return t.getCloudResources(ctx)
+				}, 3).Should(BeEmpty())
 			})
 
 			It("should invoke the cloud provider to clean up", func(ctx context.Context) {
@@ -596,15 +901,107 @@ func testManagedClusterAddOn(t *configControllerTestDriver) {
 			})
 		})
 
+		Context("and cleaning up the cloud environment fails", func() {
+			BeforeEach(func() {
+				t.config.Status.ManagedClusterInfo.Platform = aws
+				t.config.Status.CloudResources = plannedCloudResources()
+				t.config.Spec.CredentialsSecret = &corev1.LocalObjectReference{Name: "aws-creds"}
+				t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(plannedCloudResources(), nil).AnyTimes()
+				t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any(), plannedCloudResources()).Return(
+					errors.New("invalid credentials")).MinTimes(1)
+			})
+
+			It("should record the orphaned cloud resources", func(ctx context.Context) {
+				Eventually(func() *configv1alpha1.OrphanedCloudResources {
+					return t.getOrphanedCloudResources(ctx)
+				}, 3).ShouldNot(BeNil())
+
+				orphans := t.getOrphanedCloudResources(ctx)
+				Expect(orphans.Resources).To(Equal(plannedCloudResources()))
+				Expect(orphans.CredentialsSecret).To(Equal("aws-creds"))
+				Expect(orphans.Message).To(ContainSubstring("invalid credentials"))
+			})
+		})
+
+		Context("and cleaning up a cloud environment without an inventory fails", func() {
+			BeforeEach(func() {
+				t.config.Status.ManagedClusterInfo.Platform = aws
+				t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(plannedCloudResources(), nil).AnyTimes()
+				t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any(), gomock.Any()).Return(
+					errors.New("invalid credentials")).MinTimes(1)
+			})
+
+			It("should record every kind of cloud resource as orphaned", func(ctx context.Context) {
+				Eventually(func() *configv1alpha1.OrphanedCloudResources {
+					return t.getOrphanedCloudResources(ctx)
+				}, 3).ShouldNot(BeNil())
+
+				Expect(t.getOrphanedCloudResources(ctx).Resources).To(ConsistOf(
+					configv1alpha1.CloudResource{Kind: configv1alpha1.CloudResourceSecurityGroup},
+					configv1alpha1.CloudResource{Kind: configv1alpha1.CloudResourceFirewallRule},
+					configv1alpha1.CloudResource{Kind: configv1alpha1.CloudResourceMachineSet},
+					configv1alpha1.CloudResource{Kind: configv1alpha1.CloudResourceMachinePool}))
+			})
+		})
+
+		Context("and cloud resources were previously orphaned", func() {
+			orphan := configv1alpha1.CloudResource{Kind: configv1alpha1.CloudResourceMachineSet, Name: "test-submariner-gw-1"}
+
+			BeforeEach(func() {
+				t.config.Status.ManagedClusterInfo.Platform = aws
+				t.config.Status.CloudResources = plannedCloudResources()
+				t.config.Status.OrphanedCloudResources = &configv1alpha1.OrphanedCloudResources{
+					Resources: []configv1alpha1.CloudResource{orphan},
+				}
+				t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(plannedCloudResources(), nil).AnyTimes()
+				t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any(),
+					append(plannedCloudResources(), orphan)).Return(nil).MinTimes(1)
+			})
+
+			It("should clean them up along with the inventory and clear the record", func(ctx context.Context) {
+				Eventually(func() *configv1alpha1.OrphanedCloudResources {
+					return t.getOrphanedCloudResources(ctx)
+				}, 3).Should(BeNil())
+				Expect(t.getCloudResources(ctx)).To(BeEmpty())
+			})
+		})
+
+		Context("the cloud preparation plan mode is enabled", func() {
+			BeforeEach(func() {
+				t.config.Status.ManagedClusterInfo.Platform = aws
+				t.config.Spec.CloudPreparePlanOnly = true
+				t.cloudProvider.EXPECT().PlanSubmarinerClusterEnv(gomock.Any(), gomock.Any()).Return(plannedCloudResources(), nil).MinTimes(1)
+			})
+
+			It("should publish a delete plan without cleaning up", func(ctx context.Context) {
+				t.awaitCloudPreparePlan(ctx, configv1alpha1.CloudPreparePlanDelete)
+			})
+		})
+
+		Context("the cloud preparation plan mode is enabled after the cluster environment was prepared", func() {
+			BeforeEach(func() {
+				t.config.Status.ManagedClusterInfo.Platform = aws
+				t.config.Status.CloudResources = plannedCloudResources()
+				t.config.Spec.CloudPreparePlanOnly = true
+				t.cloudProvider.EXPECT().PlanSubmarinerClusterEnv(gomock.Any(), configv1alpha1.CloudPreparePlanCreate).Return(
+					plannedCloudResources(), nil).AnyTimes()
+			})
+
+			It("should publish a delete plan of the inventoried cloud resources without cleaning up", func(ctx context.Context) {
+				t.awaitCloudPreparePlan(ctx, configv1alpha1.CloudPreparePlanDelete)
+				Expect(t.getCloudResources(ctx)).To(Equal(plannedCloudResources()))
+			})
+		})
+
 		Context("the SubmarinerConfig's Platform field is set to GCP", func() {
 			BeforeEach(func() {
-				t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(nil).AnyTimes()
+				t.cloudProvider.EXPECT().PrepareSubmarinerClusterEnv(gomock.Any()).Return(plannedCloudResources(), nil).AnyTimes()
 				t.config.Status.ManagedClusterInfo.Platform = gcp
 			})
 
 			Context("", func() {
 				BeforeEach(func() {
-					t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any()).Return(nil).MinTimes(1)
+					t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any(), gomock.Any()).Return(nil).MinTimes(1)
 				})
 
 				It("should invoke the cloud provider to clean up", func(ctx context.Context) {
@@ -618,7 +1015,7 @@ func testManagedClusterAddOn(t *configControllerTestDriver) {
 
 			Context("", func() {
 				BeforeEach(func() {
-					t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any()).Return(nil).AnyTimes()
+					t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 				})
 
 				It("should not unlabel the gateway nodes", func(ctx context.Context) {
@@ -633,12 +1030,13 @@ func testManagedClusterAddOn(t *configControllerTestDriver) {
 					waitCh = make(chan struct{})
 
 					gomock.InOrder(
-						t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any()).Return(errors.New("fake error")).Times(1),
-						t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any()).DoAndReturn(func(context.Context) error {
-							<-waitCh
+						t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any(), gomock.Any()).Return(errors.New("fake error")).Times(1),
+						t.cloudProvider.EXPECT().CleanUpSubmarinerClusterEnv(gomock.Any(), gomock.Any()).DoAndReturn(
+							func(context.Context, []configv1alpha1.CloudResource) error {
+								<-waitCh
 
-							return nil
-						}).AnyTimes(),
+								return nil
+							}).AnyTimes(),
 					)
 				})
 
@@ -670,6 +1068,7 @@ type configControllerTestDriver struct {
 	cloudProvider   *cloudFake.MockProvider
 	providerFactory *cloudFake.MockProviderFactory
 	mockCtrl        *gomock.Controller
+	envCheckPeriod  time.Duration
 }
 
 func newConfigControllerTestDriver() *configControllerTestDriver {
@@ -678,6 +1077,7 @@ func newConfigControllerTestDriver() *configControllerTestDriver {
 	BeforeEach(func() {
 		t.mockCtrl = gomock.NewController(GinkgoT())
 		t.config = newSubmarinerConfig()
+		t.envCheckPeriod = 0
 
 		t.nodes = []*corev1.Node{
 			newWorkerNode("worker-1"),
@@ -729,19 +1129,21 @@ func newConfigControllerTestDriver() *configControllerTestDriver {
 		dynInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(t.dynamicClient, 0)
 
 		t.controller = submarineragent.NewSubmarinerConfigController(&submarineragent.SubmarinerConfigControllerInput{
-			ClusterName:          clusterName,
-			Namespace:            submarinerNS,
-			KubeClient:           t.kubeClient,
-			ConfigClient:         t.configClient,
-			DynamicClient:        t.dynamicClient,
-			AddOnClient:          t.addOnClient,
-			NodeInformer:         kubeInformerFactory.Core().V1().Nodes(),
-			AddOnInformer:        addOnInformerFactory.Addon().V1beta1().ManagedClusterAddOns(),
-			ConfigInformer:       configInformerFactory.Submarineraddon().V1alpha1().SubmarinerConfigs(),
-			SubmarinerInformer:   dynInformerFactory.ForResource(submarinerv1a1.GroupVersion.WithResource("submariners")),
-			CloudProviderFactory: t.providerFactory,
-			Recorder:             events.NewLoggingEventRecorder("test", clock.RealClock{}),
-			OnSyncDefer:          GinkgoRecover,
+			ClusterName:             clusterName,
+			Namespace:               submarinerNS,
+			KubeClient:              t.kubeClient,
+			ConfigClient:            t.configClient,
+			DynamicClient:           t.dynamicClient,
+			AddOnClient:             t.addOnClient,
+			NodeInformer:            kubeInformerFactory.Core().V1().Nodes(),
+			AddOnInformer:           addOnInformerFactory.Addon().V1beta1().ManagedClusterAddOns(),
+			ConfigInformer:          configInformerFactory.Submarineraddon().V1alpha1().SubmarinerConfigs(),
+			SubmarinerInformer:      dynInformerFactory.ForResource(submarinerv1a1.GroupVersion.WithResource("submariners")),
+			SecretInformer:          kubeInformerFactory.Core().V1().Secrets(),
+			CloudProviderFactory:    t.providerFactory,
+			Recorder:                events.NewLoggingEventRecorder("test", clock.RealClock{}),
+			OnSyncDefer:             GinkgoRecover,
+			ClusterEnvCheckInterval: t.envCheckPeriod,
 		})
 
 		controllerCtx, stop := context.WithCancel(context.TODO())
@@ -754,6 +1156,7 @@ func newConfigControllerTestDriver() *configControllerTestDriver {
 		dynInformerFactory.Start(controllerCtx.Done())
 
 		cache.WaitForCacheSync(controllerCtx.Done(), kubeInformerFactory.Core().V1().Nodes().Informer().HasSynced,
+			kubeInformerFactory.Core().V1().Secrets().Informer().HasSynced,
 			configInformerFactory.Submarineraddon().V1alpha1().SubmarinerConfigs().Informer().HasSynced,
 			addOnInformerFactory.Addon().V1beta1().ManagedClusterAddOns().Informer().HasSynced)
 
@@ -823,6 +1226,39 @@ func (t *configControllerTestDriver) awaitSubmarinerConfigStatusCondition(ctx co
 	})
 }
 
+func (t *configControllerTestDriver) getCloudPreparePlan(ctx context.Context) *configv1alpha1.CloudPreparePlan {
+	config, err := t.configClient.SubmarineraddonV1alpha1().SubmarinerConfigs(clusterName).Get(ctx,
+		constants.SubmarinerConfigName, metav1.GetOptions{})
+	Expect(err).To(Succeed())
+
+	return config.Status.CloudPreparePlan
+}
+
+func (t *configControllerTestDriver) getCloudResources(ctx context.Context) []configv1alpha1.CloudResource {
+	config, err := t.configClient.SubmarineraddonV1alpha1().SubmarinerConfigs(clusterName).Get(ctx,
+		constants.SubmarinerConfigName, metav1.GetOptions{})
+	Expect(err).To(Succeed())
+
+	return config.Status.CloudResources
+}
+
+func (t *configControllerTestDriver) getOrphanedCloudResources(ctx context.Context) *configv1alpha1.OrphanedCloudResources {
+	config, err := t.configClient.SubmarineraddonV1alpha1().SubmarinerConfigs(clusterName).Get(ctx,
+		constants.SubmarinerConfigName, metav1.GetOptions{})
+	Expect(err).To(Succeed())
+
+	return config.Status.OrphanedCloudResources
+}
+
+func (t *configControllerTestDriver) awaitCloudPreparePlan(ctx context.Context, operation string) {
+	Eventually(func() *configv1alpha1.CloudPreparePlan {
+		return t.getCloudPreparePlan(ctx)
+	}, 3).Should(Equal(&configv1alpha1.CloudPreparePlan{
+		Operation: operation,
+		Resources: plannedCloudResources(),
+	}))
+}
+
 func (t *configControllerTestDriver) getLabeledWorkerNodes(ctx context.Context) []*corev1.Node {
 	foundNodes := []*corev1.Node{}
 
@@ -905,6 +1341,22 @@ func (t *configControllerTestDriver) finalizeAddOn(ctx context.Context) {
 	}).Should(BeTrue())
 }
 
+func plannedCloudResources() []configv1alpha1.CloudResource {
+	return []configv1alpha1.CloudResource{
+		{
+			Kind:  configv1alpha1.CloudResourceSecurityGroup,
+			Name:  "test-submariner-gw-sg",
+			Ports: []configv1alpha1.CloudPort{{Port: 4500, Protocol: "udp"}},
+		},
+		{
+			Kind:         configv1alpha1.CloudResourceMachineSet,
+			Name:         "test-submariner-gw",
+			InstanceType: "m5.xlarge",
+			Replicas:     1,
+		},
+	}
+}
+
 func newWorkerNode(name string) *corev1.Node {
 	return &corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{
@@ -215,17 +215,20 @@ func deployManagedClusterWithAddOn(managedClusterSetName, managedClusterName, br
 }
 
 func startControllerManager() func() {
+	return startControllerManagerWithOptions(hub.AddOnOptions{})
+}
+
+func startControllerManagerWithOptions(addOnOptions hub.AddOnOptions) func() {
 	ctx, stop := context.WithCancel(context.Background())
 
+	addOnOptions.AgentImage = "test"
+	addOnOptions.EventRecorder = util.NewIntegrationTestEventRecorder("submariner-addon")
+
 	go func() {
 		defer GinkgoRecover()
 
 		createClusterManagementAddOn(ctx)
 
-		addOnOptions := hub.AddOnOptions{
-			AgentImage:    "test",
-			EventRecorder: util.NewIntegrationTestEventRecorder("submariner-addon"),
-		}
 		err := addOnOptions.RunControllerManager(ctx, cfg, nil)
 		Expect(err).NotTo(HaveOccurred())
 	}()
@@ -0,0 +1,184 @@
+package integration_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/submariner-addon/pkg/constants"
+	"github.com/stolostron/submariner-addon/pkg/hub"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarineragent"
+	"github.com/stolostron/submariner-addon/pkg/hub/submarinerbroker"
+	"github.com/stolostron/submariner-addon/test/util"
+	"github.com/submariner-io/submariner-operator/api/v1alpha1"
+	"github.com/submariner-io/submariner-operator/pkg/discovery/globalnet"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/rand"
+	workv1 "open-cluster-management.io/api/work/v1"
+)
+
+var _ = Describe("Submariner Restore", func() {
+	const (
+		restoredPSK        = "restored-psk"
+		restoredGlobalCIDR = "242.1.0.0/16"
+		staleToken         = "stale-token"
+	)
+
+	var (
+		managedClusterSetName string
+		managedClusterName    string
+		brokerNamespace       string
+		staleTokenSecretName  string
+	)
+
+	BeforeEach(func() {
+		managedClusterSetName = "set-" + rand.String(6)
+		managedClusterName = "cluster-" + rand.String(6)
+		brokerNamespace = managedClusterSetName + "-broker"
+		staleTokenSecretName = managedClusterName + "-token-stale"
+
+		restoreRetryInterval := submarinerbroker.RestoreRetryInterval
+		submarinerbroker.RestoreRetryInterval = time.Second
+
+		DeferCleanup(func() {
+			submarinerbroker.RestoreRetryInterval = restoreRetryInterval
+		})
+
+		DeferCleanup(startControllerManagerWithOptions(hub.AddOnOptions{RestoreAware: true}))
+
+		By("Create the restored ManagedClusterSet")
+
+		clusterSet := util.NewManagedClusterSet(managedClusterSetName)
+		clusterSet.Annotations = map[string]string{
+			submarinerbroker.SubmBrokerNamespaceKey:   brokerNamespace,
+			submarinerbroker.BrokerDeployedAnnotation: "true",
+		}
+
+		_, err := clusterClient.ClusterV1beta2().ManagedClusterSets().Create(context.Background(), clusterSet, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	When("a ManagedClusterSet whose broker was deployed is restored on a new hub", func() {
+		It("should reuse the restored state and re-issue the broker credentials", func() {
+			By("Await creation of the broker Namespace")
+
+			Eventually(func() error {
+				_, err := kubeClient.CoreV1().Namespaces().Get(context.Background(), brokerNamespace, metav1.GetOptions{})
+				return err
+			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+
+			By("Ensure the IPsec PSK Secret is not generated")
+
+			Consistently(func() bool {
+				_, err := kubeClient.CoreV1().Secrets(brokerNamespace).Get(context.Background(), constants.IPSecPSKSecretName,
+					metav1.GetOptions{})
+				return apierrors.IsNotFound(err)
+			}, 3, eventuallyInterval).Should(BeTrue(), "IPsec PSK Secret was generated")
+
+			By("Restore the IPsec PSK Secret")
+
+			_, err := kubeClient.CoreV1().Secrets(brokerNamespace).Create(context.Background(), &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   constants.IPSecPSKSecretName,
+					Labels: map[string]string{constants.BackupLabelKey: constants.BackupLabelValue},
+				},
+				Data: map[string][]byte{"psk": []byte(restoredPSK)},
+			}, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() bool {
+				return util.CheckBrokerResources(kubeClient, brokerNamespace, true)
+			}, eventuallyTimeout, eventuallyInterval).Should(BeTrue())
+
+			By("Restore the token Secret of the ServiceAccount of the previous hub")
+
+			_, err = kubeClient.CoreV1().Secrets(brokerNamespace).Create(context.Background(), &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: staleTokenSecretName,
+					Annotations: map[string]string{
+						corev1.ServiceAccountNameKey: managedClusterName,
+						corev1.ServiceAccountUIDKey:  "previous-uid",
+					},
+				},
+				Data: map[string][]byte{
+					"ca.crt": []byte("stale-ca"),
+					"token":  []byte(staleToken),
+				},
+				Type: corev1.SecretTypeServiceAccountToken,
+			}, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			deployManagedClusterWithAddOn(managedClusterSetName, managedClusterName, brokerNamespace)
+
+			By("Ensure the ManifestWorks are not deployed before the globalnet ConfigMap is restored")
+
+			Consistently(func() bool {
+				ok, _ := util.CheckManifestWorks(workClient, managedClusterName, false, submarineragent.OperatorManifestWorkName,
+					submarineragent.SubmarinerCRManifestWorkName)
+				return ok
+			}, 3, eventuallyInterval).Should(BeTrue(), "ManifestWorks were deployed")
+
+			By("Restore the globalnet ConfigMap")
+
+			restoreGlobalnetConfigMap(brokerNamespace, managedClusterName, restoredGlobalCIDR)
+
+			works := awaitSubmarinerManifestWorks(managedClusterName)
+
+			var submarinerCRWork *workv1.ManifestWork
+
+			for _, w := range works {
+				if w.Name == submarineragent.SubmarinerCRManifestWorkName {
+					submarinerCRWork = w
+					break
+				}
+			}
+
+			Expect(submarinerCRWork).NotTo(BeNil(), "SubmarinerCR ManifestWork not found")
+
+			manifestObjs := util.UnmarshallManifestObjs(submarinerCRWork)
+
+			By("Verify the restored IPsec PSK is reused")
+
+			pskSecret := &corev1.Secret{}
+			Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(
+				util.AssertManifestObj(manifestObjs, "Secret", constants.IPSecPSKSecretName).Object, pskSecret)).To(Succeed())
+			Expect(string(pskSecret.Data["psk"])).To(Equal(restoredPSK))
+
+			By("Verify the restored global CIDR is reused")
+
+			submariner := &v1alpha1.Submariner{}
+			Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(
+				util.AssertManifestObj(manifestObjs, "Submariner", "").Object, submariner)).To(Succeed())
+			Expect(submariner.Spec.GlobalCIDR).To(Equal(restoredGlobalCIDR))
+
+			By("Verify the broker credentials are re-issued")
+
+			brokerSecret := &corev1.Secret{}
+			Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(
+				util.AssertManifestObj(manifestObjs, "Secret", constants.BrokerK8sSecretName).Object, brokerSecret)).To(Succeed())
+			Expect(brokerSecret.Data["token"]).NotTo(BeEmpty(), "Broker token should not be empty")
+			Expect(string(brokerSecret.Data["token"])).NotTo(Equal(staleToken))
+
+			Eventually(func() bool {
+				_, err := kubeClient.CoreV1().Secrets(brokerNamespace).Get(context.Background(), staleTokenSecretName,
+					metav1.GetOptions{})
+				return apierrors.IsNotFound(err)
+			}, eventuallyTimeout, eventuallyInterval).Should(BeTrue(), "The stale token Secret was not deleted")
+		})
+	})
+})
+
+func restoreGlobalnetConfigMap(brokerNamespace, clusterName, globalCIDR string) {
+	configMap, err := globalnet.NewGlobalnetConfigMap(true, "242.0.0.0/8", 65536, brokerNamespace)
+	Expect(err).NotTo(HaveOccurred())
+
+	configMap.Labels = map[string]string{constants.BackupLabelKey: constants.BackupLabelValue}
+	configMap.Data["clusterinfo"] = fmt.Sprintf(`[{"cluster_id":%q,"global_cidr":[%q]}]`, clusterName, globalCIDR)
+
+	Expect(controllerClient.Create(context.Background(), configMap)).To(Succeed())
+}